@@ -30,6 +30,23 @@ func EncodeToString(b []byte) string {
 	return hex.EncodeToString(b)
 }
 
+// Encode encodes src into the hexadecimal dst, and returns the number of bytes written to dst.
+// The dst must have at least 2*len(src) bytes. Unlike EncodeToString, it does not allocate.
+func Encode(dst, src []byte) int {
+	return hex.Encode(dst, src)
+}
+
+// Decode decodes the hexadecimal src into dst, and returns the number of bytes written to dst.
+// The dst must have at least len(src)/2 bytes. Unlike DecodeString, it does not allocate.
+func Decode(dst, src []byte) (int, error) {
+	return hex.Decode(dst, src)
+}
+
+// DecodeString returns the bytes represented by the hexadecimal string s.
+func DecodeString(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
+
 // byteSliceToString converts the []byte to string without a heap allocation.
 func byteSliceToString(b []byte) string {
 	return *(*string)(unsafe.Pointer(&reflect.StringHeader{
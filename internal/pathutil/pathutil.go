@@ -81,3 +81,15 @@ func FindProjectRoot(path string) (string, error) {
 
 	return "", fmt.Errorf("couldn't find project root in %s", first)
 }
+
+// Canonical returns the absolute and symbolic links resolved path of path.
+// If the path cannot be resolved, it returns the cleaned absolute path as far as possible.
+func Canonical(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
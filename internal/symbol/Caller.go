@@ -52,8 +52,29 @@ func (rcv *Caller) MutateFuncCall(n byte) bool {
 	return rcv._tab.MutateByteSlot(6, n)
 }
 
+/// Args start locations of the arguments of the function call.
+func (rcv *Caller) Args(obj *Location, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *Caller) ArgsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
 func CallerStart(builder *flatbuffers.Builder) {
-	builder.StartObject(2)
+	builder.StartObject(3)
 }
 func CallerAddLocation(builder *flatbuffers.Builder, Location flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(Location), 0)
@@ -61,6 +82,12 @@ func CallerAddLocation(builder *flatbuffers.Builder, Location flatbuffers.UOffse
 func CallerAddFuncCall(builder *flatbuffers.Builder, FuncCall byte) {
 	builder.PrependByteSlot(1, FuncCall, 0)
 }
+func CallerAddArgs(builder *flatbuffers.Builder, Args flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(2, flatbuffers.UOffsetT(Args), 0)
+}
+func CallerStartArgsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
 func CallerEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -47,8 +47,64 @@ func (rcv *CodeCompleteResults) ResultsLength() int {
 	return 0
 }
 
+func (rcv *CodeCompleteResults) Truncated() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(6))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CodeCompleteResults) MutateTruncated(n byte) bool {
+	return rcv._tab.MutateByteSlot(6, n)
+}
+
+func (rcv *CodeCompleteResults) Total() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CodeCompleteResults) MutateTotal(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(8, n)
+}
+
+func (rcv *CodeCompleteResults) Diagnostics(obj *Diagnostic, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *CodeCompleteResults) DiagnosticsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *CodeCompleteResults) Context() uint64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.GetUint64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CodeCompleteResults) MutateContext(n uint64) bool {
+	return rcv._tab.MutateUint64Slot(12, n)
+}
+
 func CodeCompleteResultsStart(builder *flatbuffers.Builder) {
-	builder.StartObject(1)
+	builder.StartObject(5)
 }
 func CodeCompleteResultsAddResults(builder *flatbuffers.Builder, Results flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(Results), 0)
@@ -56,6 +112,21 @@ func CodeCompleteResultsAddResults(builder *flatbuffers.Builder, Results flatbuf
 func CodeCompleteResultsStartResultsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(4, numElems, 4)
 }
+func CodeCompleteResultsAddTruncated(builder *flatbuffers.Builder, Truncated byte) {
+	builder.PrependByteSlot(1, Truncated, 0)
+}
+func CodeCompleteResultsAddTotal(builder *flatbuffers.Builder, Total uint32) {
+	builder.PrependUint32Slot(2, Total, 0)
+}
+func CodeCompleteResultsAddDiagnostics(builder *flatbuffers.Builder, Diagnostics flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(3, flatbuffers.UOffsetT(Diagnostics), 0)
+}
+func CodeCompleteResultsStartDiagnosticsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func CodeCompleteResultsAddContext(builder *flatbuffers.Builder, Context uint64) {
+	builder.PrependUint64Slot(4, Context, 0)
+}
 func CodeCompleteResultsEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -91,8 +91,104 @@ func (rcv *CompleteItem) MutateDup(n byte) bool {
 	return rcv._tab.MutateByteSlot(16, n)
 }
 
+func (rcv *CompleteItem) Priority() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutatePriority(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(18, n)
+}
+
+func (rcv *CompleteItem) Snippet() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *CompleteItem) Availability() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(22))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutateAvailability(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(22, n)
+}
+
+func (rcv *CompleteItem) Deprecated() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutateDeprecated(n byte) bool {
+	return rcv._tab.MutateByteSlot(24, n)
+}
+
+func (rcv *CompleteItem) CursorKind() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(26))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutateCursorKind(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(26, n)
+}
+
+func (rcv *CompleteItem) SnippetSyntax() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(28))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutateSnippetSyntax(n byte) bool {
+	return rcv._tab.MutateByteSlot(28, n)
+}
+
+func (rcv *CompleteItem) Overloads() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *CompleteItem) MutateOverloads(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(30, n)
+}
+
+func (rcv *CompleteItem) UserData() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(32))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *CompleteItem) SortText() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(34))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
 func CompleteItemStart(builder *flatbuffers.Builder) {
-	builder.StartObject(7)
+	builder.StartObject(16)
 }
 func CompleteItemAddWord(builder *flatbuffers.Builder, Word flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(Word), 0)
@@ -115,6 +211,33 @@ func CompleteItemAddIcase(builder *flatbuffers.Builder, Icase byte) {
 func CompleteItemAddDup(builder *flatbuffers.Builder, Dup byte) {
 	builder.PrependByteSlot(6, Dup, 0)
 }
+func CompleteItemAddPriority(builder *flatbuffers.Builder, Priority uint32) {
+	builder.PrependUint32Slot(7, Priority, 0)
+}
+func CompleteItemAddSnippet(builder *flatbuffers.Builder, Snippet flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(8, flatbuffers.UOffsetT(Snippet), 0)
+}
+func CompleteItemAddAvailability(builder *flatbuffers.Builder, Availability uint32) {
+	builder.PrependUint32Slot(9, Availability, 0)
+}
+func CompleteItemAddDeprecated(builder *flatbuffers.Builder, Deprecated byte) {
+	builder.PrependByteSlot(10, Deprecated, 0)
+}
+func CompleteItemAddCursorKind(builder *flatbuffers.Builder, CursorKind uint32) {
+	builder.PrependUint32Slot(11, CursorKind, 0)
+}
+func CompleteItemAddSnippetSyntax(builder *flatbuffers.Builder, SnippetSyntax byte) {
+	builder.PrependByteSlot(12, SnippetSyntax, 0)
+}
+func CompleteItemAddOverloads(builder *flatbuffers.Builder, Overloads uint32) {
+	builder.PrependUint32Slot(13, Overloads, 0)
+}
+func CompleteItemAddUserData(builder *flatbuffers.Builder, UserData flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(14, flatbuffers.UOffsetT(UserData), 0)
+}
+func CompleteItemAddSortText(builder *flatbuffers.Builder, SortText flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(15, flatbuffers.UOffsetT(SortText), 0)
+}
 func CompleteItemEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -110,13 +110,17 @@ func (rcv *File) HeadersLength() int {
 }
 
 /// Headers headers of file.
-func (rcv *File) Includes(j int) []byte {
+/// Includes #include directives of file.
+func (rcv *File) Includes(obj *Include, j int) bool {
 	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
 	if o != 0 {
-		a := rcv._tab.Vector(o)
-		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
 	}
-	return nil
+	return false
 }
 
 func (rcv *File) IncludesLength() int {
@@ -127,8 +131,96 @@ func (rcv *File) IncludesLength() int {
 	return 0
 }
 
+/// Includes #include directives of file.
+/// TUOmitted whether the TranslationUnit was omitted because it exceeded the size limit.
+func (rcv *File) TUOmitted() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// Includes #include directives of file.
+/// TUOmitted whether the TranslationUnit was omitted because it exceeded the size limit.
+func (rcv *File) MutateTUOmitted(n byte) bool {
+	return rcv._tab.MutateByteSlot(16, n)
+}
+
+/// Metadata tool-specific key/value metadata of file, sorted by key.
+func (rcv *File) Metadata(obj *Meta, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *File) MetadataLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// Metadata tool-specific key/value metadata of file, sorted by key.
+/// Language source language of file.
+func (rcv *File) Language() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// Language source language of file.
+func (rcv *File) MutateLanguage(n byte) bool {
+	return rcv._tab.MutateByteSlot(20, n)
+}
+
+/// Unsaved whether the content of file was read from the unsaved buffer instead of the disk.
+func (rcv *File) Unsaved() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(22))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// Unsaved whether the content of file was read from the unsaved buffer instead of the disk.
+func (rcv *File) MutateUnsaved(n byte) bool {
+	return rcv._tab.MutateByteSlot(22, n)
+}
+
+/// Diagnostics diagnostics of the clang parse of file.
+func (rcv *File) Diagnostics(obj *Diagnostic, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *File) DiagnosticsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// Diagnostics diagnostics of the clang parse of file.
 func FileStart(builder *flatbuffers.Builder) {
-	builder.StartObject(6)
+	builder.StartObject(11)
 }
 func FileAddName(builder *flatbuffers.Builder, Name flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(Name), 0)
@@ -160,6 +252,27 @@ func FileAddIncludes(builder *flatbuffers.Builder, Includes flatbuffers.UOffsetT
 func FileStartIncludesVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(4, numElems, 4)
 }
+func FileAddTUOmitted(builder *flatbuffers.Builder, TUOmitted byte) {
+	builder.PrependByteSlot(6, TUOmitted, 0)
+}
+func FileAddMetadata(builder *flatbuffers.Builder, Metadata flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(7, flatbuffers.UOffsetT(Metadata), 0)
+}
+func FileStartMetadataVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func FileAddLanguage(builder *flatbuffers.Builder, Language byte) {
+	builder.PrependByteSlot(8, Language, 0)
+}
+func FileAddUnsaved(builder *flatbuffers.Builder, Unsaved byte) {
+	builder.PrependByteSlot(9, Unsaved, 0)
+}
+func FileAddDiagnostics(builder *flatbuffers.Builder, Diagnostics flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(10, flatbuffers.UOffsetT(Diagnostics), 0)
+}
+func FileStartDiagnosticsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
 func FileEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -47,8 +47,28 @@ func (rcv *Header) MutateMtime(n int64) bool {
 	return rcv._tab.MutateInt64Slot(6, n)
 }
 
+func (rcv *Header) Path() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *Header) Exists() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 1
+}
+
+func (rcv *Header) MutateExists(n byte) bool {
+	return rcv._tab.MutateByteSlot(10, n)
+}
+
 func HeaderStart(builder *flatbuffers.Builder) {
-	builder.StartObject(2)
+	builder.StartObject(4)
 }
 func HeaderAddFileID(builder *flatbuffers.Builder, FileID flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(FileID), 0)
@@ -56,6 +76,12 @@ func HeaderAddFileID(builder *flatbuffers.Builder, FileID flatbuffers.UOffsetT)
 func HeaderAddMtime(builder *flatbuffers.Builder, Mtime int64) {
 	builder.PrependInt64Slot(1, Mtime, 0)
 }
+func HeaderAddPath(builder *flatbuffers.Builder, Path flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(2, flatbuffers.UOffsetT(Path), 0)
+}
+func HeaderAddExists(builder *flatbuffers.Builder, Exists byte) {
+	builder.PrependByteSlot(3, Exists, 1)
+}
 func HeaderEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -96,8 +96,243 @@ func (rcv *Info) CallersLength() int {
 }
 
 /// Callers caller of functions.
+/// Name spelling of the symbol.
+func (rcv *Info) Name() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// Name spelling of the symbol.
+/// Type type spelling of the symbol.
+func (rcv *Info) Type() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// Type type spelling of the symbol.
+/// ResultType result type spelling of the function symbol.
+func (rcv *Info) ResultType() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// ResultType result type spelling of the function symbol.
+/// Params parameters of the function symbol.
+func (rcv *Info) Params(obj *Param, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *Info) ParamsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// Params parameters of the function symbol.
+/// Variadic whether the function symbol is variadic.
+func (rcv *Info) Variadic() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// Variadic whether the function symbol is variadic.
+func (rcv *Info) MutateVariadic(n byte) bool {
+	return rcv._tab.MutateByteSlot(20, n)
+}
+
+/// Namespace enclosing namespace chain of the symbol, such as "a::b".
+func (rcv *Info) Namespace() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(22))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// Namespace enclosing namespace chain of the symbol, such as "a::b".
+/// Bases USRs of the base classes.
+func (rcv *Info) Bases(j int) []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+	}
+	return nil
+}
+
+func (rcv *Info) BasesLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// Bases USRs of the base classes.
+/// IsVirtual whether the method symbol is virtual.
+func (rcv *Info) IsVirtual() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(26))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// IsVirtual whether the method symbol is virtual.
+func (rcv *Info) MutateIsVirtual(n byte) bool {
+	return rcv._tab.MutateByteSlot(26, n)
+}
+
+/// Overrides USRs of the overridden methods.
+func (rcv *Info) Overrides(j int) []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(28))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+	}
+	return nil
+}
+
+func (rcv *Info) OverridesLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(28))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// Overrides USRs of the overridden methods.
+/// PackedLocations varint encoded Decls, Def and Callers, which used instead of them if present.
+func (rcv *Info) PackedLocations() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// PackedLocations varint encoded Decls, Def and Callers, which used instead of them if present.
+/// PackedStrings file names and USRs referenced by PackedLocations.
+func (rcv *Info) PackedStrings(j int) []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(32))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+	}
+	return nil
+}
+
+func (rcv *Info) PackedStringsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(32))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+/// PackedStrings file names and USRs referenced by PackedLocations.
+/// DefMtime modified time of the file which contains the definition.
+func (rcv *Info) DefMtime() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(34))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// DefMtime modified time of the file which contains the definition.
+func (rcv *Info) MutateDefMtime(n int64) bool {
+	return rcv._tab.MutateInt64Slot(34, n)
+}
+
+/// CommentRange source range of the documentation comment.
+func (rcv *Info) CommentRange(obj *Range) *Range {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(36))
+	if o != 0 {
+		x := rcv._tab.Indirect(o + rcv._tab.Pos)
+		if obj == nil {
+			obj = new(Range)
+		}
+		obj.Init(rcv._tab.Bytes, x)
+		return obj
+	}
+	return nil
+}
+
+/// CommentRange source range of the documentation comment.
+/// Kind stable SymbolKind of the symbol declaration.
+func (rcv *Info) Kind() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(38))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// Kind stable SymbolKind of the symbol declaration.
+func (rcv *Info) MutateKind(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(38, n)
+}
+
+/// ParentUSR USR of the semantic parent, such as the enclosing struct of the field.
+func (rcv *Info) ParentUSR() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(40))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// ParentUSR USR of the semantic parent, such as the enclosing struct of the field.
+/// ParamIndex zero-based index of the parameter symbol in its function.
+func (rcv *Info) ParamIndex() uint32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(42))
+	if o != 0 {
+		return rcv._tab.GetUint32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// ParamIndex zero-based index of the parameter symbol in its function.
+func (rcv *Info) MutateParamIndex(n uint32) bool {
+	return rcv._tab.MutateUint32Slot(42, n)
+}
+
+/// ObjCSelector selector of the Objective-C method symbol, such as "initWithName:age:".
+func (rcv *Info) ObjCSelector() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(44))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+/// ObjCSelector selector of the Objective-C method symbol, such as "initWithName:age:".
+
 func InfoStart(builder *flatbuffers.Builder) {
-	builder.StartObject(4)
+	builder.StartObject(21)
 }
 func InfoAddID(builder *flatbuffers.Builder, ID flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(ID), 0)
@@ -117,6 +352,72 @@ func InfoAddCallers(builder *flatbuffers.Builder, Callers flatbuffers.UOffsetT)
 func InfoStartCallersVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(4, numElems, 4)
 }
+func InfoAddName(builder *flatbuffers.Builder, Name flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(4, flatbuffers.UOffsetT(Name), 0)
+}
+func InfoAddType(builder *flatbuffers.Builder, Type flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(5, flatbuffers.UOffsetT(Type), 0)
+}
+func InfoAddResultType(builder *flatbuffers.Builder, ResultType flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(6, flatbuffers.UOffsetT(ResultType), 0)
+}
+func InfoAddParams(builder *flatbuffers.Builder, Params flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(7, flatbuffers.UOffsetT(Params), 0)
+}
+func InfoStartParamsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func InfoAddVariadic(builder *flatbuffers.Builder, Variadic byte) {
+	builder.PrependByteSlot(8, Variadic, 0)
+}
+func InfoAddNamespace(builder *flatbuffers.Builder, Namespace flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(9, flatbuffers.UOffsetT(Namespace), 0)
+}
+func InfoAddBases(builder *flatbuffers.Builder, Bases flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(10, flatbuffers.UOffsetT(Bases), 0)
+}
+func InfoStartBasesVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func InfoAddIsVirtual(builder *flatbuffers.Builder, IsVirtual byte) {
+	builder.PrependByteSlot(11, IsVirtual, 0)
+}
+func InfoAddOverrides(builder *flatbuffers.Builder, Overrides flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(12, flatbuffers.UOffsetT(Overrides), 0)
+}
+func InfoStartOverridesVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func InfoAddPackedLocations(builder *flatbuffers.Builder, PackedLocations flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(13, flatbuffers.UOffsetT(PackedLocations), 0)
+}
+func InfoStartPackedLocationsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(1, numElems, 1)
+}
+func InfoAddPackedStrings(builder *flatbuffers.Builder, PackedStrings flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(14, flatbuffers.UOffsetT(PackedStrings), 0)
+}
+func InfoStartPackedStringsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func InfoAddDefMtime(builder *flatbuffers.Builder, DefMtime int64) {
+	builder.PrependInt64Slot(15, DefMtime, 0)
+}
+func InfoAddCommentRange(builder *flatbuffers.Builder, CommentRange flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(16, flatbuffers.UOffsetT(CommentRange), 0)
+}
+func InfoAddKind(builder *flatbuffers.Builder, Kind uint32) {
+	builder.PrependUint32Slot(17, Kind, 0)
+}
+func InfoAddParentUSR(builder *flatbuffers.Builder, ParentUSR flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(18, flatbuffers.UOffsetT(ParentUSR), 0)
+}
+func InfoAddParamIndex(builder *flatbuffers.Builder, ParamIndex uint32) {
+	builder.PrependUint32Slot(19, ParamIndex, 0)
+}
+func InfoAddObjCSelector(builder *flatbuffers.Builder, ObjCSelector flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(20, flatbuffers.UOffsetT(ObjCSelector), 0)
+}
 func InfoEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
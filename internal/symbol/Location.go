@@ -89,8 +89,22 @@ func (rcv *Location) USR() []byte {
 }
 
 /// USR Unified Symbol Resolution of cursor.
+/// IsForward whether the location is the forward declaration, which has no definition.
+func (rcv *Location) IsForward() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+/// IsForward whether the location is the forward declaration, which has no definition.
+func (rcv *Location) MutateIsForward(n byte) bool {
+	return rcv._tab.MutateByteSlot(14, n)
+}
+
 func LocationStart(builder *flatbuffers.Builder) {
-	builder.StartObject(5)
+	builder.StartObject(6)
 }
 func LocationAddFileName(builder *flatbuffers.Builder, FileName flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(FileName), 0)
@@ -107,6 +121,9 @@ func LocationAddOffset(builder *flatbuffers.Builder, Offset uint32) {
 func LocationAddUSR(builder *flatbuffers.Builder, USR flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(4, flatbuffers.UOffsetT(USR), 0)
 }
+func LocationAddIsForward(builder *flatbuffers.Builder, IsForward byte) {
+	builder.PrependByteSlot(5, IsForward, 0)
+}
 func LocationEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -65,6 +65,10 @@ type Config struct {
 	ClangOption uint32
 	Jobs        int
 
+	// MaxTranslationUnitBytes maximum size of the TranslationUnit data stored in the index.
+	// The zero value means no limit.
+	MaxTranslationUnitBytes int
+
 	Debug bool
 }
 
@@ -241,6 +245,8 @@ func (p *Parser) ParseFile(arg parseArg) error {
 
 	rootCursor := tu.TranslationUnitCursor()
 	file := symbol.NewFile(arg.filename, arg.flag)
+	file.SetMaxTranslationUnitBytes(p.config.MaxTranslationUnitBytes)
+	var visitErr error // the first error of the Add methods, which stops the visiting
 	visitNode := func(cursor, parent clang.Cursor) clang.ChildVisitResult {
 		if cursor.IsNull() {
 			log.Debug("cursor: <none>")
@@ -253,47 +259,89 @@ func (p *Parser) ParseFile(arg parseArg) error {
 			return clang.ChildVisit_Continue
 		}
 
+		var err error
 		kind := cursor.Kind()
 		switch kind {
-		case clang.Cursor_FunctionDecl, clang.Cursor_StructDecl, clang.Cursor_FieldDecl, clang.Cursor_TypedefDecl, clang.Cursor_EnumDecl, clang.Cursor_EnumConstantDecl:
+		case clang.Cursor_FunctionDecl, clang.Cursor_CXXMethod, clang.Cursor_ClassDecl, clang.Cursor_ClassTemplate, clang.Cursor_StructDecl, clang.Cursor_UnionDecl, clang.Cursor_FieldDecl, clang.Cursor_TypedefDecl, clang.Cursor_EnumDecl, clang.Cursor_EnumConstantDecl,
+			clang.Cursor_ObjCInterfaceDecl, clang.Cursor_ObjCCategoryDecl, clang.Cursor_ObjCProtocolDecl, clang.Cursor_ObjCInstanceMethodDecl:
 			defCursor := cursor.Definition()
 			if defCursor.IsNull() {
-				file.AddDecl(cursorLoc)
+				err = file.AddDecl(cursorLoc)
 			} else {
 				defLoc := symbol.FromCursor(defCursor)
-				file.AddDefinition(cursorLoc, defLoc)
+				err = file.AddDefinition(cursorLoc, defLoc)
+			}
+			if err == nil {
+				err = file.AddCursor(cursor)
 			}
 		case clang.Cursor_MacroDefinition:
-			file.AddDefinition(cursorLoc, cursorLoc)
+			err = file.AddDefinition(cursorLoc, cursorLoc)
 		case clang.Cursor_VarDecl:
-			file.AddDecl(cursorLoc)
+			if err = file.AddDecl(cursorLoc); err == nil {
+				err = file.AddCursor(cursor)
+			}
 		case clang.Cursor_ParmDecl:
 			if cursor.Spelling() != "" {
-				file.AddDecl(cursorLoc)
+				if err = file.AddDecl(cursorLoc); err == nil {
+					err = file.AddCursor(cursor)
+				}
 			}
 		case clang.Cursor_CallExpr:
 			refCursor := cursor.Referenced()
 			refLoc := symbol.FromCursor(refCursor)
-			file.AddCaller(cursorLoc, refLoc, true)
+			args := make([]symbol.Location, 0, cursor.NumArguments())
+			for i := int32(0); i < cursor.NumArguments(); i++ {
+				extent := symbol.FromSourceRange(cursor.Argument(uint32(i)).Extent())
+				args = append(args, extent.Start())
+			}
+			err = file.AddCallArguments(cursorLoc, refLoc, args)
 		case clang.Cursor_DeclRefExpr, clang.Cursor_TypeRef, clang.Cursor_MemberRefExpr, clang.Cursor_MacroExpansion:
 			refCursor := cursor.Referenced()
 			refLoc := symbol.FromCursor(refCursor)
-			file.AddCaller(cursorLoc, refLoc, false)
+			err = file.AddCaller(cursorLoc, refLoc, false)
 		case clang.Cursor_InclusionDirective:
 			incFile := cursor.IncludedFile()
-			file.AddHeader(cursor.Spelling(), incFile)
+			if err = file.AddHeader(cursor.Spelling(), incFile); err == nil {
+				err = file.AddInclude(cursor.Spelling(), cursorLoc, incFile)
+			}
 		default:
 			if p.debugUncatched {
 				p.uncachedKind[kind]++
 			}
 		}
+		switch {
+		case err == nil:
+		case errors.Cause(err) == symbol.ErrUnknownSymbol:
+			// the callee which is not indexed, such as of the system header
+			log.Debugf("skip the caller at %s:%d:%d: %v", cursorLoc.FileName(), cursorLoc.Line(), cursorLoc.Col(), err)
+		default:
+			visitErr = err
+			return clang.ChildVisit_Break
+		}
 
 		return clang.ChildVisit_Recurse
 	}
 
 	rootCursor.Visit(visitNode)
-	file.AddTranslationUnit(<-tuch)
-	buf := file.Serialize()
+	// wait for the serialization before the deferred tu.Dispose, even if the visiting failed
+	tuBuf := <-tuch
+	if visitErr != nil {
+		return errors.Wrapf(visitErr, "could not index %s", arg.filename)
+	}
+	for _, d := range tu.Diagnostics() {
+		err := file.AddDiagnostic(symbol.FromDiagnostic(d))
+		d.Dispose()
+		if err != nil {
+			return errors.Wrapf(err, "could not index %s", arg.filename)
+		}
+	}
+	if err := file.AddTranslationUnit(tuBuf); err != nil {
+		return errors.Wrapf(err, "could not index %s", arg.filename)
+	}
+	buf, err := file.SerializeChecked()
+	if err != nil {
+		return errors.Wrapf(err, "could not serialize %s", arg.filename)
+	}
 
 	out := symbol.GetRootAsFile(buf.FinishedBytes(), 0)
 	printFile(out) // for debug
@@ -7,6 +7,8 @@ package parser
 import (
 	"runtime"
 	"sync"
+
+	"github.com/zchee/clang-server/internal/log"
 )
 
 // dispatcher represents a management workers.
@@ -99,7 +101,9 @@ func (w *worker) start() {
 			select {
 			case v := <-w.data:
 				if arg, ok := v.(parseArg); ok {
-					w.fn(arg)
+					if err := w.fn(arg); err != nil {
+						log.Printf("could not parse %s: %v", arg.filename, err)
+					}
 				}
 
 				w.dispatcher.wg.Done()
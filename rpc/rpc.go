@@ -80,13 +80,13 @@ func (s *server) Completion(ctx context.Context, loc *symbol.SymbolLocation) (*f
 
 		file := symbol.GetRootAsFile(buf, 0)
 
-		if cErr := s.idx.ParseTranslationUnit2(file.Name(), file.Flags(), nil, clang.DefaultEditingTranslationUnitOptions()|clang.DefaultCodeCompleteOptions()|uint32(clang.TranslationUnit_KeepGoing), &s.tu); clang.ErrorCode(cErr) != clang.Error_Success {
+		if cErr := s.idx.ParseTranslationUnit2(file.Name(), file.Flags(), nil, clang.DefaultEditingTranslationUnitOptions()|clang.DefaultCodeCompleteOptions()|uint32(clang.TranslationUnit_KeepGoing|clang.TranslationUnit_IncludeBriefCommentsInCodeCompletion), &s.tu); clang.ErrorCode(cErr) != clang.Error_Success {
 			log.Fatal(cErr)
 		}
 	}
 
 	codeCompleteResults := new(symbol.CodeCompleteResults)
-	result := codeCompleteResults.Marshal(s.tu.CodeCompleteAt(f, loc.Line(), loc.Col(), nil, clang.DefaultCodeCompleteOptions()))
+	result := codeCompleteResults.Marshal(s.tu.CodeCompleteAt(f, loc.Line(), loc.Col(), nil, clang.DefaultCodeCompleteOptions()|uint32(clang.CodeComplete_IncludeBriefComments)))
 
 	return result, nil
 }
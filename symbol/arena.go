@@ -0,0 +1,74 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+// Chunk sizes of the arena. The chunks start small for the small File, and grow up to maxArenaChunk.
+const (
+	minArenaChunk = 16
+	maxArenaChunk = 4096
+)
+
+// arena allocates the Infos, Callers and first declaration Locations of the File in chunks, so the indexing of
+// the large translation unit makes a few large allocations instead of millions of small ones.
+// The handed out values are never moved, and the chunks are garbage collected at once after ReleaseArena.
+// The zero value is ready to use.
+type arena struct {
+	infos   []Info
+	callers []Caller
+	locs    []Location
+}
+
+// nextChunk returns the size of the chunk which follows the full chunk of n.
+func nextChunk(n int) int {
+	switch {
+	case n < minArenaChunk:
+		return minArenaChunk
+	case n >= maxArenaChunk:
+		return maxArenaChunk
+	default:
+		return 2 * n
+	}
+}
+
+// newInfo returns the zeroed Info.
+func (a *arena) newInfo() *Info {
+	if len(a.infos) == cap(a.infos) {
+		a.infos = make([]Info, 0, nextChunk(cap(a.infos)))
+	}
+	a.infos = a.infos[:len(a.infos)+1]
+	return &a.infos[len(a.infos)-1]
+}
+
+// newCaller returns the zeroed Caller.
+func (a *arena) newCaller() *Caller {
+	if len(a.callers) == cap(a.callers) {
+		a.callers = make([]Caller, 0, nextChunk(cap(a.callers)))
+	}
+	a.callers = a.callers[:len(a.callers)+1]
+	return &a.callers[len(a.callers)-1]
+}
+
+// newLocations returns the empty Location slice of the capacity of one, for the first declaration of the symbol.
+// The capacity is limited, so appending the second declaration reallocates it instead of overwriting the next one.
+func (a *arena) newLocations() []Location {
+	if len(a.locs) == cap(a.locs) {
+		a.locs = make([]Location, 0, nextChunk(cap(a.locs)))
+	}
+	n := len(a.locs)
+	a.locs = a.locs[:n+1]
+	return a.locs[n : n : n+1]
+}
+
+// ReleaseArena drops the in-memory symbols of f, which allocated from the arena during the indexing, so they are
+// garbage collected at once instead of lingering with the File. It is intended to be called after the File
+// was serialized, such as by Serialize, and f has no symbols afterward.
+// The Infos and Callers which obtained from f before must not be used after calling it.
+func (f *File) ReleaseArena() {
+	f.hydrate()
+	f.arena = arena{}
+	f.symbols = make(map[ID]*Info)
+	f.locations = make(map[Location]ID)
+	f.lineIndex = nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	var a arena
+	infos := make(map[*Info]bool)
+	for i := 0; i < 3*maxArenaChunk; i++ {
+		info := a.newInfo()
+		if infos[info] {
+			t.Fatalf("newInfo() #%d returned the Info twice", i)
+		}
+		infos[info] = true
+	}
+
+	// appending the second declaration must not overwrite the first declaration of the next symbol
+	first, next := a.newLocations(), a.newLocations()
+	first = append(first, Location{line: 1})
+	next = append(next, Location{line: 10})
+	first = append(first, Location{line: 2})
+	if next[0].line != 10 || first[0].line != 1 || first[1].line != 2 {
+		t.Errorf("declarations = %v and %v, want lines [1 2] and [10]", first, next)
+	}
+}
+
+func TestFile_ReleaseArena(t *testing.T) {
+	newFile := func() *File {
+		f := NewFile("main.c", nil)
+		f.AddBatch(batchRecords(100))
+		return f
+	}
+	f := newFile()
+	serialized := roundTrip(f)
+
+	f.ReleaseArena()
+	if n := len(f.Symbols()); n != 0 {
+		t.Errorf("len(Symbols()) after ReleaseArena = %d, want 0", n)
+	}
+	if !serialized.Equal(newFile()) {
+		t.Error("serialized File is broken by ReleaseArena")
+	}
+
+	// the File can be reused for the next indexing
+	f.AddBatch(batchRecords(10))
+	if n := len(f.Symbols()); n != 10 {
+		t.Errorf("len(Symbols()) after the reindexing = %d, want 10", n)
+	}
+}
+
+// BenchmarkFile_Index indexes the synthetic translation unit of 200k cursors, and serializes it.
+func BenchmarkFile_Index(b *testing.B) {
+	records := batchRecords(200000 / 3)
+	for i := range records {
+		// declare a half of the symbols twice, in the header and the source
+		if records[i].Kind == RecordDecl && i%2 == 0 {
+			records[i].Location.fileName = fmt.Sprintf("include/%d.h", i%16)
+		}
+	}
+
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		f := NewFile("main.c", nil)
+		f.AddBatch(records)
+		f.Serialize()
+		f.ReleaseArena()
+	}
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zchee/clang-server/internal/hashutil"
+)
+
+// CompletionKey represents a key of the CompletionCache.
+type CompletionKey struct {
+	File     string
+	Line     uint32
+	Col      uint32
+	Contents [hashutil.Size]byte // hash of the unsaved file contents
+	Flags    [hashutil.Size]byte // hash of the compile flags
+}
+
+// NewCompletionKey returns the CompletionKey of the completion at line and col of file,
+// which has the unsaved contents and is compiled with flags.
+func NewCompletionKey(file string, line, col uint32, contents []byte, flags []string) CompletionKey {
+	return CompletionKey{
+		File:     file,
+		Line:     line,
+		Col:      col,
+		Contents: hashutil.NewHash(contents),
+		Flags:    hashutil.NewHashString(strings.Join(flags, "\x00")),
+	}
+}
+
+// CompletionCache is the LRU cache of the finished CodeCompleteResults flatbuffers binary.
+// It is safe for concurrent use.
+type CompletionCache struct {
+	mu sync.Mutex
+
+	ttl      time.Duration
+	maxBytes int
+	bytes    int
+	ll       *list.List // front is the most recently used
+	entries  map[CompletionKey]*list.Element
+
+	hits   uint64
+	misses uint64
+
+	metrics Metrics // nil uses the package Metrics
+	now     func() time.Time
+}
+
+type completionEntry struct {
+	key     CompletionKey
+	buf     []byte
+	expires time.Time
+}
+
+// NewCompletionCache returns the new CompletionCache which holds at most maxBytes of buffers.
+// The entries expire after ttl. If ttl is zero, the entries never expire.
+func NewCompletionCache(maxBytes int, ttl time.Duration) *CompletionCache {
+	return &CompletionCache{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		entries:  make(map[CompletionKey]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns the buffer cached for key.
+func (c *CompletionCache) Get(key CompletionKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := metricsOf(c.metrics)
+	elem, ok := c.entries[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		m.IncCounter(MetricCompletionCacheMisses, 1)
+		return nil, false
+	}
+	ent := elem.Value.(*completionEntry)
+	if c.ttl > 0 && !c.now().Before(ent.expires) {
+		c.removeElement(elem)
+		atomic.AddUint64(&c.misses, 1)
+		m.IncCounter(MetricCompletionCacheMisses, 1)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+	atomic.AddUint64(&c.hits, 1)
+	m.IncCounter(MetricCompletionCacheHits, 1)
+	return ent.buf, true
+}
+
+// SetMetrics sets the Metrics which records the hits and misses of c.
+// The nil m uses the package Metrics, see SetMetrics.
+func (c *CompletionCache) SetMetrics(m Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metrics = m
+}
+
+// Put caches the finished buffer buf for key.
+// The least recently used entries are evicted until the total size fits, and buf larger than the cache is not cached.
+func (c *CompletionCache) Put(key CompletionKey, buf []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	if len(buf) > c.maxBytes {
+		return
+	}
+
+	ent := &completionEntry{key: key, buf: buf}
+	if c.ttl > 0 {
+		ent.expires = c.now().Add(c.ttl)
+	}
+	c.entries[key] = c.ll.PushFront(ent)
+	c.bytes += len(buf)
+
+	for c.bytes > c.maxBytes {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Invalidate removes all entries of file, such as when the File is reindexed.
+func (c *CompletionCache) Invalidate(file string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if key.File == file {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// Len returns the number of the cached entries.
+func (c *CompletionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Hits returns the number of the Get calls which found the entry.
+func (c *CompletionCache) Hits() uint64 {
+	return atomic.LoadUint64(&c.hits)
+}
+
+// Misses returns the number of the Get calls which did not find the entry.
+func (c *CompletionCache) Misses() uint64 {
+	return atomic.LoadUint64(&c.misses)
+}
+
+func (c *CompletionCache) removeElement(elem *list.Element) {
+	ent := c.ll.Remove(elem).(*completionEntry)
+	delete(c.entries, ent.key)
+	c.bytes -= len(ent.buf)
+}
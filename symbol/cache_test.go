@@ -0,0 +1,122 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompletionCache(t *testing.T) {
+	contents := []byte("int main() { return 0; }")
+	flags := []string{"-std=c11", "-Wall"}
+	key := NewCompletionKey("main.c", 1, 14, contents, flags)
+	buf := []byte("results")
+
+	edited := append([]byte(nil), contents...)
+	edited[len(edited)-2] = ')'
+
+	c := NewCompletionCache(1<<20, 0)
+	c.Put(key, buf)
+
+	if got, ok := c.Get(NewCompletionKey("main.c", 1, 14, contents, flags)); !ok || string(got) != string(buf) {
+		t.Errorf("Get() = %q, %v, want %q, true", got, ok, buf)
+	}
+	for name, k := range map[string]CompletionKey{
+		"file":     NewCompletionKey("util.c", 1, 14, contents, flags),
+		"line":     NewCompletionKey("main.c", 2, 14, contents, flags),
+		"col":      NewCompletionKey("main.c", 1, 15, contents, flags),
+		"contents": NewCompletionKey("main.c", 1, 14, edited, flags),
+		"flags":    NewCompletionKey("main.c", 1, 14, contents, []string{"-std=c11"}),
+	} {
+		if _, ok := c.Get(k); ok {
+			t.Errorf("Get() with the different %s hits", name)
+		}
+	}
+	if hits, misses := c.Hits(), c.Misses(); hits != 1 || misses != 5 {
+		t.Errorf("Hits(), Misses() = %d, %d, want 1, 5", hits, misses)
+	}
+
+	c.Invalidate("main.c")
+	if _, ok := c.Get(key); ok {
+		t.Error("Get() hits after Invalidate")
+	}
+}
+
+func TestCompletionCache_TTL(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	c := NewCompletionCache(1<<20, time.Minute)
+	c.now = func() time.Time { return now }
+
+	key := NewCompletionKey("main.c", 1, 1, nil, nil)
+	c.Put(key, []byte("results"))
+
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get(key); !ok {
+		t.Error("Get() misses before the TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get(key); ok {
+		t.Error("Get() hits after the TTL")
+	}
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len() = %d after the expiry, want 0", n)
+	}
+}
+
+func TestCompletionCache_Evict(t *testing.T) {
+	c := NewCompletionCache(10, 0)
+	keys := make([]CompletionKey, 3)
+	for i := range keys {
+		keys[i] = NewCompletionKey("main.c", uint32(i), 1, nil, nil)
+	}
+
+	c.Put(keys[0], make([]byte, 4))
+	c.Put(keys[1], make([]byte, 4))
+	c.Get(keys[0]) // keys[1] becomes the least recently used
+	c.Put(keys[2], make([]byte, 4))
+
+	if _, ok := c.Get(keys[1]); ok {
+		t.Error("the least recently used entry is not evicted")
+	}
+	for _, key := range []CompletionKey{keys[0], keys[2]} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("entry of line %d is evicted", key.Line)
+		}
+	}
+
+	c.Put(keys[1], make([]byte, 11))
+	if _, ok := c.Get(keys[1]); ok {
+		t.Error("buffer larger than the cache is cached")
+	}
+}
+
+func TestCompletionCache_Concurrent(t *testing.T) {
+	c := NewCompletionCache(1<<10, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := NewCompletionKey(fmt.Sprintf("%d.c", j%4), uint32(j), 1, nil, nil)
+				if _, ok := c.Get(key); !ok {
+					c.Put(key, make([]byte, 16))
+				}
+				if j%10 == i {
+					c.Invalidate(key.File)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := c.Hits() + c.Misses(); got != 800 {
+		t.Errorf("Hits() + Misses() = %d, want 800", got)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CanonicalDumpVersion version of the CanonicalDump format, which is written at the first line.
+const CanonicalDumpVersion = 1
+
+// canonicalIDLength number of the hexadecimal digits of the short IDs of CanonicalDump.
+const canonicalIDLength = 16
+
+// CanonicalDumpOption represents a option of CanonicalDump.
+type CanonicalDumpOption func(*canonicalDumpOptions)
+
+type canonicalDumpOptions struct {
+	timestamps bool
+}
+
+// WithTimestamps sets whether CanonicalDump writes the modified times of the headers and definitions.
+func WithTimestamps(timestamps bool) CanonicalDumpOption {
+	return func(o *canonicalDumpOptions) { o.timestamps = timestamps }
+}
+
+// CanonicalDump writes f to w in the canonical text form, which is diffed to find the regressions of the indexer.
+//
+// The first line is the format version, and the next is the "file" line of the name and language of f. The rest
+// is one line per the flag, metadata, header, #include directive, and the decl, def and caller of the symbols,
+// whose fields are separated by the tab in the fixed order, such as
+//
+//	decl	<short ID>	<kind>	<name>	<file>:<line>:<col>
+//
+// The lines are sorted, except the flags which are kept in order, so the semantically equal Files produce the
+// identical output regardless of the insertion order. The modified times are omitted unless WithTimestamps is set.
+func (f *File) CanonicalDump(w io.Writer, opts ...CanonicalDumpOption) error {
+	bw := bufio.NewWriter(w)
+	writeCanonicalVersion(bw)
+	writeCanonicalFile(bw, f, newCanonicalDumpOptions(opts))
+
+	if err := bw.Flush(); err != nil {
+		return errors.Wrapf(err, "could not dump %s", f.Name())
+	}
+	return nil
+}
+
+// CanonicalDump writes all Files of t to w in the canonical text form of File.CanonicalDump, which are concatenated
+// in the FileID order after the single version line.
+func (t *Table) CanonicalDump(w io.Writer, opts ...CanonicalDumpOption) error {
+	o := newCanonicalDumpOptions(opts)
+	bw := bufio.NewWriter(w)
+	writeCanonicalVersion(bw)
+	for _, f := range t.Files() {
+		writeCanonicalFile(bw, f, o)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return errors.Wrap(err, "could not dump the table")
+	}
+	return nil
+}
+
+func newCanonicalDumpOptions(opts []CanonicalDumpOption) canonicalDumpOptions {
+	var o canonicalDumpOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func writeCanonicalVersion(w *bufio.Writer) {
+	fmt.Fprintf(w, "# clang-server canonical dump v%d\n", CanonicalDumpVersion)
+}
+
+// writeCanonicalFile writes the lines of f except the version line.
+func writeCanonicalFile(w *bufio.Writer, f *File, o canonicalDumpOptions) {
+	fmt.Fprintf(w, "file\t%s\t%s\n", f.Name(), f.Language())
+	for i, flag := range f.Flags() {
+		fmt.Fprintf(w, "flag\t%d\t%s\n", i, flag)
+	}
+
+	var lines []string
+	for key, value := range f.metadata() {
+		lines = append(lines, canonicalLine("meta", key, value))
+	}
+	for _, h := range f.Headers() {
+		fields := []string{"header", shortHex(h.FileID().String()), h.Path(), strconv.FormatBool(h.Exists())}
+		if o.timestamps {
+			fields = append(fields, "mtime="+strconv.FormatInt(h.Mtime(), 10))
+		}
+		lines = append(lines, canonicalLine(fields...))
+	}
+	for _, inc := range f.Includes() {
+		fid, _ := inc.FileID()
+		lines = append(lines, canonicalLine("include", inc.Spelling(), canonicalLocation(inc.Location()), shortHex(fid.String())))
+	}
+	for _, sym := range f.Symbols() {
+		id, kind, name := shortHex(sym.ID().String()), sym.SymbolKind().String(), sym.Name()
+		for _, decl := range sym.Decls() {
+			fields := []string{"decl", id, kind, name, canonicalLocation(decl)}
+			if decl.IsForward() {
+				fields = append(fields, "forward")
+			}
+			lines = append(lines, canonicalLine(fields...))
+		}
+		if def := sym.Def(); !def.IsZero() {
+			fields := []string{"def", id, kind, name, canonicalLocation(def)}
+			if o.timestamps {
+				fields = append(fields, "mtime="+strconv.FormatInt(sym.DefModTime().Unix(), 10))
+			}
+			lines = append(lines, canonicalLine(fields...))
+		}
+		for _, caller := range sym.Callers() {
+			fields := []string{"caller", id, kind, name, canonicalLocation(caller.Location())}
+			if caller.FuncCall() {
+				fields = append(fields, "call")
+			}
+			lines = append(lines, canonicalLine(fields...))
+		}
+	}
+
+	sort.Strings(lines)
+	for _, line := range lines {
+		w.WriteString(line)
+	}
+}
+
+// canonicalLine returns the tab separated line of fields.
+func canonicalLine(fields ...string) string {
+	return strings.Join(fields, "\t") + "\n"
+}
+
+// canonicalLocation returns the "file:line:col" of loc, whose col is the 1-based byte column.
+func canonicalLocation(loc Location) string {
+	return fmt.Sprintf("%s:%d:%d", loc.FileName(), loc.Line(), loc.rawCol())
+}
+
+// shortHex returns the prefix of the hexadecimal ID s.
+func shortHex(s string) string {
+	if len(s) > canonicalIDLength {
+		return s[:canonicalIDLength]
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// canonicalTestFile returns the File of main.c which calls add of util.h at callCol, built in the reverse order
+// of the symbols if reverse is set.
+func canonicalTestFile(reverse bool, callCol uint32) *File {
+	f := NewFile("/src/main.c", []string{"-I/src", "-DDEBUG"})
+	f.headers = append(f.headers, &Header{fileid: ToFileID("/src/util.h"), mtime: time.Unix(1500000000, 0), path: "util.h", exists: true})
+	f.SetMeta("compiler", "clang-3.9")
+	f.SetMeta("host", "ci")
+
+	add := func() {
+		decl := Location{fileName: "/src/util.h", line: 1, col: 5, usr: "c:@F@add", kind: clang.Cursor_FunctionDecl}
+		f.AddDecl(decl)
+		f.Symbol(ToID("c:@F@add")).name = "add"
+		f.AddCaller(Location{fileName: "/src/main.c", line: 5, col: callCol, usr: "c:@F@add"}, decl, true)
+	}
+	main := func() {
+		def := Location{fileName: "/src/main.c", line: 3, col: 5, usr: "c:@F@main", kind: clang.Cursor_FunctionDecl}
+		f.AddDefinition(def, def)
+		f.Symbol(ToID("c:@F@main")).name = "main"
+	}
+	if reverse {
+		main()
+		add()
+	} else {
+		add()
+		main()
+	}
+
+	return f
+}
+
+func canonicalDump(t *testing.T, f *File, opts ...CanonicalDumpOption) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := f.CanonicalDump(&buf, opts...); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestFile_CanonicalDump(t *testing.T) {
+	want := canonicalDump(t, canonicalTestFile(false, 10))
+	if !strings.HasPrefix(want, "# clang-server canonical dump v1\nfile\t/src/main.c\tc\nflag\t0\t-I/src\nflag\t1\t-DDEBUG\n") {
+		t.Errorf("CanonicalDump() has the unexpected first lines:\n%s", want)
+	}
+	if strings.Contains(want, "mtime=") {
+		t.Errorf("CanonicalDump() has the timestamps:\n%s", want)
+	}
+
+	for name, f := range map[string]*File{
+		"reversed":   canonicalTestFile(true, 10),
+		"round trip": roundTrip(canonicalTestFile(true, 10)),
+	} {
+		if got := canonicalDump(t, f); got != want {
+			t.Errorf("%s: CanonicalDump() =\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+
+	// the moved call of add changes the single line
+	changed := strings.Split(canonicalDump(t, canonicalTestFile(true, 12)), "\n")
+	lines := strings.Split(want, "\n")
+	if len(changed) != len(lines) {
+		t.Fatalf("CanonicalDump() of the changed File has %d lines, want %d", len(changed), len(lines))
+	}
+	var diff []string
+	for i := range lines {
+		if lines[i] != changed[i] {
+			diff = append(diff, lines[i]+" -> "+changed[i])
+		}
+	}
+	if len(diff) != 1 || !strings.HasPrefix(diff[0], "caller\t") {
+		t.Errorf("CanonicalDump() diff = %q, want the single caller line", diff)
+	}
+
+	if got := canonicalDump(t, canonicalTestFile(false, 10), WithTimestamps(true)); !strings.Contains(got, "\tmtime=1500000000\n") {
+		t.Errorf("CanonicalDump() with the timestamps has no header mtime:\n%s", got)
+	}
+}
+
+func TestTable_CanonicalDump(t *testing.T) {
+	mainc := canonicalTestFile(false, 10)
+	util := NewFile("/src/util.c", nil)
+	def := Location{fileName: "/src/util.c", line: 1, col: 5, usr: "c:@F@add"}
+	util.AddDefinition(def, def)
+
+	var got bytes.Buffer
+	if err := NewTable([]*File{mainc, util}).CanonicalDump(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	const version = "# clang-server canonical dump v1\n"
+	want := version
+	for _, f := range NewTable([]*File{util, mainc}).Files() {
+		want += strings.TrimPrefix(canonicalDump(t, f), version)
+	}
+	if got.String() != want {
+		t.Errorf("CanonicalDump() =\n%s\nwant:\n%s", got.String(), want)
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+
+	"github.com/go-clang/v3.9/clang"
+	"github.com/pkg/errors"
+)
+
+// clangdIndexVersion is the version of the clangd RIFF index format which ImportClangdIndex reads.
+const clangdIndexVersion = 18
+
+// clangdSymbolIDSize is the size of the clangd SymbolID, which is the truncated SHA-1 of the USR.
+const clangdSymbolIDSize = 8
+
+// clangdRefKind the bit flags of the clangd RefKind.
+const (
+	clangdRefDeclaration = 1 << 0
+	clangdRefDefinition  = 1 << 1
+	clangdRefReference   = 1 << 2
+	clangdRefCall        = 1 << 4
+)
+
+// clangdSymbolKinds maps the clang::index::SymbolKind of clangd to the clang.CursorKind.
+// The kinds which have no cursor kind are imported as the unknown kind.
+var clangdSymbolKinds = map[uint8]clang.CursorKind{
+	2:  clang.Cursor_Namespace,
+	4:  clang.Cursor_MacroDefinition,
+	5:  clang.Cursor_EnumDecl,
+	6:  clang.Cursor_StructDecl,
+	7:  clang.Cursor_ClassDecl,
+	10: clang.Cursor_UnionDecl,
+	11: clang.Cursor_TypedefDecl,
+	12: clang.Cursor_FunctionDecl,
+	13: clang.Cursor_VarDecl,
+	14: clang.Cursor_FieldDecl,
+	15: clang.Cursor_EnumConstantDecl,
+	16: clang.Cursor_CXXMethod,
+	17: clang.Cursor_CXXMethod,
+	18: clang.Cursor_CXXMethod,
+	22: clang.Cursor_Constructor,
+	23: clang.Cursor_Destructor,
+	24: clang.Cursor_ConversionFunction,
+	25: clang.Cursor_ParmDecl,
+}
+
+// ImportStats represents the statistics of the import.
+type ImportStats struct {
+	Symbols int // number of imported symbols
+	Refs    int // number of imported references
+
+	// Skipped the number of skipped records per record type, such as the chunk ID of the unsupported chunk.
+	Skipped map[string]int
+}
+
+// skip counts the skipped record of typ.
+func (s *ImportStats) skip(typ string, n int) {
+	if s.Skipped == nil {
+		s.Skipped = make(map[string]int)
+	}
+	s.Skipped[typ] += n
+}
+
+// ImportClangdIndex reads the clangd RIFF index of the format version 18, such as the background index shard
+// in the .cache/clangd/index directory, and returns the File of name which has its symbols.
+//
+// The definition and canonical declaration of the symbols are added by AddDefinition and AddDecl,
+// and the references are added by AddCaller, which is the function call if the reference is the call.
+// The additional declarations in the references are added by AddDecl, and the other references are skipped.
+// The "symb", "refs" and "stri" chunks are imported, and the other chunks such as the relations,
+// include graph and compile command are skipped with the counts of the chunk ID.
+//
+// The clangd index has the SymbolID, which is the hash of the USR, instead of the USR itself.
+// The symbols are therefore identified by the "clangd:" prefixed hexadecimal SymbolID as the USR, which is
+// mapped through ToID, so the Files imported from the same index are linked, but not to the Files of the parser.
+// Use ClangdUSR to look up the imported symbol of the clang USR.
+// The clangd columns are the UTF-16 code units, which are imported as the byte columns without the file contents,
+// and the file offsets are zero.
+func ImportClangdIndex(name string, r io.Reader) (*File, ImportStats, error) {
+	var stats ImportStats
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, stats, errors.Wrap(err, "could not read the clangd index")
+	}
+	chunks, err := readRIFF(data)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	meta, ok := chunks["meta"]
+	if !ok || len(meta) < 4 {
+		return nil, stats, errors.New("clangd index: missing meta chunk")
+	}
+	if v := binary.LittleEndian.Uint32(meta); v != clangdIndexVersion {
+		return nil, stats, errors.Errorf("clangd index: unsupported version %d, want %d", v, clangdIndexVersion)
+	}
+	stri, ok := chunks["stri"]
+	if !ok {
+		return nil, stats, errors.New("clangd index: missing stri chunk")
+	}
+	strings, err := readClangdStrings(stri)
+	if err != nil {
+		return nil, stats, err
+	}
+	for id := range chunks {
+		switch id {
+		case "meta", "stri", "symb", "refs":
+		default:
+			stats.skip(id, 1)
+		}
+	}
+
+	f := NewFile(name, nil)
+	defs := make(map[string]Location) // USR -> definition or canonical declaration
+
+	rd := &clangdReader{buf: chunks["symb"], strings: strings}
+	for !rd.eof() {
+		sym := rd.symbol()
+		if rd.err != nil {
+			return nil, stats, errors.Wrapf(rd.err, "clangd index: symbol %d", stats.Symbols)
+		}
+		kind := clangdSymbolKinds[sym.kind]
+		def, decl := sym.definition, sym.declaration
+		hasDef, hasDecl := !def.IsZero(), !decl.IsZero()
+		def.usr, def.kind = sym.usr, kind
+		decl.usr, decl.kind = sym.usr, kind
+
+		switch {
+		case hasDef && hasDecl:
+			f.AddDefinition(decl, def)
+			defs[sym.usr] = def
+		case hasDef:
+			f.AddDefinition(def, def)
+			defs[sym.usr] = def
+		case hasDecl:
+			f.AddDecl(decl)
+			defs[sym.usr] = decl
+		default:
+			stats.skip("symbol without location", 1)
+			continue
+		}
+		stats.Symbols++
+	}
+
+	rd = &clangdReader{buf: chunks["refs"], strings: strings}
+	for !rd.eof() {
+		usr := rd.symbolID()
+		n := rd.uvarint()
+		for i := 0; i < int(n) && rd.err == nil; i++ {
+			kind := rd.byte()
+			loc := rd.location()
+			rd.symbolID() // container
+			if rd.err != nil {
+				break
+			}
+			loc.usr = usr
+
+			def := defs[usr]
+			switch {
+			case kind&clangdRefReference != 0:
+				if err := f.AddCaller(loc, def, kind&clangdRefCall != 0); err != nil {
+					return nil, stats, errors.Wrapf(err, "clangd index: reference of %s", usr)
+				}
+				stats.Refs++
+			case kind&clangdRefDeclaration != 0 && kind&clangdRefDefinition == 0 && !sameLocation(loc, def):
+				f.AddDecl(loc)
+				stats.Refs++
+			default:
+				stats.skip("duplicate declaration", 1)
+			}
+		}
+		if rd.err != nil {
+			return nil, stats, errors.Wrapf(rd.err, "clangd index: references of %s", usr)
+		}
+	}
+
+	return f, stats, nil
+}
+
+// ClangdUSR returns the USR which ImportClangdIndex gives the symbol of the clang usr,
+// which is the "clangd:" prefixed hexadecimal clangd SymbolID.
+func ClangdUSR(usr string) string {
+	sum := sha1.Sum([]byte(usr))
+	return clangdUSR(sum[:clangdSymbolIDSize])
+}
+
+// clangdUSR returns the USR of the clangd SymbolID id.
+func clangdUSR(id []byte) string {
+	return "clangd:" + hex.EncodeToString(id)
+}
+
+// sameLocation reports whether a and b are at the same file name, line and column.
+func sameLocation(a, b Location) bool {
+	return a.FileName() == b.FileName() && a.Line() == b.Line() && a.Col() == b.Col()
+}
+
+// readRIFF returns the chunks of the RIFF container data, keyed by the chunk ID.
+func readRIFF(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" {
+		return nil, errors.New("clangd index: not a RIFF file")
+	}
+	if string(data[8:12]) != "CdIx" {
+		return nil, errors.Errorf("clangd index: unknown RIFF type %q", data[8:12])
+	}
+	if size := binary.LittleEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		return nil, errors.Errorf("clangd index: RIFF size %d, got %d bytes", size, len(data)-8)
+	}
+
+	chunks := make(map[string][]byte)
+	buf := data[12:]
+	for len(buf) > 0 {
+		if len(buf) < 8 {
+			return nil, errors.New("clangd index: truncated chunk header")
+		}
+		id := string(buf[:4])
+		size := int(binary.LittleEndian.Uint32(buf[4:8]))
+		buf = buf[8:]
+		if size > len(buf) {
+			return nil, errors.Errorf("clangd index: chunk %q of %d bytes is truncated", id, size)
+		}
+		if _, ok := chunks[id]; ok {
+			return nil, errors.Errorf("clangd index: duplicate chunk %q", id)
+		}
+		chunks[id] = buf[:size]
+		// the chunks are aligned to 2 bytes
+		size += size & 1
+		if size > len(buf) {
+			size = len(buf)
+		}
+		buf = buf[size:]
+	}
+
+	return chunks, nil
+}
+
+// readClangdStrings returns the string table of the stri chunk, which is the null-terminated strings
+// optionally compressed by zlib.
+func readClangdStrings(stri []byte) ([]string, error) {
+	if len(stri) < 4 {
+		return nil, errors.New("clangd index: truncated stri chunk")
+	}
+	size := binary.LittleEndian.Uint32(stri)
+	table := stri[4:]
+	if size != 0 {
+		zr, err := zlib.NewReader(bytes.NewReader(table))
+		if err != nil {
+			return nil, errors.Wrap(err, "clangd index: could not decompress the string table")
+		}
+		// read at most one byte over the declared size, so the crafted chunk cannot expand without bound
+		if table, err = ioutil.ReadAll(io.LimitReader(zr, int64(size)+1)); err != nil {
+			return nil, errors.Wrap(err, "clangd index: could not decompress the string table")
+		}
+		if len(table) != int(size) {
+			return nil, errors.Errorf("clangd index: string table of %d bytes, want %d", len(table), size)
+		}
+	}
+
+	var strings []string
+	for len(table) > 0 {
+		i := bytes.IndexByte(table, 0)
+		if i < 0 {
+			return nil, errors.New("clangd index: unterminated string in the string table")
+		}
+		strings = append(strings, string(table[:i]))
+		table = table[i+1:]
+	}
+
+	return strings, nil
+}
+
+// clangdSymbol represents the symbol record of the clangd index which ImportClangdIndex uses.
+type clangdSymbol struct {
+	usr         string
+	kind        uint8
+	definition  Location
+	declaration Location
+}
+
+// clangdReader reads the records of the clangd index chunk. The first error is kept in err,
+// and the subsequent reads return the zero value.
+type clangdReader struct {
+	buf     []byte
+	strings []string
+	err     error
+}
+
+func (r *clangdReader) eof() bool {
+	return r.err != nil || len(r.buf) == 0
+}
+
+func (r *clangdReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = errors.Errorf(format, args...)
+	}
+	r.buf = nil
+}
+
+func (r *clangdReader) bytes(n int) []byte {
+	if len(r.buf) < n {
+		r.fail("truncated record")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *clangdReader) byte() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *clangdReader) uvarint() uint32 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 || v > 1<<32-1 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return uint32(v)
+}
+
+func (r *clangdReader) string() string {
+	i := r.uvarint()
+	if int(i) >= len(r.strings) {
+		r.fail("string index %d out of the %d strings", i, len(r.strings))
+		return ""
+	}
+	return r.strings[i]
+}
+
+// symbolID returns the USR which ImportClangdIndex uses for the SymbolID.
+func (r *clangdReader) symbolID() string {
+	return clangdUSR(r.bytes(clangdSymbolIDSize))
+}
+
+// location returns the start of the SymbolLocation, or the zero Location if it has no file.
+func (r *clangdReader) location() Location {
+	uri := r.string()
+	line, col := r.uvarint(), r.uvarint()
+	r.uvarint() // end line
+	r.uvarint() // end column
+	if uri == "" || r.err != nil {
+		return Location{}
+	}
+	fileName, err := FileNameFromURI(uri)
+	if err != nil {
+		r.fail("%v", err)
+		return Location{}
+	}
+
+	return Location{fileName: fileName, line: line + 1, col: col + 1}
+}
+
+func (r *clangdReader) symbol() clangdSymbol {
+	var sym clangdSymbol
+	sym.usr = r.symbolID()
+	sym.kind = r.byte()
+	r.byte()   // language
+	r.string() // name
+	r.string() // scope
+	r.string() // template specialization args
+	sym.definition = r.location()
+	sym.declaration = r.location()
+	r.uvarint() // references
+	r.byte()    // flags
+	for i := 0; i < 5; i++ {
+		r.string() // signature, snippet suffix, documentation, return type and type
+	}
+	n := r.uvarint()
+	for i := 0; i < int(n) && r.err == nil; i++ {
+		r.string()  // include header
+		r.uvarint() // references and supported directives
+	}
+
+	return sym
+}
@@ -0,0 +1,319 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// The testdata/clangd directory holds the clangd RIFF index shards of the fixture project:
+//
+//  /src/util.h:  int util(int);               // 1:5
+//  /src/util.c:  int counter;                 // 1:5
+//                int util(int n) { ... }      // 3:5
+//  /src/main.c:  int util(int);               // 2:5, redeclaration
+//                int main(void) {             // 4:5
+//                  util(1);                   // 5:3
+//                  int (*fn)(int) = util;     // 6:20, reference without call
+//                  return util(counter);      // 7:10, 7:15
+//                }
+//
+// The shards are written by clangdShard in the layout of clangd, and regenerated by:
+//
+//  go test ./symbol -run TestImportClangdIndex -update
+//
+// The existing shards are never rewritten, so they stay the reference which is independent of clangdShard.
+const clangdDir = "testdata/clangd"
+
+type clangdTestLocation struct {
+	uri       string
+	line, col uint32 // 0-based
+}
+
+type clangdTestSymbol struct {
+	usr       string
+	kind      uint8
+	name      string
+	def, decl clangdTestLocation
+}
+
+type clangdTestRef struct {
+	usr  string
+	kind uint8
+	loc  clangdTestLocation
+}
+
+// clangdShard returns the clangd RIFF index shard of the format version 18.
+func clangdShard(symbols []clangdTestSymbol, refs []clangdTestRef, compress bool) []byte {
+	// the string table is sorted and has the empty string, as clangd writes
+	uniq := map[string]bool{"": true}
+	for _, sym := range symbols {
+		uniq[sym.name], uniq[sym.def.uri], uniq[sym.decl.uri] = true, true, true
+	}
+	for _, ref := range refs {
+		uniq[ref.loc.uri] = true
+	}
+	var strs []string
+	for s := range uniq {
+		strs = append(strs, s)
+	}
+	sort.Strings(strs)
+	index := make(map[string]int)
+	var table bytes.Buffer
+	for i, s := range strs {
+		index[s] = i
+		table.WriteString(s)
+		table.WriteByte(0)
+	}
+
+	varint := func(buf *bytes.Buffer, v int) {
+		var b [binary.MaxVarintLen32]byte
+		buf.Write(b[:binary.PutUvarint(b[:], uint64(v))])
+	}
+	id := func(buf *bytes.Buffer, usr string) {
+		sum := sha1.Sum([]byte(usr))
+		buf.Write(sum[:clangdSymbolIDSize])
+	}
+	location := func(buf *bytes.Buffer, loc clangdTestLocation) {
+		varint(buf, index[loc.uri])
+		varint(buf, int(loc.line))
+		varint(buf, int(loc.col))
+		varint(buf, int(loc.line))
+		varint(buf, int(loc.col)+4)
+	}
+
+	var stri bytes.Buffer
+	if compress {
+		binary.Write(&stri, binary.LittleEndian, uint32(table.Len()))
+		zw := zlib.NewWriter(&stri)
+		zw.Write(table.Bytes())
+		zw.Close()
+	} else {
+		binary.Write(&stri, binary.LittleEndian, uint32(0))
+		stri.Write(table.Bytes())
+	}
+
+	var symb bytes.Buffer
+	for _, sym := range symbols {
+		id(&symb, sym.usr)
+		symb.Write([]byte{sym.kind, 0}) // kind and language
+		varint(&symb, index[sym.name])
+		varint(&symb, 0) // scope
+		varint(&symb, 0) // template specialization args
+		location(&symb, sym.def)
+		location(&symb, sym.decl)
+		varint(&symb, 1)  // references
+		symb.WriteByte(0) // flags
+		for i := 0; i < 5; i++ {
+			varint(&symb, 0)
+		}
+		varint(&symb, 0) // include headers
+	}
+
+	var refsChunk bytes.Buffer
+	for i := 0; i < len(refs); {
+		j := i
+		for j < len(refs) && refs[j].usr == refs[i].usr {
+			j++
+		}
+		id(&refsChunk, refs[i].usr)
+		varint(&refsChunk, j-i)
+		for _, ref := range refs[i:j] {
+			refsChunk.WriteByte(ref.kind)
+			location(&refsChunk, ref.loc)
+			id(&refsChunk, "c:@F@main") // container
+		}
+		i = j
+	}
+
+	var chunks bytes.Buffer
+	for _, c := range []struct {
+		id   string
+		data []byte
+	}{
+		{"meta", []byte{clangdIndexVersion, 0, 0, 0}},
+		{"stri", stri.Bytes()},
+		{"symb", symb.Bytes()},
+		{"refs", refsChunk.Bytes()},
+		{"rela", nil},
+		{"cmdl", []byte("cc\x00")},
+	} {
+		chunks.WriteString(c.id)
+		binary.Write(&chunks, binary.LittleEndian, uint32(len(c.data)))
+		chunks.Write(c.data)
+		if len(c.data)%2 == 1 {
+			chunks.WriteByte(0)
+		}
+	}
+
+	var riff bytes.Buffer
+	riff.WriteString("RIFF")
+	binary.Write(&riff, binary.LittleEndian, uint32(4+chunks.Len()))
+	riff.WriteString("CdIx")
+	riff.Write(chunks.Bytes())
+
+	return riff.Bytes()
+}
+
+// clangdFixtures returns the clangd index shards of the fixture project, keyed by the file name.
+func clangdFixtures() map[string][]byte {
+	const (
+		utilH = "file:///src/util.h"
+		utilC = "file:///src/util.c"
+		mainC = "file:///src/main.c"
+	)
+	util := clangdTestSymbol{usr: "c:@F@util", kind: 12, name: "util", def: clangdTestLocation{utilC, 2, 4}, decl: clangdTestLocation{utilH, 0, 4}}
+
+	return map[string][]byte{
+		"util.c.idx": clangdShard([]clangdTestSymbol{
+			{usr: "c:@counter", kind: 13, name: "counter", def: clangdTestLocation{utilC, 0, 4}, decl: clangdTestLocation{utilC, 0, 4}},
+			util,
+			{usr: "c:util.c@F@util@n", kind: 25, name: "n"}, // local symbol without location
+		}, nil, true),
+		"main.c.idx": clangdShard([]clangdTestSymbol{
+			{usr: "c:@F@main", kind: 12, name: "main", def: clangdTestLocation{mainC, 3, 4}, decl: clangdTestLocation{mainC, 3, 4}},
+			util,
+		}, []clangdTestRef{
+			{"c:@F@main", clangdRefDeclaration | clangdRefDefinition, clangdTestLocation{mainC, 3, 4}},
+			{"c:@F@util", clangdRefDeclaration, clangdTestLocation{mainC, 1, 4}},
+			{"c:@F@util", clangdRefReference | clangdRefCall, clangdTestLocation{mainC, 4, 2}},
+			{"c:@F@util", clangdRefReference, clangdTestLocation{mainC, 5, 19}},
+			{"c:@F@util", clangdRefReference | clangdRefCall, clangdTestLocation{mainC, 6, 9}},
+			{"c:@counter", clangdRefReference, clangdTestLocation{mainC, 6, 14}},
+		}, false),
+	}
+}
+
+func TestImportClangdIndex(t *testing.T) {
+	if *update {
+		for name, data := range clangdFixtures() {
+			path := filepath.Join(clangdDir, name)
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				continue
+			}
+			if err := ioutil.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	importShard := func(name string) (*File, ImportStats) {
+		r, err := os.Open(filepath.Join(clangdDir, name+".idx"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		f, stats, err := ImportClangdIndex(filepath.Join("/src", name), r)
+		if err != nil {
+			t.Fatalf("ImportClangdIndex(%s) error = %v", name, err)
+		}
+		return f, stats
+	}
+
+	t.Run("util.c", func(t *testing.T) {
+		f, stats := importShard("util.c")
+		want := ImportStats{Symbols: 2, Skipped: map[string]int{"rela": 1, "cmdl": 1, "symbol without location": 1}}
+		if !reflect.DeepEqual(stats, want) {
+			t.Errorf("ImportClangdIndex() stats = %+v, want %+v", stats, want)
+		}
+		if n := len(f.Symbols()); n != 2 {
+			t.Errorf("len(Symbols()) = %d, want 2", n)
+		}
+
+		util := f.Symbol(ToID(ClangdUSR("c:@F@util")))
+		if util == nil {
+			t.Fatal("Symbol(util) = nil")
+		}
+		def := util.Def()
+		if def.FileName() != "/src/util.c" || def.Line() != 3 || def.Col() != 5 {
+			t.Errorf("util Def() = %s:%d:%d, want /src/util.c:3:5", def.FileName(), def.Line(), def.Col())
+		}
+		if decls := util.Decls(); len(decls) != 1 || decls[0].FileName() != "/src/util.h" || decls[0].Line() != 1 {
+			t.Errorf("util Decls() = %+v, want the util.h declaration", decls)
+		}
+	})
+
+	t.Run("main.c", func(t *testing.T) {
+		f, stats := importShard("main.c")
+		want := ImportStats{Symbols: 2, Refs: 5, Skipped: map[string]int{"rela": 1, "cmdl": 1, "duplicate declaration": 1}}
+		if !reflect.DeepEqual(stats, want) {
+			t.Errorf("ImportClangdIndex() stats = %+v, want %+v", stats, want)
+		}
+		// counter is referenced, but defined in the other shard
+		if n := len(f.Symbols()); n != 3 {
+			t.Errorf("len(Symbols()) = %d, want 3", n)
+		}
+
+		util := f.Symbol(ToID(ClangdUSR("c:@F@util")))
+		if util == nil {
+			t.Fatal("Symbol(util) = nil")
+		}
+		if n := len(util.Decls()); n != 2 {
+			t.Errorf("len(util Decls()) = %d, want the header declaration and redeclaration", n)
+		}
+		var calls []string
+		for _, c := range util.Callers() {
+			calls = append(calls, positions([]Location{c.Location()})[0]+map[bool]string{true: " call", false: ""}[c.FuncCall()])
+		}
+		wantCalls := []string{"/src/main.c:5:3 call", "/src/main.c:6:20", "/src/main.c:7:10 call"}
+		if !reflect.DeepEqual(calls, wantCalls) {
+			t.Errorf("util Callers() = %v, want %v", calls, wantCalls)
+		}
+
+		// the symbol is at the position of the reference
+		if sym := f.LookupSymbolAt("/src/main.c", 7, 10); sym == nil || sym.ID() != util.ID() {
+			t.Errorf("LookupSymbolAt(main.c:7:10) = %v, want util", sym)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		data := clangdFixtures()["main.c.idx"]
+		versioned := append([]byte(nil), data...)
+		versioned[20] = clangdIndexVersion - 1 // meta chunk data
+		for name, data := range map[string][]byte{
+			"empty":     nil,
+			"version":   versioned,
+			"truncated": data[:len(data)-10],
+		} {
+			if _, _, err := ImportClangdIndex("main.c", bytes.NewReader(data)); err == nil {
+				t.Errorf("ImportClangdIndex(%s) error = nil", name)
+			}
+		}
+	})
+}
+
+func TestReadClangdStrings(t *testing.T) {
+	compressed := func(size uint32, table []byte) []byte {
+		var stri bytes.Buffer
+		binary.Write(&stri, binary.LittleEndian, size)
+		zw := zlib.NewWriter(&stri)
+		zw.Write(table)
+		zw.Close()
+		return stri.Bytes()
+	}
+
+	strs, err := readClangdStrings(compressed(10, []byte("main\x00util\x00")))
+	if err != nil || !reflect.DeepEqual(strs, []string{"main", "util"}) {
+		t.Errorf("readClangdStrings() = %q, %v, want [main util]", strs, err)
+	}
+
+	// the chunk of about 64KiB which expands to 32MiB, far over the declared size
+	bomb := compressed(8, make([]byte, 32<<20))
+	if _, err := readClangdStrings(bomb); err == nil {
+		t.Error("readClangdStrings() of the table over the declared size error = nil")
+	}
+	if _, err := readClangdStrings(compressed(16, []byte("main\x00util\x00"))); err == nil {
+		t.Error("readClangdStrings() of the table under the declared size error = nil")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// The testdata/compat directory holds the serialized File fixtures which produced by the older code.
+// Each "<name>.fb" flatbuffers binary has the "<name>.json" sidecar that describes its semantic content.
+//
+// The fixtures must keep decodable by the latest code. If the schema change breaks them, add an explicit
+// migration instead of regenerating. The fixtures are never rewritten, and new fixtures are generated from the
+// sidecars which have no fixture yet by:
+//
+//  go test ./symbol -run TestCompat -update
+var update = flag.Bool("update", false, "regenerate the golden files, and generate the missing testdata/compat fixtures")
+
+const compatDir = "testdata/compat"
+
+type compatFile struct {
+	Name            string         `json:"name"`
+	Flags           []string       `json:"flags,omitempty"`
+	TranslationUnit []byte         `json:"translationUnit,omitempty"`
+	Symbols         []compatSymbol `json:"symbols,omitempty"`
+	Headers         []compatHeader `json:"headers,omitempty"`
+}
+
+type compatSymbol struct {
+	USR     string           `json:"usr"`
+	Decls   []compatLocation `json:"decls,omitempty"`
+	Def     *compatLocation  `json:"def,omitempty"`
+	Callers []compatCaller   `json:"callers,omitempty"`
+}
+
+type compatLocation struct {
+	File   string `json:"file"`
+	Line   uint32 `json:"line"`
+	Col    uint32 `json:"col"`
+	Offset uint32 `json:"offset"`
+	USR    string `json:"usr,omitempty"`
+}
+
+type compatCaller struct {
+	Location compatLocation `json:"location"`
+	FuncCall bool           `json:"funcCall"`
+}
+
+type compatHeader struct {
+	Path    string `json:"path,omitempty"`
+	Mtime   int64  `json:"mtime"`
+	Missing bool   `json:"missing,omitempty"` // the header which was not found, which has no path
+}
+
+func (l compatLocation) location() Location {
+	return Location{
+		fileName: l.File,
+		line:     l.Line,
+		col:      l.Col,
+		offset:   l.Offset,
+		usr:      l.USR,
+	}
+}
+
+// file returns the in-memory File which described by c.
+func (c *compatFile) file() *File {
+	f := NewFile(c.Name, c.Flags)
+	f.translationUnit = c.TranslationUnit
+	for _, s := range c.Symbols {
+		info := &Info{id: ToID(s.USR)}
+		for _, decl := range s.Decls {
+			info.decls = append(info.decls, decl.location())
+		}
+		if s.Def != nil {
+			info.def = s.Def.location()
+		}
+		for _, caller := range s.Callers {
+			info.callers = append(info.callers, &Caller{
+				location: caller.Location.location(),
+				funcCall: caller.FuncCall,
+			})
+		}
+		f.symbols[info.id] = info
+	}
+	for _, hdr := range c.Headers {
+		fileid := ToFileID(hdr.Path)
+		if hdr.Missing {
+			fileid = legacyNotExistHeaderID()
+		}
+		f.headers = append(f.headers, &Header{
+			fileid: fileid,
+			mtime:  time.Unix(hdr.Mtime, 0),
+			exists: !hdr.Missing,
+		})
+	}
+
+	return f
+}
+
+func loadCompatFixture(t *testing.T, jsonPath string) *File {
+	buf, err := ioutil.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c compatFile
+	if err := json.Unmarshal(buf, &c); err != nil {
+		t.Fatalf("%s: %v", jsonPath, err)
+	}
+	return c.file()
+}
+
+func TestCompat(t *testing.T) {
+	sidecars, err := filepath.Glob(filepath.Join(compatDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sidecars) == 0 {
+		t.Fatalf("no fixtures in %s", compatDir)
+	}
+
+	for _, sidecar := range sidecars {
+		sidecar := sidecar
+		name := strings.TrimSuffix(filepath.Base(sidecar), ".json")
+		fixture := strings.TrimSuffix(sidecar, ".json") + ".fb"
+
+		t.Run(name, func(t *testing.T) {
+			want := loadCompatFixture(t, sidecar)
+
+			if _, err := os.Stat(fixture); *update && os.IsNotExist(err) {
+				// the existing fixture is produced by the older code, so only the missing one is written
+				if err := ioutil.WriteFile(fixture, want.Serialize().FinishedBytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				want = loadCompatFixture(t, sidecar)
+			}
+
+			buf, err := ioutil.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := GetRootAsFile(buf, 0)
+			if !got.Equal(want) {
+				t.Errorf("decoded %s is not equal to %s", fixture, sidecar)
+			}
+			got.Unmarshal()
+			if !got.Equal(want) {
+				t.Errorf("unmarshaled %s is not equal to %s", fixture, sidecar)
+			}
+
+			// newly produced buffers must remain decodable by the older decoder
+			v1 := decodeV1(want.Serialize().FinishedBytes())
+			if !v1.Equal(loadCompatFixture(t, sidecar)) {
+				t.Errorf("schema v1 decoder could not decode the serialized %s", sidecar)
+			}
+		})
+	}
+}
+
+// decodeV1 decodes buf with the File layout of schema version 1.
+// It is pinned to the older layout and independent of the generated code on purpose.
+func decodeV1(buf []byte) *File {
+	root := flatbuffers.Table{Bytes: buf, Pos: flatbuffers.GetUOffsetT(buf)}
+
+	f := NewFile(string(v1Bytes(root, 4)), nil)
+	if o := flatbuffers.UOffsetT(root.Offset(6)); o != 0 {
+		x := root.Vector(o)
+		for j := 0; j < root.VectorLen(o); j++ {
+			f.flags = append(f.flags, string(root.ByteVector(x+flatbuffers.UOffsetT(j*4))))
+		}
+	}
+	f.translationUnit = v1Bytes(root, 8)
+
+	for _, st := range v1Tables(root, 10) {
+		info := &Info{id: ID(decodeHash(v1Bytes(st, 4)))}
+		for _, lt := range v1Tables(st, 6) {
+			info.decls = append(info.decls, v1Location(lt))
+		}
+		if lt, ok := v1Table(st, 8); ok {
+			info.def = v1Location(lt)
+		}
+		for _, ct := range v1Tables(st, 10) {
+			lt, _ := v1Table(ct, 4)
+			info.callers = append(info.callers, &Caller{
+				location: v1Location(lt),
+				funcCall: ct.GetByteSlot(6, 0) != 0,
+			})
+		}
+		f.symbols[info.id] = info
+	}
+
+	for _, ht := range v1Tables(root, 12) {
+		// the layout has no Exists field, and the missing header has the synthetic FileID
+		fileid := FileID(decodeHash(v1Bytes(ht, 4)))
+		f.headers = append(f.headers, &Header{
+			fileid: fileid,
+			mtime:  time.Unix(ht.GetInt64Slot(6, 0), 0),
+			exists: fileid != legacyNotExistHeaderID(),
+		})
+	}
+
+	return f
+}
+
+func v1Location(t flatbuffers.Table) Location {
+	return Location{
+		fileName: string(v1Bytes(t, 4)),
+		line:     t.GetUint32Slot(6, 0),
+		col:      t.GetUint32Slot(8, 0),
+		offset:   t.GetUint32Slot(10, 0),
+		usr:      string(v1Bytes(t, 12)),
+	}
+}
+
+func v1Bytes(t flatbuffers.Table, slot flatbuffers.VOffsetT) []byte {
+	o := flatbuffers.UOffsetT(t.Offset(slot))
+	if o == 0 {
+		return nil
+	}
+	return t.ByteVector(o + t.Pos)
+}
+
+func v1Table(t flatbuffers.Table, slot flatbuffers.VOffsetT) (flatbuffers.Table, bool) {
+	o := flatbuffers.UOffsetT(t.Offset(slot))
+	if o == 0 {
+		return flatbuffers.Table{}, false
+	}
+	return flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(o + t.Pos)}, true
+}
+
+func v1Tables(t flatbuffers.Table, slot flatbuffers.VOffsetT) []flatbuffers.Table {
+	o := flatbuffers.UOffsetT(t.Offset(slot))
+	if o == 0 {
+		return nil
+	}
+	x := t.Vector(o)
+	n := t.VectorLen(o)
+	tables := make([]flatbuffers.Table, n)
+	for j := 0; j < n; j++ {
+		tables[j] = flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(x + flatbuffers.UOffsetT(j*4))}
+	}
+	return tables
+}
@@ -0,0 +1,652 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// CompleteOptions represents a options of CodeCompleteResults.Marshal.
+// The zero value is the default behavior.
+type CompleteOptions struct {
+	// NoSort disables sorting the results by the clang completion priority.
+	NoSort bool
+
+	// KeepUnavailable keeps the NotAvailable and NotAccessible results, such as the deleted functions
+	// and private members. They are dropped by default.
+	KeepUnavailable bool
+
+	// Snippet syntax of the CompleteItem snippet.
+	Snippet SnippetSyntax
+
+	// NoDedup disables deduplicating the identical results.
+	NoDedup bool
+
+	// GroupOverloads groups the results which have the same word and cursor kind into a single item.
+	// The item of the highest priority represents the group, and the others are folded into its info.
+	GroupOverloads bool
+
+	// MaxResults maximum number of the results. If zero, all results are returned.
+	MaxResults int
+
+	// Offset number of the leading results to skip, for requesting the next page.
+	// The results are sorted stably, so the same request always returns the same page.
+	Offset int
+
+	// KindFunc maps the cursor kind of the completion result to the CompleteItem kind.
+	// If nil, CompleteKind is used.
+	KindFunc func(clang.CursorKind) string
+
+	// MaxCommentLength maximum number of characters of the brief comment in the CompleteItem info.
+	// The longer comment is truncated with an ellipsis. If zero, DefaultMaxCommentLength is used.
+	MaxCommentLength int
+
+	// UserData attaches the encoded UserData payload to the CompleteItem.
+	UserData bool
+
+	// SymbolFunc returns the USR and the providing header of the completion result which named word,
+	// for the UserData payload. If nil, the payload carries only the placeholder.
+	SymbolFunc func(word string, kind clang.CursorKind) (usr, header string)
+
+	// MaxUserDataLength maximum number of bytes of the encoded UserData payload.
+	// If zero, DefaultMaxUserDataLength is used.
+	MaxUserDataLength int
+
+	// NoMacros drops the macro names from the results.
+	NoMacros bool
+
+	// NoKeywords drops the language keywords, such as "return" and "static", from the results.
+	NoKeywords bool
+
+	// NoPatterns drops the code patterns, such as the "for" and "if" statement templates, from the results.
+	NoPatterns bool
+
+	// FilterByContext drops the results which are not valid in the completion context, such as the global
+	// functions after the member access operator, see CompletionContext.Allows.
+	FilterByContext bool
+
+	// MaxDiagnostics maximum number of the diagnostics of the completion parse.
+	// If zero, DefaultMaxDiagnostics is used, and if negative, the diagnostics are dropped.
+	MaxDiagnostics int
+
+	// MaxDiagnosticLength maximum number of characters of the diagnostic message.
+	// The longer message is truncated with an ellipsis. If zero, DefaultMaxDiagnosticLength is used.
+	MaxDiagnosticLength int
+}
+
+// DefaultMaxCommentLength default maximum number of characters of the brief comment in the CompleteItem info.
+const DefaultMaxCommentLength = 500
+
+const (
+	// DefaultMaxDiagnostics default maximum number of the diagnostics of the completion parse.
+	DefaultMaxDiagnostics = 10
+	// DefaultMaxDiagnosticLength default maximum number of characters of the diagnostic message.
+	DefaultMaxDiagnosticLength = 200
+)
+
+func (opts CompleteOptions) kindFunc() func(clang.CursorKind) string {
+	if opts.KindFunc == nil {
+		return CompleteKind
+	}
+	return opts.KindFunc
+}
+
+func (opts CompleteOptions) maxCommentLength() int {
+	if opts.MaxCommentLength <= 0 {
+		return DefaultMaxCommentLength
+	}
+	return opts.MaxCommentLength
+}
+
+func (opts CompleteOptions) maxDiagnostics() int {
+	switch {
+	case opts.MaxDiagnostics < 0:
+		return 0
+	case opts.MaxDiagnostics == 0:
+		return DefaultMaxDiagnostics
+	default:
+		return opts.MaxDiagnostics
+	}
+}
+
+func (opts CompleteOptions) maxDiagnosticLength() int {
+	if opts.MaxDiagnosticLength <= 0 {
+		return DefaultMaxDiagnosticLength
+	}
+	return opts.MaxDiagnosticLength
+}
+
+func (opts CompleteOptions) maxUserDataLength() int {
+	if opts.MaxUserDataLength <= 0 {
+		return DefaultMaxUserDataLength
+	}
+	return opts.MaxUserDataLength
+}
+
+// excludes reports whether the completion result res is dropped by the category options of opts.
+func (opts CompleteOptions) excludes(res completionResult) bool {
+	switch res.cursorKind {
+	case clang.Cursor_MacroDefinition:
+		return opts.NoMacros
+	case clang.Cursor_NotImplemented:
+		// clang reports both the keywords and the code patterns as not implemented,
+		// the keyword is only a typed text while the pattern has the placeholders and punctuation
+		if isKeyword(res.cs) {
+			return opts.NoKeywords
+		}
+		return opts.NoPatterns
+	default:
+		return false
+	}
+}
+
+// isKeyword reports whether cs of the not implemented cursor kind is a language keyword.
+func isKeyword(cs completionString) bool {
+	return cs.NumChunks() == 1 && cs.ChunkKind(0) == clang.CompletionChunk_TypedText
+}
+
+// completionString is the subset of clang.CompletionString methods which used by completion.
+type completionString interface {
+	NumChunks() uint32
+	ChunkKind(chunkNumber uint32) clang.CompletionChunkKind
+	ChunkText(chunkNumber uint32) string
+	Priority() uint32
+	Availability() clang.AvailabilityKind
+	BriefComment() string
+}
+
+// deprecatedMenu is the suffix of the deprecated CompleteItem menu.
+const deprecatedMenu = "(deprecated)"
+
+// appendMenu appends s to the CompleteItem menu text.
+func appendMenu(menu, s string) string {
+	if menu == "" {
+		return s
+	}
+	return menu + " " + s
+}
+
+// isAvailable reports whether the item is available in the completion context.
+func (c *CompleteItem) isAvailable() bool {
+	switch c.availability {
+	case clang.Availability_NotAvailable, clang.Availability_NotAccessible:
+		return false
+	default:
+		return true
+	}
+}
+
+// completionResult represents a single result of clang code completion.
+type completionResult struct {
+	cursorKind clang.CursorKind
+	cs         completionString
+}
+
+// CompletionContext represents the set of clang.CompletionContext flags under which the completion occurs.
+type CompletionContext uint64
+
+// Has reports whether c contains all bits of flag.
+func (c CompletionContext) Has(flag clang.CompletionContext) bool {
+	return flag != clang.CompletionContext_Unexposed && uint64(c)&uint64(flag) == uint64(flag)
+}
+
+// IsMemberAccess reports whether the completion occurs after the member access operator, such as "." or "->".
+func (c CompletionContext) IsMemberAccess() bool {
+	return c.Has(clang.CompletionContext_DotMemberAccess) ||
+		c.Has(clang.CompletionContext_ArrowMemberAccess) ||
+		c.Has(clang.CompletionContext_ObjCPropertyAccess)
+}
+
+// IsMacroName reports whether the completion occurs at the preprocessor macro name, such as after "#ifdef".
+func (c CompletionContext) IsMacroName() bool {
+	return c.Has(clang.CompletionContext_MacroName)
+}
+
+// IsUnknown reports whether the completion context is unknown to clang, and all kinds of results are appropriate.
+func (c CompletionContext) IsUnknown() bool {
+	return c.Has(clang.CompletionContext_Unknown)
+}
+
+// memberKinds cursor kinds of the results which are valid after the member access operator. The records are
+// the qualifiers of the member, such as "Base" of "p->Base::f()".
+var memberKinds = map[clang.CursorKind]bool{
+	clang.Cursor_FieldDecl:              true,
+	clang.Cursor_CXXMethod:              true,
+	clang.Cursor_FunctionTemplate:       true,
+	clang.Cursor_ConversionFunction:     true,
+	clang.Cursor_Destructor:             true,
+	clang.Cursor_ObjCIvarDecl:           true,
+	clang.Cursor_ObjCPropertyDecl:       true,
+	clang.Cursor_ObjCInstanceMethodDecl: true,
+	clang.Cursor_StructDecl:             true,
+	clang.Cursor_UnionDecl:              true,
+	clang.Cursor_ClassDecl:              true,
+	clang.Cursor_ClassTemplate:          true,
+}
+
+// tagContexts the contexts which accept only the names of the tags or namespaces, and the cursor kinds of them.
+var tagContexts = []struct {
+	flag  clang.CompletionContext
+	kinds []clang.CursorKind
+}{
+	{clang.CompletionContext_EnumTag, []clang.CursorKind{clang.Cursor_EnumDecl}},
+	{clang.CompletionContext_UnionTag, []clang.CursorKind{clang.Cursor_UnionDecl}},
+	{clang.CompletionContext_StructTag, []clang.CursorKind{clang.Cursor_StructDecl}},
+	{clang.CompletionContext_ClassTag, []clang.CursorKind{clang.Cursor_ClassDecl, clang.Cursor_ClassTemplate, clang.Cursor_ClassTemplatePartialSpecialization}},
+	{clang.CompletionContext_Namespace, []clang.CursorKind{clang.Cursor_Namespace, clang.Cursor_NamespaceAlias}},
+	{clang.CompletionContext_NestedNameSpecifier, []clang.CursorKind{
+		clang.Cursor_Namespace, clang.Cursor_NamespaceAlias, clang.Cursor_StructDecl, clang.Cursor_UnionDecl, clang.Cursor_ClassDecl,
+		clang.Cursor_EnumDecl, clang.Cursor_ClassTemplate, clang.Cursor_TypedefDecl, clang.Cursor_TypeAliasDecl,
+	}},
+}
+
+// Allows reports whether the result of the cursor kind is valid in the completion context c.
+//
+// After the member access operator, only the members and the qualifiers of them are valid, and at the macro name,
+// only the macros are valid. The context of the tag or namespace name, such as after "enum" or "using namespace",
+// accepts only the declarations of the kinds, unless the types or values are also valid. The other contexts,
+// including the unknown and unexposed context, allow all kinds.
+func (c CompletionContext) Allows(kind clang.CursorKind) bool {
+	switch {
+	case c == 0 || c.IsUnknown():
+		return true
+	case c.IsMemberAccess():
+		return memberKinds[kind]
+	case c.IsMacroName():
+		return kind == clang.Cursor_MacroDefinition
+	case c.Has(clang.CompletionContext_AnyType) || c.Has(clang.CompletionContext_AnyValue):
+		return true
+	}
+
+	tagged := false
+	for _, tag := range tagContexts {
+		if !c.Has(tag.flag) {
+			continue
+		}
+		tagged = true
+		for _, k := range tag.kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+
+	return !tagged
+}
+
+// The single letter kinds of CompleteItem, following the vim complete-items convention.
+const (
+	CompleteKindFunction  = "f" // function or method
+	CompleteKindVariable  = "v" // variable
+	CompleteKindMember    = "m" // member of struct or class
+	CompleteKindType      = "t" // typedef, struct, class, union or enum
+	CompleteKindMacro     = "d" // #define or macro
+	CompleteKindEnum      = "e" // enum constant
+	CompleteKindNamespace = "n" // namespace
+)
+
+// CompleteKind returns the single letter kind of the completion result of the cursor kind.
+// It returns the empty string for the kinds which has no meaningful letter, such as the keywords.
+func CompleteKind(kind clang.CursorKind) string {
+	switch kind {
+	case clang.Cursor_FunctionDecl,
+		clang.Cursor_FunctionTemplate,
+		clang.Cursor_CXXMethod,
+		clang.Cursor_Constructor,
+		clang.Cursor_Destructor,
+		clang.Cursor_ConversionFunction,
+		clang.Cursor_ObjCInstanceMethodDecl,
+		clang.Cursor_ObjCClassMethodDecl:
+		return CompleteKindFunction
+	case clang.Cursor_VarDecl,
+		clang.Cursor_ParmDecl,
+		clang.Cursor_NonTypeTemplateParameter:
+		return CompleteKindVariable
+	case clang.Cursor_FieldDecl,
+		clang.Cursor_ObjCIvarDecl,
+		clang.Cursor_ObjCPropertyDecl:
+		return CompleteKindMember
+	case clang.Cursor_StructDecl,
+		clang.Cursor_UnionDecl,
+		clang.Cursor_ClassDecl,
+		clang.Cursor_EnumDecl,
+		clang.Cursor_TypedefDecl,
+		clang.Cursor_TypeAliasDecl,
+		clang.Cursor_ClassTemplate,
+		clang.Cursor_ClassTemplatePartialSpecialization,
+		clang.Cursor_TemplateTypeParameter,
+		clang.Cursor_TemplateTemplateParameter,
+		clang.Cursor_ObjCInterfaceDecl,
+		clang.Cursor_ObjCProtocolDecl,
+		clang.Cursor_ObjCCategoryDecl:
+		return CompleteKindType
+	case clang.Cursor_MacroDefinition:
+		return CompleteKindMacro
+	case clang.Cursor_EnumConstantDecl:
+		return CompleteKindEnum
+	case clang.Cursor_Namespace,
+		clang.Cursor_NamespaceAlias:
+		return CompleteKindNamespace
+	default:
+		return ""
+	}
+}
+
+// truncate truncates s to max characters with an ellipsis.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max]) + "…"
+}
+
+// limitDiagnostics returns the diagnostics which limited by opts.
+// The ignored diagnostics are dropped, and the messages are truncated.
+func limitDiagnostics(diags []*Diagnostic, opts CompleteOptions) []*Diagnostic {
+	max := opts.maxDiagnostics()
+	limited := make([]*Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if len(limited) >= max {
+			break
+		}
+		if d.Severity() == clang.Diagnostic_Ignored {
+			continue
+		}
+		limited = append(limited, &Diagnostic{
+			severity: d.Severity(),
+			message:  truncate(d.Message(), opts.maxDiagnosticLength()),
+			location: d.Location().value(),
+		})
+	}
+
+	return limited
+}
+
+// completeInfo returns the CompleteItem info which displayed in the preview window.
+// The brief comment follows the signature if not empty, and truncated to max characters.
+func completeInfo(signature, comment string, max int) string {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return signature
+	}
+
+	return signature + "\n\n" + truncate(comment, max)
+}
+
+// dedupCompleteItems removes the duplicated items which clang returns for the same symbol via the
+// different lookup paths, such as using-declarations and inherited members.
+//
+// The items are identical if they have the same word, kind and menu. The abbr is also compared so that
+// the overloads, which differ only in the parameters, are not removed.
+// The item of the highest priority is kept at the position of the first appearance.
+func dedupCompleteItems(items []*CompleteItem) []*CompleteItem {
+	type key struct {
+		word, kind, menu, abbr string
+	}
+
+	seen := make(map[key]int, len(items))
+	deduped := items[:0]
+	for _, item := range items {
+		k := key{word: item.word, kind: item.kind, menu: item.menu, abbr: item.abbr}
+		if i, ok := seen[k]; ok {
+			if item.priority < deduped[i].priority {
+				deduped[i] = item
+			}
+			continue
+		}
+		seen[k] = len(deduped)
+		deduped = append(deduped, item)
+	}
+
+	return deduped
+}
+
+// MergeCompleteResults merges the results of sets, such as the completions at the point which reachable through
+// the several code paths.
+//
+// The items which have the same word and kind are merged into the one of the highest priority, and the merged
+// items are sorted as same as Marshal. The diagnostics and completion contexts of sets are combined, and the
+// result is truncated if any of sets is truncated. The nil sets are ignored.
+func MergeCompleteResults(sets ...*CodeCompleteResults) *CodeCompleteResults {
+	type key struct {
+		word, kind string
+	}
+
+	var (
+		items     []*CompleteItem
+		diags     []*Diagnostic
+		context   CompletionContext
+		truncated bool
+	)
+	seen := make(map[key]int)
+	for _, c := range sets {
+		if c == nil || c.codeCompleteResults == nil {
+			continue
+		}
+
+		results := c.Results()
+		for i := range results {
+			item := &results[i]
+			k := key{word: item.word, kind: item.kind}
+			if j, ok := seen[k]; ok {
+				if item.priority < items[j].priority {
+					items[j] = item
+				}
+				continue
+			}
+			seen[k] = len(items)
+			items = append(items, item)
+		}
+		diags = append(diags, c.Diagnostics()...)
+		context |= c.Context()
+		truncated = truncated || c.Truncated()
+	}
+	sortCompleteItems(items)
+
+	buf := serializeCompleteItems(items, truncated, len(items), diags, context).FinishedBytes()
+
+	return GetRootAsCodeCompleteResults(buf, 0)
+}
+
+// groupOverloads groups the items by word and cursor kind, and returns the representative items
+// in the order of the first appearance of each group.
+func groupOverloads(items []*CompleteItem) []*CompleteItem {
+	type key struct {
+		word string
+		kind clang.CursorKind
+	}
+
+	groups := make(map[key][]*CompleteItem, len(items))
+	keys := make([]key, 0, len(items))
+	for _, item := range items {
+		k := key{word: item.word, kind: item.cursorKind}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], item)
+	}
+	if len(keys) == len(items) {
+		return items
+	}
+
+	grouped := items[:0]
+	for _, k := range keys {
+		group := groups[k]
+		rep := group[0]
+		for _, item := range group[1:] {
+			if item.priority < rep.priority {
+				rep = item
+			}
+		}
+		if len(group) > 1 {
+			rep.foldOverloads(group)
+		}
+		grouped = append(grouped, rep)
+	}
+
+	return grouped
+}
+
+// foldOverloads folds the signatures of the overloads in group except c into the c info.
+func (c *CompleteItem) foldOverloads(group []*CompleteItem) {
+	c.overloads = uint32(len(group) - 1)
+
+	var buf bytes.Buffer
+	buf.WriteString(c.info)
+	fmt.Fprintf(&buf, "\n\n+%d overload", c.overloads)
+	if c.overloads > 1 {
+		buf.WriteByte('s')
+	}
+	for _, item := range group {
+		if item == c {
+			continue
+		}
+		buf.WriteString("\n")
+		buf.WriteString(item.signature)
+	}
+	c.info = buf.String()
+}
+
+// paginate returns the page of items which starts at offset and has at most max items.
+// It reports whether the items following the page remain.
+func paginate(items []*CompleteItem, offset, max int) ([]*CompleteItem, bool) {
+	if offset > 0 {
+		if offset >= len(items) {
+			return items[:0], false
+		}
+		items = items[offset:]
+	}
+	if max > 0 && len(items) > max {
+		return items[:max], true
+	}
+
+	return items, false
+}
+
+// completeSortText returns the CompleteItem sort text of the priority and word.
+// The priority is zero-padded so that the sort text is ordered by the priority first.
+func completeSortText(priority uint32, word string) string {
+	return fmt.Sprintf("%010d%s", priority, strings.ToLower(word))
+}
+
+// sortCompleteItems sorts the items ascending by the sort text, and ties are broken by word.
+// The client can respect the order of the sorted items as is.
+func sortCompleteItems(items []*CompleteItem) {
+	keys := make(map[*CompleteItem]string, len(items))
+	for _, item := range items {
+		keys[item] = item.SortText()
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if ki, kj := keys[items[i]], keys[items[j]]; ki != kj {
+			return ki < kj
+		}
+		return items[i].word < items[j].word
+	})
+}
+
+// CaseMode represents a case sensitivity of completion filtering.
+type CaseMode int
+
+const (
+	// CaseSensitive matches the prefix case-sensitively.
+	CaseSensitive CaseMode = iota
+	// CaseInsensitive matches the prefix case-insensitively.
+	CaseInsensitive
+	// SmartCase matches the prefix case-insensitively, unless the prefix contains upper case characters.
+	SmartCase
+)
+
+// FilterOptions represents a options of completion filtering.
+type FilterOptions struct {
+	// Case case sensitivity of prefix matching.
+	Case CaseMode
+}
+
+// Filter returns the items whose word matches the typed prefix.
+// The empty prefix matches all items.
+func (c *CodeCompleteResults) Filter(prefix string, opts FilterOptions) []CompleteItem {
+	results := c.Results()
+	items := results[:0]
+	for _, item := range results {
+		if matchPrefixBytes(item.WordBytes(), prefix, opts.Case) {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// filterCompleteItems returns the items whose word matches the typed prefix.
+func filterCompleteItems(items []*CompleteItem, prefix string, opts FilterOptions) []*CompleteItem {
+	if prefix == "" {
+		return items
+	}
+
+	filtered := items[:0]
+	for _, item := range items {
+		if matchPrefix(item.word, prefix, opts.Case) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
+
+// matchPrefix reports whether the word begins with prefix in mode case sensitivity.
+func matchPrefix(word, prefix string, mode CaseMode) bool {
+	switch mode {
+	case CaseInsensitive:
+		return hasPrefixFold(word, prefix)
+	case SmartCase:
+		if hasUpper(prefix) {
+			return strings.HasPrefix(word, prefix)
+		}
+		return hasPrefixFold(word, prefix)
+	default:
+		return strings.HasPrefix(word, prefix)
+	}
+}
+
+// matchPrefixBytes is like matchPrefix, but the case-sensitive matching does not copy the word.
+func matchPrefixBytes(word []byte, prefix string, mode CaseMode) bool {
+	if mode == CaseSensitive || (mode == SmartCase && hasUpper(prefix)) {
+		return len(word) >= len(prefix) && string(word[:len(prefix)]) == prefix
+	}
+	return matchPrefix(string(word), prefix, mode)
+}
+
+// hasPrefixFold reports whether s begins with prefix under Unicode case-folding.
+func hasPrefixFold(s, prefix string) bool {
+	for _, pr := range prefix {
+		if s == "" {
+			return false
+		}
+		sr, size := utf8.DecodeRuneInString(s)
+		if sr != pr && !strings.EqualFold(string(sr), string(pr)) {
+			return false
+		}
+		s = s[size:]
+	}
+
+	return true
+}
+
+// hasUpper reports whether s contains upper case characters.
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,872 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+type fakeChunk struct {
+	kind clang.CompletionChunkKind
+	text string
+}
+
+// fakeCompletionString implements completionString for the fabricated completion results.
+type fakeCompletionString struct {
+	chunks   []fakeChunk
+	priority uint32
+	comment  string
+
+	availability clang.AvailabilityKind
+}
+
+func (cs fakeCompletionString) NumChunks() uint32 { return uint32(len(cs.chunks)) }
+func (cs fakeCompletionString) ChunkKind(n uint32) clang.CompletionChunkKind {
+	return cs.chunks[n].kind
+}
+func (cs fakeCompletionString) ChunkText(n uint32) string { return cs.chunks[n].text }
+func (cs fakeCompletionString) Priority() uint32          { return cs.priority }
+func (cs fakeCompletionString) BriefComment() string      { return cs.comment }
+func (cs fakeCompletionString) Availability() clang.AvailabilityKind {
+	return cs.availability
+}
+
+// fakeFunction returns the completion result of function which named word.
+func fakeFunction(word, result string, priority uint32) completionResult {
+	return completionResult{
+		cursorKind: clang.Cursor_FunctionDecl,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_ResultType, text: result},
+				{kind: clang.CompletionChunk_TypedText, text: word},
+				{kind: clang.CompletionChunk_LeftParen, text: "("},
+				{kind: clang.CompletionChunk_RightParen, text: ")"},
+			},
+			priority: priority,
+		},
+	}
+}
+
+// marshalResults marshals the results with opts, and returns the decoded CodeCompleteResults.
+func marshalResults(opts CompleteOptions, results []completionResult) *CodeCompleteResults {
+	c := &CodeCompleteResults{Options: opts}
+	return GetRootAsCodeCompleteResults(c.marshal(completion{results: results}, nil).FinishedBytes(), 0)
+}
+
+func completeWords(items []CompleteItem) []string {
+	words := make([]string, len(items))
+	for i := range items {
+		words[i] = items[i].Word()
+	}
+	return words
+}
+
+func TestCodeCompleteResults_Marshal_Priority(t *testing.T) {
+	results := []completionResult{
+		fakeFunction("push_back", "void", 50),
+		fakeFunction("size", "size_type", 34),
+		fakeFunction("begin", "iterator", 34),
+		fakeFunction("operator=", "vector &", 80),
+		fakeFunction("at", "reference", 34),
+	}
+
+	tests := []struct {
+		name         string
+		opts         CompleteOptions
+		want         []string
+		wantPriority []uint32
+	}{
+		{
+			name:         "sort by priority then word",
+			opts:         CompleteOptions{},
+			want:         []string{"at", "begin", "size", "push_back", "operator="},
+			wantPriority: []uint32{34, 34, 34, 50, 80},
+		},
+		{
+			name:         "no sort",
+			opts:         CompleteOptions{NoSort: true},
+			want:         []string{"push_back", "size", "begin", "operator=", "at"},
+			wantPriority: []uint32{50, 34, 34, 80, 34},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := marshalResults(tt.opts, results).Results()
+			if got := completeWords(items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Results() words = %v, want %v", got, tt.want)
+			}
+			for i := range items {
+				if got := items[i].Priority(); got != tt.wantPriority[i] {
+					t.Errorf("Results()[%d].Priority() = %d, want %d", i, got, tt.wantPriority[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_RoundTrip(t *testing.T) {
+	items := marshalResults(CompleteOptions{}, []completionResult{fakeFunction("at", "reference", 34)}).Results()
+	if len(items) != 1 {
+		t.Fatalf("len(Results()) = %d, want 1", len(items))
+	}
+	got := items[0]
+	want := CompleteItem{word: "at", abbr: "at()", menu: "reference", info: "reference at()", kind: CompleteKindFunction, cursorKind: clang.Cursor_FunctionDecl, icase: true, dup: true, priority: 34, snippet: "at()"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Results()[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestCodeCompleteResults_Marshal_Empty(t *testing.T) {
+	if got := marshalResults(CompleteOptions{}, nil).Results(); len(got) != 0 {
+		t.Errorf("Results() = %v, want empty", got)
+	}
+}
+
+func TestCodeCompleteResults_FilterAndMarshal(t *testing.T) {
+	results := []completionResult{
+		fakeFunction("push_back", "void", 50),
+		fakeFunction("PushFront", "void", 50),
+		fakeFunction("pop", "void", 50),
+		fakeFunction("Über", "void", 50),
+		fakeFunction("überAll", "void", 50),
+		fakeFunction("Σίσυφος", "void", 50),
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		opts   FilterOptions
+		want   []string
+	}{
+		{
+			name:   "empty prefix",
+			prefix: "",
+			opts:   FilterOptions{},
+			want:   []string{"pop", "push_back", "PushFront", "Über", "überAll", "Σίσυφος"},
+		},
+		{
+			name:   "case sensitive",
+			prefix: "pu",
+			opts:   FilterOptions{Case: CaseSensitive},
+			want:   []string{"push_back"},
+		},
+		{
+			name:   "case insensitive",
+			prefix: "pu",
+			opts:   FilterOptions{Case: CaseInsensitive},
+			want:   []string{"push_back", "PushFront"},
+		},
+		{
+			name:   "smart case lower",
+			prefix: "pu",
+			opts:   FilterOptions{Case: SmartCase},
+			want:   []string{"push_back", "PushFront"},
+		},
+		{
+			name:   "smart case upper",
+			prefix: "Pu",
+			opts:   FilterOptions{Case: SmartCase},
+			want:   []string{"PushFront"},
+		},
+		{
+			name:   "unicode case sensitive",
+			prefix: "über",
+			opts:   FilterOptions{Case: CaseSensitive},
+			want:   []string{"überAll"},
+		},
+		{
+			name:   "unicode case insensitive",
+			prefix: "über",
+			opts:   FilterOptions{Case: CaseInsensitive},
+			want:   []string{"Über", "überAll"},
+		},
+		{
+			name:   "unicode greek",
+			prefix: "σί",
+			opts:   FilterOptions{Case: SmartCase},
+			want:   []string{"Σίσυφος"},
+		},
+		{
+			name:   "prefix longer than word",
+			prefix: "popcorn",
+			opts:   FilterOptions{Case: CaseInsensitive},
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := new(CodeCompleteResults)
+			buf := c.marshal(completion{results: results}, func(items []*CompleteItem) []*CompleteItem {
+				return filterCompleteItems(items, tt.prefix, tt.opts)
+			}).FinishedBytes()
+			if got := completeWords(GetRootAsCodeCompleteResults(buf, 0).Results()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterAndMarshal(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+
+			all := marshalResults(CompleteOptions{}, results)
+			if got := completeWords(all.Filter(tt.prefix, tt.opts)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteItem_parse_Info(t *testing.T) {
+	withComment := func(comment string) fakeCompletionString {
+		cs := fakeFunction("size", "size_type", 34).cs.(fakeCompletionString)
+		cs.comment = comment
+		return cs
+	}
+
+	tests := []struct {
+		name string
+		cs   fakeCompletionString
+		opts CompleteOptions
+		want string
+	}{
+		{
+			name: "without comment",
+			cs:   withComment(""),
+			want: "size_type size()",
+		},
+		{
+			name: "with comment",
+			cs:   withComment("Returns the number of elements."),
+			want: "size_type size()\n\nReturns the number of elements.",
+		},
+		{
+			name: "blank comment",
+			cs:   withComment(" \n"),
+			want: "size_type size()",
+		},
+		{
+			name: "truncated comment",
+			cs:   withComment("Returns the number of elements."),
+			opts: CompleteOptions{MaxCommentLength: 7},
+			want: "size_type size()\n\nReturns…",
+		},
+		{
+			name: "truncated multibyte comment",
+			cs:   withComment("要素数を返す"),
+			opts: CompleteOptions{MaxCommentLength: 3},
+			want: "size_type size()\n\n要素数…",
+		},
+		{
+			name: "default limit",
+			cs:   withComment(strings.Repeat("a", DefaultMaxCommentLength)),
+			want: "size_type size()\n\n" + strings.Repeat("a", DefaultMaxCommentLength),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := new(CompleteItem)
+			item.parse(completionResult{cs: tt.cs}, tt.opts)
+			if got := item.Info(); got != tt.want {
+				t.Errorf("Info() = %q, want %q", got, tt.want)
+			}
+			if got, want := item.Abbr(), "size()"; got != want {
+				t.Errorf("Abbr() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_Availability(t *testing.T) {
+	withAvailability := func(word string, availability clang.AvailabilityKind) completionResult {
+		res := fakeFunction(word, "void", 50)
+		cs := res.cs.(fakeCompletionString)
+		cs.availability = availability
+		res.cs = cs
+		return res
+	}
+	results := []completionResult{
+		withAvailability("available", clang.Availability_Available),
+		withAvailability("deprecated", clang.Availability_Deprecated),
+		withAvailability("not_available", clang.Availability_NotAvailable),
+		withAvailability("not_accessible", clang.Availability_NotAccessible),
+	}
+
+	type want struct {
+		availability clang.AvailabilityKind
+		deprecated   bool
+		menu         string
+	}
+	tests := []struct {
+		name string
+		opts CompleteOptions
+		want map[string]want
+	}{
+		{
+			name: "drop unavailable by default",
+			opts: CompleteOptions{},
+			want: map[string]want{
+				"available":  {availability: clang.Availability_Available, menu: "void"},
+				"deprecated": {availability: clang.Availability_Deprecated, deprecated: true, menu: "void (deprecated)"},
+			},
+		},
+		{
+			name: "keep unavailable",
+			opts: CompleteOptions{KeepUnavailable: true},
+			want: map[string]want{
+				"available":      {availability: clang.Availability_Available, menu: "void"},
+				"deprecated":     {availability: clang.Availability_Deprecated, deprecated: true, menu: "void (deprecated)"},
+				"not_available":  {availability: clang.Availability_NotAvailable, menu: "void"},
+				"not_accessible": {availability: clang.Availability_NotAccessible, menu: "void"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := marshalResults(tt.opts, results).Results()
+			if len(items) != len(tt.want) {
+				t.Fatalf("Results() = %v, want %d items", completeWords(items), len(tt.want))
+			}
+			for _, item := range items {
+				w, ok := tt.want[item.Word()]
+				if !ok {
+					t.Errorf("unexpected item %q", item.Word())
+					continue
+				}
+				if got := item.Availability(); got != w.availability {
+					t.Errorf("%s: Availability() = %v, want %v", item.Word(), got, w.availability)
+				}
+				if got := item.Deprecated(); got != w.deprecated {
+					t.Errorf("%s: Deprecated() = %v, want %v", item.Word(), got, w.deprecated)
+				}
+				if got := item.Menu(); got != w.menu {
+					t.Errorf("%s: Menu() = %q, want %q", item.Word(), got, w.menu)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteKind(t *testing.T) {
+	tests := []struct {
+		kind clang.CursorKind
+		want string
+	}{
+		{kind: clang.Cursor_FunctionDecl, want: CompleteKindFunction},
+		{kind: clang.Cursor_CXXMethod, want: CompleteKindFunction},
+		{kind: clang.Cursor_Constructor, want: CompleteKindFunction},
+		{kind: clang.Cursor_Destructor, want: CompleteKindFunction},
+		{kind: clang.Cursor_VarDecl, want: CompleteKindVariable},
+		{kind: clang.Cursor_FieldDecl, want: CompleteKindMember},
+		{kind: clang.Cursor_ClassDecl, want: CompleteKindType},
+		{kind: clang.Cursor_TypedefDecl, want: CompleteKindType},
+		{kind: clang.Cursor_MacroDefinition, want: CompleteKindMacro},
+		{kind: clang.Cursor_EnumConstantDecl, want: CompleteKindEnum},
+		{kind: clang.Cursor_Namespace, want: CompleteKindNamespace},
+		{kind: clang.Cursor_NotImplemented, want: ""},
+	}
+	for _, tt := range tests {
+		if got := CompleteKind(tt.kind); got != tt.want {
+			t.Errorf("CompleteKind(%v) = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestCodeCompleteResults_Marshal_KindFunc(t *testing.T) {
+	ctor := fakeFunction("vector", "", 50)
+	ctor.cursorKind = clang.Cursor_Constructor
+
+	tests := []struct {
+		name     string
+		opts     CompleteOptions
+		wantKind string
+	}{
+		{name: "default", opts: CompleteOptions{}, wantKind: CompleteKindFunction},
+		{
+			name: "custom",
+			opts: CompleteOptions{KindFunc: func(kind clang.CursorKind) string {
+				if kind == clang.Cursor_Constructor {
+					return "c"
+				}
+				return CompleteKind(kind)
+			}},
+			wantKind: "c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := marshalResults(tt.opts, []completionResult{ctor}).Results()
+			if got := items[0].Kind(); got != tt.wantKind {
+				t.Errorf("Kind() = %q, want %q", got, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_GroupOverloads(t *testing.T) {
+	pushBack := func(arg string, priority uint32) completionResult {
+		return completionResult{
+			cursorKind: clang.Cursor_CXXMethod,
+			cs: fakeCompletionString{
+				chunks: []fakeChunk{
+					{kind: clang.CompletionChunk_ResultType, text: "void"},
+					{kind: clang.CompletionChunk_TypedText, text: "push_back"},
+					{kind: clang.CompletionChunk_LeftParen, text: "("},
+					{kind: clang.CompletionChunk_Placeholder, text: arg},
+					{kind: clang.CompletionChunk_RightParen, text: ")"},
+				},
+				priority: priority,
+			},
+		}
+	}
+	class := fakeFunction("vector", "", 50)
+	class.cursorKind = clang.Cursor_ClassTemplate
+	ctor := fakeFunction("vector", "", 40)
+	ctor.cursorKind = clang.Cursor_Constructor
+
+	results := []completionResult{
+		pushBack("const value_type &x", 37),
+		fakeFunction("pop_back", "void", 37),
+		pushBack("value_type &&x", 35),
+		class,
+		ctor,
+		pushBack("std::initializer_list<value_type> il", 40),
+	}
+
+	t.Run("ungrouped by default", func(t *testing.T) {
+		items := marshalResults(CompleteOptions{}, results).Results()
+		if len(items) != len(results) {
+			t.Errorf("len(Results()) = %d, want %d", len(items), len(results))
+		}
+		for _, item := range items {
+			if item.Overloads() != 0 {
+				t.Errorf("%s: Overloads() = %d, want 0", item.Word(), item.Overloads())
+			}
+		}
+	})
+
+	t.Run("grouped", func(t *testing.T) {
+		items := marshalResults(CompleteOptions{GroupOverloads: true}, results).Results()
+		if got, want := completeWords(items), []string{"push_back", "pop_back", "vector", "vector"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Results() = %v, want %v", got, want)
+		}
+
+		rep := items[0]
+		if got, want := rep.Overloads(), uint32(2); got != want {
+			t.Errorf("Overloads() = %d, want %d", got, want)
+		}
+		if got, want := rep.Abbr(), "push_back(value_type &&x)"; got != want {
+			t.Errorf("representative Abbr() = %q, want the highest priority %q", got, want)
+		}
+		wantInfo := "void push_back(value_type &&x)\n\n+2 overloads\nvoid push_back(const value_type &x)\nvoid push_back(std::initializer_list<value_type> il)"
+		if got := rep.Info(); got != wantInfo {
+			t.Errorf("Info() = %q, want %q", got, wantInfo)
+		}
+
+		for _, item := range items[1:] {
+			if item.Overloads() != 0 {
+				t.Errorf("%s (%v): Overloads() = %d, want 0", item.Word(), item.CursorKind(), item.Overloads())
+			}
+		}
+	})
+}
+
+func TestCodeCompleteResults_Marshal_Pagination(t *testing.T) {
+	results := []completionResult{
+		fakeFunction("push_back", "void", 50),
+		fakeFunction("size", "size_type", 34),
+		fakeFunction("begin", "iterator", 34),
+		fakeFunction("end", "iterator", 34),
+		fakeFunction("at", "reference", 34),
+	}
+	all := []string{"at", "begin", "end", "size", "push_back"}
+
+	tests := []struct {
+		name          string
+		opts          CompleteOptions
+		want          []string
+		wantTruncated bool
+	}{
+		{name: "no limit", opts: CompleteOptions{}, want: all, wantTruncated: false},
+		{name: "limit smaller than results", opts: CompleteOptions{MaxResults: 2}, want: all[:2], wantTruncated: true},
+		{name: "limit equal to results", opts: CompleteOptions{MaxResults: 5}, want: all, wantTruncated: false},
+		{name: "limit larger than results", opts: CompleteOptions{MaxResults: 10}, want: all, wantTruncated: false},
+		{name: "page 2", opts: CompleteOptions{MaxResults: 2, Offset: 2}, want: all[2:4], wantTruncated: true},
+		{name: "last page", opts: CompleteOptions{MaxResults: 2, Offset: 4}, want: all[4:], wantTruncated: false},
+		{name: "offset past the end", opts: CompleteOptions{MaxResults: 2, Offset: 6}, want: []string{}, wantTruncated: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := marshalResults(tt.opts, results)
+			if got := completeWords(c.Results()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Results() = %v, want %v", got, tt.want)
+			}
+			if got := c.Truncated(); got != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", got, tt.wantTruncated)
+			}
+			if got := c.Total(); got != len(results) {
+				t.Errorf("Total() = %d, want %d", got, len(results))
+			}
+		})
+	}
+
+	t.Run("page 2 consistency", func(t *testing.T) {
+		// the pages of the same request never overlap nor skip, even though the priorities tie
+		var pages []string
+		for offset := 0; ; offset += 2 {
+			c := marshalResults(CompleteOptions{MaxResults: 2, Offset: offset}, results)
+			pages = append(pages, completeWords(c.Results())...)
+			if !c.Truncated() {
+				break
+			}
+		}
+		if !reflect.DeepEqual(pages, all) {
+			t.Errorf("pages = %v, want %v", pages, all)
+		}
+	})
+}
+
+func TestCodeCompleteResults_Marshal_Dedup(t *testing.T) {
+	withKind := func(res completionResult, kind clang.CursorKind) completionResult {
+		res.cursorKind = kind
+		return res
+	}
+	results := []completionResult{
+		fakeFunction("size", "size_type", 34),
+		// inherited member via the base class lookup
+		fakeFunction("size", "size_type", 36),
+		// using-declaration with the higher priority
+		fakeFunction("size", "size_type", 30),
+		// same word, different kind
+		withKind(fakeFunction("size", "size_type", 50), clang.Cursor_FieldDecl),
+		// same word and kind, different menu
+		fakeFunction("size", "int", 34),
+		fakeFunction("empty", "bool", 34),
+	}
+
+	tests := []struct {
+		name         string
+		opts         CompleteOptions
+		want         []string
+		wantPriority []uint32
+		wantTotal    int
+	}{
+		{
+			name:         "dedup",
+			opts:         CompleteOptions{},
+			want:         []string{"size", "empty", "size", "size"},
+			wantPriority: []uint32{30, 34, 34, 50},
+			wantTotal:    4,
+		},
+		{
+			name:         "dedup before pagination",
+			opts:         CompleteOptions{MaxResults: 2},
+			want:         []string{"size", "empty"},
+			wantPriority: []uint32{30, 34},
+			wantTotal:    4,
+		},
+		{
+			name:         "disabled",
+			opts:         CompleteOptions{NoDedup: true},
+			want:         []string{"size", "empty", "size", "size", "size", "size"},
+			wantPriority: []uint32{30, 34, 34, 34, 36, 50},
+			wantTotal:    6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := marshalResults(tt.opts, results)
+			items := c.Results()
+			if got := completeWords(items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Results() = %v, want %v", got, tt.want)
+			}
+			priorities := make([]uint32, len(items))
+			for i := range items {
+				priorities[i] = items[i].Priority()
+			}
+			if !reflect.DeepEqual(priorities, tt.wantPriority) {
+				t.Errorf("priorities = %v, want %v", priorities, tt.wantPriority)
+			}
+			if got := c.Total(); got != tt.wantTotal {
+				t.Errorf("Total() = %d, want %d", got, tt.wantTotal)
+			}
+		})
+	}
+
+	t.Run("kinds are preserved", func(t *testing.T) {
+		kinds := make(map[string]bool)
+		for _, item := range marshalResults(CompleteOptions{}, results).Results() {
+			kinds[item.Kind()+" "+item.Menu()] = true
+		}
+		for _, want := range []string{"f size_type", "m size_type", "f int", "f bool"} {
+			if !kinds[want] {
+				t.Errorf("item of kind and menu %q is removed", want)
+			}
+		}
+	})
+}
+
+func TestCodeCompleteResults_Marshal_Diagnostics(t *testing.T) {
+	unknownType := &Diagnostic{
+		severity: clang.Diagnostic_Error,
+		message:  "unknown type name 'foo_t'",
+		location: Location{fileName: "main.c", line: 3, col: 1, offset: 20},
+	}
+	marshal := func(opts CompleteOptions, results []completionResult, diags []*Diagnostic) *CodeCompleteResults {
+		c := &CodeCompleteResults{Options: opts}
+		return GetRootAsCodeCompleteResults(c.marshal(completion{results: results, diags: diags}, nil).FinishedBytes(), 0)
+	}
+
+	t.Run("empty results with errors", func(t *testing.T) {
+		c := marshal(CompleteOptions{}, nil, []*Diagnostic{unknownType})
+		if n := len(c.Results()); n != 0 {
+			t.Errorf("len(Results()) = %d, want 0", n)
+		}
+		diags := c.Diagnostics()
+		if len(diags) != 1 {
+			t.Fatalf("len(Diagnostics()) = %d, want 1", len(diags))
+		}
+		d := diags[0]
+		if d.Severity() != unknownType.severity || d.Message() != unknownType.message {
+			t.Errorf("Diagnostics()[0] = %v %q, want %v %q", d.Severity(), d.Message(), unknownType.severity, unknownType.message)
+		}
+		if got := d.Location().value(); got != unknownType.location {
+			t.Errorf("Diagnostics()[0].Location() = %+v, want %+v", got, unknownType.location)
+		}
+	})
+
+	t.Run("happy path", func(t *testing.T) {
+		c := marshal(CompleteOptions{}, []completionResult{fakeFunction("size", "size_type", 34)}, nil)
+		if diags := c.Diagnostics(); len(diags) != 0 {
+			t.Errorf("Diagnostics() = %v, want empty", diags)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		diags := []*Diagnostic{
+			{severity: clang.Diagnostic_Ignored, message: "ignored"},
+			{severity: clang.Diagnostic_Warning, message: "implicit declaration of function 'bar'"},
+			unknownType,
+			{severity: clang.Diagnostic_Error, message: "expected ';'"},
+		}
+		c := marshal(CompleteOptions{MaxDiagnostics: 2, MaxDiagnosticLength: 8}, nil, diags)
+		var got []string
+		for _, d := range c.Diagnostics() {
+			got = append(got, d.Message())
+		}
+		want := []string{"implicit…", "unknown …"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Diagnostics() messages = %q, want %q", got, want)
+		}
+
+		c = marshal(CompleteOptions{MaxDiagnostics: -1}, nil, diags)
+		if n := len(c.Diagnostics()); n != 0 {
+			t.Errorf("len(Diagnostics()) = %d with the negative MaxDiagnostics, want 0", n)
+		}
+	})
+}
+
+func TestCodeCompleteResults_Marshal_Context(t *testing.T) {
+	tests := []struct {
+		name             string
+		context          CompletionContext
+		wantMemberAccess bool
+		wantMacroName    bool
+	}{
+		{
+			name:             "member access",
+			context:          CompletionContext(clang.CompletionContext_DotMemberAccess),
+			wantMemberAccess: true,
+		},
+		{
+			name:          "macro name",
+			context:       CompletionContext(clang.CompletionContext_MacroName),
+			wantMacroName: true,
+		},
+		{
+			name:    "unexposed",
+			context: CompletionContext(clang.CompletionContext_Unexposed),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CodeCompleteResults{}
+			comp := completion{results: []completionResult{fakeFunction("size", "size_type", 34)}, context: tt.context}
+			got := GetRootAsCodeCompleteResults(c.marshal(comp, nil).FinishedBytes(), 0).Context()
+			if got != tt.context {
+				t.Errorf("Context() = %#x, want %#x", got, tt.context)
+			}
+			if got.IsMemberAccess() != tt.wantMemberAccess {
+				t.Errorf("IsMemberAccess() = %v, want %v", got.IsMemberAccess(), tt.wantMemberAccess)
+			}
+			if got.Has(clang.CompletionContext_DotMemberAccess) != tt.wantMemberAccess {
+				t.Errorf("Has(DotMemberAccess) = %v, want %v", !tt.wantMemberAccess, tt.wantMemberAccess)
+			}
+			if got.IsMacroName() != tt.wantMacroName {
+				t.Errorf("IsMacroName() = %v, want %v", got.IsMacroName(), tt.wantMacroName)
+			}
+			if got.IsUnknown() {
+				t.Error("IsUnknown() = true, want false")
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_Categories(t *testing.T) {
+	macro := completionResult{
+		cursorKind: clang.Cursor_MacroDefinition,
+		cs: fakeCompletionString{
+			chunks:   []fakeChunk{{kind: clang.CompletionChunk_TypedText, text: "NULL"}},
+			priority: 70,
+		},
+	}
+	keyword := completionResult{
+		cursorKind: clang.Cursor_NotImplemented,
+		cs: fakeCompletionString{
+			chunks:   []fakeChunk{{kind: clang.CompletionChunk_TypedText, text: "static"}},
+			priority: 40,
+		},
+	}
+	pattern := completionResult{
+		cursorKind: clang.Cursor_NotImplemented,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_TypedText, text: "for"},
+				{kind: clang.CompletionChunk_LeftParen, text: "("},
+				{kind: clang.CompletionChunk_Placeholder, text: "init-statement"},
+				{kind: clang.CompletionChunk_RightParen, text: ")"},
+			},
+			priority: 40,
+		},
+	}
+	results := []completionResult{fakeFunction("free", "void", 50), macro, keyword, pattern}
+
+	tests := []struct {
+		name string
+		opts CompleteOptions
+		want []string
+	}{
+		{name: "default", want: []string{"for", "static", "free", "NULL"}},
+		{name: "no macros", opts: CompleteOptions{NoMacros: true}, want: []string{"for", "static", "free"}},
+		{name: "no keywords", opts: CompleteOptions{NoKeywords: true}, want: []string{"for", "free", "NULL"}},
+		{name: "no patterns", opts: CompleteOptions{NoPatterns: true}, want: []string{"static", "free", "NULL"}},
+		{
+			name: "symbols only",
+			opts: CompleteOptions{NoMacros: true, NoKeywords: true, NoPatterns: true},
+			want: []string{"free"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completeWords(marshalResults(tt.opts, results).Results())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Results() words = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_FilterByContext(t *testing.T) {
+	result := func(kind clang.CursorKind, word string) completionResult {
+		return completionResult{
+			cursorKind: kind,
+			cs: fakeCompletionString{
+				chunks:   []fakeChunk{{kind: clang.CompletionChunk_TypedText, text: word}},
+				priority: 50,
+			},
+		}
+	}
+	results := []completionResult{
+		result(clang.Cursor_FunctionDecl, "free"),
+		result(clang.Cursor_VarDecl, "errno"),
+		result(clang.Cursor_FieldDecl, "size"),
+		result(clang.Cursor_CXXMethod, "push"),
+		result(clang.Cursor_StructDecl, "point"),
+		result(clang.Cursor_EnumDecl, "color"),
+		result(clang.Cursor_Namespace, "std"),
+		result(clang.Cursor_MacroDefinition, "NULL"),
+		result(clang.Cursor_NotImplemented, "static"),
+	}
+	all := []string{"NULL", "color", "errno", "free", "point", "push", "size", "static", "std"}
+
+	tests := []struct {
+		name    string
+		context clang.CompletionContext
+		off     bool
+		want    []string
+	}{
+		{name: "arrow member access", context: clang.CompletionContext_ArrowMemberAccess, want: []string{"point", "push", "size"}},
+		// the designated initializer is the dot member access
+		{name: "dot member access", context: clang.CompletionContext_DotMemberAccess, want: []string{"point", "push", "size"}},
+		{name: "macro name", context: clang.CompletionContext_MacroName, want: []string{"NULL"}},
+		{name: "enum tag", context: clang.CompletionContext_EnumTag, want: []string{"color"}},
+		{name: "namespace", context: clang.CompletionContext_Namespace, want: []string{"std"}},
+		{name: "nested name specifier", context: clang.CompletionContext_NestedNameSpecifier, want: []string{"color", "point", "std"}},
+		{name: "enum tag and any type", context: clang.CompletionContext_EnumTag | clang.CompletionContext_AnyType, want: all},
+		{name: "any value", context: clang.CompletionContext_AnyValue, want: all},
+		{name: "unknown", context: clang.CompletionContext_Unknown | clang.CompletionContext_ArrowMemberAccess, want: all},
+		{name: "unexposed", context: clang.CompletionContext_Unexposed, want: all},
+		{name: "option disabled", context: clang.CompletionContext_ArrowMemberAccess, off: true, want: all},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CodeCompleteResults{Options: CompleteOptions{FilterByContext: !tt.off}}
+			comp := completion{results: results, context: CompletionContext(tt.context)}
+			got := GetRootAsCodeCompleteResults(c.marshal(comp, nil).FinishedBytes(), 0)
+			words := completeWords(got.Results())
+			sort.Strings(words)
+			if !reflect.DeepEqual(words, tt.want) {
+				t.Errorf("Results() words = %q, want %q", words, tt.want)
+			}
+			// the context is kept for the clients
+			if got.Context() != CompletionContext(tt.context) {
+				t.Errorf("Context() = %#x, want %#x", got.Context(), tt.context)
+			}
+		})
+	}
+}
+
+func TestCompleteItem_SortText(t *testing.T) {
+	results := []completionResult{
+		fakeFunction("foobar", "int", 50),
+		fakeFunction("Zeta", "int", 50),
+		fakeFunction("FooBar", "int", 50),
+		fakeFunction("alpha", "int", 60),
+	}
+	items := marshalResults(CompleteOptions{}, results).Results()
+
+	// the words differ only in case sort adjacently, and the ties are broken by the exact word
+	if got, want := completeWords(items), []string{"FooBar", "foobar", "Zeta", "alpha"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Results() words = %q, want %q", got, want)
+	}
+	for _, item := range items[:2] {
+		if got, want := item.SortText(), "0000000050foobar"; got != want {
+			t.Errorf("%s: SortText() = %q, want %q", item.Word(), got, want)
+		}
+	}
+	if got := NewCompleteItem("Zeta", WithPriority(50)).SortText(); got != items[2].SortText() {
+		t.Errorf("in-memory SortText() = %q, want %q", got, items[2].SortText())
+	}
+}
+
+func TestMergeCompleteResults(t *testing.T) {
+	a := marshalResults(CompleteOptions{}, []completionResult{fakeFunction("printf", "int", 50), fakeFunction("puts", "int", 40)})
+	b := marshalResults(CompleteOptions{}, []completionResult{fakeFunction("printf", "int", 30), fakeFunction("fputs", "int", 50)})
+
+	merged := MergeCompleteResults(a, nil, b)
+	results := merged.Results()
+	if got, want := completeWords(results), []string{"printf", "puts", "fputs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Results() words = %q, want %q", got, want)
+	}
+	if got := results[0].Priority(); got != 30 {
+		t.Errorf("Priority() of the overlapping printf = %d, want the best 30", got)
+	}
+	if merged.Total() != 3 || merged.Truncated() {
+		t.Errorf("Total(), Truncated() = %d, %v, want 3, false", merged.Total(), merged.Truncated())
+	}
+
+	if got := completeWords(MergeCompleteResults().Results()); len(got) != 0 {
+		t.Errorf("MergeCompleteResults() without sets = %q, want empty", got)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// CompleteItemOption represents a option of NewCompleteItem.
+type CompleteItemOption func(*CompleteItem)
+
+// WithAbbr sets the abbreviation of the CompleteItem which displayed in the menu instead of the word.
+func WithAbbr(abbr string) CompleteItemOption {
+	return func(c *CompleteItem) { c.abbr = abbr }
+}
+
+// WithMenu sets the extra text of the CompleteItem which displayed in the menu, such as the result type.
+func WithMenu(menu string) CompleteItemOption {
+	return func(c *CompleteItem) { c.menu = menu }
+}
+
+// WithInfo sets the more information of the CompleteItem which displayed in the preview window.
+func WithInfo(info string) CompleteItemOption {
+	return func(c *CompleteItem) { c.info = info }
+}
+
+// WithKind sets the single letter kind of the CompleteItem, such as CompleteKind returns.
+func WithKind(kind string) CompleteItemOption {
+	return func(c *CompleteItem) { c.kind = kind }
+}
+
+// WithIcase sets whether the case is ignored when comparing the CompleteItem word.
+func WithIcase(icase bool) CompleteItemOption {
+	return func(c *CompleteItem) { c.icase = icase }
+}
+
+// WithDup sets whether the CompleteItem is added even if the same word is already present.
+func WithDup(dup bool) CompleteItemOption {
+	return func(c *CompleteItem) { c.dup = dup }
+}
+
+// WithPriority sets the priority of the CompleteItem. The smaller value is more likely, same as clang.
+func WithPriority(priority uint32) CompleteItemOption {
+	return func(c *CompleteItem) { c.priority = priority }
+}
+
+// WithSnippet sets the snippet of the CompleteItem, which is written in the syntax.
+func WithSnippet(snippet string, syntax SnippetSyntax) CompleteItemOption {
+	return func(c *CompleteItem) { c.snippet, c.snippetSyntax = snippet, syntax }
+}
+
+// WithAvailability sets the availability of the CompleteItem.
+func WithAvailability(availability clang.AvailabilityKind) CompleteItemOption {
+	return func(c *CompleteItem) { c.availability = availability }
+}
+
+// WithDeprecated sets whether the CompleteItem is deprecated.
+func WithDeprecated(deprecated bool) CompleteItemOption {
+	return func(c *CompleteItem) { c.deprecated = deprecated }
+}
+
+// WithCursorKind sets the cursor kind of the CompleteItem.
+func WithCursorKind(kind clang.CursorKind) CompleteItemOption {
+	return func(c *CompleteItem) { c.cursorKind = kind }
+}
+
+// WithOverloads sets the number of the overloads which folded into the CompleteItem.
+func WithOverloads(overloads uint32) CompleteItemOption {
+	return func(c *CompleteItem) { c.overloads = overloads }
+}
+
+// WithUserData sets the encoded UserData payload of the CompleteItem, which can be decoded by DecodeUserData.
+func WithUserData(userData string) CompleteItemOption {
+	return func(c *CompleteItem) { c.userData = userData }
+}
+
+// NewCompleteItem returns the in-memory CompleteItem of word configured by opts,
+// for the completion sources other than clang, such as the snippet providers or the cached items.
+// The items can be combined with the clang results by CodeCompleteResults.MarshalItems.
+func NewCompleteItem(word string, opts ...CompleteItemOption) *CompleteItem {
+	c := &CompleteItem{word: word}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Equal reports whether c and o have the same serialized fields, regardless of whether
+// either of them is backed by the flatbuffers buffer.
+func (c CompleteItem) Equal(o CompleteItem) bool {
+	return bytes.Equal(c.WordBytes(), o.WordBytes()) &&
+		bytes.Equal(c.AbbrBytes(), o.AbbrBytes()) &&
+		bytes.Equal(c.MenuBytes(), o.MenuBytes()) &&
+		bytes.Equal(c.InfoBytes(), o.InfoBytes()) &&
+		bytes.Equal(c.KindBytes(), o.KindBytes()) &&
+		c.Icase() == o.Icase() &&
+		c.Dup() == o.Dup() &&
+		c.Priority() == o.Priority() &&
+		bytes.Equal(c.SnippetBytes(), o.SnippetBytes()) &&
+		c.Availability() == o.Availability() &&
+		c.Deprecated() == o.Deprecated() &&
+		c.CursorKind() == o.CursorKind() &&
+		c.SnippetSyntax() == o.SnippetSyntax() &&
+		c.Overloads() == o.Overloads() &&
+		bytes.Equal(c.UserDataBytes(), o.UserDataBytes())
+}
+
+// Hash returns the FNV-1a hash of the fields compared by Equal, so the equal items have the same hash.
+// The hash is stable across processes and can be used as the cache key of the item.
+func (c CompleteItem) Hash() uint64 {
+	h := fnv.New64a()
+	var buf [binary.MaxVarintLen64]byte
+	// each string is prefixed by its length so that the boundaries of adjacent fields are not ambiguous
+	writeBytes := func(b []byte) {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(b)))])
+		h.Write(b)
+	}
+	writeUint := func(v uint64) {
+		h.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+	writeBool := func(v bool) {
+		if v {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	}
+
+	writeBytes(c.WordBytes())
+	writeBytes(c.AbbrBytes())
+	writeBytes(c.MenuBytes())
+	writeBytes(c.InfoBytes())
+	writeBytes(c.KindBytes())
+	writeBool(c.Icase())
+	writeBool(c.Dup())
+	writeUint(uint64(c.Priority()))
+	writeBytes(c.SnippetBytes())
+	writeUint(uint64(c.Availability()))
+	writeBool(c.Deprecated())
+	writeUint(uint64(c.CursorKind()))
+	writeUint(uint64(c.SnippetSyntax()))
+	writeUint(uint64(c.Overloads()))
+	writeBytes(c.UserDataBytes())
+
+	return h.Sum64()
+}
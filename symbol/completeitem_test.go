@@ -0,0 +1,122 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewCompleteItem(t *testing.T) {
+	item := NewCompleteItem("for",
+		WithAbbr("for (...)"),
+		WithMenu("[snippet]"),
+		WithInfo("for loop"),
+		WithKind("s"),
+		WithIcase(true),
+		WithDup(true),
+		WithPriority(20),
+	)
+
+	check := func(t *testing.T, got *CompleteItem) {
+		t.Helper()
+		for _, tt := range []struct {
+			name      string
+			got, want interface{}
+		}{
+			{"Word", got.Word(), "for"},
+			{"Abbr", got.Abbr(), "for (...)"},
+			{"Menu", got.Menu(), "[snippet]"},
+			{"Info", got.Info(), "for loop"},
+			{"Kind", got.Kind(), "s"},
+			{"Icase", got.Icase(), true},
+			{"Dup", got.Dup(), true},
+			{"Priority", got.Priority(), uint32(20)},
+		} {
+			if tt.got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		}
+	}
+
+	t.Run("in-memory", func(t *testing.T) {
+		check(t, item)
+	})
+	t.Run("round trip", func(t *testing.T) {
+		c := &CodeCompleteResults{}
+		results := GetRootAsCodeCompleteResults(c.MarshalItems(nil, []*CompleteItem{item}).FinishedBytes(), 0).Results()
+		if len(results) != 1 {
+			t.Fatalf("len(Results()) = %d, want 1", len(results))
+		}
+		check(t, &results[0])
+	})
+
+	if got := NewCompleteItem("x"); got.Icase() || got.Dup() || got.Priority() != 0 || got.Abbr() != "" {
+		t.Errorf("NewCompleteItem() without options = %+v, want zero fields", got)
+	}
+}
+
+func TestCodeCompleteResults_MarshalItems(t *testing.T) {
+	items := []*CompleteItem{
+		NewCompleteItem("print_all", WithKind("s"), WithPriority(45)),
+		NewCompleteItem("printf", WithKind("f"), WithMenu("int"), WithAbbr("printf()"), WithPriority(10)),
+	}
+	c := &CodeCompleteResults{}
+	c.Options.GroupOverloads = true
+
+	// combined with the clang results, the identical printf is deduplicated
+	buf := c.marshal(completion{
+		results: []completionResult{fakeFunction("printf", "int", 50), fakeFunction("puts", "int", 50)},
+		items:   items,
+	}, nil)
+	got := completeWords(GetRootAsCodeCompleteResults(buf.FinishedBytes(), 0).Results())
+	if want := []string{"printf", "print_all", "puts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Results() words = %q, want %q", got, want)
+	}
+
+	if items[1].Priority() != 10 || items[1].Overloads() != 0 {
+		t.Errorf("MarshalItems modified the item: %+v", items[1])
+	}
+}
+
+func TestCompleteItem_Equal(t *testing.T) {
+	item := NewCompleteItem("printf", WithMenu("int"), WithKind("f"), WithPriority(50))
+	same := NewCompleteItem("printf", WithMenu("int"), WithKind("f"), WithPriority(50))
+	other := NewCompleteItem("printf", WithMenu("void"), WithKind("f"), WithPriority(50))
+
+	if !item.Equal(*same) {
+		t.Error("Equal() = false for the same fields, want true")
+	}
+	if item.Hash() != same.Hash() {
+		t.Errorf("Hash() = %x and %x for the same fields, want equal", item.Hash(), same.Hash())
+	}
+	if item.Equal(*other) {
+		t.Error("Equal() = true for the different Menu, want false")
+	}
+	if item.Hash() == other.Hash() {
+		t.Errorf("Hash() = %x for the different Menu, want different", item.Hash())
+	}
+
+	// the adjacent fields must not be ambiguous
+	a := NewCompleteItem("ab", WithAbbr("c"))
+	b := NewCompleteItem("a", WithAbbr("bc"))
+	if a.Equal(*b) || a.Hash() == b.Hash() {
+		t.Error("items differing in the field boundary are equal")
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		c := &CodeCompleteResults{}
+		results := GetRootAsCodeCompleteResults(c.MarshalItems(nil, []*CompleteItem{item}).FinishedBytes(), 0).Results()
+		if len(results) != 1 {
+			t.Fatalf("len(Results()) = %d, want 1", len(results))
+		}
+		if !results[0].Equal(*item) {
+			t.Error("Equal() = false between the serialized and in-memory item, want true")
+		}
+		if results[0].Hash() != item.Hash() {
+			t.Errorf("Hash() = %x, want %x", results[0].Hash(), item.Hash())
+		}
+	})
+}
@@ -0,0 +1,275 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-clang/v3.9/clang"
+	"github.com/pkg/errors"
+)
+
+// Provenance represents the source of the symbols of the File.
+type Provenance string
+
+// The Provenance values.
+const (
+	// ProvenanceClang the symbols are parsed by clang, which is the default.
+	ProvenanceClang Provenance = "clang"
+	// ProvenanceCtags the symbols are imported from the tags file by ImportTags, which are imprecise.
+	ProvenanceCtags Provenance = "ctags"
+)
+
+// provenanceMetaKey is the metadata key of the Provenance.
+const provenanceMetaKey = "provenance"
+
+// SetProvenance sets the source of the symbols of f. It is serialized as the metadata of f.
+func (f *File) SetProvenance(p Provenance) {
+	f.SetMeta(provenanceMetaKey, string(p))
+}
+
+// Provenance return the source of the symbols of f, which is ProvenanceClang if not set.
+func (f *File) Provenance() Provenance {
+	if p, ok := f.Meta(provenanceMetaKey); ok && p != "" {
+		return Provenance(p)
+	}
+	return ProvenanceClang
+}
+
+// ctagsKind represents the kind of the tag.
+type ctagsKind struct {
+	cursorKind clang.CursorKind
+	usr        string // USR component prefix of the name, such as "@F@"
+	decl       bool   // the tag is the declaration, not the definition
+}
+
+// ctagsKinds maps the long and single letter kinds of the C and C++ tags of universal-ctags to ctagsKind.
+// The kinds which are not the symbols, such as the local variables, headers and labels, are skipped.
+var ctagsKinds = map[string]ctagsKind{
+	"class":      {clang.Cursor_ClassDecl, "@S@", false},
+	"macro":      {clang.Cursor_MacroDefinition, "@macro@", false},
+	"enumerator": {clang.Cursor_EnumConstantDecl, "@", false},
+	"function":   {clang.Cursor_FunctionDecl, "@F@", false},
+	"enum":       {clang.Cursor_EnumDecl, "@E@", false},
+	"member":     {clang.Cursor_FieldDecl, "@FI@", false},
+	"namespace":  {clang.Cursor_Namespace, "@N@", false},
+	"prototype":  {clang.Cursor_FunctionDecl, "@F@", true},
+	"struct":     {clang.Cursor_StructDecl, "@S@", false},
+	"typedef":    {clang.Cursor_TypedefDecl, "@T@", false},
+	"union":      {clang.Cursor_UnionDecl, "@U@", false},
+	"variable":   {clang.Cursor_VarDecl, "@", false},
+	"externvar":  {clang.Cursor_VarDecl, "@", true},
+}
+
+// ctagsKindLetters maps the single letter kinds to the long kinds.
+var ctagsKindLetters = map[string]string{
+	"c": "class",
+	"d": "macro",
+	"e": "enumerator",
+	"f": "function",
+	"g": "enum",
+	"m": "member",
+	"n": "namespace",
+	"p": "prototype",
+	"s": "struct",
+	"t": "typedef",
+	"u": "union",
+	"v": "variable",
+	"x": "externvar",
+}
+
+// ctagsScopeUSRs maps the scope kinds of the scope fields to the USR component prefix.
+var ctagsScopeUSRs = map[string]string{
+	"namespace": "@N@",
+	"class":     "@S@",
+	"struct":    "@S@",
+	"union":     "@U@",
+	"enum":      "@E@",
+}
+
+// ctagsTag represents a line of the tags file.
+type ctagsTag struct {
+	name      string
+	file      string
+	line      uint32
+	col       uint32
+	kind      string
+	scopeKind string
+	scope     []string
+}
+
+// ImportTags reads the tags file of ctags, such as universal-ctags generates by
+//
+//	ctags -R --fields=+nK --extras=-F --languages=C,C++ .
+//
+// and returns the Files of the tagged source files, ordered by the name. The relative file names of the tags
+// are resolved against root, which is the directory of the tags file usually.
+//
+// The tags are added by AddDefinition, or by AddDecl for the prototypes and extern variables. ctags reports
+// no USR, so the USR is derived from the scope, kind and name in the manner of clang, such as
+// "c:@N@geom@S@Shape@F@area". It matches the clang USR of the C symbols, but not of the C++ functions whose
+// clang USR has the signature. The column is the position of the name in the search pattern, and zero if the
+// pattern does not have it. The tags without the line number, which --fields=+n adds to the tags of the
+// search pattern, are skipped. The Files have ProvenanceCtags, so the Table prefers the Files of clang to them.
+func ImportTags(r io.Reader, root string) ([]*File, error) {
+	files := make(map[string]*File)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "!_") {
+			continue
+		}
+		tag, err := parseCtagsLine(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "tags:%d", n)
+		}
+		kind, ok := ctagsKinds[tag.kind]
+		if !ok || tag.line == 0 {
+			continue
+		}
+
+		name := tag.file
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(root, name)
+		}
+		f, ok := files[name]
+		if !ok {
+			f = NewFile(name, nil)
+			f.SetProvenance(ProvenanceCtags)
+			files[name] = f
+		}
+
+		parentUSR := ctagsParentUSR(tag)
+		usr := "c:" + strings.TrimPrefix(parentUSR, "c:") + kind.usr + tag.name
+		loc := Location{fileName: name, line: tag.line, col: tag.col, usr: usr, kind: kind.cursorKind}
+		if kind.decl {
+			f.AddDecl(loc)
+		} else {
+			f.AddDefinition(loc, loc)
+		}
+		if info := f.Symbol(ToID(usr)); info != nil {
+			info.name = tag.name
+			info.parentUSR = parentUSR
+			if tag.scopeKind == "namespace" {
+				info.namespace = strings.Join(tag.scope, "::")
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read the tags")
+	}
+
+	result := make([]*File, 0, len(files))
+	for _, f := range files {
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result, nil
+}
+
+// ctagsParentUSR returns the USR of the scope of tag, or empty if tag has no scope.
+// The kinds of the outer scopes are not reported by ctags, so they are assumed to be the namespaces.
+func ctagsParentUSR(tag *ctagsTag) string {
+	if len(tag.scope) == 0 {
+		return ""
+	}
+
+	usr := "c:"
+	for i, s := range tag.scope {
+		prefix := "@N@"
+		if i == len(tag.scope)-1 {
+			if p, ok := ctagsScopeUSRs[tag.scopeKind]; ok {
+				prefix = p
+			}
+		}
+		usr += prefix + s
+	}
+
+	return usr
+}
+
+// parseCtagsLine parses the line of the tags file, which is
+//
+//	{tagname}<Tab>{tagfile}<Tab>{tagaddress}[;"<Tab>{tagfield}...]
+func parseCtagsLine(line string) (*ctagsTag, error) {
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) < 3 {
+		return nil, errors.Errorf("malformed tag %q", line)
+	}
+	tag := &ctagsTag{name: fields[0], file: fields[1]}
+
+	address, ext := fields[2], ""
+	if i := strings.Index(address, ";\"\t"); i >= 0 {
+		address, ext = address[:i], address[i+3:]
+	} else {
+		address = strings.TrimSuffix(address, ";\"")
+	}
+	if n, err := strconv.ParseUint(address, 10, 32); err == nil {
+		tag.line = uint32(n)
+	} else if pattern := ctagsPattern(address); pattern != "" {
+		if i := strings.Index(pattern, tag.name); i >= 0 {
+			tag.col = uint32(i) + 1
+		}
+	}
+
+	for _, field := range strings.Split(ext, "\t") {
+		if field == "" {
+			continue
+		}
+		i := strings.IndexByte(field, ':')
+		if i < 0 {
+			// the kind without the "kind:" key
+			tag.kind = field
+			continue
+		}
+		key, value := field[:i], field[i+1:]
+		switch key {
+		case "kind":
+			tag.kind = value
+		case "line":
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, errors.Errorf("invalid line %q", value)
+			}
+			tag.line = uint32(n)
+		case "scope":
+			// the scope field of --fields=+Z, such as "scope:class:geom::Shape"
+			if j := strings.IndexByte(value, ':'); j >= 0 {
+				tag.scopeKind, tag.scope = value[:j], strings.Split(value[j+1:], "::")
+			}
+		default:
+			if _, ok := ctagsScopeUSRs[key]; ok {
+				tag.scopeKind, tag.scope = key, strings.Split(value, "::")
+			}
+		}
+	}
+	if long, ok := ctagsKindLetters[tag.kind]; ok {
+		tag.kind = long
+	}
+
+	return tag, nil
+}
+
+// ctagsPattern returns the unescaped search pattern of the tag address, such as "/^int main(void)$/",
+// without the anchors. It returns empty if address is not the search pattern.
+func ctagsPattern(address string) string {
+	if len(address) < 2 || (address[0] != '/' && address[0] != '?') || address[len(address)-1] != address[0] {
+		return ""
+	}
+	pattern := address[1 : len(address)-1]
+	pattern = strings.TrimPrefix(pattern, "^")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	return strings.NewReplacer(`\\`, `\`, `\/`, `/`, `\?`, `?`).Replace(pattern)
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func importTestTags(t *testing.T) map[string]*File {
+	t.Helper()
+	r, err := os.Open(filepath.Join("testdata", "ctags", "tags"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	files, err := ImportTags(r, "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]*File, len(files))
+	for _, f := range files {
+		m[f.Name()] = f
+	}
+
+	return m
+}
+
+func TestImportTags(t *testing.T) {
+	files := importTestTags(t)
+	if len(files) != 3 {
+		t.Fatalf("ImportTags() = %d files, want 3", len(files))
+	}
+
+	tests := []struct {
+		file      string
+		usr       string
+		kind      clang.CursorKind
+		line, col uint32
+		def       bool
+		parentUSR string
+	}{
+		{"/repo/src/geom.hpp", "c:@macro@MAX_POINTS", clang.Cursor_MacroDefinition, 3, 9, true, ""},
+		{"/repo/src/geom.hpp", "c:@N@geom", clang.Cursor_Namespace, 5, 11, true, ""},
+		{"/repo/src/geom.hpp", "c:@N@geom@S@Point", clang.Cursor_StructDecl, 6, 8, true, "c:@N@geom"},
+		{"/repo/src/geom.hpp", "c:@N@geom@S@Point@FI@x", clang.Cursor_FieldDecl, 7, 7, true, "c:@N@geom@S@Point"},
+		{"/repo/src/geom.hpp", "c:@N@geom@S@Shape", clang.Cursor_ClassDecl, 11, 7, true, "c:@N@geom"},
+		{"/repo/src/geom.hpp", "c:@N@geom@S@Shape@F@area", clang.Cursor_FunctionDecl, 13, 18, false, "c:@N@geom@S@Shape"},
+		{"/repo/src/geom.hpp", "c:@N@geom@S@Shape@F@scale", clang.Cursor_FunctionDecl, 14, 8, false, "c:@N@geom@S@Shape"},
+		{"/repo/src/geom.cpp", "c:@N@geom@S@Shape@F@area", clang.Cursor_FunctionDecl, 5, 15, true, "c:@N@geom@S@Shape"},
+		{"/repo/src/geom.cpp", "c:@N@geom@origin", clang.Cursor_VarDecl, 3, 14, true, "c:@N@geom"},
+		{"/repo/src/main.c", "c:@F@main", clang.Cursor_FunctionDecl, 8, 5, true, ""},
+		// the numeric address has no pattern to find the column
+		{"/repo/src/main.c", "c:@point_count", clang.Cursor_VarDecl, 4, 0, true, ""},
+	}
+	symbols := make(map[string]int)
+	for _, tt := range tests {
+		symbols[tt.file]++
+		for name, f := range map[string]*File{"in-memory": files[tt.file], "round trip": roundTrip(files[tt.file])} {
+			if f.Provenance() != ProvenanceCtags {
+				t.Errorf("%s: %s Provenance() = %q, want %q", name, tt.file, f.Provenance(), ProvenanceCtags)
+			}
+			sym := findSymbol(f, tt.usr)
+			if sym == nil {
+				t.Errorf("%s: %s has no %s", name, tt.file, tt.usr)
+				continue
+			}
+			if sym.Kind() != tt.kind || sym.ParentUSR() != tt.parentUSR || !strings.HasSuffix(tt.usr, sym.Name()) {
+				t.Errorf("%s: %s = kind %d, parent %q, name %q, want kind %d, parent %q", name, tt.usr, sym.Kind(), sym.ParentUSR(), sym.Name(), tt.kind, tt.parentUSR)
+			}
+			loc := sym.Decls()[0]
+			if loc.FileName() != tt.file || loc.Line() != tt.line || loc.Col() != tt.col {
+				t.Errorf("%s: %s declared at %s:%d:%d, want %s:%d:%d", name, tt.usr, loc.FileName(), loc.Line(), loc.Col(), tt.file, tt.line, tt.col)
+			}
+			if def := sym.Def(); def.IsZero() == tt.def {
+				t.Errorf("%s: %s Def() = %s:%d:%d, want the definition %v", name, tt.usr, def.FileName(), def.Line(), def.Col(), tt.def)
+			}
+		}
+	}
+	for name, want := range symbols {
+		// the local variable of main.c is skipped
+		if got := len(files[name].Symbols()); got != want {
+			t.Errorf("%s has %d symbols, want %d", name, got, want)
+		}
+	}
+	if ns := findSymbol(files["/repo/src/geom.hpp"], "c:@N@geom@S@Point").Namespace(); ns != "geom" {
+		t.Errorf("Namespace() = %q, want %q", ns, "geom")
+	}
+
+	if _, err := ImportTags(strings.NewReader("main\tmain.c\n"), "/repo"); err == nil {
+		t.Error("ImportTags() of the malformed tag error = nil, want the error")
+	}
+}
+
+func TestImportTags_PreferClang(t *testing.T) {
+	files := importTestTags(t)
+	var tagged []*File
+	for _, f := range files {
+		tagged = append(tagged, f)
+	}
+	table := NewTable(tagged)
+
+	const pointUSR = "c:@N@geom@S@Point"
+	if syms := table.Symbols(ToID(pointUSR)); len(syms) != 1 || syms[0] != files["/repo/src/geom.hpp"].Symbol(ToID(pointUSR)) {
+		t.Fatalf("Symbols() before clang = %d symbols, want the tag", len(syms))
+	}
+
+	// clang parses geom.cpp, which has the precise Point of geom.hpp
+	parsed := NewFile("/repo/src/geom.cpp", nil)
+	def := Location{fileName: "/repo/src/geom.hpp", line: 6, col: 8, usr: pointUSR, kind: clang.Cursor_StructDecl}
+	parsed.AddDefinition(def, def)
+	table.Update(parsed)
+	if got := table.File("/repo/src/geom.cpp"); got != parsed {
+		t.Errorf("File() = %p, want the clang File %p", got, parsed)
+	}
+	syms := table.Symbols(ToID(pointUSR))
+	if len(syms) != 1 || syms[0] != parsed.Symbol(ToID(pointUSR)) {
+		t.Errorf("Symbols() = %d symbols, want only the clang symbol", len(syms))
+	}
+
+	// the tags imported again do not replace the clang File
+	table.Update(files["/repo/src/geom.cpp"])
+	if got := table.File("/repo/src/geom.cpp"); got != parsed {
+		t.Errorf("File() after the tags = %p, want the clang File %p", got, parsed)
+	}
+	// but replace the previous tags
+	retagged := importTestTags(t)["/repo/src/main.c"]
+	table.Update(retagged)
+	if got := table.File("/repo/src/main.c"); got != retagged {
+		t.Errorf("File() = %p, want the new tags File %p", got, retagged)
+	}
+}
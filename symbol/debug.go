@@ -0,0 +1,305 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// DefaultMaxDebugResponseBytes default size cap of the DebugHandler responses.
+const DefaultMaxDebugResponseBytes = 1 << 20
+
+// DebugHandler serves the JSON lookups of the Table for the operational debugging, such as
+//
+//	mux.Handle("/symbol", h.Symbol()) // /symbol?usr=c:@F@main
+//	mux.Handle("/file", h.File())     // /file?name=/src/main.c
+//	mux.Handle("/refs", h.Refs())     // /refs?file=/src/main.c&line=10&col=5
+//
+// The missing or malformed query is answered by 400, and the unknown symbol or file by 404.
+// The lists of the response are truncated to fit in the size cap, and the response reports "truncated".
+type DebugHandler struct {
+	table    *Table
+	maxBytes int
+}
+
+// NewDebugHandler returns the DebugHandler of t, which caps the responses to DefaultMaxDebugResponseBytes.
+func NewDebugHandler(t *Table) *DebugHandler {
+	return &DebugHandler{table: t, maxBytes: DefaultMaxDebugResponseBytes}
+}
+
+// SetMaxResponseBytes sets the size cap of the responses. The zero or negative n disables the cap.
+func (h *DebugHandler) SetMaxResponseBytes(n int) {
+	h.maxBytes = n
+}
+
+// debugLocation represents the JSON encoding of Location. The col is in the column base, see SetColumnBase.
+type debugLocation struct {
+	File string `json:"file"`
+	Line uint32 `json:"line"`
+	Col  uint32 `json:"col"`
+}
+
+func toDebugLocations(locs []Location) []debugLocation {
+	dls := make([]debugLocation, len(locs))
+	for i, loc := range locs {
+		dls[i] = debugLocation{File: loc.FileName(), Line: loc.Line(), Col: loc.Col()}
+	}
+	return dls
+}
+
+// debugResponse represents the response which can be truncated to fit in the size cap.
+type debugResponse interface {
+	// truncate halves the longest list of the response, and reports false if no list remains.
+	truncate() bool
+}
+
+// halve halves the longest list of lens, and reports the index of it, or -1 if all lists are empty.
+func halve(lens ...int) (int, int) {
+	longest := -1
+	for i, n := range lens {
+		if n > 0 && (longest < 0 || n > lens[longest]) {
+			longest = i
+		}
+	}
+	if longest < 0 {
+		return -1, 0
+	}
+	return longest, lens[longest] / 2
+}
+
+type debugSymbol struct {
+	ID           string          `json:"id"`
+	USR          string          `json:"usr"`
+	Name         string          `json:"name"`
+	Kind         string          `json:"kind"`
+	Namespace    string          `json:"namespace,omitempty"`
+	Signature    string          `json:"signature,omitempty"`
+	Definitions  []debugLocation `json:"definitions"`
+	Declarations []debugLocation `json:"declarations"`
+	Truncated    bool            `json:"truncated,omitempty"`
+}
+
+func (s *debugSymbol) truncate() bool {
+	i, n := halve(len(s.Definitions), len(s.Declarations))
+	switch i {
+	case 0:
+		s.Definitions = s.Definitions[:n]
+	case 1:
+		s.Declarations = s.Declarations[:n]
+	default:
+		return false
+	}
+	s.Truncated = true
+	return true
+}
+
+// Symbol returns the handler of the symbol lookup by the "usr" query. The symbol is merged across the Files,
+// and the definitions and declarations are as same as Resolver.Definition collects.
+func (h *DebugHandler) Symbol() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usr := r.URL.Query().Get("usr")
+		if usr == "" {
+			http.Error(w, "missing usr", http.StatusBadRequest)
+			return
+		}
+		infos := h.table.Symbols(ToID(usr))
+		if len(infos) == 0 {
+			http.Error(w, fmt.Sprintf("no symbol of usr %q", usr), http.StatusNotFound)
+			return
+		}
+
+		named := infos[0]
+		for _, info := range infos {
+			if info.Name() != "" {
+				named = info
+				break
+			}
+		}
+		defs, decls := definitions(infos)
+		h.writeJSON(w, &debugSymbol{
+			ID:           named.ID().String(),
+			USR:          usr,
+			Name:         named.Name(),
+			Kind:         named.SymbolKind().String(),
+			Namespace:    named.Namespace(),
+			Signature:    named.Signature(),
+			Definitions:  toDebugLocations(defs),
+			Declarations: toDebugLocations(decls),
+		})
+	})
+}
+
+type debugFileSymbol struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type debugFile struct {
+	Name      string            `json:"name"`
+	Language  string            `json:"language"`
+	Flags     []string          `json:"flags"`
+	Headers   []string          `json:"headers"`
+	TUOmitted bool              `json:"tu_omitted"`
+	Symbols   []debugFileSymbol `json:"symbols"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+func (f *debugFile) truncate() bool {
+	i, n := halve(len(f.Flags), len(f.Headers), len(f.Symbols))
+	switch i {
+	case 0:
+		f.Flags = f.Flags[:n]
+	case 1:
+		f.Headers = f.Headers[:n]
+	case 2:
+		f.Symbols = f.Symbols[:n]
+	default:
+		return false
+	}
+	f.Truncated = true
+	return true
+}
+
+// File returns the handler of the File lookup by the "name" query. The symbols are ordered by the ID.
+func (h *DebugHandler) File() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name", http.StatusBadRequest)
+			return
+		}
+		f := h.table.File(name)
+		if f == nil {
+			http.Error(w, fmt.Sprintf("no file %q", name), http.StatusNotFound)
+			return
+		}
+
+		resp := &debugFile{
+			Name:      f.Name(),
+			Language:  f.Language().String(),
+			Flags:     f.Flags(),
+			Headers:   []string{},
+			TUOmitted: f.TranslationUnitOmitted(),
+			Symbols:   []debugFileSymbol{},
+		}
+		if resp.Flags == nil {
+			resp.Flags = []string{}
+		}
+		for _, hdr := range f.Headers() {
+			resp.Headers = append(resp.Headers, hdr.Path())
+		}
+		syms := f.Symbols()
+		sort.Slice(syms, func(i, j int) bool {
+			a, b := syms[i].ID(), syms[j].ID()
+			return bytes.Compare(a[:], b[:]) < 0
+		})
+		for _, sym := range syms {
+			resp.Symbols = append(resp.Symbols, debugFileSymbol{
+				ID:   sym.ID().String(),
+				Name: sym.Name(),
+				Kind: sym.SymbolKind().String(),
+			})
+		}
+		h.writeJSON(w, resp)
+	})
+}
+
+type debugRefs struct {
+	Symbols    []debugFileSymbol `json:"symbols"`
+	References []debugLocation   `json:"references"`
+	Truncated  bool              `json:"truncated,omitempty"`
+}
+
+func (refs *debugRefs) truncate() bool {
+	if len(refs.References) == 0 {
+		return false
+	}
+	refs.References = refs.References[:len(refs.References)/2]
+	refs.Truncated = true
+	return true
+}
+
+// Refs returns the handler of the references of the symbol at the "file", "line" and "col" query.
+// The col is in the column base, see SetColumnBase. The references are the callers in all Files,
+// which are deduplicated and ordered by the file name, line and column.
+func (h *DebugHandler) Refs() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		file := q.Get("file")
+		if file == "" {
+			http.Error(w, "missing file", http.StatusBadRequest)
+			return
+		}
+		line, err := strconv.ParseUint(q.Get("line"), 10, 32)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid line %q", q.Get("line")), http.StatusBadRequest)
+			return
+		}
+		col, err := strconv.ParseUint(q.Get("col"), 10, 32)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid col %q", q.Get("col")), http.StatusBadRequest)
+			return
+		}
+
+		at := h.table.SymbolsAt(file, uint32(line), uint32(col))
+		if len(at) == 0 {
+			http.Error(w, fmt.Sprintf("no symbol at %s:%d:%d", file, line, col), http.StatusNotFound)
+			return
+		}
+
+		resp := &debugRefs{Symbols: []debugFileSymbol{}}
+		var locs []Location
+		seen := make(map[ID]bool)
+		for _, sym := range at {
+			id := sym.ID()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			infos := h.table.Symbols(id)
+			ref := debugFileSymbol{ID: id.String(), Kind: sym.SymbolKind().String()}
+			for _, info := range infos {
+				if ref.Name == "" && info.Name() != "" {
+					ref.Name, ref.Kind = info.Name(), info.SymbolKind().String()
+				}
+				for _, caller := range info.Callers() {
+					locs = append(locs, caller.Location())
+				}
+			}
+			resp.Symbols = append(resp.Symbols, ref)
+		}
+		resp.References = toDebugLocations(dedupLocations(locs))
+		h.writeJSON(w, resp)
+	})
+}
+
+// writeJSON writes the JSON encoding of resp, which is truncated to fit in the size cap.
+// The response which does not fit even without the lists is answered by 500.
+func (h *DebugHandler) writeJSON(w http.ResponseWriter, resp debugResponse) {
+	for {
+		buf, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if h.maxBytes > 0 && len(buf) > h.maxBytes {
+			if resp.truncate() {
+				continue
+			}
+			http.Error(w, fmt.Sprintf("response exceeds %d bytes", h.maxBytes), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(buf, '\n'))
+		return
+	}
+}
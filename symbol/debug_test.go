@@ -0,0 +1,163 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// debugTable returns the Table of util.c which defines add, and main.c which calls add calls times.
+func debugTable(t *testing.T, calls int) *Table {
+	header := Location{fileName: "/repo/util.h", line: 1, col: 5, usr: "c:@F@add"}
+	def := Location{fileName: "/repo/util.c", line: 3, col: 5, usr: "c:@F@add"}
+
+	util := NewFile("/repo/util.c", []string{"-std=c11"})
+	util.AddDefinition(def, def)
+	util.AddDecl(header)
+	sym := util.Symbol(ToID("c:@F@add"))
+	sym.name, sym.kind = "add", SymbolKindFunction
+
+	mainc := NewFile("/repo/main.c", nil)
+	mainc.addHeader("/repo/util.h", time.Unix(1500000000, 0))
+	mainc.AddDecl(header)
+	for i := 0; i < calls; i++ {
+		if err := mainc.AddCaller(Location{fileName: "/repo/main.c", line: uint32(5 + i), col: 10, usr: "c:@F@add"}, header, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewTable([]*File{util, mainc})
+}
+
+// serveDebug serves the request of target by handler, and decodes the JSON response into v if the status is 200.
+func serveDebug(t *testing.T, handler http.Handler, target string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	if w.Code == http.StatusOK {
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type = %q, want application/json", target, ct)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v: %s", target, err, w.Body)
+		}
+	}
+	return w
+}
+
+func TestDebugHandler_Status(t *testing.T) {
+	h := NewDebugHandler(debugTable(t, 1))
+	tests := []struct {
+		handler http.Handler
+		target  string
+		want    int
+	}{
+		{h.Symbol(), "/symbol?usr=c:@F@add", http.StatusOK},
+		{h.Symbol(), "/symbol", http.StatusBadRequest},
+		{h.Symbol(), "/symbol?usr=c:@F@sub", http.StatusNotFound},
+		{h.File(), "/file?name=/repo/main.c", http.StatusOK},
+		{h.File(), "/file", http.StatusBadRequest},
+		{h.File(), "/file?name=/repo/util.h", http.StatusNotFound},
+		{h.Refs(), "/refs?file=/repo/main.c&line=5&col=10", http.StatusOK},
+		{h.Refs(), "/refs?line=5&col=10", http.StatusBadRequest},
+		{h.Refs(), "/refs?file=/repo/main.c&line=five&col=10", http.StatusBadRequest},
+		{h.Refs(), "/refs?file=/repo/main.c&line=5", http.StatusBadRequest},
+		{h.Refs(), "/refs?file=/repo/main.c&line=1&col=1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		if w := serveDebug(t, tt.handler, tt.target, &v); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.target, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestDebugHandler_Symbol(t *testing.T) {
+	h := NewDebugHandler(debugTable(t, 1))
+	var got debugSymbol
+	serveDebug(t, h.Symbol(), "/symbol?usr=c:@F@add", &got)
+	want := debugSymbol{
+		ID:          ToID("c:@F@add").String(),
+		USR:         "c:@F@add",
+		Name:        "add",
+		Kind:        "function",
+		Definitions: []debugLocation{{File: "/repo/util.c", Line: 3, Col: 5}},
+		// the definition is also recorded as the declaration by AddDefinition
+		Declarations: []debugLocation{{File: "/repo/util.c", Line: 3, Col: 5}, {File: "/repo/util.h", Line: 1, Col: 5}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/symbol = %+v, want %+v", got, want)
+	}
+}
+
+func TestDebugHandler_File(t *testing.T) {
+	h := NewDebugHandler(debugTable(t, 1))
+	var got debugFile
+	serveDebug(t, h.File(), "/file?name=/repo/util.c", &got)
+	want := debugFile{
+		Name:     "/repo/util.c",
+		Language: "c",
+		Flags:    []string{"-std=c11"},
+		Headers:  []string{},
+		Symbols:  []debugFileSymbol{{ID: ToID("c:@F@add").String(), Name: "add", Kind: "function"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/file = %+v, want %+v", got, want)
+	}
+
+	serveDebug(t, h.File(), "/file?name=/repo/main.c", &got)
+	if !reflect.DeepEqual(got.Headers, []string{"/repo/util.h"}) {
+		t.Errorf("/file headers = %q, want [/repo/util.h]", got.Headers)
+	}
+}
+
+func TestDebugHandler_Refs(t *testing.T) {
+	h := NewDebugHandler(debugTable(t, 2))
+	var got debugRefs
+	// the position of the declaration in the header
+	serveDebug(t, h.Refs(), "/refs?file=/repo/util.h&line=1&col=5", &got)
+	want := debugRefs{
+		Symbols: []debugFileSymbol{{ID: ToID("c:@F@add").String(), Name: "add", Kind: "function"}},
+		References: []debugLocation{
+			{File: "/repo/main.c", Line: 5, Col: 10},
+			{File: "/repo/main.c", Line: 6, Col: 10},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/refs = %+v, want %+v", got, want)
+	}
+}
+
+func TestDebugHandler_SetMaxResponseBytes(t *testing.T) {
+	h := NewDebugHandler(debugTable(t, 100))
+	const target = "/refs?file=/repo/main.c&line=5&col=10"
+
+	var full debugRefs
+	serveDebug(t, h.Refs(), target, &full)
+	if full.Truncated || len(full.References) != 100 {
+		t.Fatalf("/refs = %d references, truncated %v, want 100 references", len(full.References), full.Truncated)
+	}
+
+	h.SetMaxResponseBytes(1024)
+	var got debugRefs
+	w := serveDebug(t, h.Refs(), target, &got)
+	if w.Body.Len() > 1024 {
+		t.Errorf("/refs = %d bytes, want at most 1024 bytes", w.Body.Len())
+	}
+	if !got.Truncated || len(got.References) == 0 || !reflect.DeepEqual(got.References, full.References[:len(got.References)]) {
+		t.Errorf("/refs = %d references, truncated %v, want the truncated prefix", len(got.References), got.Truncated)
+	}
+
+	// the response does not fit even without the references
+	h.SetMaxResponseBytes(16)
+	if w := serveDebug(t, h.Refs(), target, &got); w.Code != http.StatusInternalServerError {
+		t.Errorf("/refs status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
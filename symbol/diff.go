@@ -0,0 +1,366 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFilesDiffer is the cause of the error which FilesEqual returns for the first difference of the Files.
+var ErrFilesDiffer = errors.New("files differ")
+
+// DiffKind represents a kind of the symbol difference.
+type DiffKind int
+
+const (
+	// DiffAdded the symbol exists only in the new File.
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved the symbol exists only in the old File.
+	DiffRemoved
+	// DiffChanged the symbol exists in both Files, but has the different declarations, definition, callers or type information.
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffFiles compares the old and new Files, and calls fn for each symbol difference with the name of the File.
+//
+// The Files are matched by File.Name. All symbols of the File which present only in old are reported as removed,
+// and the ones only in new are reported as added.
+// The differences are streamed per File instead of collected, so the memory usage does not grow with the index size.
+// The Files are visited in the order of old followed by the ones only in new, and the order of the symbols within
+// a File is unspecified.
+func DiffFiles(old, new []*File, fn func(kind DiffKind, name string, id ID)) {
+	newFiles := make(map[string]*File, len(new))
+	for _, f := range new {
+		if _, ok := newFiles[f.Name()]; !ok {
+			newFiles[f.Name()] = f
+		}
+	}
+
+	seen := make(map[string]bool, len(old))
+	for _, of := range old {
+		name := of.Name()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		nf, ok := newFiles[name]
+		if !ok {
+			for _, sym := range of.Symbols() {
+				fn(DiffRemoved, name, sym.ID())
+			}
+			continue
+		}
+		diffFile(of, nf, func(kind DiffKind, id ID) { fn(kind, name, id) })
+	}
+
+	for _, nf := range new {
+		name := nf.Name()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		for _, sym := range nf.Symbols() {
+			fn(DiffAdded, name, sym.ID())
+		}
+	}
+}
+
+// diffFile compares the symbols of old and new File which have the same name, and calls fn for each difference.
+func diffFile(old, new *File, fn func(kind DiffKind, id ID)) {
+	newSyms := new.Symbols()
+	byID := make(map[ID]*Info, len(newSyms))
+	for _, sym := range newSyms {
+		byID[sym.ID()] = sym
+	}
+
+	for _, sym := range old.Symbols() {
+		id := sym.ID()
+		nsym, ok := byID[id]
+		if !ok {
+			fn(DiffRemoved, id)
+			continue
+		}
+		delete(byID, id)
+		if !sym.equal(nsym) {
+			fn(DiffChanged, id)
+		}
+	}
+
+	for _, sym := range newSyms {
+		if _, ok := byID[sym.ID()]; ok {
+			fn(DiffAdded, sym.ID())
+		}
+	}
+}
+
+// FilesEqual reports whether the serialized Files a and b have the same semantic content, even if the maps were
+// serialized in the different order.
+//
+// The symbols are matched by ID, and the declarations, callers and headers are compared regardless of their order.
+// The other fields are compared as same as File.Equal. If the Files differ, the error of the ErrFilesDiffer cause
+// describes the first difference in the order of the file fields, the symbols sorted by ID, the headers and the
+// #include directives in order.
+// The other error is returned if either buffer is corrupted.
+func FilesEqual(a, b []byte) (bool, error) {
+	for _, buf := range []struct {
+		name string
+		buf  []byte
+	}{{"a", a}, {"b", b}} {
+		if _, err := InspectFile(buf.buf); err != nil {
+			return false, errors.Wrapf(err, "file %s", buf.name)
+		}
+	}
+
+	if diff := diffFileContents(GetRootAsFile(a, 0), GetRootAsFile(b, 0)); diff != "" {
+		return false, errors.Wrap(ErrFilesDiffer, diff)
+	}
+
+	return true, nil
+}
+
+// diffFileContents returns the description of the first difference of f and o, or the empty string if they are equal.
+func diffFileContents(f, o *File) string {
+	switch {
+	case f.Name() != o.Name():
+		return fmt.Sprintf("name: %q != %q", f.Name(), o.Name())
+	case !stringsEqual(f.Flags(), o.Flags()):
+		return fmt.Sprintf("flags: %q != %q", f.Flags(), o.Flags())
+	case f.Language() != o.Language():
+		return fmt.Sprintf("language: %s != %s", f.Language(), o.Language())
+	case !bytes.Equal(f.TranslationUnit(), o.TranslationUnit()):
+		return fmt.Sprintf("translation unit: %d bytes != %d bytes", len(f.TranslationUnit()), len(o.TranslationUnit()))
+	case f.TranslationUnitOmitted() != o.TranslationUnitOmitted():
+		return fmt.Sprintf("translation unit omitted: %v != %v", f.TranslationUnitOmitted(), o.TranslationUnitOmitted())
+	case f.IsUnsaved() != o.IsUnsaved():
+		return fmt.Sprintf("unsaved: %v != %v", f.IsUnsaved(), o.IsUnsaved())
+	}
+
+	meta, ometa := f.metadata(), o.metadata()
+	keys := make([]string, 0, len(meta)+len(ometa))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	for key := range ometa {
+		if _, ok := meta[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := meta[key]
+		ovalue, ook := ometa[key]
+		if ok != ook || value != ovalue {
+			return fmt.Sprintf("metadata %q: %q != %q", key, value, ovalue)
+		}
+	}
+
+	syms, osyms := symbolsByID(f.Symbols()), symbolsByID(o.Symbols())
+	ids := make([]ID, 0, len(syms)+len(osyms))
+	for id := range syms {
+		ids = append(ids, id)
+	}
+	for id := range osyms {
+		if _, ok := syms[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+	for _, id := range ids {
+		sym, osym := syms[id], osyms[id]
+		switch {
+		case osym == nil:
+			return fmt.Sprintf("symbol %s %q: only in a", id, sym.Name())
+		case sym == nil:
+			return fmt.Sprintf("symbol %s %q: only in b", id, osym.Name())
+		}
+		if diff := diffInfo(sym, osym); diff != "" {
+			return fmt.Sprintf("symbol %s %q: %s", id, sym.Name(), diff)
+		}
+	}
+
+	hdrs, ohdrs := sortedHeaders(f.Headers()), sortedHeaders(o.Headers())
+	for i := 0; i < len(hdrs) || i < len(ohdrs); i++ {
+		switch {
+		case i >= len(ohdrs):
+			return fmt.Sprintf("header %q: only in a", hdrs[i].Path())
+		case i >= len(hdrs):
+			return fmt.Sprintf("header %q: only in b", ohdrs[i].Path())
+		}
+		h, oh := hdrs[i], ohdrs[i]
+		if h.FileID() != oh.FileID() || h.Path() != oh.Path() {
+			return fmt.Sprintf("header: %q != %q", h.Path(), oh.Path())
+		}
+		if h.Mtime() != oh.Mtime() || h.Exists() != oh.Exists() {
+			return fmt.Sprintf("header %q: mtime %d, exists %v != mtime %d, exists %v", h.Path(), h.Mtime(), h.Exists(), oh.Mtime(), oh.Exists())
+		}
+	}
+
+	incs, oincs := f.Includes(), o.Includes()
+	for i := 0; i < len(incs) || i < len(oincs); i++ {
+		switch {
+		case i >= len(oincs):
+			return fmt.Sprintf("include %q: only in a", incs[i].Spelling())
+		case i >= len(incs):
+			return fmt.Sprintf("include %q: only in b", oincs[i].Spelling())
+		case !incs[i].equal(oincs[i]):
+			return fmt.Sprintf("include %q at %s != %q at %s", incs[i].Spelling(), locationString(incs[i].Location().value()), oincs[i].Spelling(), locationString(oincs[i].Location().value()))
+		}
+	}
+
+	diags, odiags := f.Diagnostics(), o.Diagnostics()
+	for i := 0; i < len(diags) || i < len(odiags); i++ {
+		switch {
+		case i >= len(odiags):
+			return fmt.Sprintf("diagnostic %q: only in a", diags[i].Message())
+		case i >= len(diags):
+			return fmt.Sprintf("diagnostic %q: only in b", odiags[i].Message())
+		case !diags[i].equal(odiags[i]):
+			return fmt.Sprintf("diagnostic %q at %s != %q at %s", diags[i].Message(), locationString(diags[i].Location().value()), odiags[i].Message(), locationString(odiags[i].Location().value()))
+		}
+	}
+
+	return ""
+}
+
+// diffInfo returns the description of the first difference of info and o which have the same ID,
+// or the empty string if they are equal. The declarations and callers are compared regardless of their order.
+func diffInfo(info, o *Info) string {
+	if !info.equalType(o) {
+		return "type information differs"
+	}
+
+	decls, odecls := sortedLocationValues(info.Decls()), sortedLocationValues(o.Decls())
+	for i := 0; i < len(decls) || i < len(odecls); i++ {
+		switch {
+		case i >= len(odecls):
+			return fmt.Sprintf("decl %s: only in a", locationString(decls[i]))
+		case i >= len(decls):
+			return fmt.Sprintf("decl %s: only in b", locationString(odecls[i]))
+		case decls[i] != odecls[i]:
+			return fmt.Sprintf("decl %s != %s", locationString(decls[i]), locationString(odecls[i]))
+		}
+	}
+
+	if def, odef := info.Def().value(), o.Def().value(); def != odef {
+		return fmt.Sprintf("def %s != %s", locationString(def), locationString(odef))
+	}
+	if mtime, omtime := info.DefModTime().Unix(), o.DefModTime().Unix(); mtime != omtime {
+		return fmt.Sprintf("def mtime %d != %d", mtime, omtime)
+	}
+	if r, or := info.CommentRange().value(), o.CommentRange().value(); r != or {
+		return fmt.Sprintf("comment range %s-%s != %s-%s", locationString(r.start), locationString(r.end), locationString(or.start), locationString(or.end))
+	}
+
+	callers, ocallers := sortedCallerValues(info.Callers()), sortedCallerValues(o.Callers())
+	for i := 0; i < len(callers) || i < len(ocallers); i++ {
+		switch {
+		case i >= len(ocallers):
+			return fmt.Sprintf("caller %s: only in a", locationString(callers[i].location))
+		case i >= len(callers):
+			return fmt.Sprintf("caller %s: only in b", locationString(ocallers[i].location))
+		case callers[i] != ocallers[i]:
+			return fmt.Sprintf("caller %s (func call %v, args %s) != %s (func call %v, args %s)", locationString(callers[i].location), callers[i].funcCall, callers[i].args, locationString(ocallers[i].location), ocallers[i].funcCall, ocallers[i].args)
+		}
+	}
+
+	return ""
+}
+
+// callerValue is the comparable value of the Caller.
+type callerValue struct {
+	location Location
+	funcCall bool
+	args     string // locations of the arguments joined by ","
+}
+
+func symbolsByID(syms []*Info) map[ID]*Info {
+	byID := make(map[ID]*Info, len(syms))
+	for _, sym := range syms {
+		byID[sym.ID()] = sym
+	}
+	return byID
+}
+
+// sortedLocationValues returns the values of locs ordered by Location.Less, and then by the USR and
+// whether the location is the forward declaration.
+func sortedLocationValues(locs []Location) []Location {
+	values := make([]Location, len(locs))
+	for i, loc := range locs {
+		values[i] = loc.value()
+	}
+	sort.Slice(values, func(i, j int) bool { return locationValueLess(values[i], values[j]) })
+	return values
+}
+
+func sortedCallerValues(callers []*Caller) []callerValue {
+	values := make([]callerValue, len(callers))
+	for i, caller := range callers {
+		args := make([]string, 0, len(caller.Args()))
+		for _, arg := range caller.Args() {
+			args = append(args, locationString(arg.value()))
+		}
+		values[i] = callerValue{location: caller.Location().value(), funcCall: caller.FuncCall(), args: "[" + strings.Join(args, ",") + "]"}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		if a.location != b.location {
+			return locationValueLess(a.location, b.location)
+		}
+		if a.funcCall != b.funcCall {
+			return !a.funcCall && b.funcCall
+		}
+		return a.args < b.args
+	})
+	return values
+}
+
+func locationValueLess(a, b Location) bool {
+	switch {
+	case a.Less(b):
+		return true
+	case b.Less(a):
+		return false
+	case a.usr != b.usr:
+		return a.usr < b.usr
+	}
+	return !a.isForward && b.isForward
+}
+
+func sortedHeaders(hdrs []*Header) []*Header {
+	sorted := append([]*Header(nil), hdrs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if a, b := sorted[i].Path(), sorted[j].Path(); a != b {
+			return a < b
+		}
+		a, b := sorted[i].FileID(), sorted[j].FileID()
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	return sorted
+}
+
+// locationString returns the "file:line:col" of loc for the messages.
+func locationString(loc Location) string {
+	return fmt.Sprintf("%s:%d:%d", loc.FileName(), loc.Line(), loc.Col())
+}
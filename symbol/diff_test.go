@@ -0,0 +1,121 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestDiffFiles(t *testing.T) {
+	type diff struct {
+		kind DiffKind
+		name string
+		id   ID
+	}
+
+	decl := func(file, usr string, line uint32) Location {
+		return Location{fileName: file, line: line, col: 5, offset: line * 10, usr: usr}
+	}
+	newFile := func(name string, decls ...Location) *File {
+		f := NewFile(name, nil)
+		for _, loc := range decls {
+			f.AddDecl(loc)
+		}
+		return roundTrip(f)
+	}
+
+	old := []*File{
+		newFile("main.c", decl("main.c", "c:@F@main", 1), decl("main.c", "c:@F@helper", 10)),
+		newFile("removed.c", decl("removed.c", "c:@F@gone", 3)),
+	}
+	new := []*File{
+		newFile("added.c", decl("added.c", "c:@F@fresh", 4)),
+		// helper moved to the line 12
+		newFile("main.c", decl("main.c", "c:@F@main", 1), decl("main.c", "c:@F@helper", 12)),
+	}
+
+	var got []diff
+	DiffFiles(old, new, func(kind DiffKind, name string, id ID) {
+		got = append(got, diff{kind: kind, name: name, id: id})
+	})
+
+	want := []diff{
+		{kind: DiffChanged, name: "main.c", id: ToID("c:@F@helper")},
+		{kind: DiffRemoved, name: "removed.c", id: ToID("c:@F@gone")},
+		{kind: DiffAdded, name: "added.c", id: ToID("c:@F@fresh")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffFiles() = %+v, want %+v", got, want)
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		DiffFiles(old, old, func(kind DiffKind, name string, id ID) {
+			t.Errorf("DiffFiles() reports %v %s %s for the identical files", kind, name, id)
+		})
+	})
+}
+
+func TestFilesEqual(t *testing.T) {
+	loc := func(file string, line uint32, usr string) Location {
+		return Location{fileName: file, line: line, col: 5, usr: usr}
+	}
+	// build adds the same data into the File in order, or in the reversed order
+	build := func(reverse bool, def Location) []byte {
+		var steps []func(f *File)
+		for _, usr := range []string{"c:@F@add", "c:@F@sub", "c:@F@mul"} {
+			usr := usr
+			steps = append(steps, func(f *File) {
+				f.AddDecl(loc("util.h", uint32(len(usr)), usr))
+				f.AddDecl(loc("main.c", uint32(len(usr)+1), usr))
+			})
+		}
+		steps = append(steps,
+			func(f *File) { f.AddDefinition(def, def) },
+			func(f *File) { f.AddCaller(loc("main.c", 20, "c:@F@add"), def, true) },
+			func(f *File) { f.AddCaller(loc("main.c", 30, "c:@F@add"), def, false) },
+			func(f *File) { f.addHeader("util.h", time.Unix(1500000000, 0)) },
+			func(f *File) { f.addHeader("stdio.h", time.Unix(1400000000, 0)) },
+		)
+
+		f := NewFile("main.c", []string{"-std=c11"})
+		for i := range steps {
+			if reverse {
+				steps[len(steps)-1-i](f)
+			} else {
+				steps[i](f)
+			}
+		}
+		return mustSerializeBytes(f)
+	}
+
+	def := loc("main.c", 10, "c:@F@add")
+	a, b := build(false, def), build(true, def)
+	if bytes.Equal(a, b) {
+		t.Fatal("the reordered Files are serialized to the same bytes")
+	}
+	if ok, err := FilesEqual(a, b); !ok || err != nil {
+		t.Errorf("FilesEqual(reordered) = %v, %v, want true", ok, err)
+	}
+
+	changed := build(true, loc("main.c", 11, "c:@F@add"))
+	ok, err := FilesEqual(a, changed)
+	if ok || errors.Cause(err) != ErrFilesDiffer {
+		t.Fatalf("FilesEqual(changed def) = %v, %v, want the ErrFilesDiffer cause", ok, err)
+	}
+	// AddDefinition also records the definition as the declaration
+	if !strings.Contains(err.Error(), "decl main.c:10:5 != main.c:11:5") {
+		t.Errorf("FilesEqual(changed def) error = %q, want the definition difference", err)
+	}
+
+	if ok, err := FilesEqual(a, []byte{1, 2}); ok || err == nil || errors.Cause(err) == ErrFilesDiffer {
+		t.Errorf("FilesEqual(corrupted) = %v, %v, want the error of the corrupted buffer", ok, err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"sort"
+	"unicode"
+)
+
+// Score weights of the fuzzy matching.
+const (
+	scoreMatch       = 16 // each matched character
+	scoreCaseMatch   = 1  // matched character which has the same case as pattern
+	scoreConsecutive = 6  // matched character which is directly after the previous match
+	bonusBoundary    = 10 // matched character at the beginning, or after the separator such as '_'
+	bonusCamel       = 9  // matched character at the camelCase hump, or the first digit after a letter
+	penaltyGapStart  = 3  // the first unmatched character between matches
+	penaltyGapExtend = 1  // each subsequent unmatched character between matches
+)
+
+// Score scores the fuzzy matching of pattern against candidate.
+//
+// The pattern matches if its characters appear in candidate in order, compared case-insensitively.
+// Matches at word boundaries, camelCase humps and after underscores score higher, and
+// consecutive matches score higher than scattered ones. It reports false if pattern does not match.
+// The empty pattern matches any candidate with zero score.
+func Score(pattern, candidate string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	p := []rune(pattern)
+	c := []rune(candidate)
+	if len(p) > len(c) {
+		return 0, false
+	}
+
+	const none = -1 << 30
+
+	// prev[j] and cur[j] are the best scores where pattern[i-1] and pattern[i] matched at candidate[j]
+	prev := make([]int, len(c))
+	cur := make([]int, len(c))
+	for i := range p {
+		gap := none // best score of pattern[i-1] match followed by the unmatched gap before j
+		for j := range c {
+			cur[j] = none
+			if j >= 2 && i > 0 {
+				gap = maxInt(gap-penaltyGapExtend, prev[j-2]-penaltyGapStart)
+			}
+			if !equalFoldRune(p[i], c[j]) {
+				continue
+			}
+
+			s := scoreMatch + bonusAt(c, j)
+			if p[i] == c[j] {
+				s += scoreCaseMatch
+			}
+
+			switch {
+			case i == 0:
+				cur[j] = s
+			case j == 0:
+				// pattern[i-1] must have matched before j
+			default:
+				best := gap
+				if prev[j-1] > none {
+					best = maxInt(best, prev[j-1]+scoreConsecutive)
+				}
+				if best > none {
+					cur[j] = best + s
+				}
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	best := none
+	for _, s := range prev {
+		best = maxInt(best, s)
+	}
+	if best <= none/2 {
+		return 0, false
+	}
+
+	return best, true
+}
+
+// bonusAt returns the position bonus of candidate[j].
+func bonusAt(c []rune, j int) int {
+	if j == 0 {
+		return bonusBoundary
+	}
+
+	r, before := c[j], c[j-1]
+	switch {
+	case !unicode.IsLetter(before) && !unicode.IsDigit(before):
+		return bonusBoundary
+	case unicode.IsUpper(r) && unicode.IsLower(before):
+		return bonusCamel
+	case unicode.IsDigit(r) && unicode.IsLetter(before):
+		return bonusCamel
+	default:
+		return 0
+	}
+}
+
+// equalFoldRune reports whether a and b are equal under simple Unicode case-folding.
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// FuzzyFilter returns the items whose word fuzzy matches the pattern.
+//
+// The items are sorted by descending score, and ties are broken by the clang completion priority and word.
+// If limit is greater than zero, the results are truncated to at most limit items.
+func (c *CodeCompleteResults) FuzzyFilter(pattern string, limit int) []CompleteItem {
+	type scored struct {
+		item  CompleteItem
+		score int
+	}
+
+	results := c.Results()
+	matches := make([]scored, 0, len(results))
+	for _, item := range results {
+		if score, ok := Score(pattern, item.Word()); ok {
+			matches = append(matches, scored{item: item, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.item.Priority() != b.item.Priority() {
+			return a.item.Priority() < b.item.Priority()
+		}
+		return bytes.Compare(a.item.WordBytes(), b.item.WordBytes()) < 0
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	items := make([]CompleteItem, len(matches))
+	for i, m := range matches {
+		items[i] = m.item
+	}
+
+	return items
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		candidate string
+		wantOk    bool
+	}{
+		{pattern: "", candidate: "vector", wantOk: true},
+		{pattern: "vec", candidate: "vector", wantOk: true},
+		{pattern: "VEC", candidate: "vector", wantOk: true},
+		{pattern: "usmp", candidate: "unique_shared_map_ptr", wantOk: true},
+		{pattern: "ptr", candidate: "nullptr_t", wantOk: true},
+		{pattern: "vce", candidate: "vector", wantOk: false},
+		{pattern: "vectors", candidate: "vector", wantOk: false},
+		{pattern: "x", candidate: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.candidate, func(t *testing.T) {
+			if _, ok := Score(tt.pattern, tt.candidate); ok != tt.wantOk {
+				t.Errorf("Score(%q, %q) = %v, want %v", tt.pattern, tt.candidate, ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+// TestScore_Ranking is the regression tests of the ranking order.
+// The candidates in want must be ranked in the order from the best.
+func TestScore_Ranking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{
+			pattern: "usmp",
+			want:    []string{"unique_shared_map_ptr", "user_smtp", "upsample_map"},
+		},
+		{
+			pattern: "sb",
+			want:    []string{"StringBuilder", "subscribe", "is_absolute"},
+		},
+		{
+			pattern: "vec",
+			want:    []string{"vector", "VkExtensionCount", "reverse_iterator_category"},
+		},
+		{
+			pattern: "push",
+			want:    []string{"push_back", "push_heap", "emplace_push_front", "PartialUnsortedHash"},
+		},
+		{
+			pattern: "mkshr",
+			want:    []string{"mkShared", "make_shared", "remake_shader"},
+		},
+		{
+			pattern: "ui8",
+			want:    []string{"uint8_t", "UInt8Array", "unique_id_map_8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			scores := make(map[string]int, len(tt.want))
+			for _, candidate := range tt.want {
+				score, ok := Score(tt.pattern, candidate)
+				if !ok {
+					t.Fatalf("Score(%q, %q) does not match", tt.pattern, candidate)
+				}
+				scores[candidate] = score
+			}
+
+			got := append([]string(nil), tt.want...)
+			sort.SliceStable(got, func(i, j int) bool { return scores[got[i]] > scores[got[j]] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ranking = %v, want %v (scores %v)", got, tt.want, scores)
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_FuzzyFilter(t *testing.T) {
+	c := marshalResults(CompleteOptions{}, []completionResult{
+		fakeFunction("push_back", "void", 50),
+		fakeFunction("pop_back", "void", 50),
+		fakeFunction("push_heap", "void", 34),
+		fakeFunction("emplace_back", "void", 50),
+		fakeFunction("PartialUnsortedHash", "void", 34),
+		fakeFunction("size", "size_type", 34),
+	})
+
+	tests := []struct {
+		name    string
+		pattern string
+		limit   int
+		want    []string
+	}{
+		{
+			name:    "empty pattern keeps priority order",
+			pattern: "",
+			want:    []string{"PartialUnsortedHash", "push_heap", "size", "emplace_back", "pop_back", "push_back"},
+		},
+		{
+			name:    "score then priority",
+			pattern: "push",
+			want:    []string{"push_heap", "push_back", "PartialUnsortedHash"},
+		},
+		{
+			name:    "word boundary",
+			pattern: "pb",
+			want:    []string{"pop_back", "push_back", "emplace_back"},
+		},
+		{
+			name:    "limit",
+			pattern: "push",
+			limit:   2,
+			want:    []string{"push_heap", "push_back"},
+		},
+		{
+			name:    "no match",
+			pattern: "xyz",
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completeWords(c.FuzzyFilter(tt.pattern, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FuzzyFilter(%q, %d) = %v, want %v", tt.pattern, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
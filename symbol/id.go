@@ -5,13 +5,32 @@
 package symbol
 
 import (
+	"hash"
+	"sync/atomic"
+
 	blake2b "github.com/minio/blake2b-simd"
+	"github.com/pkg/errors"
 	"github.com/zchee/clang-server/internal/hashutil"
 )
 
 // ID id of cursor.USR with blake2b hash.
+// It is the fixed-size array, so it can be the map key and compared without the allocation.
 type ID [blake2b.Size]byte
 
+// ParseID parses the hexadecimal string form of ID which returned by String.
+func ParseID(s string) (ID, error) {
+	var id ID
+	if len(s) != 2*len(id) {
+		return ID{}, errors.Errorf("invalid ID length: %d", len(s))
+	}
+	if _, err := hashutil.Decode(id[:], []byte(s)); err != nil {
+		return ID{}, errors.Wrapf(err, "invalid ID %q", s)
+	}
+
+	return id, nil
+}
+
+// String returns the hexadecimal string form of id, which is also the serialized representation.
 func (id ID) String() string {
 	return hashutil.EncodeToString(id[:])
 }
@@ -21,14 +40,21 @@ func (id ID) Bytes() []byte {
 	return id[:]
 }
 
-// IsEmpty reports whether id is empty.
+// IsEmpty reports whether id is the zero ID.
 func (id ID) IsEmpty() bool {
-	return len(id) == 0
+	return id == ID{}
 }
 
 // FileID id of filename with blake2b hash.
 type FileID [blake2b.Size]byte
 
+// ParseFileID parses the hexadecimal string form of FileID which returned by String.
+func ParseFileID(s string) (FileID, error) {
+	id, err := ParseID(s)
+	return FileID(id), err
+}
+
+// String returns the hexadecimal string form of id, which is also the serialized representation.
 func (id FileID) String() string {
 	return hashutil.EncodeToString(id[:])
 }
@@ -38,17 +64,81 @@ func (id FileID) Bytes() []byte {
 	return id[:]
 }
 
-// IsEmpty reports whether id is empty.
+// IsEmpty reports whether id is the zero FileID.
 func (id FileID) IsEmpty() bool {
-	return len(id) == 0
+	return id == FileID{}
+}
+
+// idHasher holds the idHasherFunc of the hash function of the IDs, which is accessed atomically by loadIDHasher.
+var idHasher atomic.Value
+
+// idHasherFunc the hash function of the IDs, nil means the blake2b sum512.
+// It is wrapped in the struct, because atomic.Value cannot store nil.
+type idHasherFunc struct {
+	h func() hash.Hash
+}
+
+// SetIDHasher sets the hash function which ToID and ToFileID use instead of the blake2b, such as the faster
+// non-cryptographic hash. The sum is truncated or zero padded to the size of ID. The nil h restores the blake2b.
+//
+// The hasher is process-wide and meant to be set once at the initialization, before any ToID or ToFileID.
+// Changing it later is race-free, but invalidates the existing indexes, because the serialized IDs no longer
+// match the IDs of the USRs and file names.
+func SetIDHasher(h func() hash.Hash) {
+	idHasher.Store(idHasherFunc{h: h})
 }
 
-// ToID converts the string to blake2b sum512 hash.
+// loadIDHasher returns the hash function set by SetIDHasher, or nil for the blake2b.
+func loadIDHasher() func() hash.Hash {
+	fn, _ := idHasher.Load().(idHasherFunc)
+	return fn.h
+}
+
+// hashID returns the hash of b with the hasher of SetIDHasher.
+func hashID(b []byte) [hashutil.Size]byte {
+	hasher := loadIDHasher()
+	if hasher == nil {
+		return hashutil.NewHash(b)
+	}
+	h := hasher()
+	h.Write(b)
+	var sum [hashutil.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// ToID converts the string to the hash of the hasher of SetIDHasher, which is the blake2b sum512 by default.
 func ToID(s string) ID {
-	return hashutil.NewHashString(s)
+	if loadIDHasher() == nil {
+		return hashutil.NewHashString(s)
+	}
+	return hashID([]byte(s))
 }
 
-// ToFileID converts the string to blake2b sum512 hash.
+// ToFileID converts the string to the hash of the hasher of SetIDHasher, which is the blake2b sum512 by default.
 func ToFileID(s string) FileID {
-	return hashutil.NewHashString(s)
+	return FileID(ToID(s))
+}
+
+// ToIDBytes is like ToID, but hashes b directly, such as the USRBytes of the flatbuffers backed Location.
+func ToIDBytes(b []byte) ID {
+	return hashID(b)
+}
+
+// ToFileIDBytes is like ToFileID, but hashes b directly.
+func ToFileIDBytes(b []byte) FileID {
+	return hashID(b)
+}
+
+// decodeHash decodes the hexadecimal encoded hash which stored in flatbuffers.
+func decodeHash(b []byte) [hashutil.Size]byte {
+	var h [hashutil.Size]byte
+	if len(b) != 2*len(h) {
+		return h
+	}
+	if _, err := hashutil.Decode(h[:], b); err != nil {
+		return [hashutil.Size]byte{}
+	}
+
+	return h
 }
@@ -0,0 +1,151 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	id := ToID("c:@F@foo")
+	got, err := ParseID(id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("ParseID(%s) = %s, want %s", id, got, id)
+	}
+	if fid, err := ParseFileID(ToFileID("main.c").String()); err != nil || fid != ToFileID("main.c") {
+		t.Errorf("ParseFileID() = %s, %v, want %s", fid, err, ToFileID("main.c"))
+	}
+
+	for _, s := range []string{"", "abcd", id.String()[1:] + "x"} {
+		if _, err := ParseID(s); err == nil {
+			t.Errorf("ParseID(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestID_IsEmpty(t *testing.T) {
+	if !(ID{}).IsEmpty() || !(FileID{}).IsEmpty() {
+		t.Error("IsEmpty() of the zero ID = false, want true")
+	}
+	if ToID("c:@F@foo").IsEmpty() || ToFileID("main.c").IsEmpty() {
+		t.Error("IsEmpty() of the hashed ID = true, want false")
+	}
+}
+
+// setIDHasher sets the hasher of the IDs and returns the function which restores the original.
+func setIDHasher(h func() hash.Hash) func() {
+	orig := loadIDHasher()
+	SetIDHasher(h)
+	return func() { SetIDHasher(orig) }
+}
+
+func TestSetIDHasher(t *testing.T) {
+	const usr = "c:@F@foo"
+	blake := ToID(usr)
+
+	defer setIDHasher(func() hash.Hash { return fnv.New64a() })()
+
+	id := ToID(usr)
+	if id == blake {
+		t.Fatal("ToID() with the FNV hasher = the blake2b ID")
+	}
+	if id.IsEmpty() || id != ToID(usr) {
+		t.Errorf("ToID(%q) is not stable: %s, %s", usr, id, ToID(usr))
+	}
+	if got := ToIDBytes([]byte(usr)); got != id {
+		t.Errorf("ToIDBytes(%q) = %s, want %s", usr, got, id)
+	}
+	if got := ToFileIDBytes([]byte("main.c")); got != ToFileID("main.c") {
+		t.Errorf("ToFileIDBytes(main.c) = %s, want %s", got, ToFileID("main.c"))
+	}
+	// the 8 bytes sum is zero padded
+	h := fnv.New64a()
+	h.Write([]byte(usr))
+	var want ID
+	copy(want[:], h.Sum(nil))
+	if id != want {
+		t.Errorf("ToID(%q) = %s, want %s", usr, id, want)
+	}
+	if got, err := ParseID(id.String()); err != nil || got != id {
+		t.Errorf("ParseID(%s) = %s, %v, want %s", id, got, err, id)
+	}
+
+	f := NewFile("/repo/main.c", nil)
+	f.AddDecl(Location{fileName: "/repo/main.c", line: 1, col: 5, usr: usr})
+	got := roundTrip(f)
+	if sym := got.Symbol(ToID(usr)); sym == nil || sym.ID() != id {
+		t.Errorf("Symbol(%s) of the round-tripped File = %v, want the symbol of %q", id, sym, usr)
+	}
+	if !got.Equal(f) {
+		t.Error("the round-tripped File is not equal to the original")
+	}
+
+	SetIDHasher(nil)
+	if got := ToID(usr); got != blake {
+		t.Errorf("ToID() after SetIDHasher(nil) = %s, want the blake2b ID %s", got, blake)
+	}
+}
+
+func TestSetIDHasher_Concurrent(t *testing.T) {
+	defer setIDHasher(nil)()
+
+	// the IDs are hashed while the hasher is set, which the race detector reports without the atomic access
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if ToID("c:@F@foo").IsEmpty() {
+				t.Error("ToID() = the empty ID")
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			SetIDHasher(func() hash.Hash { return fnv.New64a() })
+		} else {
+			SetIDHasher(nil)
+		}
+	}
+	<-done
+}
+
+func BenchmarkIDMap(b *testing.B) {
+	const n = 10000
+	ids := make([]ID, n)
+	for i := range ids {
+		ids[i] = ToID(fmt.Sprintf("c:@F@func%d", i))
+	}
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := make(map[string]int, n)
+			for j, id := range ids {
+				m[id.String()] = j
+			}
+			for _, id := range ids {
+				_ = m[id.String()]
+			}
+		}
+	})
+	b.Run("array", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := make(map[ID]int, n)
+			for j, id := range ids {
+				m[id] = j
+			}
+			for _, id := range ids {
+				_ = m[id]
+			}
+		}
+	})
+}
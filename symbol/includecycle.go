@@ -0,0 +1,288 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// maxIncludeCycles is the upper bound of the cycles which FindIncludeCycles returns, since the number of the
+// elementary cycles grows exponentially in the densely connected headers.
+const maxIncludeCycles = 100
+
+// FindIncludeCycles returns the elementary cycles of the include graph of files, whose edges are from each File
+// to its Headers which are also in files. The missing headers are not the part of any cycle.
+//
+// Each cycle starts at its smallest FileID and lists each File once, in the include order; the edge from the
+// last File back to the first closes the cycle. The cycles are ordered by the first FileID and then the FileIDs
+// of the includes in order, and at most maxIncludeCycles cycles are returned.
+//
+// The strongly connected components are found by the Tarjan's algorithm, and the cycles in each of them are
+// enumerated by the Johnson's algorithm.
+func FindIncludeCycles(files map[FileID]*File) [][]FileID {
+	g := newIncludeGraph(files)
+
+	var cycles [][]FileID
+	for _, scc := range g.components() {
+		if len(scc) == 1 && !g.hasEdge(scc[0], scc[0]) {
+			continue
+		}
+		cycles = g.circuits(scc, cycles)
+		if len(cycles) >= maxIncludeCycles {
+			return cycles[:maxIncludeCycles]
+		}
+	}
+
+	return cycles
+}
+
+// FormatIncludeCycle returns cycle of FindIncludeCycles as the path of the file names, such as
+// "a.h -> b.h -> a.h". Each file is named by the Header path which the previous File recorded, or by the name
+// of the File if the header is unknown.
+func FormatIncludeCycle(files map[FileID]*File, cycle []FileID) string {
+	if len(cycle) == 0 {
+		return ""
+	}
+
+	name := func(from, to FileID) string {
+		if f := files[from]; f != nil {
+			for _, hdr := range f.Headers() {
+				if hdr.FileID() == to && hdr.Path() != "" {
+					return hdr.Path()
+				}
+			}
+		}
+		if f := files[to]; f != nil {
+			return f.Name()
+		}
+		return to.String()
+	}
+
+	names := make([]string, 0, len(cycle)+1)
+	names = append(names, name(cycle[len(cycle)-1], cycle[0]))
+	for i := 1; i < len(cycle); i++ {
+		names = append(names, name(cycle[i-1], cycle[i]))
+	}
+	names = append(names, names[0])
+
+	return strings.Join(names, " -> ")
+}
+
+// IncludeCycles returns the include cycles of the Files of t, see FindIncludeCycles.
+func (t *Table) IncludeCycles() [][]FileID {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return FindIncludeCycles(t.files)
+}
+
+// FormatIncludeCycle is like the FormatIncludeCycle function of the Files of t.
+func (t *Table) FormatIncludeCycle(cycle []FileID) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return FormatIncludeCycle(t.files, cycle)
+}
+
+// includeGraph is the include graph of FindIncludeCycles, whose nodes are the indexes of the sorted FileIDs.
+type includeGraph struct {
+	ids   []FileID
+	edges [][]int // sorted successors of each node
+}
+
+// newIncludeGraph builds the includeGraph of files.
+func newIncludeGraph(files map[FileID]*File) *includeGraph {
+	g := &includeGraph{ids: make([]FileID, 0, len(files))}
+	for fid := range files {
+		g.ids = append(g.ids, fid)
+	}
+	sort.Slice(g.ids, func(i, j int) bool {
+		return bytes.Compare(g.ids[i][:], g.ids[j][:]) < 0
+	})
+	index := make(map[FileID]int, len(g.ids))
+	for i, fid := range g.ids {
+		index[fid] = i
+	}
+
+	g.edges = make([][]int, len(g.ids))
+	for i, fid := range g.ids {
+		seen := make(map[int]bool)
+		for _, hdr := range files[fid].Headers() {
+			j, ok := index[hdr.FileID()]
+			if !ok || !hdr.Exists() || seen[j] {
+				continue
+			}
+			seen[j] = true
+			g.edges[i] = append(g.edges[i], j)
+		}
+		sort.Ints(g.edges[i])
+	}
+
+	return g
+}
+
+// hasEdge reports whether g has the edge from v to w.
+func (g *includeGraph) hasEdge(v, w int) bool {
+	i := sort.SearchInts(g.edges[v], w)
+	return i < len(g.edges[v]) && g.edges[v][i] == w
+}
+
+// components returns the strongly connected components of g by the Tarjan's algorithm. Each component is
+// sorted, and the components are ordered by their smallest node.
+func (g *includeGraph) components() [][]int {
+	const unvisited = -1
+	index := make([]int, len(g.ids))
+	low := make([]int, len(g.ids))
+	onStack := make([]bool, len(g.ids))
+	for i := range index {
+		index[i] = unvisited
+	}
+
+	var (
+		next  int
+		stack []int
+		sccs  [][]int
+	)
+	// the iterative depth-first search, since the include chain may be deep
+	type frame struct{ v, edge int }
+	for root := range g.ids {
+		if index[root] != unvisited {
+			continue
+		}
+		frames := []frame{{v: root}}
+		index[root], low[root] = next, next
+		next++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(frames) > 0 {
+			fr := &frames[len(frames)-1]
+			v := fr.v
+			if fr.edge < len(g.edges[v]) {
+				w := g.edges[v][fr.edge]
+				fr.edge++
+				switch {
+				case index[w] == unvisited:
+					index[w], low[w] = next, next
+					next++
+					stack = append(stack, w)
+					onStack[w] = true
+					frames = append(frames, frame{v: w})
+				case onStack[w]:
+					low[v] = minInt(low[v], index[w])
+				}
+				continue
+			}
+
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].v
+				low[parent] = minInt(low[parent], low[v])
+			}
+			if low[v] != index[v] {
+				continue
+			}
+			var scc []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			sort.Ints(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+
+	sort.Slice(sccs, func(i, j int) bool {
+		return sccs[i][0] < sccs[j][0]
+	})
+
+	return sccs
+}
+
+// circuits appends the elementary cycles in the strongly connected component scc to cycles by the Johnson's
+// algorithm, and returns the extended cycles. It stops at maxIncludeCycles cycles.
+//
+// The cycles are searched from each start node in order, through the nodes after the start in scc only, so each
+// cycle is found once from its smallest node.
+func (g *includeGraph) circuits(scc []int, cycles [][]FileID) [][]FileID {
+	inSCC := make(map[int]bool, len(scc))
+	for _, v := range scc {
+		inSCC[v] = true
+	}
+	blocked := make(map[int]bool, len(scc))
+	blockedBy := make(map[int]map[int]bool, len(scc))
+
+	var unblock func(v int)
+	unblock = func(v int) {
+		blocked[v] = false
+		for w := range blockedBy[v] {
+			delete(blockedBy[v], w)
+			if blocked[w] {
+				unblock(w)
+			}
+		}
+	}
+
+	var path []int
+	var circuit func(v, start int) bool
+	circuit = func(v, start int) bool {
+		found := false
+		path = append(path, v)
+		blocked[v] = true
+		for _, w := range g.edges[v] {
+			if len(cycles) >= maxIncludeCycles {
+				break
+			}
+			if !inSCC[w] || w < start {
+				continue
+			}
+			if w == start {
+				cycle := make([]FileID, len(path))
+				for i, u := range path {
+					cycle[i] = g.ids[u]
+				}
+				cycles = append(cycles, cycle)
+				found = true
+			} else if !blocked[w] && circuit(w, start) {
+				found = true
+			}
+		}
+		if found {
+			unblock(v)
+		} else {
+			for _, w := range g.edges[v] {
+				if !inSCC[w] || w < start {
+					continue
+				}
+				if blockedBy[w] == nil {
+					blockedBy[w] = make(map[int]bool)
+				}
+				blockedBy[w][v] = true
+			}
+		}
+		path = path[:len(path)-1]
+		return found
+	}
+
+	for _, start := range scc {
+		if len(cycles) >= maxIncludeCycles {
+			break
+		}
+		for _, v := range scc {
+			blocked[v] = false
+			delete(blockedBy, v)
+		}
+		circuit(start, start)
+	}
+
+	return cycles
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// includeCycleTestFiles returns the Files of the include graph, whose keys include the header paths of the values.
+// The header path "-" is the missing header.
+func includeCycleTestFiles(graph map[string][]string) map[FileID]*File {
+	files := make(map[FileID]*File)
+	for name, headers := range graph {
+		f := NewFile(name, nil)
+		for _, hdr := range headers {
+			if hdr == "-" {
+				hdr = ""
+			}
+			f.addHeader(hdr, time.Unix(1, 0))
+		}
+		files[ToFileID(name)] = f
+	}
+
+	return files
+}
+
+// cycleOf returns the FileIDs of names in the order of FindIncludeCycles, which starts at the smallest FileID.
+func cycleOf(names ...string) []FileID {
+	cycle := make([]FileID, len(names))
+	first := 0
+	for i, name := range names {
+		cycle[i] = ToFileID(name)
+		if bytes.Compare(cycle[i][:], cycle[first][:]) < 0 {
+			first = i
+		}
+	}
+
+	return append(cycle[first:], cycle[:first]...)
+}
+
+// sortCycles sorts cycles by the FileIDs, which is the order of FindIncludeCycles.
+func sortCycles(cycles [][]FileID) [][]FileID {
+	sort.Slice(cycles, func(i, j int) bool {
+		a, b := cycles[i], cycles[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if c := bytes.Compare(a[k][:], b[k][:]); c != 0 {
+				return c < 0
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return cycles
+}
+
+func TestFindIncludeCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph map[string][]string
+		want  [][]FileID
+	}{
+		{
+			name: "2-node cycle",
+			graph: map[string][]string{
+				"/src/main.c": {"/src/a.h", "/src/b.h"},
+				"/src/a.h":    {"/src/b.h"},
+				"/src/b.h":    {"/src/a.h"},
+			},
+			want: [][]FileID{cycleOf("/src/a.h", "/src/b.h")},
+		},
+		{
+			name: "4-node cycle with the chord",
+			graph: map[string][]string{
+				"/src/main.c": {"/src/a.h", "-"},
+				"/src/a.h":    {"/src/b.h", "/usr/include/stdio.h"},
+				"/src/b.h":    {"/src/c.h", "/src/d.h"},
+				"/src/c.h":    {"/src/d.h", "-"},
+				"/src/d.h":    {"/src/a.h"},
+			},
+			want: sortCycles([][]FileID{
+				cycleOf("/src/a.h", "/src/b.h", "/src/c.h", "/src/d.h"),
+				cycleOf("/src/a.h", "/src/b.h", "/src/d.h"),
+			}),
+		},
+		{
+			name: "self include",
+			graph: map[string][]string{
+				"/src/main.c": {"/src/self.h"},
+				"/src/self.h": {"/src/self.h"},
+			},
+			want: [][]FileID{cycleOf("/src/self.h")},
+		},
+		{
+			name: "acyclic",
+			graph: map[string][]string{
+				"/src/main.c": {"/src/a.h", "/src/b.h", "/src/c.h"},
+				"/src/a.h":    {"/src/c.h"},
+				"/src/b.h":    {"/src/c.h", "/src/a.h"},
+				"/src/c.h":    {"-"},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := includeCycleTestFiles(tt.graph)
+			got := FindIncludeCycles(files)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindIncludeCycles() = %v, want %v", got, tt.want)
+			}
+			if again := FindIncludeCycles(files); !reflect.DeepEqual(again, got) {
+				t.Errorf("FindIncludeCycles() is not deterministic: %v, then %v", got, again)
+			}
+		})
+	}
+}
+
+func TestFindIncludeCycles_Max(t *testing.T) {
+	// every pair of the 6 headers includes each other, which has 409 cycles
+	graph := make(map[string][]string)
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			if i != j {
+				name := fmt.Sprintf("/src/%d.h", i)
+				graph[name] = append(graph[name], fmt.Sprintf("/src/%d.h", j))
+			}
+		}
+	}
+
+	cycles := FindIncludeCycles(includeCycleTestFiles(graph))
+	if len(cycles) != maxIncludeCycles {
+		t.Fatalf("len(FindIncludeCycles()) = %d, want %d", len(cycles), maxIncludeCycles)
+	}
+	seen := make(map[string]bool)
+	for _, cycle := range cycles {
+		key := fmt.Sprint(cycle)
+		if seen[key] {
+			t.Errorf("cycle %v is returned twice", cycle)
+		}
+		seen[key] = true
+	}
+	if got := sortCycles(append([][]FileID(nil), cycles...)); !reflect.DeepEqual(got, cycles) {
+		t.Error("FindIncludeCycles() is not ordered by the FileIDs")
+	}
+}
+
+func TestFormatIncludeCycle(t *testing.T) {
+	files := includeCycleTestFiles(map[string][]string{
+		"/src/a.h": {"/src/sub/../b.h"},
+		"/src/b.h": {"/src/c.h"},
+		"/src/c.h": {"/src/a.h"},
+	})
+
+	cycles := FindIncludeCycles(files)
+	if len(cycles) != 1 {
+		t.Fatalf("FindIncludeCycles() = %v, want the one cycle", cycles)
+	}
+	// the header path is the spelling recorded by the including File
+	want := map[string]string{
+		"/src/a.h": "/src/a.h -> /src/sub/../b.h -> /src/c.h -> /src/a.h",
+		"/src/b.h": "/src/sub/../b.h -> /src/c.h -> /src/a.h -> /src/sub/../b.h",
+		"/src/c.h": "/src/c.h -> /src/a.h -> /src/sub/../b.h -> /src/c.h",
+	}[files[cycles[0][0]].Name()]
+	if got := FormatIncludeCycle(files, cycles[0]); got != want {
+		t.Errorf("FormatIncludeCycle() = %q, want %q", got, want)
+	}
+
+	var list []*File
+	for _, f := range files {
+		list = append(list, f)
+	}
+	table := NewTable(list)
+	if got := table.IncludeCycles(); !reflect.DeepEqual(got, cycles) {
+		t.Errorf("Table.IncludeCycles() = %v, want %v", got, cycles)
+	}
+	if got := table.FormatIncludeCycle(cycles[0]); got != want {
+		t.Errorf("Table.FormatIncludeCycle() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// headerExts extensions of the files which CompleteIncludePath completes. The files without the extension,
+// such as the C++ standard library headers, are also completed.
+var headerExts = map[string]bool{
+	".h":   true,
+	".hh":  true,
+	".hpp": true,
+	".hxx": true,
+	".h++": true,
+	".inc": true,
+	".def": true,
+	".tcc": true,
+}
+
+// IncludeSearchDirs returns the directories of the -iquote, -I, -isystem and -idirafter flags of f in the
+// search order of clang. If quote is true, which is the `#include "..."` form, the result is prefixed by
+// the directory of f and the -iquote directories, otherwise they are not searched.
+func (f *File) IncludeSearchDirs(quote bool) []string {
+	var quoteDirs, dirs, systemDirs, afterDirs []string
+	flags := f.Flags()
+	for i := 0; i < len(flags); i++ {
+		var dst *[]string
+		var value string
+	opts:
+		for _, opt := range []struct {
+			name string
+			dst  *[]string
+		}{
+			{"-iquote", &quoteDirs},
+			{"-isystem", &systemDirs},
+			{"-idirafter", &afterDirs},
+			{"-I", &dirs},
+		} {
+			switch {
+			case flags[i] == opt.name:
+				if i+1 < len(flags) {
+					i++
+					dst, value = opt.dst, flags[i]
+				}
+			case strings.HasPrefix(flags[i], opt.name):
+				dst, value = opt.dst, strings.TrimPrefix(flags[i], opt.name)
+			default:
+				continue
+			}
+			break opts
+		}
+		if dst != nil && value != "" {
+			*dst = append(*dst, value)
+		}
+	}
+
+	var searchDirs []string
+	if quote {
+		searchDirs = append(searchDirs, filepath.Dir(f.Name()))
+		searchDirs = append(searchDirs, quoteDirs...)
+	}
+	searchDirs = append(searchDirs, dirs...)
+	searchDirs = append(searchDirs, systemDirs...)
+	return append(searchDirs, afterDirs...)
+}
+
+// CompleteIncludePath returns the completion items of the header path of the #include directive, whose
+// spelling is partial before the cursor, such as "foo/ba" of `#include <foo/ba`.
+//
+// The items are the headers and subdirectories in the directory of partial under searchDirs, which are usually
+// File.IncludeSearchDirs. If quote is true, the searchDirs are expected to have the directory of the current
+// file as the compiler searches it for the `#include "..."` form. The word of the item is the whole path to be
+// inserted, and the directories have the trailing "/" so that the completion can continue into them, while the
+// headers have the closing `"` or `>` of the form, as same as clangd.
+//
+// The absolute searchDirs are resolved in fsys, which is usually os.DirFS("/"), by stripping the leading "/".
+// The path found by the several searchDirs is completed once, as the first one hides the others in the
+// compiler. The items are ordered by the word, and the searchDirs which do not exist are ignored.
+func CompleteIncludePath(partial string, quote bool, searchDirs []string, fsys fs.FS) ([]CompleteItem, error) {
+	dir, prefix := path.Split(filepath.ToSlash(partial))
+	if path.IsAbs(dir) {
+		searchDirs = []string{"/"}
+	}
+
+	closing := ">"
+	if quote {
+		closing = `"`
+	}
+
+	seen := make(map[string]bool)
+	var items []CompleteItem
+	for _, searchDir := range searchDirs {
+		root := includeFSPath(path.Join(filepath.ToSlash(searchDir), dir))
+		entries, err := fs.ReadDir(fsys, root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "could not read %s", root)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+				continue
+			}
+			abbr, menu := name, "header"
+			if isIncludeDir(fsys, root, entry) {
+				abbr, menu = name+"/", "directory"
+			} else if ext := path.Ext(name); ext != "" && !headerExts[ext] {
+				continue
+			}
+			if seen[abbr] {
+				continue
+			}
+			seen[abbr] = true
+
+			word := dir + abbr
+			if menu == "header" {
+				word += closing
+			}
+			item := NewCompleteItem(word,
+				WithAbbr(abbr),
+				WithMenu(menu),
+				WithInfo(path.Join(filepath.ToSlash(searchDir), dir, name)),
+			)
+			items = append(items, *item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].word < items[j].word })
+
+	return items, nil
+}
+
+// includeFSPath returns the fs.FS path of the slash separated dir, which has no leading "/".
+func includeFSPath(dir string) string {
+	dir = strings.TrimPrefix(path.Clean(dir), "/")
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+// isIncludeDir reports whether entry in root is the directory, following the symbolic link.
+func isIncludeDir(fsys fs.FS, root string, entry fs.DirEntry) bool {
+	if entry.Type()&fs.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+	fi, err := fs.Stat(fsys, path.Join(root, entry.Name()))
+	return err == nil && fi.IsDir()
+}
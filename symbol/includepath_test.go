@@ -0,0 +1,131 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFile_IncludeSearchDirs(t *testing.T) {
+	f := NewFile("/repo/src/main.c", []string{
+		"-DDEBUG", "-I/repo/include", "-isystem", "/usr/include", "-iquote", "/repo/quote",
+		"-I", "/repo/third_party", "-idirafter/opt/include", "-isystem/usr/local/include", "-I",
+	})
+
+	if got, want := f.IncludeSearchDirs(true), []string{
+		"/repo/src", "/repo/quote", "/repo/include", "/repo/third_party", "/usr/include", "/usr/local/include", "/opt/include",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IncludeSearchDirs(true) = %v, want %v", got, want)
+	}
+	if got, want := f.IncludeSearchDirs(false), []string{
+		"/repo/include", "/repo/third_party", "/usr/include", "/usr/local/include", "/opt/include",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IncludeSearchDirs(false) = %v, want %v", got, want)
+	}
+}
+
+func TestCompleteIncludePath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo/src/main.c":              {},
+		"repo/src/util.h":              {},
+		"repo/src/.hidden.h":           {},
+		"repo/include/foo/bar.h":       {},
+		"repo/include/foo/baz/qux.hpp": {},
+		"repo/include/foo/readme.txt":  {},
+		"repo/include/util.h":          {},
+		"usr/include/foo/bar.h":        {},
+		"usr/include/foo/bits/types.h": {},
+		"usr/include/c++/v1/vector":    {},
+		"usr/include/stdio.h":          {},
+		"usr/include/stdlib.h":         {},
+	}
+	f := NewFile("/repo/src/main.c", []string{"-I/repo/include", "-I/repo/missing", "-isystem", "/usr/include"})
+
+	type item struct{ word, abbr, menu, info string }
+	tests := []struct {
+		name    string
+		partial string
+		quote   bool
+		want    []item
+	}{
+		{
+			name:    "angled top level",
+			partial: "st",
+			want: []item{
+				{"stdio.h>", "stdio.h", "header", "/usr/include/stdio.h"},
+				{"stdlib.h>", "stdlib.h", "header", "/usr/include/stdlib.h"},
+			},
+		},
+		{
+			name:    "angled does not search the current directory",
+			partial: "u",
+			want: []item{
+				{"util.h>", "util.h", "header", "/repo/include/util.h"},
+			},
+		},
+		{
+			name:    "quoted searches the current directory first",
+			partial: "u",
+			quote:   true,
+			want: []item{
+				{`util.h"`, "util.h", "header", "/repo/src/util.h"},
+			},
+		},
+		{
+			name:    "nested and deduplicated across the search dirs",
+			partial: "foo/b",
+			want: []item{
+				{"foo/bar.h>", "bar.h", "header", "/repo/include/foo/bar.h"},
+				{"foo/baz/", "baz/", "directory", "/repo/include/foo/baz"},
+				{"foo/bits/", "bits/", "directory", "/usr/include/foo/bits"},
+			},
+		},
+		{
+			name:    "into the subdirectory",
+			partial: "foo/baz/",
+			quote:   true,
+			want: []item{
+				{`foo/baz/qux.hpp"`, "qux.hpp", "header", "/repo/include/foo/baz/qux.hpp"},
+			},
+		},
+		{
+			name:    "extensionless header",
+			partial: "c++/v1/vec",
+			want: []item{
+				{"c++/v1/vector>", "vector", "header", "/usr/include/c++/v1/vector"},
+			},
+		},
+		{
+			name:    "non-header files are skipped",
+			partial: "foo/r",
+			want:    nil,
+		},
+		{
+			name:    "hidden files are completed only by the dot prefix",
+			partial: ".h",
+			quote:   true,
+			want: []item{
+				{`.hidden.h"`, ".hidden.h", "header", "/repo/src/.hidden.h"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := CompleteIncludePath(tt.partial, tt.quote, f.IncludeSearchDirs(tt.quote), fsys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []item
+			for _, it := range items {
+				got = append(got, item{it.Word(), it.Abbr(), it.Menu(), it.Info()})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompleteIncludePath(%q, %t) = %v, want %v", tt.partial, tt.quote, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// InlayHint represents a hint of the parameter name at the argument of the function call, which the editors
+// render before the argument, such as "count:" of `foo(/*count:*/ 3)`.
+type InlayHint struct {
+	// Location start location of the argument.
+	Location Location
+	// Label text of the hint, which is the parameter name followed by ":".
+	Label string
+	// Callee symbol of the called function.
+	Callee *Info
+	// Param index of the parameter in the Params of Callee.
+	Param int
+}
+
+// CallArgumentsHints returns the hints of the parameter names at the arguments of the function calls in file,
+// whose arguments start within rangeStart inclusive and rangeEnd exclusive. The file names of rangeStart and
+// rangeEnd are ignored.
+//
+// The arguments are recorded by File.AddCallArguments, and joined with the Params of the callee in order.
+// The parameters are taken from any File which knows them, as the File which only calls the function may not.
+// The arguments of the variadic part and of the unnamed parameters have no hint. The hints of the call recorded by
+// the several Files, such as in the header, are deduplicated, and ordered by the line and column.
+func (t *Table) CallArgumentsHints(file string, rangeStart, rangeEnd Location) []InlayHint {
+	file = filepath.Clean(file)
+	inRange := func(loc Location) bool {
+		return filepath.Clean(loc.FileName()) == file && !positionLess(loc, rangeStart) && positionLess(loc, rangeEnd)
+	}
+
+	params := make(map[ID][]*Param)
+	paramsOf := func(sym *Info) []*Param {
+		id := sym.ID()
+		if p, ok := params[id]; ok {
+			return p
+		}
+		var p []*Param
+		for _, info := range append([]*Info{sym}, t.Symbols(id)...) {
+			if p = info.Params(); len(p) > 0 {
+				break
+			}
+		}
+		params[id] = p
+		return p
+	}
+
+	seen := make(map[locationKey]bool)
+	var hints []InlayHint
+	for _, f := range t.Files() {
+		for _, sym := range f.Symbols() {
+			for _, caller := range sym.Callers() {
+				args := caller.Args()
+				if len(args) == 0 {
+					continue
+				}
+				p := paramsOf(sym)
+				for i, arg := range args {
+					if i >= len(p) {
+						// the variadic arguments
+						break
+					}
+					if p[i].Name() == "" || !inRange(arg) || seen[keyOf(arg)] {
+						continue
+					}
+					seen[keyOf(arg)] = true
+					hints = append(hints, InlayHint{Location: arg, Label: p[i].Name() + ":", Callee: sym, Param: i})
+				}
+			}
+		}
+	}
+
+	sort.Slice(hints, func(i, j int) bool {
+		return positionLess(hints[i].Location, hints[j].Location)
+	})
+
+	return hints
+}
+
+// positionLess reports whether a is before b by the line and column, regardless of the file name.
+func positionLess(a, b Location) bool {
+	if a.Line() != b.Line() {
+		return a.Line() < b.Line()
+	}
+	return a.rawCol() < b.rawCol()
+}
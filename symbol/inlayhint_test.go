@@ -0,0 +1,175 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// inlayHintTestFiles returns the Files of the fixture project:
+//
+//	/src/util.h:  int add(int a, int b);                  // 1:5
+//	              void log_msg(const char *fmt, ...);     // 2:6
+//	              void set(int, int value);               // 3:6
+//	              static inline int twice(int x) {
+//	                return add(x, x);                     // 5:10, args 5:14 5:17
+//	              }
+//	/src/main.c:  #include "util.h"
+//	              add(1, 2);                              // 5:3, args 5:7 5:10
+//	              log_msg("%d %d", x, y);                 // 6:3, args 6:11 6:19 6:22
+//	              set(0, 42);                             // 7:3, args 7:7 7:10
+//	              add(3, 4);                              // 9:3, args 9:7 9:10
+//	/src/b.c:     #include "util.h"
+//
+// The parameters are recorded only by main.c, and the call in util.h by both Files.
+func inlayHintTestFiles() []*File {
+	loc := func(file string, line, col uint32) Location {
+		return Location{fileName: file, line: line, col: col, offset: line*100 + col}
+	}
+	decl := func(usr string, line, col uint32) Location {
+		l := loc("/src/util.h", line, col)
+		l.usr = usr
+		return l
+	}
+	add, logMsg, set := decl("c:@F@add", 1, 5), decl("c:@F@log_msg", 2, 6), decl("c:@F@set", 3, 6)
+	inline := func(f *File) {
+		f.AddCallArguments(loc("/src/util.h", 5, 10), add, []Location{loc("/src/util.h", 5, 14), loc("/src/util.h", 5, 17)})
+	}
+
+	main := NewFile("/src/main.c", nil)
+	for _, d := range []Location{add, logMsg, set} {
+		main.AddDecl(d)
+	}
+	main.Symbol(ToID("c:@F@add")).params = []*Param{{name: "a", typ: "int"}, {name: "b", typ: "int"}}
+	main.Symbol(ToID("c:@F@log_msg")).params = []*Param{{name: "fmt", typ: "const char *"}}
+	main.Symbol(ToID("c:@F@log_msg")).variadic = true
+	main.Symbol(ToID("c:@F@set")).params = []*Param{{typ: "int"}, {name: "value", typ: "int"}}
+	inline(main)
+	main.AddCallArguments(loc("/src/main.c", 5, 3), add, []Location{loc("/src/main.c", 5, 7), loc("/src/main.c", 5, 10)})
+	main.AddCallArguments(loc("/src/main.c", 6, 3), logMsg, []Location{loc("/src/main.c", 6, 11), loc("/src/main.c", 6, 19), loc("/src/main.c", 6, 22)})
+	main.AddCallArguments(loc("/src/main.c", 7, 3), set, []Location{loc("/src/main.c", 7, 7), loc("/src/main.c", 7, 10)})
+	main.AddCallArguments(loc("/src/main.c", 9, 3), add, []Location{loc("/src/main.c", 9, 7), loc("/src/main.c", 9, 10)})
+	// the reference which is not the call has no arguments
+	main.AddCaller(loc("/src/main.c", 10, 8), add, false)
+
+	b := NewFile("/src/b.c", nil)
+	b.AddDecl(add)
+	inline(b)
+
+	return []*File{main, b}
+}
+
+// hintStrings returns the "line:col label" form of hints.
+func hintStrings(hints []InlayHint) []string {
+	var s []string
+	for _, h := range hints {
+		s = append(s, fmt.Sprintf("%d:%d %s", h.Location.Line(), h.Location.Col(), h.Label))
+	}
+	return s
+}
+
+func TestTable_CallArgumentsHints(t *testing.T) {
+	files := inlayHintTestFiles()
+
+	tests := []struct {
+		name       string
+		file       string
+		start, end Location
+		want       []string
+	}{
+		{
+			name:  "calls in the range",
+			file:  "/src/main.c",
+			start: Location{line: 1, col: 1},
+			end:   Location{line: 9, col: 1},
+			// the variadic arguments of log_msg and the unnamed parameter of set have no hint
+			want: []string{"5:7 a:", "5:10 b:", "6:11 fmt:", "7:10 value:"},
+		},
+		{
+			name:  "range in the middle of the call",
+			file:  "/src/main.c",
+			start: Location{line: 5, col: 8},
+			end:   Location{line: 9, col: 8},
+			want:  []string{"5:10 b:", "6:11 fmt:", "7:10 value:", "9:7 a:"},
+		},
+		{
+			name:  "header call recorded by the several Files",
+			file:  "/src/util.h",
+			start: Location{line: 1, col: 1},
+			end:   Location{line: 100, col: 1},
+			want:  []string{"5:14 a:", "5:17 b:"},
+		},
+		{
+			name:  "other file",
+			file:  "/src/b.c",
+			start: Location{line: 1, col: 1},
+			end:   Location{line: 100, col: 1},
+			want:  nil,
+		},
+	}
+
+	for _, serialized := range []bool{false, true} {
+		for _, packed := range []bool{false, true} {
+			if !serialized && packed {
+				continue
+			}
+			var fs []*File
+			for _, f := range files {
+				if serialized {
+					f.SetPackedLocations(packed)
+					f = roundTrip(f)
+				}
+				fs = append(fs, f)
+			}
+			table := NewTable(fs)
+			for _, tt := range tests {
+				t.Run(fmt.Sprintf("%s/serialized=%t,packed=%t", tt.name, serialized, packed), func(t *testing.T) {
+					hints := table.CallArgumentsHints(tt.file, tt.start, tt.end)
+					if got := hintStrings(hints); !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("CallArgumentsHints(%s) = %v, want %v", tt.file, got, tt.want)
+					}
+					for _, h := range hints {
+						wantParam := map[string]int{"a:": 0, "b:": 1, "fmt:": 0, "value:": 1}[h.Label]
+						if h.Callee == nil || h.Param != wantParam {
+							t.Errorf("hint %s has the callee %v and param %d", h.Label, h.Callee, h.Param)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestFile_AddCallArguments(t *testing.T) {
+	f := NewFile("/src/main.c", nil)
+	def := Location{fileName: "/src/util.c", line: 1, col: 5, usr: "c:@F@add", kind: 8}
+	args := []Location{{fileName: "/src/main.c", line: 5, col: 7, usr: "c:@x", kind: 8}, {fileName: "/src/main.c", line: 5, col: 10}}
+	if err := f.AddCallArguments(Location{fileName: "/src/main.c", line: 5, col: 3}, def, args); err != nil {
+		t.Fatal(err)
+	}
+
+	callers := f.Symbol(ToID("c:@F@add")).Callers()
+	if len(callers) != 1 || !callers[0].FuncCall() {
+		t.Fatalf("Callers() = %v, want the function call", callers)
+	}
+	want := []Location{{fileName: "/src/main.c", line: 5, col: 7}, {fileName: "/src/main.c", line: 5, col: 10}}
+	if got := callers[0].Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %v, want %v", got, want)
+	}
+
+	// the arguments are compared by Equal and FilesEqual
+	o := NewFile("/src/main.c", nil)
+	o.AddCallArguments(Location{fileName: "/src/main.c", line: 5, col: 3}, def, args[:1])
+	if f.Equal(o) {
+		t.Error("Equal() of the different arguments = true, want false")
+	}
+	if eq, err := FilesEqual(mustSerializeBytes(f), mustSerializeBytes(o)); eq || errors.Cause(err) != ErrFilesDiffer {
+		t.Errorf("FilesEqual() of the different arguments = %t, %v, want the ErrFilesDiffer cause", eq, err)
+	}
+}
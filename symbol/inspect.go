@@ -0,0 +1,183 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// vtable offsets of the File table fields.
+const (
+	fileNameSlot            flatbuffers.VOffsetT = 4
+	fileFlagsSlot           flatbuffers.VOffsetT = 6
+	fileTranslationUnitSlot flatbuffers.VOffsetT = 8
+	fileSymbolsSlot         flatbuffers.VOffsetT = 10
+	fileHeadersSlot         flatbuffers.VOffsetT = 12
+	fileIncludesSlot        flatbuffers.VOffsetT = 14
+	fileTUOmittedSlot       flatbuffers.VOffsetT = 16
+	fileMetadataSlot        flatbuffers.VOffsetT = 18
+	fileLanguageSlot        flatbuffers.VOffsetT = 20
+	fileUnsavedSlot         flatbuffers.VOffsetT = 22
+	fileDiagnosticsSlot     flatbuffers.VOffsetT = 24
+)
+
+// vtable offsets of the Info table fields.
+const (
+	infoIDSlot         flatbuffers.VOffsetT = 4
+	infoDeclsSlot      flatbuffers.VOffsetT = 6
+	infoDefSlot        flatbuffers.VOffsetT = 8
+	infoCallersSlot    flatbuffers.VOffsetT = 10
+	infoNameSlot       flatbuffers.VOffsetT = 12
+	infoTypeSlot       flatbuffers.VOffsetT = 14
+	infoResultTypeSlot flatbuffers.VOffsetT = 16
+	infoParamsSlot     flatbuffers.VOffsetT = 18
+	infoVariadicSlot   flatbuffers.VOffsetT = 20
+	infoNamespaceSlot  flatbuffers.VOffsetT = 22
+	infoBasesSlot      flatbuffers.VOffsetT = 24
+	infoIsVirtualSlot  flatbuffers.VOffsetT = 26
+	infoOverridesSlot  flatbuffers.VOffsetT = 28
+
+	infoPackedLocationsSlot flatbuffers.VOffsetT = 30
+	infoPackedStringsSlot   flatbuffers.VOffsetT = 32
+	infoDefMtimeSlot        flatbuffers.VOffsetT = 34
+)
+
+// vtable offsets of the Header table fields.
+const (
+	headerExistsSlot flatbuffers.VOffsetT = 10
+)
+
+// vtable offsets of the Caller table fields.
+const (
+	callerArgsSlot flatbuffers.VOffsetT = 8
+)
+
+// vtable offsets of the Diagnostic table fields.
+const (
+	diagnosticFixItsSlot flatbuffers.VOffsetT = 12
+)
+
+// FileInspection represents the fields present in the serialized File.
+//
+// The scalar fields which equal to the default value are not written to the flatbuffers binary,
+// so the presence of them reports only the non-default values.
+type FileInspection struct {
+	HasName            bool
+	HasFlags           bool
+	HasTranslationUnit bool
+	HasSymbols         bool
+	HasHeaders         bool
+	HasIncludes        bool
+	HasTUOmitted       bool
+	HasMetadata        bool
+	HasLanguage        bool
+	HasUnsaved         bool
+	HasDiagnostics     bool
+
+	// Info the fields present in any of the symbols.
+	Info InfoInspection
+
+	NumFlags   int
+	NumSymbols int
+	NumHeaders int
+	NumDecls   int // total number of declarations of all symbols
+	NumCallers int // total number of callers of all symbols
+}
+
+// InfoInspection represents the fields present in the serialized Info.
+type InfoInspection struct {
+	HasID         bool
+	HasDecls      bool
+	HasDef        bool
+	HasCallers    bool
+	HasName       bool
+	HasType       bool
+	HasResultType bool
+	HasParams     bool
+	HasVariadic   bool
+	HasNamespace  bool
+	HasBases      bool
+	HasIsVirtual  bool
+	HasOverrides  bool
+
+	HasPackedLocations bool
+	HasDefMtime        bool
+}
+
+// InspectFile reports which fields the serialized File buf contains, and the basic counts of it.
+// It reads only the flatbuffers vtables, without Unmarshal.
+func InspectFile(buf []byte) (insp FileInspection, err error) {
+	if len(buf) < flatbuffers.SizeUOffsetT {
+		return FileInspection{}, errors.Errorf("buffer too short: %d bytes", len(buf))
+	}
+	if root := flatbuffers.GetUOffsetT(buf); int(root) >= len(buf) {
+		return FileInspection{}, errors.Errorf("root table offset %d out of range", root)
+	}
+
+	// the flatbuffers accessors panic on the corrupted buffer
+	defer func() {
+		if r := recover(); r != nil {
+			insp, err = FileInspection{}, errors.Errorf("corrupted buffer: %v", r)
+		}
+	}()
+
+	file := symbol.GetRootAsFile(buf, 0)
+	tab := file.Table()
+	insp.HasName = tab.Offset(fileNameSlot) != 0
+	insp.HasFlags = tab.Offset(fileFlagsSlot) != 0
+	insp.HasTranslationUnit = tab.Offset(fileTranslationUnitSlot) != 0
+	insp.HasSymbols = tab.Offset(fileSymbolsSlot) != 0
+	insp.HasHeaders = tab.Offset(fileHeadersSlot) != 0
+	insp.HasIncludes = tab.Offset(fileIncludesSlot) != 0
+	insp.HasTUOmitted = tab.Offset(fileTUOmittedSlot) != 0
+	insp.HasMetadata = tab.Offset(fileMetadataSlot) != 0
+	insp.HasLanguage = tab.Offset(fileLanguageSlot) != 0
+	insp.HasUnsaved = tab.Offset(fileUnsavedSlot) != 0
+	insp.HasDiagnostics = tab.Offset(fileDiagnosticsSlot) != 0
+
+	insp.NumFlags = file.FlagsLength()
+	insp.NumSymbols = file.SymbolsLength()
+	insp.NumHeaders = file.HeadersLength()
+
+	info := new(symbol.Info)
+	for i := 0; i < insp.NumSymbols; i++ {
+		if !file.Symbols(info, i) {
+			continue
+		}
+		insp.Info.merge(info.Table())
+		if packed := packedBlob(info); len(packed) > 0 {
+			d := &packedDecoder{buf: packed, info: info}
+			insp.NumDecls += len(d.decls())
+			d.def()
+			insp.NumCallers += len(d.callers())
+			continue
+		}
+		insp.NumDecls += info.DeclsLength()
+		insp.NumCallers += info.CallersLength()
+	}
+
+	return insp, nil
+}
+
+// merge merges the fields present in the Info table tab into insp.
+func (insp *InfoInspection) merge(tab flatbuffers.Table) {
+	insp.HasID = insp.HasID || tab.Offset(infoIDSlot) != 0
+	insp.HasDecls = insp.HasDecls || tab.Offset(infoDeclsSlot) != 0
+	insp.HasDef = insp.HasDef || tab.Offset(infoDefSlot) != 0
+	insp.HasCallers = insp.HasCallers || tab.Offset(infoCallersSlot) != 0
+	insp.HasName = insp.HasName || tab.Offset(infoNameSlot) != 0
+	insp.HasType = insp.HasType || tab.Offset(infoTypeSlot) != 0
+	insp.HasResultType = insp.HasResultType || tab.Offset(infoResultTypeSlot) != 0
+	insp.HasParams = insp.HasParams || tab.Offset(infoParamsSlot) != 0
+	insp.HasVariadic = insp.HasVariadic || tab.Offset(infoVariadicSlot) != 0
+	insp.HasPackedLocations = insp.HasPackedLocations || tab.Offset(infoPackedLocationsSlot) != 0
+	insp.HasDefMtime = insp.HasDefMtime || tab.Offset(infoDefMtimeSlot) != 0
+	insp.HasNamespace = insp.HasNamespace || tab.Offset(infoNamespaceSlot) != 0
+	insp.HasBases = insp.HasBases || tab.Offset(infoBasesSlot) != 0
+	insp.HasIsVirtual = insp.HasIsVirtual || tab.Offset(infoIsVirtualSlot) != 0
+	insp.HasOverrides = insp.HasOverrides || tab.Offset(infoOverridesSlot) != 0
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInspectFile(t *testing.T) {
+	// written by the older code, before the symbol type information was added
+	old, err := ioutil.ReadFile(filepath.Join(compatDir, "basic.fb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(Location{fileName: "main.c", line: 2, col: 5, offset: 10, usr: "c:@N@a@F@printf"})
+	info := findSymbol(f, "c:@N@a@F@printf")
+	info.name = "printf"
+	info.typ = "int (const char *, ...)"
+	info.resultType = "int"
+	info.params = []*Param{{name: "format", typ: "const char *"}}
+	info.variadic = true
+	info.namespace = "a"
+	latest := f.Serialize().FinishedBytes()
+
+	tests := []struct {
+		name string
+		buf  []byte
+		want FileInspection
+	}{
+		{
+			name: "without newer fields",
+			buf:  old,
+			want: FileInspection{
+				HasName:            true,
+				HasFlags:           true,
+				HasTranslationUnit: true,
+				HasSymbols:         true,
+				HasHeaders:         true,
+				Info: InfoInspection{
+					HasID:      true,
+					HasDecls:   true,
+					HasDef:     true,
+					HasCallers: true,
+				},
+				NumFlags:   2,
+				NumSymbols: 3,
+				NumHeaders: 2,
+				NumDecls:   4,
+				NumCallers: 2,
+			},
+		},
+		{
+			name: "with newer fields",
+			buf:  latest,
+			want: FileInspection{
+				HasName:            true,
+				HasFlags:           true,
+				HasTranslationUnit: true,
+				HasSymbols:         true,
+				HasHeaders:         true,
+				HasLanguage:        true,
+				Info: InfoInspection{
+					HasID:         true,
+					HasDecls:      true,
+					HasName:       true,
+					HasType:       true,
+					HasResultType: true,
+					HasParams:     true,
+					HasVariadic:   true,
+					HasNamespace:  true,
+				},
+				NumSymbols: 1,
+				NumDecls:   1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InspectFile(tt.buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InspectFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectFile_Invalid(t *testing.T) {
+	for _, buf := range [][]byte{nil, {1, 2}, {0xff, 0xff, 0, 0}} {
+		if _, err := InspectFile(buf); err == nil {
+			t.Errorf("InspectFile(%v) returned nil error", buf)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// vimCompleteItem represents the JSON encoding of the vim complete-items dictionary.
+// The empty optional keys are omitted, and the icase and dup are 0 or 1 as vim expects.
+type vimCompleteItem struct {
+	Word     string `json:"word"`
+	Abbr     string `json:"abbr,omitempty"`
+	Menu     string `json:"menu,omitempty"`
+	Info     string `json:"info,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Icase    int    `json:"icase"`
+	Dup      int    `json:"dup"`
+	UserData string `json:"user_data,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// It encodes c to the vim complete-items dictionary, which can be passed to complete() as is.
+func (c *CompleteItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vimCompleteItem{
+		Word:     c.Word(),
+		Abbr:     c.Abbr(),
+		Menu:     c.Menu(),
+		Info:     c.Info(),
+		Kind:     c.Kind(),
+		Icase:    int(boolToByte(c.Icase())),
+		Dup:      int(boolToByte(c.Dup())),
+		UserData: c.UserData(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It decodes the vim complete-items dictionary to the in-memory c, so the clang specific fields are zero.
+func (c *CompleteItem) UnmarshalJSON(data []byte) error {
+	var v vimCompleteItem
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*c = CompleteItem{
+		word:     v.Word,
+		abbr:     v.Abbr,
+		menu:     v.Menu,
+		info:     v.Info,
+		kind:     v.Kind,
+		icase:    v.Icase != 0,
+		dup:      v.Dup != 0,
+		userData: v.UserData,
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// It encodes the results of c to the JSON list of the vim complete-items dictionary.
+func (c *CodeCompleteResults) MarshalJSON() ([]byte, error) {
+	results := c.Results()
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := range results {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := results[i].MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It decodes the JSON list of the vim complete-items dictionary, and replaces c with the flatbuffers binary of it.
+func (c *CodeCompleteResults) UnmarshalJSON(data []byte) error {
+	var results []CompleteItem
+	if err := json.Unmarshal(data, &results); err != nil {
+		return err
+	}
+
+	items := make([]*CompleteItem, len(results))
+	for i := range results {
+		items[i] = &results[i]
+	}
+	buf := serializeCompleteItems(items, false, len(items), nil, 0).FinishedBytes()
+	c.codeCompleteResults = symbol.GetRootAsCodeCompleteResults(buf, 0)
+
+	return nil
+}
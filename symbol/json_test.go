@@ -0,0 +1,133 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestCodeCompleteResults_MarshalJSON(t *testing.T) {
+	method := completionResult{
+		cursorKind: clang.Cursor_CXXMethod,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_ResultType, text: "void"},
+				{kind: clang.CompletionChunk_TypedText, text: "push_back"},
+				{kind: clang.CompletionChunk_LeftParen, text: "("},
+				{kind: clang.CompletionChunk_Placeholder, text: "const value_type &x"},
+				{kind: clang.CompletionChunk_RightParen, text: ")"},
+			},
+			priority: 37,
+			comment:  "Adds an element to the end.",
+		},
+	}
+	keyword := completionResult{
+		cursorKind: clang.Cursor_NotImplemented,
+		cs: fakeCompletionString{
+			chunks:   []fakeChunk{{kind: clang.CompletionChunk_TypedText, text: "return"}},
+			priority: 40,
+		},
+	}
+	symbolFunc := func(word string, kind clang.CursorKind) (string, string) {
+		return "c:@F@" + word, "/usr/include/stdio.h"
+	}
+
+	tests := []struct {
+		name    string
+		opts    CompleteOptions
+		results []completionResult
+	}{
+		{name: "empty"},
+		{name: "method", opts: CompleteOptions{Snippet: SnippetVim}, results: []completionResult{method}},
+		{name: "keyword", results: []completionResult{keyword}},
+		{
+			name:    "user_data",
+			opts:    CompleteOptions{UserData: true, SymbolFunc: symbolFunc},
+			results: []completionResult{fakeFunction("printf", "int", 50), fakeFunction("puts", "int", 50)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := marshalResults(tt.opts, tt.results)
+			got, err := json.Marshal(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "vim", tt.name+".json")
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+
+			var decoded CodeCompleteResults
+			if err := json.Unmarshal(want, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			reencoded, err := json.Marshal(&decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(append(reencoded, '\n'), want) {
+				t.Errorf("round trip mismatch:\ngot:\n%s\nwant:\n%s", reencoded, want)
+			}
+		})
+	}
+}
+
+func TestCompleteItem_MarshalJSON(t *testing.T) {
+	item := CompleteItem{word: "printf", kind: "f", dup: true}
+	got, err := json.Marshal(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"word":"printf","kind":"f","icase":0,"dup":1}`; string(got) != want {
+		t.Errorf("MarshalJSON() = %s, want %s", got, want)
+	}
+
+	var decoded CompleteItem
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != item {
+		t.Errorf("UnmarshalJSON() = %+v, want %+v", decoded, item)
+	}
+}
+
+func BenchmarkCodeCompleteResults_MarshalJSON(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			results := make([]completionResult, n)
+			for i := range results {
+				results[i] = fakeFunction(fmt.Sprintf("func%d", i), "int", 50)
+			}
+			c := marshalResults(CompleteOptions{}, results)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.MarshalJSON(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import "github.com/go-clang/v3.9/clang"
+
+// SymbolKind represents a kind of the symbol which persisted in the index.
+//
+// The values are stable across the go-clang and libclang versions, unlike the clang.CursorKind whose
+// values may be reordered. The existing values must not be changed, and the new kinds are appended.
+type SymbolKind uint32
+
+// The SymbolKind values.
+const (
+	SymbolKindUnknown                            SymbolKind = 0
+	SymbolKindStruct                             SymbolKind = 1
+	SymbolKindUnion                              SymbolKind = 2
+	SymbolKindClass                              SymbolKind = 3
+	SymbolKindEnum                               SymbolKind = 4
+	SymbolKindField                              SymbolKind = 5
+	SymbolKindEnumConstant                       SymbolKind = 6
+	SymbolKindFunction                           SymbolKind = 7
+	SymbolKindVariable                           SymbolKind = 8
+	SymbolKindParameter                          SymbolKind = 9
+	SymbolKindTypedef                            SymbolKind = 10
+	SymbolKindMethod                             SymbolKind = 11
+	SymbolKindNamespace                          SymbolKind = 12
+	SymbolKindConstructor                        SymbolKind = 13
+	SymbolKindDestructor                         SymbolKind = 14
+	SymbolKindConversionFunction                 SymbolKind = 15
+	SymbolKindTemplateTypeParameter              SymbolKind = 16
+	SymbolKindNonTypeTemplateParameter           SymbolKind = 17
+	SymbolKindTemplateTemplateParameter          SymbolKind = 18
+	SymbolKindFunctionTemplate                   SymbolKind = 19
+	SymbolKindClassTemplate                      SymbolKind = 20
+	SymbolKindClassTemplatePartialSpecialization SymbolKind = 21
+	SymbolKindNamespaceAlias                     SymbolKind = 22
+	SymbolKindTypeAlias                          SymbolKind = 23
+	SymbolKindTypeAliasTemplate                  SymbolKind = 24
+	SymbolKindMacro                              SymbolKind = 25
+	SymbolKindObjCInterface                      SymbolKind = 26
+	SymbolKindObjCCategory                       SymbolKind = 27
+	SymbolKindObjCProtocol                       SymbolKind = 28
+	SymbolKindObjCInstanceMethod                 SymbolKind = 29
+)
+
+// symbolKinds the mapping between SymbolKind and clang.CursorKind.
+var symbolKinds = []struct {
+	kind       SymbolKind
+	cursorKind clang.CursorKind
+	name       string
+}{
+	{SymbolKindStruct, clang.Cursor_StructDecl, "struct"},
+	{SymbolKindUnion, clang.Cursor_UnionDecl, "union"},
+	{SymbolKindClass, clang.Cursor_ClassDecl, "class"},
+	{SymbolKindEnum, clang.Cursor_EnumDecl, "enum"},
+	{SymbolKindField, clang.Cursor_FieldDecl, "field"},
+	{SymbolKindEnumConstant, clang.Cursor_EnumConstantDecl, "enum constant"},
+	{SymbolKindFunction, clang.Cursor_FunctionDecl, "function"},
+	{SymbolKindVariable, clang.Cursor_VarDecl, "variable"},
+	{SymbolKindParameter, clang.Cursor_ParmDecl, "parameter"},
+	{SymbolKindTypedef, clang.Cursor_TypedefDecl, "typedef"},
+	{SymbolKindMethod, clang.Cursor_CXXMethod, "method"},
+	{SymbolKindNamespace, clang.Cursor_Namespace, "namespace"},
+	{SymbolKindConstructor, clang.Cursor_Constructor, "constructor"},
+	{SymbolKindDestructor, clang.Cursor_Destructor, "destructor"},
+	{SymbolKindConversionFunction, clang.Cursor_ConversionFunction, "conversion function"},
+	{SymbolKindTemplateTypeParameter, clang.Cursor_TemplateTypeParameter, "template type parameter"},
+	{SymbolKindNonTypeTemplateParameter, clang.Cursor_NonTypeTemplateParameter, "non-type template parameter"},
+	{SymbolKindTemplateTemplateParameter, clang.Cursor_TemplateTemplateParameter, "template template parameter"},
+	{SymbolKindFunctionTemplate, clang.Cursor_FunctionTemplate, "function template"},
+	{SymbolKindClassTemplate, clang.Cursor_ClassTemplate, "class template"},
+	{SymbolKindClassTemplatePartialSpecialization, clang.Cursor_ClassTemplatePartialSpecialization, "class template partial specialization"},
+	{SymbolKindNamespaceAlias, clang.Cursor_NamespaceAlias, "namespace alias"},
+	{SymbolKindTypeAlias, clang.Cursor_TypeAliasDecl, "type alias"},
+	{SymbolKindTypeAliasTemplate, clang.Cursor_TypeAliasTemplateDecl, "type alias template"},
+	{SymbolKindMacro, clang.Cursor_MacroDefinition, "macro"},
+	{SymbolKindObjCInterface, clang.Cursor_ObjCInterfaceDecl, "objc interface"},
+	{SymbolKindObjCCategory, clang.Cursor_ObjCCategoryDecl, "objc category"},
+	{SymbolKindObjCProtocol, clang.Cursor_ObjCProtocolDecl, "objc protocol"},
+	{SymbolKindObjCInstanceMethod, clang.Cursor_ObjCInstanceMethodDecl, "objc instance method"},
+}
+
+// cursorToSymbolKind the reverse mapping of symbolKinds.
+var cursorToSymbolKind = func() map[clang.CursorKind]SymbolKind {
+	m := make(map[clang.CursorKind]SymbolKind, len(symbolKinds))
+	for _, k := range symbolKinds {
+		m[k.cursorKind] = k.kind
+	}
+	return m
+}()
+
+// ToSymbolKind converts the clang.CursorKind to SymbolKind.
+// It returns SymbolKindUnknown if kind is not the symbol declaration kind.
+func ToSymbolKind(kind clang.CursorKind) SymbolKind {
+	return cursorToSymbolKind[kind]
+}
+
+// CursorKind returns the clang.CursorKind of k. It returns zero if k is SymbolKindUnknown or not known.
+func (k SymbolKind) CursorKind() clang.CursorKind {
+	if k == SymbolKindUnknown || int(k) > len(symbolKinds) {
+		return 0
+	}
+	return symbolKinds[k-1].cursorKind
+}
+
+func (k SymbolKind) String() string {
+	if k == SymbolKindUnknown || int(k) > len(symbolKinds) {
+		return "unknown"
+	}
+	return symbolKinds[k-1].name
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestSymbolKind(t *testing.T) {
+	// the persisted values must not change, regardless of the clang.CursorKind values
+	stable := []struct {
+		kind       SymbolKind
+		value      uint32
+		cursorKind clang.CursorKind
+	}{
+		{SymbolKindUnknown, 0, 0},
+		{SymbolKindStruct, 1, clang.Cursor_StructDecl},
+		{SymbolKindUnion, 2, clang.Cursor_UnionDecl},
+		{SymbolKindClass, 3, clang.Cursor_ClassDecl},
+		{SymbolKindEnum, 4, clang.Cursor_EnumDecl},
+		{SymbolKindField, 5, clang.Cursor_FieldDecl},
+		{SymbolKindEnumConstant, 6, clang.Cursor_EnumConstantDecl},
+		{SymbolKindFunction, 7, clang.Cursor_FunctionDecl},
+		{SymbolKindVariable, 8, clang.Cursor_VarDecl},
+		{SymbolKindParameter, 9, clang.Cursor_ParmDecl},
+		{SymbolKindTypedef, 10, clang.Cursor_TypedefDecl},
+		{SymbolKindMethod, 11, clang.Cursor_CXXMethod},
+		{SymbolKindNamespace, 12, clang.Cursor_Namespace},
+		{SymbolKindConstructor, 13, clang.Cursor_Constructor},
+		{SymbolKindDestructor, 14, clang.Cursor_Destructor},
+		{SymbolKindConversionFunction, 15, clang.Cursor_ConversionFunction},
+		{SymbolKindTemplateTypeParameter, 16, clang.Cursor_TemplateTypeParameter},
+		{SymbolKindNonTypeTemplateParameter, 17, clang.Cursor_NonTypeTemplateParameter},
+		{SymbolKindTemplateTemplateParameter, 18, clang.Cursor_TemplateTemplateParameter},
+		{SymbolKindFunctionTemplate, 19, clang.Cursor_FunctionTemplate},
+		{SymbolKindClassTemplate, 20, clang.Cursor_ClassTemplate},
+		{SymbolKindClassTemplatePartialSpecialization, 21, clang.Cursor_ClassTemplatePartialSpecialization},
+		{SymbolKindNamespaceAlias, 22, clang.Cursor_NamespaceAlias},
+		{SymbolKindTypeAlias, 23, clang.Cursor_TypeAliasDecl},
+		{SymbolKindTypeAliasTemplate, 24, clang.Cursor_TypeAliasTemplateDecl},
+		{SymbolKindMacro, 25, clang.Cursor_MacroDefinition},
+		{SymbolKindObjCInterface, 26, clang.Cursor_ObjCInterfaceDecl},
+		{SymbolKindObjCCategory, 27, clang.Cursor_ObjCCategoryDecl},
+		{SymbolKindObjCProtocol, 28, clang.Cursor_ObjCProtocolDecl},
+		{SymbolKindObjCInstanceMethod, 29, clang.Cursor_ObjCInstanceMethodDecl},
+	}
+	if len(stable) != len(symbolKinds)+1 {
+		t.Fatalf("the stable table has %d kinds, want %d", len(stable), len(symbolKinds)+1)
+	}
+	for _, tt := range stable {
+		if uint32(tt.kind) != tt.value {
+			t.Errorf("%s = %d, want the stable %d", tt.kind, tt.kind, tt.value)
+		}
+		if got := tt.kind.CursorKind(); got != tt.cursorKind {
+			t.Errorf("SymbolKind(%d).CursorKind() = %d, want %d", tt.value, got, tt.cursorKind)
+		}
+		if tt.cursorKind != 0 {
+			if got := ToSymbolKind(tt.cursorKind); got != tt.kind {
+				t.Errorf("ToSymbolKind(%d) = %d, want %d", tt.cursorKind, got, tt.kind)
+			}
+		}
+	}
+	if got := ToSymbolKind(clang.Cursor_CallExpr); got != SymbolKindUnknown {
+		t.Errorf("ToSymbolKind(Cursor_CallExpr) = %d, want SymbolKindUnknown", got)
+	}
+	if got := SymbolKind(1000).CursorKind(); got != 0 {
+		t.Errorf("CursorKind() of the unknown SymbolKind = %d, want 0", got)
+	}
+
+	// the serialized Info stores the stable value instead of the clang.CursorKind
+	f := NewFile("main.c", nil)
+	f.AddDecl(Location{fileName: "main.c", line: 1, usr: "c:@F@main", kind: clang.Cursor_FunctionDecl})
+	sym := findSymbol(roundTrip(f), "c:@F@main")
+	if got := sym.info.Kind(); got != uint32(SymbolKindFunction) {
+		t.Errorf("serialized Kind = %d, want %d", got, SymbolKindFunction)
+	}
+	if sym.Kind() != clang.Cursor_FunctionDecl || sym.SymbolKind() != SymbolKindFunction {
+		t.Errorf("Kind(), SymbolKind() = %d, %d, want %d, %d", sym.Kind(), sym.SymbolKind(), clang.Cursor_FunctionDecl, SymbolKindFunction)
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Kythe fact names and edge kinds, see https://kythe.io/docs/schema/.
+const (
+	kytheFactNodeKind     = "/kythe/node/kind"
+	kytheFactSubkind      = "/kythe/subkind"
+	kytheFactTextEncoding = "/kythe/text/encoding"
+	kytheFactLocStart     = "/kythe/loc/start"
+	kytheFactLocEnd       = "/kythe/loc/end"
+	kytheFactComplete     = "/kythe/complete"
+	kytheFactEdge         = "/"
+
+	kytheEdgeChildOf        = "/kythe/edge/childof"
+	kytheEdgeDefinesBinding = "/kythe/edge/defines/binding"
+	kytheEdgeRef            = "/kythe/edge/ref"
+	kytheEdgeRefCall        = "/kythe/edge/ref/call"
+)
+
+// KytheOptions represents the options of ExportKythe.
+type KytheOptions struct {
+	// Corpus and Root of the VNames of the exported nodes.
+	Corpus string
+	Root   string
+
+	// ProjectRoot absolute path of the project root. The paths of the VNames are relative to it, and the files
+	// outside of it are not exported.
+	ProjectRoot string
+}
+
+// KytheStats represents the statistics of ExportKythe.
+type KytheStats struct {
+	Entries int
+	Files   int // number of file nodes
+	Anchors int // number of anchor nodes
+
+	SkippedOffset  int // anchors skipped because the location has no byte offset
+	SkippedExtent  int // anchors skipped because the extent is unknown, such as the symbol without name
+	SkippedOutside int // anchors skipped because the file is outside of the project root
+}
+
+// KytheVName represents the Kythe VName, which identifies the node.
+type KytheVName struct {
+	Signature string `json:"signature,omitempty"`
+	Corpus    string `json:"corpus,omitempty"`
+	Root      string `json:"root,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Language  string `json:"language,omitempty"`
+}
+
+// KytheEntry represents the Kythe Entry in the JSON encoding, which is the fact of the source node, or the edge
+// from the source node to the target node if EdgeKind is not empty. The FactValue is encoded in base64.
+type KytheEntry struct {
+	Source    KytheVName  `json:"source"`
+	EdgeKind  string      `json:"edge_kind,omitempty"`
+	Target    *KytheVName `json:"target,omitempty"`
+	FactName  string      `json:"fact_name"`
+	FactValue []byte      `json:"fact_value,omitempty"`
+}
+
+// kytheSymbolData represents a symbol resolved across the Table.
+type kytheSymbolData struct {
+	vname KytheVName
+	name  string
+	defs  map[locationKey]bool // definitions, see definitions
+}
+
+// kytheExporter exports the Table to the Kythe entries.
+type kytheExporter struct {
+	table *Table
+	opts  KytheOptions
+	stats KytheStats
+
+	entries map[string]bool // the JSON encoded entries, which deduplicates the header entries of the several Files
+	files   map[string]bool // paths of the emitted file nodes
+	symbols map[ID]*kytheSymbolData
+}
+
+// ExportKythe writes the Kythe entries of the Files in t to w, in the newline delimited JSON of KytheEntry
+// which the Kythe entrystream tool reads.
+//
+// The file nodes are emitted for the files which have the anchors, with the UTF-8 text encoding. The anchors span
+// the symbol name from the byte offset of the declarations, definitions and callers, and bind the semantic node
+// of the symbol by the defines/binding edge, or the ref and ref/call edges of the callers. The semantic node is
+// named by the USR as the signature. The locations without the offset, such as of the imported index, are skipped
+// and counted in the SkippedOffset.
+//
+// The entries are sorted, so the output is deterministic regardless of the order of the Files.
+func ExportKythe(w io.Writer, t *Table, opts KytheOptions) (KytheStats, error) {
+	if !filepath.IsAbs(opts.ProjectRoot) {
+		return KytheStats{}, errors.Errorf("project root must be absolute: %q", opts.ProjectRoot)
+	}
+	e := &kytheExporter{
+		table:   t,
+		opts:    opts,
+		entries: make(map[string]bool),
+		files:   make(map[string]bool),
+		symbols: make(map[ID]*kytheSymbolData),
+	}
+	for _, f := range t.Files() {
+		if err := e.file(f); err != nil {
+			return e.stats, err
+		}
+	}
+
+	lines := make([]string, 0, len(e.entries))
+	for line := range e.entries {
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return e.stats, errors.Wrap(err, "could not write the entries")
+	}
+	e.stats.Entries = len(lines)
+
+	return e.stats, nil
+}
+
+// file emits the entries of the symbols of f.
+func (e *kytheExporter) file(f *File) error {
+	lang := kytheLanguage(f.Language())
+	for _, sym := range f.Symbols() {
+		data := e.symbol(sym, lang)
+		if err := e.node(sym, data); err != nil {
+			return err
+		}
+
+		def := sym.Def()
+		if !def.IsZero() && data.defs[keyOf(def)] {
+			if err := e.anchor(def, data, kytheEdgeDefinesBinding); err != nil {
+				return err
+			}
+		}
+		for _, decl := range sym.Decls() {
+			if sameLocation(decl, def) && data.defs[keyOf(def)] {
+				continue
+			}
+			if err := e.anchor(decl, data, kytheEdgeDefinesBinding); err != nil {
+				return err
+			}
+		}
+		for _, caller := range sym.Callers() {
+			edge := kytheEdgeRef
+			if caller.FuncCall() {
+				edge = kytheEdgeRefCall
+			}
+			if err := e.anchor(caller.Location(), data, edge); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// symbol returns the kytheSymbolData of sym, which is resolved once per symbol.
+func (e *kytheExporter) symbol(sym *Info, lang string) *kytheSymbolData {
+	id := sym.ID()
+	if data, ok := e.symbols[id]; ok {
+		return data
+	}
+
+	infos := e.table.Symbols(id)
+	data := &kytheSymbolData{
+		vname: KytheVName{Corpus: e.opts.Corpus, Root: e.opts.Root, Language: lang},
+		defs:  make(map[locationKey]bool),
+	}
+	defs, decls := definitions(infos)
+	for _, def := range defs {
+		data.defs[keyOf(def)] = true
+	}
+	for _, info := range infos {
+		if data.name == "" {
+			data.name = info.Name()
+		}
+	}
+	for _, loc := range append(defs, decls...) {
+		if usr := loc.USR(); usr != "" {
+			data.vname.Signature = usr
+			break
+		}
+	}
+	if data.vname.Signature == "" {
+		// the symbol recorded only by the callers
+		data.vname.Signature = id.String()
+	}
+	e.symbols[id] = data
+
+	return data
+}
+
+// node emits the facts of the semantic node of sym.
+func (e *kytheExporter) node(sym *Info, data *kytheSymbolData) error {
+	kind, subkind := kytheNodeKind(sym.SymbolKind())
+	if kind == "" {
+		return nil
+	}
+	if err := e.fact(data.vname, kytheFactNodeKind, kind); err != nil {
+		return err
+	}
+	if subkind != "" {
+		if err := e.fact(data.vname, kytheFactSubkind, subkind); err != nil {
+			return err
+		}
+	}
+	switch kind {
+	case "function", "record", "sum":
+		complete := "incomplete"
+		if len(data.defs) > 0 {
+			complete = "definition"
+		}
+		return e.fact(data.vname, kytheFactComplete, complete)
+	}
+
+	return nil
+}
+
+// anchor emits the anchor of loc spanning the name of the symbol, and the edge from it to the semantic node.
+func (e *kytheExporter) anchor(loc Location, data *kytheSymbolData, edge string) error {
+	if loc.Offset() == 0 {
+		e.stats.SkippedOffset++
+		return nil
+	}
+	if data.name == "" {
+		e.stats.SkippedExtent++
+		return nil
+	}
+	path, ok := e.relativePath(filepath.Clean(loc.FileName()))
+	if !ok {
+		e.stats.SkippedOutside++
+		return nil
+	}
+
+	file := KytheVName{Corpus: e.opts.Corpus, Root: e.opts.Root, Path: path}
+	if !e.files[path] {
+		e.files[path] = true
+		e.stats.Files++
+		if err := e.fact(file, kytheFactNodeKind, "file"); err != nil {
+			return err
+		}
+		if err := e.fact(file, kytheFactTextEncoding, "utf-8"); err != nil {
+			return err
+		}
+	}
+
+	start := loc.Offset()
+	end := start + uint32(len(data.name))
+	anchor := KytheVName{
+		Signature: "@" + strconv.FormatUint(uint64(start), 10) + ":" + strconv.FormatUint(uint64(end), 10),
+		Corpus:    e.opts.Corpus,
+		Root:      e.opts.Root,
+		Path:      path,
+		Language:  data.vname.Language,
+	}
+	n := len(e.entries)
+	for _, fact := range []struct{ name, value string }{
+		{kytheFactNodeKind, "anchor"},
+		{kytheFactLocStart, strconv.FormatUint(uint64(start), 10)},
+		{kytheFactLocEnd, strconv.FormatUint(uint64(end), 10)},
+	} {
+		if err := e.fact(anchor, fact.name, fact.value); err != nil {
+			return err
+		}
+	}
+	if len(e.entries) > n {
+		e.stats.Anchors++
+	}
+	if err := e.edge(anchor, kytheEdgeChildOf, file); err != nil {
+		return err
+	}
+
+	return e.edge(anchor, edge, data.vname)
+}
+
+func (e *kytheExporter) fact(source KytheVName, name, value string) error {
+	return e.add(KytheEntry{Source: source, FactName: name, FactValue: []byte(value)})
+}
+
+func (e *kytheExporter) edge(source KytheVName, kind string, target KytheVName) error {
+	return e.add(KytheEntry{Source: source, EdgeKind: kind, Target: &target, FactName: kytheFactEdge})
+}
+
+func (e *kytheExporter) add(entry KytheEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrapf(err, "could not encode the entry of %s", entry.Source.Signature)
+	}
+	e.entries[string(buf)] = true
+
+	return nil
+}
+
+// relativePath returns the path of fileName relative to the project root.
+func (e *kytheExporter) relativePath(fileName string) (string, bool) {
+	return projectRelativePath(e.opts.ProjectRoot, fileName)
+}
+
+// kytheNodeKind returns the Kythe node kind and subkind of the symbol of kind, or empty if kind has no node kind.
+func kytheNodeKind(kind SymbolKind) (string, string) {
+	switch kind {
+	case SymbolKindStruct:
+		return "record", "struct"
+	case SymbolKindUnion:
+		return "record", "union"
+	case SymbolKindClass, SymbolKindClassTemplate, SymbolKindClassTemplatePartialSpecialization:
+		return "record", "class"
+	case SymbolKindEnum:
+		return "sum", "enum"
+	case SymbolKindEnumConstant:
+		return "constant", ""
+	case SymbolKindFunction, SymbolKindFunctionTemplate, SymbolKindMethod, SymbolKindConversionFunction:
+		return "function", ""
+	case SymbolKindConstructor:
+		return "function", "constructor"
+	case SymbolKindDestructor:
+		return "function", "destructor"
+	case SymbolKindVariable, SymbolKindParameter:
+		return "variable", ""
+	case SymbolKindField:
+		return "variable", "field"
+	case SymbolKindTypedef, SymbolKindTypeAlias, SymbolKindTypeAliasTemplate:
+		return "talias", ""
+	case SymbolKindNamespace, SymbolKindNamespaceAlias:
+		return "package", "namespace"
+	case SymbolKindMacro:
+		return "macro", ""
+	case SymbolKindTemplateTypeParameter, SymbolKindNonTypeTemplateParameter, SymbolKindTemplateTemplateParameter:
+		return "absvar", ""
+	case SymbolKindObjCInterface:
+		return "record", "class"
+	case SymbolKindObjCCategory:
+		return "record", "category"
+	case SymbolKindObjCProtocol:
+		return "interface", ""
+	case SymbolKindObjCInstanceMethod:
+		return "function", ""
+	}
+	return "", ""
+}
+
+// kytheLanguage returns the Kythe language of lang, which is "c++" for the C and C++ as the Kythe C++ indexer.
+func kytheLanguage(lang Language) string {
+	switch lang {
+	case LanguageObjC, LanguageObjCXX:
+		return "objc"
+	}
+	return "c++"
+}
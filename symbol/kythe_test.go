@@ -0,0 +1,110 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// kytheTestFiles returns the Files of util.c which defines add, and main.c which calls add declared in util.h.
+func kytheTestFiles(t *testing.T) []*File {
+	const addUSR = "c:@F@add"
+	header := Location{fileName: "/repo/util.h", line: 1, col: 5, offset: 4, usr: addUSR, kind: clang.Cursor_FunctionDecl}
+
+	util := NewFile("/repo/util.c", nil)
+	util.AddDecl(header)
+	def := Location{fileName: "/repo/util.c", line: 3, col: 5, offset: 30, usr: addUSR, kind: clang.Cursor_FunctionDecl}
+	util.AddDefinition(def, def)
+	util.Symbol(ToID(addUSR)).name = "add"
+
+	mainc := NewFile("/repo/main.c", nil)
+	mainc.AddDecl(header)
+	mainDef := Location{fileName: "/repo/main.c", line: 3, col: 5, offset: 20, usr: "c:@F@main", kind: clang.Cursor_FunctionDecl}
+	mainc.AddDefinition(mainDef, mainDef)
+	mainc.Symbol(ToID("c:@F@main")).name = "main"
+	if err := mainc.AddCaller(Location{fileName: "/repo/main.c", line: 5, col: 10, offset: 60, usr: addUSR}, header, true); err != nil {
+		t.Fatal(err)
+	}
+	// the imported declaration without the offset, and the declaration outside of the project root
+	mainc.AddDecl(Location{fileName: "/repo/main.c", line: 2, col: 5, usr: "c:@count", kind: clang.Cursor_VarDecl})
+	mainc.Symbol(ToID("c:@count")).name = "count"
+	mainc.AddDecl(Location{fileName: "/usr/include/stdio.h", line: 300, col: 5, offset: 9000, usr: "c:@F@printf", kind: clang.Cursor_FunctionDecl})
+	mainc.Symbol(ToID("c:@F@printf")).name = "printf"
+
+	return []*File{util, mainc}
+}
+
+func exportKythe(t *testing.T, files []*File) (string, KytheStats) {
+	t.Helper()
+	var buf bytes.Buffer
+	stats, err := ExportKythe(&buf, NewTable(files), KytheOptions{Corpus: "example.com/repo", Root: "src", ProjectRoot: "/repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), stats
+}
+
+func TestExportKythe(t *testing.T) {
+	files := kytheTestFiles(t)
+	out, stats := exportKythe(t, files)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for i, line := range lines {
+		var entry KytheEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %d: %v: %s", i+1, err, line)
+		}
+		if entry.FactName == "" || (entry.EdgeKind == "") != (entry.Target == nil) {
+			t.Errorf("line %d: malformed entry %s", i+1, line)
+		}
+	}
+
+	for _, want := range []string{
+		// file node
+		`{"source":{"corpus":"example.com/repo","root":"src","path":"util.h"},"fact_name":"/kythe/node/kind","fact_value":"ZmlsZQ=="}`,
+		`{"source":{"corpus":"example.com/repo","root":"src","path":"util.h"},"fact_name":"/kythe/text/encoding","fact_value":"dXRmLTg="}`,
+		// semantic node
+		`{"source":{"signature":"c:@F@add","corpus":"example.com/repo","root":"src","language":"c++"},"fact_name":"/kythe/node/kind","fact_value":"ZnVuY3Rpb24="}`,
+		`{"source":{"signature":"c:@F@add","corpus":"example.com/repo","root":"src","language":"c++"},"fact_name":"/kythe/complete","fact_value":"ZGVmaW5pdGlvbg=="}`,
+		// anchor of the declaration in util.h, which both Files record
+		`{"source":{"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"fact_name":"/kythe/node/kind","fact_value":"YW5jaG9y"}`,
+		`{"source":{"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"fact_name":"/kythe/loc/start","fact_value":"NA=="}`,
+		`{"source":{"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"fact_name":"/kythe/loc/end","fact_value":"Nw=="}`,
+		`{"source":{"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"edge_kind":"/kythe/edge/childof","target":{"corpus":"example.com/repo","root":"src","path":"util.h"},"fact_name":"/"}`,
+		`{"source":{"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"edge_kind":"/kythe/edge/defines/binding","target":{"signature":"c:@F@add","corpus":"example.com/repo","root":"src","language":"c++"},"fact_name":"/"}`,
+		// anchor of the definition and the call
+		`{"source":{"signature":"@30:33","corpus":"example.com/repo","root":"src","path":"util.c","language":"c++"},"edge_kind":"/kythe/edge/defines/binding","target":{"signature":"c:@F@add","corpus":"example.com/repo","root":"src","language":"c++"},"fact_name":"/"}`,
+		`{"source":{"signature":"@60:63","corpus":"example.com/repo","root":"src","path":"main.c","language":"c++"},"edge_kind":"/kythe/edge/ref/call","target":{"signature":"c:@F@add","corpus":"example.com/repo","root":"src","language":"c++"},"fact_name":"/"}`,
+	} {
+		if strings.Count(out, want+"\n") != 1 {
+			t.Errorf("ExportKythe() has not exactly one entry\n%s", want)
+		}
+	}
+	// the referenced declaration in util.h is not the definition of main.c
+	if strings.Contains(out, `"signature":"@4:7","corpus":"example.com/repo","root":"src","path":"util.h","language":"c++"},"edge_kind":"/kythe/edge/ref`) {
+		t.Error("ExportKythe() has the ref edge of the declaration")
+	}
+
+	want := KytheStats{Entries: len(lines), Files: 3, Anchors: 4, SkippedOffset: 1, SkippedOutside: 1}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	// the output does not depend on the order of the Files
+	reversed, _ := exportKythe(t, []*File{roundTrip(files[1]), roundTrip(files[0])})
+	if reversed != out {
+		t.Errorf("ExportKythe() is not deterministic:\n%s\nwant:\n%s", reversed, out)
+	}
+
+	var buf bytes.Buffer
+	if _, err := ExportKythe(&buf, NewTable(files), KytheOptions{ProjectRoot: "repo"}); err == nil {
+		t.Error("ExportKythe() with the relative project root error = nil, want the error")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Language represents a source language of the File.
+//
+// The values are persisted in the index, so the existing values must not be changed.
+type Language uint8
+
+// The Language values.
+const (
+	LanguageUnknown Language = 0
+	LanguageC       Language = 1
+	LanguageCXX     Language = 2
+	LanguageObjC    Language = 3
+	LanguageObjCXX  Language = 4
+)
+
+// String returns the name of the language, which is the clang "-x" language name.
+func (l Language) String() string {
+	switch l {
+	case LanguageC:
+		return "c"
+	case LanguageCXX:
+		return "c++"
+	case LanguageObjC:
+		return "objective-c"
+	case LanguageObjCXX:
+		return "objective-c++"
+	default:
+		return "unknown"
+	}
+}
+
+// languageExts the source languages of the file extensions.
+// The header extensions such as ".h" are ambiguous, so they are determined by the flags.
+var languageExts = map[string]Language{
+	".c":   LanguageC,
+	".i":   LanguageC,
+	".cc":  LanguageCXX,
+	".cp":  LanguageCXX,
+	".cpp": LanguageCXX,
+	".cxx": LanguageCXX,
+	".c++": LanguageCXX,
+	".C":   LanguageCXX,
+	".CPP": LanguageCXX,
+	".ii":  LanguageCXX,
+	".hh":  LanguageCXX,
+	".hpp": LanguageCXX,
+	".hxx": LanguageCXX,
+	".m":   LanguageObjC,
+	".mi":  LanguageObjC,
+	".mm":  LanguageObjCXX,
+	".M":   LanguageObjCXX,
+}
+
+// DetectLanguage returns the source language of the file name compiled with flags, as clang determines it.
+//
+// The "-x" flag takes precedence over the file extension, and the "-std" flag of C or C++ determines
+// the language of the header. It returns LanguageUnknown if the language is undeterminable.
+func DetectLanguage(name string, flags []string) Language {
+	// the last "-x" flag applies to the file, as clang does
+	x := LanguageUnknown
+	for i := 0; i < len(flags); i++ {
+		var lang string
+		switch flag := flags[i]; {
+		case flag == "-x" && i+1 < len(flags):
+			i++
+			lang = flags[i]
+		case strings.HasPrefix(flag, "-x"):
+			lang = flag[len("-x"):]
+		default:
+			continue
+		}
+		switch strings.TrimSuffix(lang, "-header") {
+		case "c", "cpp-output":
+			x = LanguageC
+		case "c++", "c++-cpp-output":
+			x = LanguageCXX
+		case "objective-c":
+			x = LanguageObjC
+		case "objective-c++":
+			x = LanguageObjCXX
+		}
+	}
+	if x != LanguageUnknown {
+		return x
+	}
+
+	if lang, ok := languageExts[filepath.Ext(name)]; ok {
+		return lang
+	}
+
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-std=") {
+			continue
+		}
+		if std := flag[len("-std="):]; strings.Contains(std, "++") {
+			return LanguageCXX
+		} else if strings.HasPrefix(std, "c") || strings.HasPrefix(std, "gnu") || strings.HasPrefix(std, "iso9899") {
+			return LanguageC
+		}
+	}
+
+	return LanguageUnknown
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		want  Language
+	}{
+		{"main.c", []string{"-std=c11"}, LanguageC},
+		{"main.cpp", nil, LanguageCXX},
+		{"main.cc", []string{"-std=c++14"}, LanguageCXX},
+		{"main.m", nil, LanguageObjC},
+		{"main.mm", nil, LanguageObjCXX},
+		{"main.c", []string{"-x", "c++"}, LanguageCXX},
+		{"main.c", []string{"-xc++", "-x", "objective-c"}, LanguageObjC},
+		{"util.h", []string{"-x", "c++-header"}, LanguageCXX},
+		{"util.h", []string{"-std=gnu99"}, LanguageC},
+		{"util.h", []string{"-std=gnu++11"}, LanguageCXX},
+		{"util.h", nil, LanguageUnknown},
+		{"main.go", []string{"-x", "go"}, LanguageUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.name, tt.flags); got != tt.want {
+			t.Errorf("DetectLanguage(%q, %q) = %s, want %s", tt.name, tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestFile_Language(t *testing.T) {
+	tests := []struct {
+		name string
+		want Language
+	}{
+		{"/src/main.cpp", LanguageCXX},
+		{"/src/main.c", LanguageC},
+		{"/src/util.h", LanguageUnknown},
+	}
+	for _, tt := range tests {
+		f := NewFile(tt.name, nil)
+		if got := f.Language(); got != tt.want {
+			t.Errorf("%s: Language() = %s, want %s", tt.name, got, tt.want)
+		}
+		if got := roundTrip(f).Language(); got != tt.want {
+			t.Errorf("%s: serialized Language() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// the language of the translation unit overrides the detected language
+	f := NewFile("/src/util.h", nil)
+	f.SetLanguage(LanguageObjC)
+	got := roundTrip(f)
+	if got.Language() != LanguageObjC {
+		t.Errorf("serialized Language() = %s, want %s", got.Language(), LanguageObjC)
+	}
+	got.Unmarshal()
+	if got.Language() != LanguageObjC || !got.Equal(f) {
+		t.Errorf("unmarshaled Language() = %s, want %s", got.Language(), LanguageObjC)
+	}
+}
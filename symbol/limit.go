@@ -0,0 +1,222 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// ErrVectorTooLong is returned by OpenFile if the serialized File declares a vector longer than the buffer
+// can hold, or than the limit of SetMaxVectorLength.
+var ErrVectorTooLong = errors.New("vector length exceeds the limit")
+
+// maxVectorLength the maximum length of the vectors of the serialized File. The zero means no limit other than
+// the buffer size. It is accessed atomically.
+var maxVectorLength int64
+
+// SetMaxVectorLength sets the maximum length of the vectors which the serialized File can declare, such as the
+// symbols, headers and declarations. The longer vector is rejected by OpenFile, and read as empty by the accessors
+// instead of allocating for it. The byte vector of the packed locations is limited only by the buffer size.
+// The zero n limits the vectors only by the buffer size, which is the default.
+//
+// The limit is process-wide and meant to be set once at the initialization. Changing it later is race-free,
+// but the File opened before keeps reading the vectors which were validated by the previous limit.
+func SetMaxVectorLength(n int) {
+	atomic.StoreInt64(&maxVectorLength, int64(n))
+}
+
+// vectorLength returns the length of the vector of tab at slot, or -1 if the declared length does not fit in
+// the buffer or exceeds the limit. Every element of the vectors of tables occupies an offset at least.
+func vectorLength(tab flatbuffers.Table, slot flatbuffers.VOffsetT) int {
+	o := flatbuffers.UOffsetT(tab.Offset(slot))
+	if o == 0 {
+		return 0
+	}
+
+	n := tab.VectorLen(o)
+	if limit := atomic.LoadInt64(&maxVectorLength); limit > 0 && int64(n) > limit {
+		return -1
+	}
+	if start := int(tab.Vector(o)); n > (len(tab.Bytes)-start)/flatbuffers.SizeUOffsetT {
+		return -1
+	}
+
+	return n
+}
+
+// byteVectorLength is like vectorLength of the byte vector, which is limited only by the buffer size.
+func byteVectorLength(tab flatbuffers.Table, slot flatbuffers.VOffsetT) int {
+	o := flatbuffers.UOffsetT(tab.Offset(slot))
+	if o == 0 {
+		return 0
+	}
+
+	n := tab.VectorLen(o)
+	if start := int(tab.Vector(o)); n > len(tab.Bytes)-start {
+		return -1
+	}
+
+	return n
+}
+
+// safeLength returns the length of the vector of tab at slot, or zero if the length is invalid.
+func safeLength(tab flatbuffers.Table, slot flatbuffers.VOffsetT) int {
+	if n := vectorLength(tab, slot); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// OpenFile returns the File of the serialized buf, like GetRootAsFile, but validates the lengths of all vectors of
+// the File, the symbols, the callers and the diagnostics first, so the crafted buf which declares the huge vectors
+// is rejected with ErrVectorTooLong instead of allocating for them.
+func OpenFile(buf []byte) (f *File, err error) {
+	if err := checkRoot(buf); err != nil {
+		return nil, err
+	}
+
+	// the flatbuffers accessors panic on the corrupted buffer
+	defer func() {
+		if r := recover(); r != nil {
+			f, err = nil, errors.Errorf("corrupted buffer: %v", r)
+		}
+	}()
+
+	file := symbol.GetRootAsFile(buf, 0)
+	if err := checkVectors(file.Table(), "", fileVectors); err != nil {
+		return nil, err
+	}
+	n := vectorLength(file.Table(), fileSymbolsSlot)
+	if n < 0 {
+		return nil, errors.Wrapf(ErrVectorTooLong, "symbols")
+	}
+
+	info := new(symbol.Info)
+	caller := new(symbol.Caller)
+	for i := 0; i < n; i++ {
+		if !file.Symbols(info, i) {
+			continue
+		}
+		tab := info.Table()
+		if err := checkVectors(tab, fmt.Sprintf("symbols[%d] ", i), infoVectors); err != nil {
+			return nil, err
+		}
+		if byteVectorLength(tab, infoPackedLocationsSlot) < 0 {
+			return nil, errors.Wrapf(ErrVectorTooLong, "symbols[%d] packed locations", i)
+		}
+		m := vectorLength(tab, infoCallersSlot)
+		if m < 0 {
+			return nil, errors.Wrapf(ErrVectorTooLong, "symbols[%d] callers", i)
+		}
+		for j := 0; j < m; j++ {
+			if info.Callers(caller, j) && vectorLength(caller.Table(), callerArgsSlot) < 0 {
+				return nil, errors.Wrapf(ErrVectorTooLong, "symbols[%d] callers[%d] args", i, j)
+			}
+		}
+	}
+
+	diag := new(symbol.Diagnostic)
+	for i, n := 0, vectorLength(file.Table(), fileDiagnosticsSlot); i < n; i++ {
+		if file.Diagnostics(diag, i) && vectorLength(diag.Table(), diagnosticFixItsSlot) < 0 {
+			return nil, errors.Wrapf(ErrVectorTooLong, "diagnostics[%d] fix-its", i)
+		}
+	}
+
+	return GetRootAsFile(buf, 0), nil
+}
+
+// namedVector represents the slot of the vector which OpenFile validates, and its name for the error.
+type namedVector struct {
+	slot flatbuffers.VOffsetT
+	name string
+}
+
+// fileVectors and infoVectors are the vectors of the offsets of the File and Info tables, except the symbols and
+// callers whose elements OpenFile validates in turn.
+var (
+	fileVectors = []namedVector{
+		{fileFlagsSlot, "flags"},
+		{fileHeadersSlot, "headers"},
+		{fileIncludesSlot, "includes"},
+		{fileMetadataSlot, "metadata"},
+		{fileDiagnosticsSlot, "diagnostics"},
+	}
+	infoVectors = []namedVector{
+		{infoDeclsSlot, "decls"},
+		{infoParamsSlot, "params"},
+		{infoBasesSlot, "bases"},
+		{infoOverridesSlot, "overrides"},
+		{infoPackedStringsSlot, "packed strings"},
+	}
+)
+
+// checkVectors returns the error of the ErrVectorTooLong cause for the first invalid vector of tab in vectors,
+// whose name is prefixed by prefix.
+func checkVectors(tab flatbuffers.Table, prefix string, vectors []namedVector) error {
+	for _, v := range vectors {
+		if vectorLength(tab, v.slot) < 0 {
+			return errors.Wrapf(ErrVectorTooLong, "%s%s", prefix, v.name)
+		}
+	}
+	return nil
+}
+
+// HeadersOnly returns the headers of the serialized buf without reading the symbols, such as for the fast scan
+// of the include dependencies. The length of the headers vector is validated as same as OpenFile, but the symbols
+// are skipped entirely, so it is much faster than OpenFile for the File which has many symbols.
+//
+// The headers are copied out of buf, so they are valid after buf is reused.
+func HeadersOnly(buf []byte) (headers []*Header, err error) {
+	if err := checkRoot(buf); err != nil {
+		return nil, err
+	}
+
+	// the flatbuffers accessors panic on the corrupted buffer
+	defer func() {
+		if r := recover(); r != nil {
+			headers, err = nil, errors.Errorf("corrupted buffer: %v", r)
+		}
+	}()
+
+	file := symbol.GetRootAsFile(buf, 0)
+	n := vectorLength(file.Table(), fileHeadersSlot)
+	if n < 0 {
+		return nil, errors.Wrapf(ErrVectorTooLong, "headers")
+	}
+
+	headers = make([]*Header, 0, n)
+	obj := new(symbol.Header)
+	for i := 0; i < n; i++ {
+		if !file.Headers(obj, i) {
+			continue
+		}
+		h := Header{header: obj}
+		headers = append(headers, &Header{
+			fileid: h.FileID(),
+			mtime:  time.Unix(h.Mtime(), 0),
+			path:   h.Path(),
+			exists: h.Exists(),
+		})
+	}
+
+	return headers, nil
+}
+
+// checkRoot reports the error if buf is too short to have the root table.
+func checkRoot(buf []byte) error {
+	if len(buf) < flatbuffers.SizeUOffsetT {
+		return errors.Errorf("buffer too short: %d bytes", len(buf))
+	}
+	if root := flatbuffers.GetUOffsetT(buf); int(root) >= len(buf) {
+		return errors.Errorf("root table offset %d out of range", root)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-clang/v3.9/clang"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+func setMaxVectorLength(n int) func() {
+	orig := atomic.LoadInt64(&maxVectorLength)
+	SetMaxVectorLength(n)
+	return func() { SetMaxVectorLength(int(orig)) }
+}
+
+// setVectorLength overwrites the declared length of the vector of tab at slot.
+func setVectorLength(buf []byte, tab flatbuffers.Table, slot flatbuffers.VOffsetT, n uint32) {
+	vec := tab.Vector(flatbuffers.UOffsetT(tab.Offset(slot))) - flatbuffers.SizeUOffsetT
+	flatbuffers.WriteUint32(buf[vec:], n)
+}
+
+func TestOpenFile(t *testing.T) {
+	f := NewFile("main.c", nil)
+	for _, usr := range []string{"c:@F@main", "c:@F@add"} {
+		def := Location{fileName: "main.c", line: 1, col: 5, usr: usr}
+		f.AddDefinition(def, def)
+	}
+	f.addHeader("util.h", time.Time{})
+	buf := mustSerializeBytes(f)
+
+	got, err := OpenFile(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(got.Symbols()); n != 2 {
+		t.Errorf("len(Symbols()) = %d, want 2", n)
+	}
+
+	t.Run("absurd symbols", func(t *testing.T) {
+		buf := append([]byte(nil), buf...)
+		setVectorLength(buf, symbol.GetRootAsFile(buf, 0).Table(), fileSymbolsSlot, 1<<30)
+		if _, err := OpenFile(buf); errors.Cause(err) != ErrVectorTooLong {
+			t.Errorf("OpenFile() error = %v, want ErrVectorTooLong", err)
+		}
+		// the accessors read the vector as empty instead of allocating for it
+		if n := len(GetRootAsFile(buf, 0).Symbols()); n != 0 {
+			t.Errorf("len(Symbols()) = %d, want 0", n)
+		}
+	})
+
+	t.Run("absurd headers and decls", func(t *testing.T) {
+		buf := append([]byte(nil), buf...)
+		file := symbol.GetRootAsFile(buf, 0)
+		setVectorLength(buf, file.Table(), fileHeadersSlot, 1<<31)
+		info := new(symbol.Info)
+		file.Symbols(info, 0)
+		setVectorLength(buf, info.Table(), infoDeclsSlot, 1<<20)
+
+		if _, err := OpenFile(buf); errors.Cause(err) != ErrVectorTooLong {
+			t.Errorf("OpenFile() error = %v, want ErrVectorTooLong", err)
+		}
+		got := GetRootAsFile(buf, 0)
+		if n := len(got.Headers()); n != 0 {
+			t.Errorf("len(Headers()) = %d, want 0", n)
+		}
+		if n := len(got.Symbols()[0].Decls()); n != 0 {
+			t.Errorf("len(Decls()) = %d, want 0", n)
+		}
+	})
+
+	t.Run("absurd other vectors", func(t *testing.T) {
+		rich := NewFile("main.c", []string{"-DDEBUG"})
+		rich.SetMeta("host", "build-1")
+		def := Location{fileName: "main.c", line: 1, col: 5, usr: "c:@S@Derived@F@run#I#"}
+		rich.AddDefinition(def, def)
+		sym := rich.Symbol(ToID(def.usr))
+		sym.params = []*Param{{name: "n", typ: "int"}}
+		sym.bases = []string{"c:@S@Base"}
+		sym.overrides = []string{"c:@S@Base@F@run#I#"}
+		diag := NewDiagnostic(clang.Diagnostic_Error, "expected ';' after expression", def)
+		diag.fixIts = []FixIt{{rng: Range{start: def, end: def}, replacement: ";"}}
+		rich.AddDiagnostic(diag)
+		plain := mustSerializeBytes(rich)
+		rich.SetPackedLocations(true)
+		packed := mustSerializeBytes(rich)
+
+		tests := []struct {
+			name string
+			buf  []byte
+			tab  string // the table of the slot, "file", "info" or "diagnostic"
+			slot flatbuffers.VOffsetT
+		}{
+			{"flags", plain, "file", fileFlagsSlot},
+			{"metadata", plain, "file", fileMetadataSlot},
+			{"diagnostics", plain, "file", fileDiagnosticsSlot},
+			{"params", plain, "info", infoParamsSlot},
+			{"bases", plain, "info", infoBasesSlot},
+			{"overrides", plain, "info", infoOverridesSlot},
+			{"packed strings", packed, "info", infoPackedStringsSlot},
+			{"packed locations", packed, "info", infoPackedLocationsSlot},
+			{"fix-its", plain, "diagnostic", diagnosticFixItsSlot},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				buf := append([]byte(nil), tt.buf...)
+				file := symbol.GetRootAsFile(buf, 0)
+				tab := file.Table()
+				switch tt.tab {
+				case "info":
+					info := new(symbol.Info)
+					file.Symbols(info, 0)
+					tab = info.Table()
+				case "diagnostic":
+					diag := new(symbol.Diagnostic)
+					file.Diagnostics(diag, 0)
+					tab = diag.Table()
+				}
+				setVectorLength(buf, tab, tt.slot, 1<<31)
+
+				if _, err := OpenFile(buf); errors.Cause(err) != ErrVectorTooLong {
+					t.Errorf("OpenFile() error = %v, want ErrVectorTooLong", err)
+				}
+				// the accessors read the vector as empty instead of allocating for it
+				got := GetRootAsFile(buf, 0)
+				got.Flags()
+				got.FlagsBytes()
+				got.Meta("host")
+				for _, sym := range got.Symbols() {
+					sym.Params()
+					sym.Bases()
+					sym.Overrides()
+					sym.Decls()
+				}
+				for _, d := range got.Diagnostics() {
+					d.FixIts()
+				}
+				got.Unmarshal()
+			})
+		}
+	})
+
+	t.Run("SetMaxVectorLength", func(t *testing.T) {
+		defer setMaxVectorLength(1)()
+		if _, err := OpenFile(buf); errors.Cause(err) != ErrVectorTooLong {
+			t.Errorf("OpenFile() error = %v, want ErrVectorTooLong", err)
+		}
+		if n := len(GetRootAsFile(buf, 0).Symbols()); n != 0 {
+			t.Errorf("len(Symbols()) = %d, want 0", n)
+		}
+
+		SetMaxVectorLength(2)
+		if _, err := OpenFile(buf); err != nil {
+			t.Errorf("OpenFile() error = %v, want nil", err)
+		}
+	})
+
+	if _, err := OpenFile(buf[:2]); err == nil {
+		t.Error("OpenFile() of the short buffer error = nil, want the error")
+	}
+}
+
+// headerFields returns the fields of headers to compare.
+func headerFields(headers []*Header) []string {
+	var fields []string
+	for _, h := range headers {
+		fields = append(fields, fmt.Sprintf("%s %s %d %t", h.FileID(), h.Path(), h.Mtime(), h.Exists()))
+	}
+	return fields
+}
+
+func TestHeadersOnly(t *testing.T) {
+	f := NewFile("main.c", nil)
+	def := Location{fileName: "main.c", line: 1, col: 5, usr: "c:@F@main"}
+	f.AddDefinition(def, def)
+	f.addHeader("/usr/include/stdio.h", time.Unix(1500000000, 0))
+	f.addHeader("util.h", time.Unix(1500000100, 0))
+	f.addHeader("", time.Time{})
+	buf := mustSerializeBytes(f)
+
+	headers, err := HeadersOnly(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := headerFields(GetRootAsFile(buf, 0).Headers())
+	if got := headerFields(headers); len(got) != 3 || !reflect.DeepEqual(got, want) {
+		t.Errorf("HeadersOnly() = %v, want %v", got, want)
+	}
+	// the headers are copied out of buf
+	for i := range buf {
+		buf[i] = 0
+	}
+	if got := headerFields(headers); !reflect.DeepEqual(got, want) {
+		t.Errorf("HeadersOnly() after overwriting buf = %v, want %v", got, want)
+	}
+
+	if headers, err := HeadersOnly(mustSerializeBytes(NewFile("empty.c", nil))); err != nil || len(headers) != 0 {
+		t.Errorf("HeadersOnly() of the File without headers = %v, %v, want empty", headers, err)
+	}
+
+	buf = mustSerializeBytes(f)
+	setVectorLength(buf, symbol.GetRootAsFile(buf, 0).Table(), fileHeadersSlot, 1<<31)
+	if _, err := HeadersOnly(buf); errors.Cause(err) != ErrVectorTooLong {
+		t.Errorf("HeadersOnly() error = %v, want ErrVectorTooLong", err)
+	}
+	if _, err := HeadersOnly(buf[:2]); err == nil {
+		t.Error("HeadersOnly() of the short buffer error = nil, want the error")
+	}
+}
+
+func BenchmarkHeadersOnly(b *testing.B) {
+	const n = 50000
+	f := NewFile("main.c", nil)
+	for i := 0; i < n; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		loc := Location{fileName: "main.c", line: uint32(i + 1), col: 6, usr: usr}
+		f.AddDefinition(loc, loc)
+	}
+	for i := 0; i < 50; i++ {
+		f.addHeader(fmt.Sprintf("/usr/include/header%d.h", i), time.Unix(1500000000, 0))
+	}
+	buf := mustSerializeBytes(f)
+
+	b.Run("HeadersOnly", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := HeadersOnly(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("OpenFile", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f, err := OpenFile(buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			f.Unmarshal()
+			f.Headers()
+		}
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"sort"
+)
+
+// lineEntry is the declaration or definition line of the symbol in the line index of File.
+type lineEntry struct {
+	line uint32
+	info *Info
+}
+
+// SymbolsInRange return the symbols which declared or defined in fileName between the startLine and endLine,
+// inclusive. The symbols are ordered by the first line in the range, and each symbol appears once.
+//
+// The per-file index sorted by line is built on the first call, and rebuilt after the File is mutated.
+func (f *File) SymbolsInRange(fileName string, startLine, endLine uint32) []*Info {
+	entries := f.lines()[fileName]
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].line >= startLine
+	})
+
+	var symbols []*Info
+	seen := make(map[*Info]bool)
+	for ; i < len(entries) && entries[i].line <= endLine; i++ {
+		info := entries[i].info
+		if !seen[info] {
+			seen[info] = true
+			symbols = append(symbols, info)
+		}
+	}
+
+	return symbols
+}
+
+// lines returns the line index of f, which maps the file name to the declaration and definition lines sorted
+// by line, and the ties are ordered by the symbol ID.
+func (f *File) lines() map[string][]lineEntry {
+	f.lineMu.Lock()
+	defer f.lineMu.Unlock()
+	if f.lineIndex != nil {
+		return f.lineIndex
+	}
+
+	index := make(map[string][]lineEntry)
+	for _, sym := range f.Symbols() {
+		for _, decl := range sym.Decls() {
+			index[decl.FileName()] = append(index[decl.FileName()], lineEntry{line: decl.Line(), info: sym})
+		}
+		if def := sym.Def(); !def.IsZero() {
+			index[def.FileName()] = append(index[def.FileName()], lineEntry{line: def.Line(), info: sym})
+		}
+	}
+	for _, entries := range index {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].line != entries[j].line {
+				return entries[i].line < entries[j].line
+			}
+			a, b := entries[i].info.ID(), entries[j].info.ID()
+			return bytes.Compare(a[:], b[:]) < 0
+		})
+	}
+	f.lineIndex = index
+
+	return index
+}
+
+// LineHeat returns the number of the symbol references per line in f itself, such as for the reference density
+// heatmap of the code review. The declarations, definitions and callers located in the file of f name are
+// counted, and the definition which is also recorded as the declaration at the same location is counted once.
+func (f *File) LineHeat() map[uint32]int {
+	name := f.Name()
+	heat := make(map[uint32]int)
+	for _, sym := range f.Symbols() {
+		def := sym.Def()
+		defCounted := def.IsZero()
+		for _, decl := range sym.Decls() {
+			if decl.FileName() == name {
+				heat[decl.Line()]++
+			}
+			if sameLocation(decl, def) {
+				defCounted = true
+			}
+		}
+		if !defCounted && def.FileName() == name {
+			heat[def.Line()]++
+		}
+		for _, caller := range sym.Callers() {
+			if loc := caller.Location(); loc.FileName() == name {
+				heat[loc.Line()]++
+			}
+		}
+	}
+
+	return heat
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_SymbolsInRange(t *testing.T) {
+	f := NewFile("main.c", nil)
+	for _, sym := range []struct {
+		usr       string
+		decl, def Location
+	}{
+		{usr: "c:@F@before", def: Location{fileName: "main.c", line: 5}},
+		{usr: "c:@F@first", def: Location{fileName: "main.c", line: 10}},
+		{usr: "c:@F@inner", decl: Location{fileName: "main.h", line: 3}, def: Location{fileName: "main.c", line: 15}},
+		{usr: "c:@F@last", def: Location{fileName: "main.c", line: 20}},
+		{usr: "c:@F@after", def: Location{fileName: "main.c", line: 21}},
+		{usr: "c:@F@header", decl: Location{fileName: "main.h", line: 12}},
+	} {
+		sym.decl.usr, sym.def.usr = sym.usr, sym.usr
+		f.addSymbol(sym.usr, 0, sym.decl, sym.def)
+	}
+
+	symbolIDs := func(symbols []*Info) []ID {
+		var ids []ID
+		for _, sym := range symbols {
+			ids = append(ids, sym.ID())
+		}
+		return ids
+	}
+	want := []ID{ToID("c:@F@first"), ToID("c:@F@inner"), ToID("c:@F@last")}
+
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		if got := symbolIDs(f.SymbolsInRange("main.c", 10, 20)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: SymbolsInRange(main.c, 10, 20) = %v, want %v", name, got, want)
+		}
+		// main.h:12 is in the window, but the other file
+		if got := symbolIDs(f.SymbolsInRange("main.h", 1, 5)); !reflect.DeepEqual(got, []ID{ToID("c:@F@inner")}) {
+			t.Errorf("%s: SymbolsInRange(main.h, 1, 5) = %v, want inner only", name, got)
+		}
+		if got := f.SymbolsInRange("main.c", 30, 40); len(got) != 0 {
+			t.Errorf("%s: SymbolsInRange(main.c, 30, 40) = %d symbols, want 0", name, len(got))
+		}
+	}
+
+	// the index is rebuilt after the mutation
+	f.AddDefinition(Location{fileName: "main.c", line: 12, usr: "c:@F@added"}, Location{fileName: "main.c", line: 12, usr: "c:@F@added"})
+	if got := f.SymbolsInRange("main.c", 11, 13); len(got) != 1 || got[0].ID() != ToID("c:@F@added") {
+		t.Errorf("SymbolsInRange() after AddDefinition = %v, want the added symbol", symbolIDs(got))
+	}
+}
+
+func TestFile_LineHeat(t *testing.T) {
+	f := NewFile("main.c", nil)
+	def := Location{fileName: "main.c", line: 3, col: 5, usr: "c:@F@add"}
+	f.AddDefinition(def, def)
+	f.AddDecl(Location{fileName: "main.h", line: 1, col: 5, usr: "c:@F@add"})
+	f.AddDecl(Location{fileName: "main.c", line: 1, col: 5, usr: "c:@F@sub"})
+	for _, col := range []uint32{10, 20} {
+		// the two calls on the same line
+		if err := f.AddCaller(Location{fileName: "main.c", line: 7, col: col, usr: "c:@F@add"}, def, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[uint32]int{1: 1, 3: 1, 7: 2}
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		if got := f.LineHeat(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: LineHeat() = %v, want %v", name, got, want)
+		}
+	}
+}
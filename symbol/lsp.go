@@ -0,0 +1,169 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// LSPCompletionItemKind represents a Language Server Protocol CompletionItemKind.
+type LSPCompletionItemKind int
+
+// The LSP CompletionItemKind values.
+const (
+	LSPText          LSPCompletionItemKind = 1
+	LSPMethod        LSPCompletionItemKind = 2
+	LSPFunction      LSPCompletionItemKind = 3
+	LSPConstructor   LSPCompletionItemKind = 4
+	LSPField         LSPCompletionItemKind = 5
+	LSPVariable      LSPCompletionItemKind = 6
+	LSPClass         LSPCompletionItemKind = 7
+	LSPInterface     LSPCompletionItemKind = 8
+	LSPModule        LSPCompletionItemKind = 9
+	LSPProperty      LSPCompletionItemKind = 10
+	LSPUnit          LSPCompletionItemKind = 11
+	LSPValue         LSPCompletionItemKind = 12
+	LSPEnum          LSPCompletionItemKind = 13
+	LSPKeyword       LSPCompletionItemKind = 14
+	LSPSnippet       LSPCompletionItemKind = 15
+	LSPColor         LSPCompletionItemKind = 16
+	LSPFile          LSPCompletionItemKind = 17
+	LSPReference     LSPCompletionItemKind = 18
+	LSPFolder        LSPCompletionItemKind = 19
+	LSPEnumMember    LSPCompletionItemKind = 20
+	LSPConstant      LSPCompletionItemKind = 21
+	LSPStruct        LSPCompletionItemKind = 22
+	LSPEvent         LSPCompletionItemKind = 23
+	LSPOperator      LSPCompletionItemKind = 24
+	LSPTypeParameter LSPCompletionItemKind = 25
+)
+
+// LSPInsertTextFormat represents a Language Server Protocol InsertTextFormat.
+type LSPInsertTextFormat int
+
+// The LSP InsertTextFormat values.
+const (
+	LSPPlainTextFormat LSPInsertTextFormat = 1
+	LSPSnippetFormat   LSPInsertTextFormat = 2
+)
+
+// LSPCompletionItem represents a Language Server Protocol CompletionItem.
+type LSPCompletionItem struct {
+	Label            string                `json:"label"`
+	Kind             LSPCompletionItemKind `json:"kind,omitempty"`
+	Detail           string                `json:"detail,omitempty"`
+	Documentation    string                `json:"documentation,omitempty"`
+	Deprecated       bool                  `json:"deprecated,omitempty"`
+	SortText         string                `json:"sortText,omitempty"`
+	FilterText       string                `json:"filterText,omitempty"`
+	InsertText       string                `json:"insertText,omitempty"`
+	InsertTextFormat LSPInsertTextFormat   `json:"insertTextFormat,omitempty"`
+	Data             string                `json:"data,omitempty"`
+}
+
+// LSPCompletionList represents a Language Server Protocol CompletionList.
+type LSPCompletionList struct {
+	IsIncomplete bool                `json:"isIncomplete"`
+	Items        []LSPCompletionItem `json:"items"`
+}
+
+// LSPKind returns the LSP CompletionItemKind of the completion result of the cursor kind.
+func LSPKind(kind clang.CursorKind) LSPCompletionItemKind {
+	switch kind {
+	case clang.Cursor_CXXMethod,
+		clang.Cursor_ConversionFunction,
+		clang.Cursor_ObjCInstanceMethodDecl,
+		clang.Cursor_ObjCClassMethodDecl:
+		return LSPMethod
+	case clang.Cursor_FunctionDecl,
+		clang.Cursor_FunctionTemplate:
+		return LSPFunction
+	case clang.Cursor_Constructor,
+		clang.Cursor_Destructor:
+		return LSPConstructor
+	case clang.Cursor_FieldDecl,
+		clang.Cursor_ObjCIvarDecl:
+		return LSPField
+	case clang.Cursor_VarDecl,
+		clang.Cursor_ParmDecl:
+		return LSPVariable
+	case clang.Cursor_ClassDecl,
+		clang.Cursor_ClassTemplate,
+		clang.Cursor_ClassTemplatePartialSpecialization,
+		clang.Cursor_TypedefDecl,
+		clang.Cursor_TypeAliasDecl,
+		clang.Cursor_ObjCInterfaceDecl,
+		clang.Cursor_ObjCCategoryDecl:
+		return LSPClass
+	case clang.Cursor_StructDecl,
+		clang.Cursor_UnionDecl:
+		return LSPStruct
+	case clang.Cursor_ObjCProtocolDecl:
+		return LSPInterface
+	case clang.Cursor_ObjCPropertyDecl:
+		return LSPProperty
+	case clang.Cursor_Namespace,
+		clang.Cursor_NamespaceAlias:
+		return LSPModule
+	case clang.Cursor_EnumDecl:
+		return LSPEnum
+	case clang.Cursor_EnumConstantDecl:
+		return LSPEnumMember
+	case clang.Cursor_TemplateTypeParameter,
+		clang.Cursor_NonTypeTemplateParameter,
+		clang.Cursor_TemplateTemplateParameter:
+		return LSPTypeParameter
+	case clang.Cursor_NotImplemented:
+		// keywords and code patterns
+		return LSPKeyword
+	default:
+		return LSPText
+	}
+}
+
+// ToLSP converts c to the LSP CompletionItem.
+//
+// The snippet is used as the insert text only if it is written in the SnippetLSP syntax,
+// otherwise the plain word is inserted. The sort text is derived from the clang completion priority,
+// and the UserData payload is carried as the data.
+func (c *CompleteItem) ToLSP() LSPCompletionItem {
+	word := c.Word()
+	label := c.Abbr()
+	if label == "" {
+		label = word
+	}
+
+	item := LSPCompletionItem{
+		Label:            label,
+		Kind:             LSPKind(c.CursorKind()),
+		Detail:           c.Menu(),
+		Documentation:    c.Info(),
+		Deprecated:       c.Deprecated(),
+		SortText:         fmt.Sprintf("%010d%s", c.Priority(), word),
+		FilterText:       word,
+		InsertText:       word,
+		InsertTextFormat: LSPPlainTextFormat,
+		Data:             c.UserData(),
+	}
+	if snippet := c.Snippet(); snippet != "" && c.SnippetSyntax() == SnippetLSP {
+		item.InsertText = snippet
+		item.InsertTextFormat = LSPSnippetFormat
+	}
+
+	return item
+}
+
+// ToLSP converts c to the LSP CompletionList.
+func (c *CodeCompleteResults) ToLSP() LSPCompletionList {
+	results := c.Results()
+	items := make([]LSPCompletionItem, len(results))
+	for i := range results {
+		items[i] = results[i].ToLSP()
+	}
+
+	return LSPCompletionList{Items: items}
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// The golden files are regenerated by:
+//
+//  go test ./symbol -run TestToLSP -update
+func checkGolden(t *testing.T, name string, v interface{}) {
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "lsp", name+".json")
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestCompleteItem_ToLSP(t *testing.T) {
+	method := completionResult{
+		cursorKind: clang.Cursor_CXXMethod,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_ResultType, text: "void"},
+				{kind: clang.CompletionChunk_TypedText, text: "push_back"},
+				{kind: clang.CompletionChunk_LeftParen, text: "("},
+				{kind: clang.CompletionChunk_Placeholder, text: "const value_type &x"},
+				{kind: clang.CompletionChunk_RightParen, text: ")"},
+			},
+			priority: 37,
+			comment:  "Adds an element to the end.",
+		},
+	}
+	field := completionResult{
+		cursorKind: clang.Cursor_FieldDecl,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_ResultType, text: "int"},
+				{kind: clang.CompletionChunk_TypedText, text: "legacy_count"},
+			},
+			priority:     35,
+			availability: clang.Availability_Deprecated,
+		},
+	}
+	keyword := completionResult{
+		cursorKind: clang.Cursor_NotImplemented,
+		cs: fakeCompletionString{
+			chunks:   []fakeChunk{{kind: clang.CompletionChunk_TypedText, text: "return"}},
+			priority: 40,
+		},
+	}
+	ctor := completionResult{
+		cursorKind: clang.Cursor_Constructor,
+		cs: fakeCompletionString{
+			chunks: []fakeChunk{
+				{kind: clang.CompletionChunk_TypedText, text: "vector"},
+				{kind: clang.CompletionChunk_LeftParen, text: "("},
+				{kind: clang.CompletionChunk_RightParen, text: ")"},
+			},
+			priority: 50,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		opts    CompleteOptions
+		results []completionResult
+	}{
+		{name: "method_snippet", opts: CompleteOptions{Snippet: SnippetLSP}, results: []completionResult{method}},
+		{name: "method_plain", opts: CompleteOptions{Snippet: SnippetVim}, results: []completionResult{method}},
+		{name: "deprecated_field", opts: CompleteOptions{Snippet: SnippetLSP}, results: []completionResult{field}},
+		{name: "keyword", opts: CompleteOptions{Snippet: SnippetLSP}, results: []completionResult{keyword}},
+		{name: "constructor", opts: CompleteOptions{Snippet: SnippetLSP}, results: []completionResult{ctor}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := marshalResults(tt.opts, tt.results).Results()
+			checkGolden(t, tt.name, items[0].ToLSP())
+		})
+	}
+
+	t.Run("list", func(t *testing.T) {
+		c := marshalResults(CompleteOptions{Snippet: SnippetLSP}, []completionResult{method, field, keyword, ctor})
+		checkGolden(t, "list", c.ToLSP())
+	})
+}
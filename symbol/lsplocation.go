@@ -0,0 +1,173 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// LSPPosition represents a Language Server Protocol Position.
+// The Line is zero-based, and the Character is the zero-based offset in the UTF-16 code units.
+type LSPPosition struct {
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// LSPRange represents a Language Server Protocol Range. The End is exclusive.
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// LSPLocation represents a Language Server Protocol Location.
+type LSPLocation struct {
+	URI   string   `json:"uri"`
+	Range LSPRange `json:"range"`
+}
+
+// ToLSPPosition converts l to the LSP Position. The line is the text of the l line without the line terminator,
+// which is needed to convert the 1-based byte column of clang to the UTF-16 code units.
+//
+// The column in the middle of a multibyte character is rounded down to the start of the character,
+// and the column past the end of line is clamped to the end of line, as the LSP defines.
+func (l *Location) ToLSPPosition(line []byte) LSPPosition {
+	var pos LSPPosition
+	if n := l.Line(); n > 0 {
+		pos.Line = n - 1
+	}
+	col := l.rawCol()
+	if col == 0 {
+		return pos
+	}
+
+	end := int(col - 1)
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRune(line[i:])
+		if i+size > end {
+			break
+		}
+		pos.Character += utf16Len(r)
+		i += size
+	}
+
+	return pos
+}
+
+// FromLSPPosition returns the Location of the LSP Position pos in fileName. The line is the text of the pos line
+// without the line terminator, which is needed to convert the UTF-16 code units to the 1-based byte column.
+//
+// The character in the middle of the surrogate pair is rounded down to the start of the character,
+// and the character past the end of line is clamped to the end of line.
+// The returned Location has no file offset nor USR.
+func FromLSPPosition(fileName string, pos LSPPosition, line []byte) Location {
+	var units uint32
+	i := 0
+	for i < len(line) {
+		r, size := utf8.DecodeRune(line[i:])
+		n := utf16Len(r)
+		if units+n > pos.Character {
+			break
+		}
+		units += n
+		i += size
+	}
+
+	return Location{
+		fileName: fileName,
+		line:     pos.Line + 1,
+		col:      uint32(i + 1),
+	}
+}
+
+// ToLSPLocation converts l to the LSP Location of the empty range at l. The line is the text of the l line,
+// see ToLSPPosition.
+func (l *Location) ToLSPLocation(line []byte) LSPLocation {
+	pos := l.ToLSPPosition(line)
+	return LSPLocation{
+		URI:   FileURI(l.FileName()),
+		Range: LSPRange{Start: pos, End: pos},
+	}
+}
+
+// utf16Len returns the number of UTF-16 code units of r. The invalid UTF-8 byte is decoded as utf8.RuneError,
+// which is a code unit.
+func utf16Len(r rune) uint32 {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// FileURI returns the "file" URI of the absolute path fileName, which escapes the characters such as the space,
+// "#", "%" and the non-ASCII characters.
+func FileURI(fileName string) string {
+	path := filepath.ToSlash(fileName)
+	if !strings.HasPrefix(path, "/") {
+		// the Windows path such as "C:/src/main.c"
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+
+	return u.String()
+}
+
+// FileNameFromURI returns the file name of the "file" URI, which is the inverse of FileURI.
+func FileNameFromURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid URI %q", uri)
+	}
+	if u.Scheme != "file" {
+		return "", errors.Errorf("not a file URI: %q", uri)
+	}
+	path := u.Path
+	if filepath.Separator == '\\' && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		// the Windows path such as "/C:/src/main.c"
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path), nil
+}
+
+// LineIndex indexes the lines of the file contents, to look up the text of the line for the LSP conversions.
+type LineIndex struct {
+	content []byte
+	starts  []int // byte offset of the start of each line
+}
+
+// NewLineIndex returns the LineIndex of content. The lines are terminated by "\n" or "\r\n".
+func NewLineIndex(content []byte) *LineIndex {
+	idx := &LineIndex{
+		content: content,
+		starts:  []int{0},
+	}
+	for i, c := range content {
+		if c == '\n' {
+			idx.starts = append(idx.starts, i+1)
+		}
+	}
+
+	return idx
+}
+
+// Line returns the text of the 1-based line n without the line terminator, or nil if content has no such line.
+func (idx *LineIndex) Line(n uint32) []byte {
+	if n == 0 || int(n) > len(idx.starts) {
+		return nil
+	}
+	start := idx.starts[n-1]
+	end := len(idx.content)
+	if int(n) < len(idx.starts) {
+		end = idx.starts[n] - 1
+	}
+
+	return bytes.TrimSuffix(idx.content[start:end], []byte{'\r'})
+}
@@ -0,0 +1,253 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// lspLines the lines of the LSP conversion tests, which cover the multibyte characters and the characters
+// outside the BMP.
+var lspLines = []string{
+	"",
+	"int x;",
+	"café = 1;",             // 2 bytes, 1 unit
+	"s = \"€€\";",           // 3 bytes, 1 unit
+	"// \U0001F600 smile",   // 4 bytes, 2 units
+	"\U0001F600\U0001F601x", // adjacent surrogate pairs
+	"aé€\U0001F600b",        // all widths
+	"\tあいう;",                // CJK
+}
+
+// utf16Units returns the number of UTF-16 code units of s.
+func utf16Units(s string) uint32 {
+	return uint32(len(utf16.Encode([]rune(s))))
+}
+
+func TestLocation_ToLSPPosition(t *testing.T) {
+	for _, line := range lspLines {
+		// every character boundary
+		for i := 0; i <= len(line); {
+			loc := Location{fileName: "main.c", line: 3, col: uint32(i + 1)}
+			want := LSPPosition{Line: 2, Character: utf16Units(line[:i])}
+			if got := loc.ToLSPPosition([]byte(line)); got != want {
+				t.Errorf("%q: ToLSPPosition(col %d) = %+v, want %+v", line, i+1, got, want)
+			}
+			if got := FromLSPPosition("main.c", want, []byte(line)); got != loc {
+				t.Errorf("%q: FromLSPPosition(%+v) = %+v, want %+v", line, want, got, loc)
+			}
+			if i == len(line) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(line[i:])
+
+			// the columns in the middle of the character are rounded down
+			for j := 1; j < size; j++ {
+				mid := Location{line: 3, col: uint32(i + j + 1)}
+				if got := mid.ToLSPPosition([]byte(line)); got != want {
+					t.Errorf("%q: ToLSPPosition(col %d) in the middle = %+v, want %+v", line, i+j+1, got, want)
+				}
+			}
+			i += size
+		}
+
+		// past the end of line
+		end := LSPPosition{Line: 2, Character: utf16Units(line)}
+		past := Location{line: 3, col: uint32(len(line) + 10)}
+		if got := past.ToLSPPosition([]byte(line)); got != end {
+			t.Errorf("%q: ToLSPPosition(col %d) past the end = %+v, want %+v", line, past.col, got, end)
+		}
+		got := FromLSPPosition("main.c", LSPPosition{Line: 2, Character: end.Character + 10}, []byte(line))
+		if got.Col() != uint32(len(line)+1) {
+			t.Errorf("%q: FromLSPPosition() past the end col = %d, want %d", line, got.Col(), len(line)+1)
+		}
+	}
+}
+
+func TestFromLSPPosition_Surrogate(t *testing.T) {
+	line := []byte("a\U0001F600b")
+	for _, tt := range []struct {
+		character uint32
+		col       uint32
+	}{
+		{0, 1},
+		{1, 2},
+		{2, 2}, // the low surrogate is rounded down to the start of the character
+		{3, 6},
+		{4, 7},
+	} {
+		got := FromLSPPosition("main.c", LSPPosition{Character: tt.character}, line)
+		if got.Col() != tt.col || got.Line() != 1 {
+			t.Errorf("FromLSPPosition(character %d) = %d:%d, want 1:%d", tt.character, got.Line(), got.Col(), tt.col)
+		}
+	}
+}
+
+func TestLocation_ToLSPPosition_Unknown(t *testing.T) {
+	// the zero line and column are the unknown location of clang
+	loc := Location{}
+	if got := loc.ToLSPPosition([]byte("int x;")); got != (LSPPosition{}) {
+		t.Errorf("ToLSPPosition() of the zero Location = %+v, want zero", got)
+	}
+	// the invalid UTF-8 byte is a code unit
+	loc = Location{line: 1, col: 3}
+	if got := loc.ToLSPPosition([]byte{0xff, 0xfe, 'x'}); got.Character != 2 {
+		t.Errorf("ToLSPPosition() of the invalid UTF-8 = %+v, want character 2", got)
+	}
+}
+
+func TestLocation_ToLSPLocation(t *testing.T) {
+	loc := Location{fileName: "/src/my project/é#1.c", line: 2, col: 5}
+	got := loc.ToLSPLocation([]byte("éé x"))
+	want := LSPLocation{
+		URI:   "file:///src/my%20project/%C3%A9%231.c",
+		Range: LSPRange{Start: LSPPosition{Line: 1, Character: 2}, End: LSPPosition{Line: 1, Character: 2}},
+	}
+	if got != want {
+		t.Errorf("ToLSPLocation() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	for _, tt := range []struct {
+		fileName, uri string
+	}{
+		{"/src/main.c", "file:///src/main.c"},
+		{"/src/my project/main.c", "file:///src/my%20project/main.c"},
+		{"/src/a#b?c.c", "file:///src/a%23b%3Fc.c"},
+		{"/src/100%.c", "file:///src/100%25.c"},
+		{"/src/日本.c", "file:///src/%E6%97%A5%E6%9C%AC.c"},
+		{"/src/\U0001F600.c", "file:///src/%F0%9F%98%80.c"},
+	} {
+		if got := FileURI(tt.fileName); got != tt.uri {
+			t.Errorf("FileURI(%q) = %q, want %q", tt.fileName, got, tt.uri)
+		}
+		got, err := FileNameFromURI(tt.uri)
+		if err != nil || got != tt.fileName {
+			t.Errorf("FileNameFromURI(%q) = %q, %v, want %q", tt.uri, got, err, tt.fileName)
+		}
+	}
+
+	for _, uri := range []string{"http://example.com/main.c", "file://%zz"} {
+		if _, err := FileNameFromURI(uri); err == nil {
+			t.Errorf("FileNameFromURI(%q) error = nil", uri)
+		}
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	idx := NewLineIndex([]byte("int x;\r\n\ncafé;\nlast"))
+	for _, tt := range []struct {
+		n    uint32
+		want string
+	}{
+		{1, "int x;"},
+		{2, ""},
+		{3, "café;"},
+		{4, "last"},
+	} {
+		if got := string(idx.Line(tt.n)); got != tt.want {
+			t.Errorf("Line(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+	for _, n := range []uint32{0, 5} {
+		if got := idx.Line(n); got != nil {
+			t.Errorf("Line(%d) = %q, want nil", n, got)
+		}
+	}
+
+	// the conversion with the line of the index
+	loc := Location{fileName: "main.c", line: 3, col: 6}
+	if got := loc.ToLSPPosition(idx.Line(loc.Line())); got != (LSPPosition{Line: 2, Character: 4}) {
+		t.Errorf("ToLSPPosition() = %+v, want 2:4", got)
+	}
+}
+
+// setColumnBase sets the column base, and returns the function which restores it.
+func setColumnBase(base int) func() {
+	orig := loadColumnBase()
+	SetColumnBase(base)
+	return func() { SetColumnBase(int(orig)) }
+}
+
+func TestSetColumnBase(t *testing.T) {
+	loc := Location{fileName: "main.c", line: 3, col: 5}
+	serialized := func() Location {
+		var l Location
+		l.location = symbol.GetRootAsLocation(CreateLocation("main.c", 3, loc.Col()).FinishedBytes(), 0)
+		return l
+	}
+
+	// the location created in the default base 1
+	created := serialized()
+	func() {
+		defer setColumnBase(0)()
+		if got := loc.Col(); got != 4 {
+			t.Errorf("base 0: Col() = %d, want 4", got)
+		}
+		if got := created.Col(); got != 4 {
+			t.Errorf("base 0: Col() of the base 1 CreateLocation = %d, want 4", got)
+		}
+		if got := loc.Line(); got != 3 {
+			t.Errorf("base 0: Line() = %d, want 3", got)
+		}
+		if got := (Location{}).value(); got.Col() != 0 {
+			t.Errorf("base 0: Col() of the unknown column = %d, want 0", got.Col())
+		}
+
+		// the location created in the base 0
+		created0 := serialized()
+		if got := created0.Col(); got != 4 {
+			t.Errorf("base 0: Col() of the base 0 CreateLocation = %d, want 4", got)
+		}
+		if got := created0.ToLSPPosition([]byte("int main")); got.Character != 4 {
+			t.Errorf("base 0: ToLSPPosition() = %+v, want the character 4", got)
+		}
+		created = created0
+	}()
+
+	if got := created.Col(); got != 5 {
+		t.Errorf("base 1: Col() of the base 0 CreateLocation = %d, want 5", got)
+	}
+	if got := loc.Col(); got != 5 {
+		t.Errorf("base 1: Col() = %d, want 5", got)
+	}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(Location{fileName: "main.c", line: 3, col: 5, usr: "c:@F@main"})
+	func() {
+		defer setColumnBase(0)()
+		if f.LookupSymbolAt("main.c", 3, 4) == nil {
+			t.Error("base 0: LookupSymbolAt(3, 4) = nil")
+		}
+	}()
+	if f.LookupSymbolAt("main.c", 3, 5) == nil {
+		t.Error("base 1: LookupSymbolAt(3, 5) = nil")
+	}
+}
+
+func TestSetColumnBase_Concurrent(t *testing.T) {
+	defer setColumnBase(1)()
+
+	// the columns are read while the base is set, which the race detector reports without the atomic access
+	loc := Location{fileName: "main.c", line: 3, col: 5}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if col := loc.Col(); col != 4 && col != 5 {
+				t.Errorf("Col() = %d, want 4 or 5", col)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetColumnBase(i % 2)
+	}
+	<-done
+}
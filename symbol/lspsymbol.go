@@ -0,0 +1,184 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"sort"
+	"strings"
+)
+
+// LSPSymbolKind represents a Language Server Protocol SymbolKind.
+type LSPSymbolKind int
+
+// The LSP SymbolKind values.
+const (
+	LSPSymbolFile          LSPSymbolKind = 1
+	LSPSymbolModule        LSPSymbolKind = 2
+	LSPSymbolNamespace     LSPSymbolKind = 3
+	LSPSymbolPackage       LSPSymbolKind = 4
+	LSPSymbolClass         LSPSymbolKind = 5
+	LSPSymbolMethod        LSPSymbolKind = 6
+	LSPSymbolProperty      LSPSymbolKind = 7
+	LSPSymbolField         LSPSymbolKind = 8
+	LSPSymbolConstructor   LSPSymbolKind = 9
+	LSPSymbolEnum          LSPSymbolKind = 10
+	LSPSymbolInterface     LSPSymbolKind = 11
+	LSPSymbolFunction      LSPSymbolKind = 12
+	LSPSymbolVariable      LSPSymbolKind = 13
+	LSPSymbolConstant      LSPSymbolKind = 14
+	LSPSymbolString        LSPSymbolKind = 15
+	LSPSymbolNumber        LSPSymbolKind = 16
+	LSPSymbolBoolean       LSPSymbolKind = 17
+	LSPSymbolArray         LSPSymbolKind = 18
+	LSPSymbolObject        LSPSymbolKind = 19
+	LSPSymbolKey           LSPSymbolKind = 20
+	LSPSymbolNull          LSPSymbolKind = 21
+	LSPSymbolEnumMember    LSPSymbolKind = 22
+	LSPSymbolStruct        LSPSymbolKind = 23
+	LSPSymbolEvent         LSPSymbolKind = 24
+	LSPSymbolOperator      LSPSymbolKind = 25
+	LSPSymbolTypeParameter LSPSymbolKind = 26
+)
+
+// LSPWorkspaceSymbol represents a Language Server Protocol WorkspaceSymbol, which is the result of the
+// workspace/symbol request.
+type LSPWorkspaceSymbol struct {
+	Name          string        `json:"name"`
+	Kind          LSPSymbolKind `json:"kind"`
+	Location      LSPLocation   `json:"location"`
+	ContainerName string        `json:"containerName,omitempty"`
+}
+
+// ToLSPSymbolKind converts the SymbolKind to the LSP SymbolKind. The unknown kind is the variable.
+func ToLSPSymbolKind(kind SymbolKind) LSPSymbolKind {
+	switch kind {
+	case SymbolKindStruct, SymbolKindUnion:
+		return LSPSymbolStruct
+	case SymbolKindClass, SymbolKindClassTemplate, SymbolKindClassTemplatePartialSpecialization,
+		SymbolKindTypedef, SymbolKindTypeAlias, SymbolKindTypeAliasTemplate,
+		SymbolKindObjCInterface, SymbolKindObjCCategory:
+		return LSPSymbolClass
+	case SymbolKindObjCProtocol:
+		return LSPSymbolInterface
+	case SymbolKindEnum:
+		return LSPSymbolEnum
+	case SymbolKindEnumConstant:
+		return LSPSymbolEnumMember
+	case SymbolKindField:
+		return LSPSymbolField
+	case SymbolKindFunction, SymbolKindFunctionTemplate:
+		return LSPSymbolFunction
+	case SymbolKindMethod, SymbolKindConversionFunction, SymbolKindObjCInstanceMethod:
+		return LSPSymbolMethod
+	case SymbolKindConstructor, SymbolKindDestructor:
+		return LSPSymbolConstructor
+	case SymbolKindNamespace, SymbolKindNamespaceAlias:
+		return LSPSymbolNamespace
+	case SymbolKindTemplateTypeParameter, SymbolKindNonTypeTemplateParameter, SymbolKindTemplateTemplateParameter:
+		return LSPSymbolTypeParameter
+	case SymbolKindMacro:
+		return LSPSymbolConstant
+	default:
+		return LSPSymbolVariable
+	}
+}
+
+// WorkspaceSymbols returns the LSP WorkspaceSymbols of the symbols of f whose name contains query, ignoring
+// the case. The empty query matches all symbols. The symbols are ordered by the name, container name and
+// location, and the parameters and the symbols without the name or location are omitted.
+//
+// The location is the range of the name at the definition, or at the first declaration if the symbol has no
+// definition. The File has no contents to convert the byte columns, so the range is exact for the ASCII
+// lines only; use ToLSPPosition with the line text for the others. The container name is the qualified
+// name of the enclosing namespaces and records, such as "ns::Outer::Inner" of the method of Inner.
+func (f *File) WorkspaceSymbols(query string) []LSPWorkspaceSymbol {
+	query = strings.ToLower(query)
+	var symbols []LSPWorkspaceSymbol
+	for _, sym := range f.Symbols() {
+		name := sym.Name()
+		if name == "" || !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		switch sym.SymbolKind() {
+		case SymbolKindParameter, SymbolKindTemplateTypeParameter, SymbolKindNonTypeTemplateParameter, SymbolKindTemplateTemplateParameter:
+			continue
+		}
+
+		loc := sym.Def()
+		if loc.IsZero() {
+			decls := sym.Decls()
+			if len(decls) == 0 {
+				continue
+			}
+			loc = decls[0]
+		}
+		symbols = append(symbols, LSPWorkspaceSymbol{
+			Name:          name,
+			Kind:          ToLSPSymbolKind(sym.SymbolKind()),
+			Location:      lspNameLocation(loc, name),
+			ContainerName: f.containerName(sym),
+		})
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := symbols[i], symbols[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.ContainerName != b.ContainerName {
+			return a.ContainerName < b.ContainerName
+		}
+		if a.Location.URI != b.Location.URI {
+			return a.Location.URI < b.Location.URI
+		}
+		return a.Location.Range.Start.Line < b.Location.Range.Start.Line
+	})
+
+	return symbols
+}
+
+// containerName returns the qualified name of the enclosing namespaces and records of sym. The enclosing
+// records are resolved from the symbols of f, and omitted if f does not have them.
+func (f *File) containerName(sym *Info) string {
+	var names []string
+	seen := map[ID]bool{sym.ID(): true}
+	for usr := sym.ParentUSR(); usr != ""; {
+		parent := f.Symbol(ToID(usr))
+		if parent == nil || seen[parent.ID()] || parent.Name() == "" {
+			break
+		}
+		if kind := parent.SymbolKind(); kind == SymbolKindNamespace || kind == SymbolKindNamespaceAlias {
+			break
+		}
+		seen[parent.ID()] = true
+		names = append(names, parent.Name())
+		usr = parent.ParentUSR()
+	}
+
+	if ns := sym.Namespace(); ns != "" {
+		names = append(names, ns)
+	}
+	return joinNamespace(names)
+}
+
+// lspNameLocation returns the LSP Location of the range of name at loc, assuming the line is ASCII.
+func lspNameLocation(loc Location, name string) LSPLocation {
+	var start LSPPosition
+	if n := loc.Line(); n > 0 {
+		start.Line = n - 1
+	}
+	if col := loc.rawCol(); col > 0 {
+		start.Character = col - 1
+	}
+	end := start
+	for _, r := range name {
+		end.Character += utf16Len(r)
+	}
+
+	return LSPLocation{
+		URI:   FileURI(loc.FileName()),
+		Range: LSPRange{Start: start, End: end},
+	}
+}
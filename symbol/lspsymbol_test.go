@@ -0,0 +1,82 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestFile_WorkspaceSymbols(t *testing.T) {
+	const (
+		ns    = "c:@N@geom"
+		shape = "c:@N@geom@S@Shape"
+		area  = "c:@N@geom@S@Shape@F@area#"
+	)
+	f := NewFile("/src/shape.cpp", nil)
+	for _, sym := range []struct {
+		usr, name, namespace, parent string
+		kind                         clang.CursorKind
+		decl, def                    Location
+	}{
+		{usr: ns, name: "geom", kind: clang.Cursor_Namespace,
+			def: Location{fileName: "/src/shape.hpp", line: 1, col: 11}},
+		{usr: shape, name: "Shape", namespace: "geom", parent: ns, kind: clang.Cursor_ClassDecl,
+			def: Location{fileName: "/src/shape.hpp", line: 2, col: 7}},
+		{usr: area, name: "area", namespace: "geom", parent: shape, kind: clang.Cursor_CXXMethod,
+			decl: Location{fileName: "/src/shape.hpp", line: 3, col: 10},
+			def:  Location{fileName: "/src/shape.cpp", line: 5, col: 15}},
+		{usr: "c:@F@areaOf#", name: "areaOf", kind: clang.Cursor_FunctionDecl,
+			decl: Location{fileName: "/src/shape.hpp", line: 8, col: 8}},
+		{usr: "c:shape.cpp@60@F@areaOf#@s", name: "area_of_shape", parent: "c:@F@areaOf#", kind: clang.Cursor_ParmDecl,
+			decl: Location{fileName: "/src/shape.hpp", line: 8, col: 22}},
+		{usr: "c:@Ea@x", kind: clang.Cursor_EnumDecl,
+			def: Location{fileName: "/src/shape.hpp", line: 10, col: 1}},
+	} {
+		for _, loc := range []*Location{&sym.decl, &sym.def} {
+			if loc.fileName != "" {
+				loc.usr = sym.usr
+			}
+		}
+		info := f.addSymbol(sym.usr, sym.kind, sym.decl, sym.def)
+		info.name, info.namespace, info.parentUSR = sym.name, sym.namespace, sym.parent
+	}
+
+	hpp, cpp := FileURI("/src/shape.hpp"), FileURI("/src/shape.cpp")
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		got := f.WorkspaceSymbols("AREA")
+		want := []LSPWorkspaceSymbol{
+			{
+				Name:          "area",
+				Kind:          LSPSymbolMethod,
+				Location:      LSPLocation{URI: cpp, Range: LSPRange{Start: LSPPosition{Line: 4, Character: 14}, End: LSPPosition{Line: 4, Character: 18}}},
+				ContainerName: "geom::Shape",
+			},
+			{
+				// no definition
+				Name:     "areaOf",
+				Kind:     LSPSymbolFunction,
+				Location: LSPLocation{URI: hpp, Range: LSPRange{Start: LSPPosition{Line: 7, Character: 7}, End: LSPPosition{Line: 7, Character: 13}}},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: WorkspaceSymbols(%q) = %+v, want %+v", name, "AREA", got, want)
+		}
+
+		var names []string
+		for _, sym := range f.WorkspaceSymbols("") {
+			names = append(names, sym.ContainerName+"/"+sym.Name)
+		}
+		// the parameter and the anonymous enum are omitted
+		if want := []string{"geom/Shape", "geom::Shape/area", "/areaOf", "/geom"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: WorkspaceSymbols(\"\") = %v, want %v", name, names, want)
+		}
+		if got := f.WorkspaceSymbols("volume"); len(got) != 0 {
+			t.Errorf("%s: WorkspaceSymbols(%q) = %+v, want none", name, "volume", got)
+		}
+	}
+}
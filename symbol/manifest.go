@@ -0,0 +1,106 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Manifest lists the serialized Files of a directory, so the tools can discover them without reading each File.
+type Manifest struct {
+	Files []ManifestEntry
+}
+
+// ManifestEntry describes a File in the Manifest.
+type ManifestEntry struct {
+	Name    string
+	Symbols int       // number of USRs
+	BuiltAt time.Time // time when the manifest was written
+	Headers []FileID  // FileIDs of the included headers
+}
+
+// manifest is the JSON encoding of Manifest.
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+// manifestEntry is the JSON encoding of ManifestEntry, which encodes the header FileIDs to the hexadecimal strings.
+type manifestEntry struct {
+	Name    string   `json:"name"`
+	Symbols int      `json:"symbols"`
+	BuiltAt int64    `json:"built_at"` // time.Time.Unix()
+	Headers []string `json:"headers"`
+}
+
+// manifestNow returns the current time for the BuiltAt. The tests replace it to make the manifest deterministic.
+var manifestNow = time.Now
+
+// WriteManifest writes the JSON manifest of files to w, one entry per File in the order of files.
+func WriteManifest(w io.Writer, files []*File) error {
+	builtAt := manifestNow().Unix()
+
+	entries := make([]manifestEntry, len(files))
+	for i, f := range files {
+		hdrs := f.Headers()
+		entry := manifestEntry{
+			Name:    f.Name(),
+			Symbols: f.symbolsLength(),
+			BuiltAt: builtAt,
+			Headers: make([]string, len(hdrs)),
+		}
+		for j, hdr := range hdrs {
+			entry.Headers[j] = hdr.FileID().String()
+		}
+		entries[i] = entry
+	}
+
+	if err := json.NewEncoder(w).Encode(manifest{Files: entries}); err != nil {
+		return errors.Wrap(err, "could not write the manifest")
+	}
+
+	return nil
+}
+
+// ReadManifest reads the JSON manifest which written by WriteManifest from r.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var v manifest
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "could not read the manifest")
+	}
+
+	m := &Manifest{Files: make([]ManifestEntry, len(v.Files))}
+	for i, entry := range v.Files {
+		hdrs := make([]FileID, len(entry.Headers))
+		for j, s := range entry.Headers {
+			id, err := ParseFileID(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "files[%d].headers[%d]", i, j)
+			}
+			hdrs[j] = id
+		}
+		m.Files[i] = ManifestEntry{
+			Name:    entry.Name,
+			Symbols: entry.Symbols,
+			BuiltAt: time.Unix(entry.BuiltAt, 0),
+			Headers: hdrs,
+		}
+	}
+
+	return m, nil
+}
+
+// symbolsLength returns the number of symbols in f, without allocating the symbols of the flatbuffers File.
+func (f *File) symbolsLength() int {
+	f.hydrate()
+	if len(f.symbols) > 0 || f.file == nil {
+		return len(f.symbols)
+	}
+
+	return safeLength(f.file.Table(), fileSymbolsSlot)
+}
@@ -0,0 +1,57 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteManifest(t *testing.T) {
+	builtAt := time.Unix(1500000000, 0)
+	orig := manifestNow
+	manifestNow = func() time.Time { return builtAt }
+	defer func() { manifestNow = orig }()
+
+	a := shardFile(3, 16)
+	b := NewFile("b.c", nil)
+	b.AddDecl(Location{fileName: "b.c", line: 1, col: 5, usr: "c:@F@b"})
+	b.addHeader("b.h", builtAt)
+	b.addHeader("common.h", builtAt)
+	c := GetRootAsFile(mustSerializeBytes(shardFile(5, 16)), 0) // the flatbuffers File
+	files := []*File{a, b, c}
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, files); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+	got, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+
+	want := &Manifest{
+		Files: []ManifestEntry{
+			{Name: "main.c", Symbols: 3, BuiltAt: builtAt, Headers: []FileID{ToFileID("main.h")}},
+			{Name: "b.c", Symbols: 1, BuiltAt: builtAt, Headers: []FileID{ToFileID("b.h"), ToFileID("common.h")}},
+			{Name: "main.c", Symbols: 5, BuiltAt: builtAt, Headers: []FileID{ToFileID("main.h")}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadManifest() = %+v, want %+v", got, want)
+	}
+
+	for _, data := range []string{
+		"{",
+		`{"files":[{"name":"a.c","headers":["xyz"]}]}`,
+	} {
+		if _, err := ReadManifest(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadManifest(%q) error = nil", data)
+		}
+	}
+}
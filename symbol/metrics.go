@@ -0,0 +1,116 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives the operational metrics of the index operations, without binding to a specific metrics
+// library. The implementation must be safe for concurrent use.
+type Metrics interface {
+	// IncCounter adds delta to the counter of name.
+	IncCounter(name string, delta float64)
+	// ObserveHistogram records v into the histogram of name.
+	ObserveHistogram(name string, v float64)
+}
+
+// The metric names which the package records. The durations are in seconds, and the sizes are in bytes.
+const (
+	MetricTableFilesAdded       = "symbol_table_files_added_total"       // counter
+	MetricTableFilesRemoved     = "symbol_table_files_removed_total"     // counter
+	MetricSerializeBytes        = "symbol_serialize_bytes"               // histogram
+	MetricSerializeSeconds      = "symbol_serialize_duration_seconds"    // histogram
+	MetricCompletionCacheHits   = "symbol_completion_cache_hits_total"   // counter
+	MetricCompletionCacheMisses = "symbol_completion_cache_misses_total" // counter
+	MetricDefinitionSeconds     = "symbol_definition_duration_seconds"   // histogram of Resolver.Definition
+	MetricSearchSeconds         = "symbol_search_duration_seconds"       // histogram of SymbolIndex.Search
+)
+
+// nopMetrics discards the metrics.
+type nopMetrics struct{}
+
+func (nopMetrics) IncCounter(string, float64)       {}
+func (nopMetrics) ObserveHistogram(string, float64) {}
+
+// metricsHolder holds the package Metrics, since atomic.Value needs the same concrete type.
+type metricsHolder struct {
+	m Metrics
+}
+
+var packageMetrics atomic.Value // metricsHolder
+
+// SetMetrics sets the package Metrics, which records the operations of the File, and of the Table and
+// CompletionCache which have no own Metrics. The nil m discards the metrics, which is the default.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	packageMetrics.Store(metricsHolder{m: m})
+}
+
+// metricsOf returns m, or the package Metrics if m is nil.
+func metricsOf(m Metrics) Metrics {
+	if m != nil {
+		return m
+	}
+	if h, ok := packageMetrics.Load().(metricsHolder); ok {
+		return h.m
+	}
+	return nopMetrics{}
+}
+
+// observeSince records the seconds elapsed since start into the histogram of name.
+func observeSince(m Metrics, name string, start time.Time) {
+	m.ObserveHistogram(name, time.Since(start).Seconds())
+}
+
+// MemoryMetrics is the in-memory Metrics, such as for the assertions of the tests.
+// The zero value is ready to use, and it is safe for concurrent use.
+type MemoryMetrics struct {
+	mu           sync.Mutex
+	counters     map[string]float64
+	observations map[string][]float64
+}
+
+// IncCounter implements Metrics.
+func (m *MemoryMetrics) IncCounter(name string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counters == nil {
+		m.counters = make(map[string]float64)
+	}
+	m.counters[name] += delta
+}
+
+// ObserveHistogram implements Metrics.
+func (m *MemoryMetrics) ObserveHistogram(name string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.observations == nil {
+		m.observations = make(map[string][]float64)
+	}
+	m.observations[name] = append(m.observations[name], v)
+}
+
+// Counter returns the value of the counter of name.
+func (m *MemoryMetrics) Counter(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counters[name]
+}
+
+// Observations returns the copy of the values recorded into the histogram of name, in the recorded order.
+func (m *MemoryMetrics) Observations(name string) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]float64(nil), m.observations[name]...)
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	pkg := new(MemoryMetrics)
+	SetMetrics(pkg)
+	defer SetMetrics(nil)
+
+	f := NewFile("main.c", nil)
+	def := Location{fileName: "main.c", line: 3, col: 5, usr: "c:@F@add"}
+	f.AddDefinition(def, def)
+	f.Symbol(ToID("c:@F@add")).name = "add"
+
+	m := new(MemoryMetrics)
+	table := NewTable(nil)
+	table.SetMetrics(m)
+	table.Update(f)
+	table.Update(f) // replaces the File
+	table.Remove("main.c")
+	table.Remove("main.c") // no File to remove
+	table.Update(f)
+	if _, err := new(Resolver).Definition(table, "main.c", 3, 5); err != nil {
+		t.Fatal(err)
+	}
+	NewSymbolIndex(table).Search("add", 0)
+
+	cache := NewCompletionCache(1024, 0)
+	cache.SetMetrics(m)
+	key := NewCompletionKey("main.c", 3, 5, nil, nil)
+	cache.Get(key)
+	cache.Put(key, []byte("results"))
+	cache.Get(key)
+	cache.Get(key)
+
+	buf := mustSerializeBytes(f)
+
+	counters := map[string]float64{
+		MetricTableFilesAdded:       3,
+		MetricTableFilesRemoved:     2,
+		MetricCompletionCacheHits:   2,
+		MetricCompletionCacheMisses: 1,
+		MetricSerializeBytes:        0,
+	}
+	for name, want := range counters {
+		if got := m.Counter(name); got != want {
+			t.Errorf("Counter(%s) = %v, want %v", name, got, want)
+		}
+		if got := pkg.Counter(name); got != 0 {
+			t.Errorf("package Counter(%s) = %v, want 0", name, got)
+		}
+	}
+
+	histograms := []struct {
+		m    *MemoryMetrics
+		name string
+		n    int
+	}{
+		{m, MetricDefinitionSeconds, 1},
+		{m, MetricSearchSeconds, 1},
+		{m, MetricSerializeSeconds, 0},
+		{pkg, MetricSerializeSeconds, 1},
+		{pkg, MetricDefinitionSeconds, 0},
+	}
+	for _, h := range histograms {
+		got := h.m.Observations(h.name)
+		if len(got) != h.n {
+			t.Errorf("Observations(%s) = %v, want %d observations", h.name, got, h.n)
+		}
+		for _, v := range got {
+			if v < 0 {
+				t.Errorf("Observations(%s) = %v, want non-negative durations", h.name, got)
+			}
+		}
+	}
+	if got, want := pkg.Observations(MetricSerializeBytes), []float64{float64(len(buf))}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Observations(%s) = %v, want %v", MetricSerializeBytes, got, want)
+	}
+}
+
+// promCounter and promObserver are satisfied by prometheus.Counter and prometheus.Histogram.
+type promCounter interface {
+	Add(float64)
+}
+
+type promObserver interface {
+	Observe(float64)
+}
+
+// prometheusMetrics is the example adapter of Metrics for the Prometheus client, such as
+//
+//	SetMetrics(prometheusMetrics{
+//		counters: map[string]promCounter{
+//			MetricTableFilesAdded: promauto.NewCounter(prometheus.CounterOpts{Name: MetricTableFilesAdded}),
+//		},
+//		histograms: map[string]promObserver{
+//			MetricSerializeSeconds: promauto.NewHistogram(prometheus.HistogramOpts{Name: MetricSerializeSeconds}),
+//		},
+//	})
+//
+// The metrics which are not registered are ignored.
+type prometheusMetrics struct {
+	counters   map[string]promCounter
+	histograms map[string]promObserver
+}
+
+func (m prometheusMetrics) IncCounter(name string, delta float64) {
+	if c, ok := m.counters[name]; ok {
+		c.Add(delta)
+	}
+}
+
+func (m prometheusMetrics) ObserveHistogram(name string, v float64) {
+	if h, ok := m.histograms[name]; ok {
+		h.Observe(v)
+	}
+}
+
+type fakePromMetric struct {
+	values []float64
+}
+
+func (m *fakePromMetric) Add(v float64)     { m.values = append(m.values, v) }
+func (m *fakePromMetric) Observe(v float64) { m.values = append(m.values, v) }
+
+func TestMetrics_Prometheus(t *testing.T) {
+	added, sizes := new(fakePromMetric), new(fakePromMetric)
+	SetMetrics(prometheusMetrics{
+		counters:   map[string]promCounter{MetricTableFilesAdded: added},
+		histograms: map[string]promObserver{MetricSerializeBytes: sizes},
+	})
+	defer SetMetrics(nil)
+
+	f := NewFile("main.c", nil)
+	NewTable([]*File{f, NewFile("util.c", nil)})
+	buf := mustSerializeBytes(f)
+
+	if want := []float64{1, 1}; !reflect.DeepEqual(added.values, want) {
+		t.Errorf("%s = %v, want %v", MetricTableFilesAdded, added.values, want)
+	}
+	if want := []float64{float64(len(buf))}; !reflect.DeepEqual(sizes.values, want) {
+		t.Errorf("%s = %v, want %v", MetricSerializeBytes, sizes.values, want)
+	}
+}
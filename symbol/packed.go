@@ -0,0 +1,247 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"encoding/binary"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// packedLocations represents the Decls, Def and Callers of the Info which encoded to the compact byte blob.
+//
+// The blob is the sequence of the unsigned varints:
+//
+//  numDecls  location...
+//  hasDef    [location]
+//  numCallers (location callerFlags [numArgs location...])...
+//
+// and each location is encoded as:
+//
+//  fileName line col offset flags [usr]
+//
+// where the flags bit 0 is set if the location has the usr, and bit 1 if it is the forward declaration.
+// The callerFlags bit 0 is set if the caller is the function call, and bit 1 if it has the arguments, so the
+// blob of the callers without the arguments is as same as of the older versions.
+// The fileName and usr are the index of the strings table. The strings table is shared by
+// all locations of the Info, so the same file name and USR are stored only once.
+type packedLocations struct {
+	buf     []byte
+	strings []string
+	index   map[string]uint64
+}
+
+// packLocations encodes decls, def and callers to the packed byte blob and the strings table.
+func packLocations(decls []Location, def Location, callers []*Caller) ([]byte, []string) {
+	p := &packedLocations{index: make(map[string]uint64)}
+
+	p.putUvarint(uint64(len(decls)))
+	for _, decl := range decls {
+		p.putLocation(decl)
+	}
+
+	if !def.IsZero() {
+		p.putUvarint(1)
+		p.putLocation(def)
+	} else {
+		p.putUvarint(0)
+	}
+
+	p.putUvarint(uint64(len(callers)))
+	for _, caller := range callers {
+		p.putLocation(caller.Location())
+		var flags uint64
+		if caller.FuncCall() {
+			flags |= packedFuncCall
+		}
+		args := caller.Args()
+		if len(args) > 0 {
+			flags |= packedHasArgs
+		}
+		p.putUvarint(flags)
+		if len(args) > 0 {
+			p.putUvarint(uint64(len(args)))
+			for _, arg := range args {
+				p.putLocation(arg)
+			}
+		}
+	}
+
+	return p.buf, p.strings
+}
+
+func (p *packedLocations) putUvarint(x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	p.buf = append(p.buf, tmp[:n]...)
+}
+
+// putString puts the index of s in the strings table, and adds s to it if not exist.
+func (p *packedLocations) putString(s string) {
+	i, ok := p.index[s]
+	if !ok {
+		i = uint64(len(p.strings))
+		p.index[s] = i
+		p.strings = append(p.strings, s)
+	}
+	p.putUvarint(i)
+}
+
+func (p *packedLocations) putLocation(l Location) {
+	p.putString(l.FileName())
+	p.putUvarint(uint64(l.Line()))
+	p.putUvarint(uint64(l.rawCol()))
+	p.putUvarint(uint64(l.Offset()))
+	var flags uint64
+	usr := l.USR()
+	if usr != "" {
+		flags |= packedHasUSR
+	}
+	if l.IsForward() {
+		flags |= packedForward
+	}
+	p.putUvarint(flags)
+	if usr != "" {
+		p.putString(usr)
+	}
+}
+
+// the flags of the packed location.
+const (
+	packedHasUSR = 1 << iota
+	packedForward
+)
+
+// the flags of the packed caller.
+const (
+	packedFuncCall = 1 << iota
+	packedHasArgs
+)
+
+// serializePackedLocations serializes the packed byte blob and the strings table to flatbuffers.UOffsetT.
+func serializePackedLocations(builder *flatbuffers.Builder, buf []byte, strs []string) (flatbuffers.UOffsetT, flatbuffers.UOffsetT) {
+	symbol.InfoStartPackedLocationsVector(builder, len(buf))
+	for i := len(buf) - 1; i >= 0; i-- {
+		builder.PrependByte(buf[i])
+	}
+	bufOffset := builder.EndVector(len(buf))
+
+	return bufOffset, serializeStrings(builder, strs, symbol.InfoStartPackedStringsVector)
+}
+
+// ----------------------------------------------------------------------------
+
+// packedDecoder decodes the packed byte blob which encoded by packLocations.
+// The decoding stops at the first malformed value, and the rest values are zero.
+type packedDecoder struct {
+	buf  []byte
+	info *symbol.Info
+	err  bool
+}
+
+func (d *packedDecoder) uvarint() uint64 {
+	if d.err {
+		return 0
+	}
+	x, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = true
+		return 0
+	}
+	d.buf = d.buf[n:]
+
+	return x
+}
+
+// count returns the number of following elements, which bounded by the remaining length of the blob.
+func (d *packedDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.err = true
+		return 0
+	}
+	return int(n)
+}
+
+func (d *packedDecoder) string() string {
+	i := d.uvarint()
+	if d.err || i >= uint64(safeLength(d.info.Table(), infoPackedStringsSlot)) {
+		d.err = true
+		return ""
+	}
+	return string(d.info.PackedStrings(int(i)))
+}
+
+func (d *packedDecoder) location() Location {
+	l := Location{fileName: d.string()}
+	l.line = uint32(d.uvarint())
+	l.col = uint32(d.uvarint())
+	l.offset = uint32(d.uvarint())
+	flags := d.uvarint()
+	if flags&packedHasUSR != 0 {
+		l.usr = d.string()
+	}
+	l.isForward = flags&packedForward != 0
+	if d.err {
+		return Location{}
+	}
+
+	return l
+}
+
+func (d *packedDecoder) decls() []Location {
+	n := d.count()
+	decls := make([]Location, 0, n)
+	for i := 0; i < n && !d.err; i++ {
+		decls = append(decls, d.location())
+	}
+
+	return decls
+}
+
+func (d *packedDecoder) def() Location {
+	if d.uvarint() == 0 {
+		return Location{}
+	}
+	return d.location()
+}
+
+func (d *packedDecoder) callers() []*Caller {
+	n := d.count()
+	callers := make([]*Caller, 0, n)
+	for i := 0; i < n && !d.err; i++ {
+		c := &Caller{location: d.location()}
+		flags := d.uvarint()
+		c.funcCall = flags&packedFuncCall != 0
+		if flags&packedHasArgs != 0 {
+			m := d.count()
+			for j := 0; j < m && !d.err; j++ {
+				c.args = append(c.args, d.location())
+			}
+		}
+		callers = append(callers, c)
+	}
+
+	return callers
+}
+
+// isPacked reports whether the serialized info has the packed locations.
+func (info *Info) isPacked() bool {
+	return info.info != nil && len(packedBlob(info.info)) > 0
+}
+
+// packedDecoder returns the decoder of the packed locations of the serialized info.
+func (info *Info) packedDecoder() *packedDecoder {
+	return &packedDecoder{buf: packedBlob(info.info), info: info.info}
+}
+
+// packedBlob returns the packed locations of info, or nil if the length of them is invalid.
+func packedBlob(info *symbol.Info) []byte {
+	if byteVectorLength(info.Table(), infoPackedLocationsSlot) <= 0 {
+		return nil
+	}
+	return info.PackedLocations()
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// manyLocationsFile returns the File which has the symbol of usr with n declarations and n callers.
+func manyLocationsFile(usr string, n int) *File {
+	f := NewFile("main.c", nil)
+	def := Location{fileName: "/usr/include/foo.h", line: 2, col: 5, offset: 20, usr: usr}
+	for i := 0; i < n; i++ {
+		f.AddDefinition(Location{fileName: fmt.Sprintf("/src/decl%d.h", i%4), line: uint32(i + 1), col: 6, offset: uint32(i * 40), usr: usr}, def)
+		f.AddCaller(Location{fileName: "/src/main.c", line: uint32(i + 10), col: 3, offset: uint32(i*30 + 300)}, def, i%2 == 0)
+	}
+	return f
+}
+
+func TestFile_SetPackedLocations(t *testing.T) {
+	const usr = "c:@F@foo"
+	f := manyLocationsFile(usr, 8)
+	f.AddDecl(Location{fileName: "/src/bar.h", line: 1, col: 6, usr: "c:@F@bar"})
+	want := roundTrip(f)
+
+	f.SetPackedLocations(true)
+	got := roundTrip(f)
+	if !got.Equal(want) {
+		t.Error("packed File is not equal to the table File")
+	}
+
+	sym, wantSym := findSymbol(got, usr), findSymbol(want, usr)
+	for i, decl := range sym.Decls() {
+		if decl != wantSym.Decls()[i].value() {
+			t.Errorf("Decls()[%d] = %+v, want %+v", i, decl, wantSym.Decls()[i].value())
+		}
+	}
+	if def := sym.Def(); def != wantSym.Def().value() {
+		t.Errorf("Def() = %+v, want %+v", def, wantSym.Def().value())
+	}
+	if def := findSymbol(got, "c:@F@bar").Def(); !def.IsZero() {
+		t.Errorf("Def() without definition = %+v, want empty", def)
+	}
+
+	got.Unmarshal()
+	if !reflect.DeepEqual(got.symbols[ToID(usr)].callers, f.symbols[ToID(usr)].callers) {
+		t.Error("Unmarshal callers differ from the original")
+	}
+
+	insp, err := InspectFile(f.Serialize().FinishedBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !insp.Info.HasPackedLocations || insp.Info.HasDecls || insp.Info.HasCallers {
+		t.Errorf("InspectFile() = %+v, want only the packed locations", insp.Info)
+	}
+	if insp.NumDecls != 9 || insp.NumCallers != 8 {
+		t.Errorf("InspectFile() NumDecls, NumCallers = %d, %d, want 9, 8", insp.NumDecls, insp.NumCallers)
+	}
+}
+
+func BenchmarkPackedLocations(b *testing.B) {
+	for _, packed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("packed=%v", packed), func(b *testing.B) {
+			f := manyLocationsFile("c:@F@foo", 1000)
+			f.SetPackedLocations(packed)
+
+			var size int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.builder.Reset()
+				size = len(f.Serialize().FinishedBytes())
+			}
+			b.ReportMetric(float64(size), "bytes/file")
+		})
+	}
+}
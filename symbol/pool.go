@@ -0,0 +1,108 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import "sync"
+
+// SymbolList is the symbols which AcquireSymbols returns. The wrappers and tables of the Symbols are reused by
+// the later AcquireSymbols after Release, so none of them may be used after Release.
+type SymbolList struct {
+	Symbols []*Info
+
+	slots []infoSlot
+}
+
+var symbolListPool = sync.Pool{
+	New: func() interface{} {
+		return new(SymbolList)
+	},
+}
+
+// AcquireSymbols is like Symbols, but takes the wrappers of the symbols from the pool instead of allocating them,
+// so iterating over the symbols of many Files reuses the same memory. Each returned SymbolList has its own
+// wrappers, so the lists acquired at the same time never alias. The caller must call Release when done.
+func (f *File) AcquireSymbols() *SymbolList {
+	l := symbolListPool.Get().(*SymbolList)
+	f.hydrate()
+	if len(f.symbols) > 0 || f.file == nil {
+		for _, sym := range f.symbols {
+			l.Symbols = append(l.Symbols, sym)
+		}
+		return l
+	}
+
+	n := safeLength(f.file.Table(), fileSymbolsSlot)
+	if cap(l.Symbols) < n {
+		l.Symbols = make([]*Info, n)
+	}
+	if cap(l.slots) < n {
+		l.slots = make([]infoSlot, n)
+	}
+	l.Symbols, l.slots = l.Symbols[:n], l.slots[:n]
+	f.decodeSymbols(l.Symbols, l.slots)
+
+	return l
+}
+
+// Release returns l to the pool. It clears the references to the symbols and their buffer, so the pooled list
+// does not keep them alive.
+func (l *SymbolList) Release() {
+	for i := range l.Symbols {
+		l.Symbols[i] = nil
+	}
+	for i := range l.slots {
+		l.slots[i] = infoSlot{}
+	}
+	l.Symbols, l.slots = l.Symbols[:0], l.slots[:0]
+	symbolListPool.Put(l)
+}
+
+// CallerList is the callers which AcquireCallers returns, see SymbolList.
+type CallerList struct {
+	Callers []*Caller
+
+	slots []callerSlot
+}
+
+var callerListPool = sync.Pool{
+	New: func() interface{} {
+		return new(CallerList)
+	},
+}
+
+// AcquireCallers is like Callers, but takes the wrappers of the callers from the pool as same as AcquireSymbols.
+// The callers of the packed locations are decoded to the values, which are not pooled.
+// The caller must call Release when done.
+func (info *Info) AcquireCallers() *CallerList {
+	l := callerListPool.Get().(*CallerList)
+	if info.info == nil || info.isPacked() {
+		l.Callers = append(l.Callers, info.Callers()...)
+		return l
+	}
+
+	n := safeLength(info.info.Table(), infoCallersSlot)
+	if cap(l.Callers) < n {
+		l.Callers = make([]*Caller, n)
+	}
+	if cap(l.slots) < n {
+		l.slots = make([]callerSlot, n)
+	}
+	l.Callers, l.slots = l.Callers[:n], l.slots[:n]
+	info.decodeCallers(l.Callers, l.slots)
+
+	return l
+}
+
+// Release returns l to the pool, see SymbolList.Release.
+func (l *CallerList) Release() {
+	for i := range l.Callers {
+		l.Callers[i] = nil
+	}
+	for i := range l.slots {
+		l.slots[i] = callerSlot{}
+	}
+	l.Callers, l.slots = l.Callers[:0], l.slots[:0]
+	callerListPool.Put(l)
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// poolTestFile returns the File which has n symbols, and each of them has the caller at its own line.
+func poolTestFile(name string, n int) *File {
+	f := NewFile(name, nil)
+	for i := 0; i < n; i++ {
+		loc := Location{fileName: name, line: uint32(i + 1), col: 5, usr: fmt.Sprintf("c:@F@%s%d", name, i)}
+		f.AddDefinition(loc, loc)
+		f.AddCaller(Location{fileName: name, line: uint32(i + 100), col: 3}, loc, true)
+	}
+	return f
+}
+
+// symbolIDs returns the sorted IDs of symbols.
+func symbolIDs(symbols []*Info) []string {
+	ids := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		ids = append(ids, sym.ID().String())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestFile_AcquireSymbols(t *testing.T) {
+	a, b := poolTestFile("a.c", 3), poolTestFile("b.c", 5)
+
+	for name, f := range map[string]*File{"in-memory": a, "round trip": roundTrip(a)} {
+		l := f.AcquireSymbols()
+		if got, want := symbolIDs(l.Symbols), symbolIDs(f.Symbols()); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: AcquireSymbols() = %v, want %v", name, got, want)
+		}
+		l.Release()
+		if len(l.Symbols) != 0 {
+			t.Errorf("%s: len(Symbols) after Release = %d, want 0", name, len(l.Symbols))
+		}
+	}
+
+	// the lists acquired at the same time must not share the wrappers
+	ra, rb := roundTrip(a), roundTrip(b)
+	la := ra.AcquireSymbols()
+	want := symbolIDs(la.Symbols)
+	lb := rb.AcquireSymbols()
+	seen := make(map[*Info]bool)
+	for _, sym := range la.Symbols {
+		seen[sym] = true
+	}
+	for _, sym := range lb.Symbols {
+		if seen[sym] {
+			t.Errorf("symbol %s of b.c shares the wrapper with a.c", sym.ID())
+		}
+	}
+	if got := symbolIDs(la.Symbols); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("symbols of a.c after acquiring b.c = %v, want %v", got, want)
+	}
+	la.Release()
+	lb.Release()
+
+	// the reused list must not keep the symbols of the previous File
+	l := rb.AcquireSymbols()
+	l.Release()
+	l = ra.AcquireSymbols()
+	if got := symbolIDs(l.Symbols); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("AcquireSymbols() after Release = %v, want %v", got, want)
+	}
+	l.Release()
+}
+
+func TestInfo_AcquireCallers(t *testing.T) {
+	f := poolTestFile("a.c", 2)
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f), "packed": roundTripPacked(f)} {
+		for _, sym := range f.Symbols() {
+			want := sym.Callers()
+			l := sym.AcquireCallers()
+			if len(l.Callers) != len(want) {
+				t.Fatalf("%s: len(AcquireCallers()) = %d, want %d", name, len(l.Callers), len(want))
+			}
+			for i, caller := range l.Callers {
+				if got, want := caller.Location().value(), want[i].Location().value(); got != want {
+					t.Errorf("%s: AcquireCallers()[%d] = %v, want %v", name, i, got, want)
+				}
+			}
+			l.Release()
+		}
+	}
+}
+
+// roundTripPacked is like roundTrip, but serializes the locations of f packed.
+func roundTripPacked(f *File) *File {
+	f.SetPackedLocations(true)
+	defer f.SetPackedLocations(false)
+	return roundTrip(f)
+}
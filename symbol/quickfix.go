@@ -0,0 +1,151 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// QuickfixItem represents the vim quickfix item, which encodes to the dictionary of setqflist().
+type QuickfixItem struct {
+	Filename string `json:"filename"`
+	Lnum     uint32 `json:"lnum"`
+	Col      uint32 `json:"col"` // 1-based byte column, as same as the clang column
+	Text     string `json:"text"`
+	Type     string `json:"type,omitempty"` // "E", "W" or "N" of the diagnostic severity
+}
+
+// QuickfixItems returns the quickfix items of locs, such as the references and definitions.
+// The text returns the text of the item at the location, and the empty text is used if text is nil.
+//
+// The items are deduplicated by the file name, line and column, and ordered by them as same as
+// the locations which the Resolver returns.
+func QuickfixItems(locs []Location, text func(Location) string) []QuickfixItem {
+	locs = dedupLocations(locs)
+	items := make([]QuickfixItem, len(locs))
+	for i, loc := range locs {
+		items[i] = QuickfixItem{
+			Filename: loc.FileName(),
+			Lnum:     loc.Line(),
+			Col:      loc.rawCol(),
+		}
+		if text != nil {
+			items[i].Text = text(loc)
+		}
+	}
+
+	return items
+}
+
+// DiagnosticQuickfixItems returns the quickfix items of diags, which have the type letter of the severity.
+// The ignored diagnostics are dropped, and the identical diagnostics are deduplicated.
+// The items are ordered by the file name, line and column, and then by the message.
+func DiagnosticQuickfixItems(diags []*Diagnostic) []QuickfixItem {
+	items, _ := diagnosticQuickfixItems(diags)
+	return items
+}
+
+// diagnosticQuickfixItems returns the items of DiagnosticQuickfixItems, and the first diagnostic of diags which
+// produced each of them.
+func diagnosticQuickfixItems(diags []*Diagnostic) ([]QuickfixItem, []*Diagnostic) {
+	type pair struct {
+		item QuickfixItem
+		diag *Diagnostic
+	}
+	pairs := make([]pair, 0, len(diags))
+	seen := make(map[QuickfixItem]bool, len(diags))
+	for _, d := range diags {
+		typ, ok := quickfixType(d.Severity())
+		if !ok {
+			continue
+		}
+		loc := d.Location()
+		item := QuickfixItem{
+			Filename: loc.FileName(),
+			Lnum:     loc.Line(),
+			Col:      loc.rawCol(),
+			Text:     d.Message(),
+			Type:     typ,
+		}
+		if !seen[item] {
+			seen[item] = true
+			pairs = append(pairs, pair{item: item, diag: d})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, b := pairs[i].item, pairs[j].item
+		switch {
+		case a.Filename != b.Filename:
+			return a.Filename < b.Filename
+		case a.Lnum != b.Lnum:
+			return a.Lnum < b.Lnum
+		case a.Col != b.Col:
+			return a.Col < b.Col
+		case a.Text != b.Text:
+			return a.Text < b.Text
+		}
+		return a.Type < b.Type
+	})
+
+	items := make([]QuickfixItem, len(pairs))
+	sorted := make([]*Diagnostic, len(pairs))
+	for i, p := range pairs {
+		items[i], sorted[i] = p.item, p.diag
+	}
+
+	return items, sorted
+}
+
+// quickfixType returns the quickfix type letter of the diagnostic severity.
+// It reports false for the ignored diagnostic.
+func quickfixType(severity clang.DiagnosticSeverity) (string, bool) {
+	switch severity {
+	case clang.Diagnostic_Error, clang.Diagnostic_Fatal:
+		return "E", true
+	case clang.Diagnostic_Warning:
+		return "W", true
+	case clang.Diagnostic_Note:
+		return "N", true
+	}
+	return "", false
+}
+
+// WriteQuickfix writes the quickfix items of locs to w, one "file:line:col: text" line per item,
+// which the default vim errorformat parses. The items are as same as QuickfixItems returns.
+func WriteQuickfix(w io.Writer, locs []Location, text func(Location) string) error {
+	return writeQuickfixItems(w, QuickfixItems(locs, text))
+}
+
+// WriteDiagnosticQuickfix writes the quickfix items of diags to w, one "file:line:col: type: message" line
+// per item, where the type is "error", "warning" or "note". The items are as same as DiagnosticQuickfixItems returns.
+func WriteDiagnosticQuickfix(w io.Writer, diags []*Diagnostic) error {
+	return writeQuickfixItems(w, DiagnosticQuickfixItems(diags))
+}
+
+// writeQuickfixItems writes items in the "file:line:col: text" form.
+// The type letter is written as the "error", "warning" or "note" prefix of the text.
+func writeQuickfixItems(w io.Writer, items []QuickfixItem) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range items {
+		text := item.Text
+		switch item.Type {
+		case "E":
+			text = "error: " + text
+		case "W":
+			text = "warning: " + text
+		case "N":
+			text = "note: " + text
+		}
+		fmt.Fprintf(bw, "%s:%d:%d: %s\n", item.Filename, item.Lnum, item.Col, text)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// checkQuickfixGolden compares got with the testdata/vim/name golden file, which is regenerated by -update.
+func checkQuickfixGolden(t *testing.T, name string, got []byte) {
+	golden := filepath.Join("testdata", "vim", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestWriteQuickfix(t *testing.T) {
+	locs := []Location{
+		{fileName: "/src/main.c", line: 12, col: 3},
+		{fileName: "/src/a.c", line: 7, col: 10},
+		{fileName: "/src/main.c", line: 4, col: 9},
+		{fileName: "/src/main.c", line: 12, col: 3, offset: 120}, // duplicate
+	}
+	text := func(loc Location) string {
+		return "util(" + filepath.Base(loc.FileName()) + ")"
+	}
+
+	var buf bytes.Buffer
+	if err := WriteQuickfix(&buf, locs, text); err != nil {
+		t.Fatal(err)
+	}
+	checkQuickfixGolden(t, "quickfix.txt", buf.Bytes())
+
+	got, err := json.Marshal(QuickfixItems(locs, text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQuickfixGolden(t, "quickfix.json", append(got, '\n'))
+}
+
+func TestWriteDiagnosticQuickfix(t *testing.T) {
+	diags := []*Diagnostic{
+		{severity: clang.Diagnostic_Warning, message: "unused variable 'n'", location: Location{fileName: "/src/main.c", line: 5, col: 7}},
+		{severity: clang.Diagnostic_Error, message: "use of undeclared identifier 'x'", location: Location{fileName: "/src/main.c", line: 3, col: 10}},
+		{severity: clang.Diagnostic_Note, message: "previous definition is here", location: Location{fileName: "/src/a.h", line: 1, col: 5}},
+		{severity: clang.Diagnostic_Fatal, message: "'b.h' file not found", location: Location{fileName: "/src/a.h", line: 1, col: 5}},
+		{severity: clang.Diagnostic_Ignored, message: "ignored", location: Location{fileName: "/src/main.c", line: 1, col: 1}},
+		{severity: clang.Diagnostic_Error, message: "use of undeclared identifier 'x'", location: Location{fileName: "/src/main.c", line: 3, col: 10}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiagnosticQuickfix(&buf, diags); err != nil {
+		t.Fatal(err)
+	}
+	checkQuickfixGolden(t, "quickfix_diagnostics.txt", buf.Bytes())
+
+	got, err := json.Marshal(DiagnosticQuickfixItems(diags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQuickfixGolden(t, "quickfix_diagnostics.json", append(got, '\n'))
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Reference represents a reference to the symbol from the File.
+type Reference struct {
+	// File name of the file which contains the reference.
+	File string
+	// Caller location of the reference.
+	Caller *Caller
+}
+
+// ReferenceIndex is the inverted index from the symbol to the references across the Files.
+// It is safe for concurrent use.
+type ReferenceIndex struct {
+	mu sync.RWMutex
+
+	refs  map[ID]map[string][]*Caller // symbol ID -> file name -> callers
+	files map[string][]ID             // file name -> symbol IDs which referenced in the file
+}
+
+// NewReferenceIndex builds the ReferenceIndex from files.
+func NewReferenceIndex(files []*File) *ReferenceIndex {
+	idx := &ReferenceIndex{
+		refs:  make(map[ID]map[string][]*Caller),
+		files: make(map[string][]ID, len(files)),
+	}
+	for _, f := range files {
+		idx.add(f)
+	}
+
+	return idx
+}
+
+// References returns the references to the symbol of usr, ordered by the file name.
+func (idx *ReferenceIndex) References(usr string) []Reference {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	byFile := idx.refs[ToID(usr)]
+	names := make([]string, 0, len(byFile))
+	for name := range byFile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var refs []Reference
+	for _, name := range names {
+		for _, caller := range byFile[name] {
+			refs = append(refs, Reference{File: name, Caller: caller})
+		}
+	}
+
+	return refs
+}
+
+// Update refreshes the references of f, replacing the entries of the file which has the same name.
+func (idx *ReferenceIndex) Update(f *File) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(f.Name())
+	idx.add(f)
+}
+
+// Remove removes the references of the file name.
+func (idx *ReferenceIndex) Remove(name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(name)
+}
+
+func (idx *ReferenceIndex) add(f *File) {
+	name := f.Name()
+	for _, sym := range f.Symbols() {
+		callers := sym.Callers()
+		if len(callers) == 0 {
+			continue
+		}
+
+		id := sym.ID()
+		byFile, ok := idx.refs[id]
+		if !ok {
+			byFile = make(map[string][]*Caller)
+			idx.refs[id] = byFile
+		}
+		byFile[name] = append(byFile[name], callers...)
+		idx.files[name] = append(idx.files[name], id)
+	}
+}
+
+func (idx *ReferenceIndex) remove(name string) {
+	for _, id := range idx.files[name] {
+		byFile := idx.refs[id]
+		delete(byFile, name)
+		if len(byFile) == 0 {
+			delete(idx.refs, id)
+		}
+	}
+	delete(idx.files, name)
+}
+
+// DefiningFile returns the name of the File which has the definition of the symbol of usr.
+//
+// The File whose name is same as the filename of the definition location is preferred, because
+// the other Files may also record the definition which reached via the included header.
+// It reports false if no File has the definition.
+func DefiningFile(files []*File, usr string) (string, bool) {
+	var (
+		name  string
+		found bool
+	)
+	for _, f := range files {
+		id := ToID(f.rewriteUSR(usr))
+		for _, sym := range f.Symbols() {
+			if sym.ID() != id {
+				continue
+			}
+			fid, ok := sym.DefinitionFile()
+			if !ok {
+				break
+			}
+			if fid == ToFileID(filepath.Clean(f.Name())) {
+				return f.Name(), true
+			}
+			if !found {
+				name, found = f.Name(), true
+			}
+			break
+		}
+	}
+
+	return name, found
+}
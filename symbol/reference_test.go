@@ -0,0 +1,97 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferenceIndex(t *testing.T) {
+	const usr = "c:@F@add"
+	def := Location{fileName: "add.c", line: 3, col: 5, offset: 24, usr: usr}
+
+	newFile := func(name string, callSites ...Location) *File {
+		f := NewFile(name, nil)
+		for _, loc := range callSites {
+			f.AddCaller(loc, def, true)
+		}
+		return f
+	}
+	refLocations := func(refs []Reference) []Location {
+		locs := make([]Location, len(refs))
+		for i, ref := range refs {
+			locs[i] = ref.Caller.Location().value()
+			if ref.File != locs[i].FileName() {
+				t.Errorf("Reference.File = %q, want %q", ref.File, locs[i].FileName())
+			}
+		}
+		return locs
+	}
+
+	mainCall := Location{fileName: "main.c", line: 7, col: 10, offset: 70}
+	utilCall := Location{fileName: "util.c", line: 12, col: 3, offset: 140}
+	utilCall2 := Location{fileName: "util.c", line: 20, col: 3, offset: 260}
+
+	// serialized files as read from the index database
+	idx := NewReferenceIndex([]*File{
+		roundTrip(newFile("util.c", utilCall)),
+		roundTrip(newFile("main.c", mainCall)),
+	})
+
+	if got, want := refLocations(idx.References(usr)), []Location{mainCall, utilCall}; !reflect.DeepEqual(got, want) {
+		t.Errorf("References() = %+v, want %+v", got, want)
+	}
+	if got := idx.References("c:@F@unknown"); len(got) != 0 {
+		t.Errorf("References() of the unknown symbol = %+v, want empty", got)
+	}
+
+	idx.Update(newFile("util.c", utilCall, utilCall2))
+	if got, want := refLocations(idx.References(usr)), []Location{mainCall, utilCall, utilCall2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("updated References() = %+v, want %+v", got, want)
+	}
+
+	idx.Remove("main.c")
+	if got, want := refLocations(idx.References(usr)), []Location{utilCall, utilCall2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("References() after Remove = %+v, want %+v", got, want)
+	}
+}
+
+func TestDefiningFile(t *testing.T) {
+	const usr = "c:@F@add"
+	def := Location{fileName: "add.c", line: 3, col: 5, offset: 24, usr: usr}
+
+	caller := NewFile("main.c", nil)
+	caller.AddDecl(Location{fileName: "add.h", line: 1, col: 5, offset: 4, usr: usr})
+
+	definer := NewFile("add.c", nil)
+	definer.AddDefinition(def, def)
+
+	// the file which includes add.c also records the definition
+	includer := NewFile("all.c", nil)
+	includer.AddDefinition(def, def)
+
+	tests := []struct {
+		name   string
+		files  []*File
+		usr    string
+		want   string
+		wantOK bool
+	}{
+		{name: "defined in one file", files: []*File{roundTrip(caller), roundTrip(definer)}, usr: usr, want: "add.c", wantOK: true},
+		{name: "prefer matching filename", files: []*File{includer, caller, definer}, usr: usr, want: "add.c", wantOK: true},
+		{name: "fallback to other file", files: []*File{caller, includer}, usr: usr, want: "all.c", wantOK: true},
+		{name: "declared only", files: []*File{caller}, usr: usr, wantOK: false},
+		{name: "unknown symbol", files: []*File{caller, definer}, usr: "c:@F@unknown", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DefiningFile(tt.files, tt.usr)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DefiningFile() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSystemDirs default directories of the system headers, whose references RenameLocations excludes.
+var DefaultSystemDirs = []string{
+	"/usr/include",
+	"/usr/local/include",
+	"/usr/lib",
+	"/Applications/Xcode.app",
+	"/Library/Developer/CommandLineTools",
+}
+
+// RenameOption represents a option of RenameLocations.
+type RenameOption func(*renameOptions)
+
+type renameOptions struct {
+	systemHeaders bool
+	systemDirs    []string
+}
+
+// WithSystemHeaders sets whether RenameLocations includes the references in the system headers.
+func WithSystemHeaders(include bool) RenameOption {
+	return func(o *renameOptions) { o.systemHeaders = include }
+}
+
+// WithSystemDirs sets the directories of the system headers instead of DefaultSystemDirs.
+func WithSystemDirs(dirs ...string) RenameOption {
+	return func(o *renameOptions) { o.systemDirs = dirs }
+}
+
+// isSystemHeader reports whether fileName is in the system header directories.
+func (o *renameOptions) isSystemHeader(fileName string) bool {
+	fileName = filepath.Clean(fileName)
+	for _, dir := range o.systemDirs {
+		dir = filepath.Clean(dir)
+		if fileName == dir || strings.HasPrefix(fileName, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameLocations returns the locations which the rename of the symbol of usr edits across files, which are
+// the declarations, definition and callers. The File of the Reference is the file of the location, and the
+// declarations and definition are the Callers which are not the function call.
+//
+// The locations recorded by the several Files, such as of the header, are deduplicated, and the references are
+// grouped by the file name and ordered by the line and column. The references in the system headers are
+// excluded unless WithSystemHeaders is set, because the rename can not edit them.
+func RenameLocations(files []*File, usr string, opts ...RenameOption) []Reference {
+	o := renameOptions{systemDirs: DefaultSystemDirs}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	seen := make(map[locationKey]bool)
+	var refs []Reference
+	add := func(caller *Caller) {
+		loc := caller.Location()
+		if loc.FileName() == "" || (!o.systemHeaders && o.isSystemHeader(loc.FileName())) {
+			return
+		}
+		k := keyOf(loc)
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		refs = append(refs, Reference{File: loc.FileName(), Caller: caller})
+	}
+
+	for _, f := range files {
+		sym := f.Symbol(ToID(f.rewriteUSR(usr)))
+		if sym == nil {
+			continue
+		}
+		if def := sym.Def(); !def.IsZero() {
+			add(&Caller{location: def})
+		}
+		for _, decl := range sym.Decls() {
+			add(&Caller{location: decl})
+		}
+		for _, caller := range sym.Callers() {
+			add(caller)
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		loc := refs[i].Caller.Location()
+		return loc.Less(refs[j].Caller.Location())
+	})
+
+	return refs
+}
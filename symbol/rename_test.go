@@ -0,0 +1,83 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenameLocations(t *testing.T) {
+	const usr = "c:@F@add"
+	decl := Location{fileName: "/src/util.h", line: 1, col: 5, offset: 4, usr: usr}
+	def := Location{fileName: "/src/util.c", line: 3, col: 5, offset: 30, usr: usr}
+	utilCall := Location{fileName: "/src/util.c", line: 9, col: 10, offset: 90, usr: usr}
+	mainCall := Location{fileName: "/src/main.c", line: 5, col: 10, offset: 60, usr: usr}
+	ptrRef := Location{fileName: "/src/main.c", line: 7, col: 14, offset: 80, usr: usr}
+	// the macro of the system header which expands to the call
+	sysCall := Location{fileName: "/usr/include/math.h", line: 40, col: 3, offset: 900, usr: usr}
+
+	util := NewFile("/src/util.c", nil)
+	util.AddDecl(decl)
+	util.AddDefinition(def, def)
+	mainc := NewFile("/src/main.c", nil)
+	mainc.AddDecl(decl)
+	for _, f := range []struct {
+		file  *File
+		loc   Location
+		fcall bool
+	}{
+		{util, utilCall, true},
+		{mainc, mainCall, true},
+		{mainc, ptrRef, false},
+		{mainc, sysCall, true},
+	} {
+		if err := f.file.AddCaller(f.loc, decl, f.fcall); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := NewFile("/src/other.c", nil)
+	other.AddDefinition(Location{fileName: "/src/other.c", line: 1, col: 5, usr: "c:@F@sub"}, Location{fileName: "/src/other.c", line: 1, col: 5, usr: "c:@F@sub"})
+
+	locations := func(refs []Reference) ([]Location, []bool) {
+		locs := make([]Location, len(refs))
+		calls := make([]bool, len(refs))
+		for i, ref := range refs {
+			locs[i] = ref.Caller.Location().value()
+			calls[i] = ref.Caller.FuncCall()
+			if ref.File != locs[i].FileName() {
+				t.Errorf("Reference.File = %q, want %q", ref.File, locs[i].FileName())
+			}
+		}
+		return locs, calls
+	}
+
+	for name, files := range map[string][]*File{
+		"in-memory":  {util, mainc, other},
+		"round trip": {roundTrip(mainc), roundTrip(other), roundTrip(util)},
+	} {
+		locs, calls := locations(RenameLocations(files, usr))
+		want := []Location{mainCall, ptrRef, def, utilCall, decl}
+		if !reflect.DeepEqual(locs, want) {
+			t.Errorf("%s: RenameLocations() = %+v, want %+v", name, locs, want)
+		}
+		if want := []bool{true, false, false, true, false}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("%s: FuncCall() = %v, want %v", name, calls, want)
+		}
+
+		locs, _ = locations(RenameLocations(files, usr, WithSystemHeaders(true)))
+		if want := append(want, sysCall); !reflect.DeepEqual(locs, want) {
+			t.Errorf("%s: RenameLocations() with the system headers = %+v, want %+v", name, locs, want)
+		}
+		locs, _ = locations(RenameLocations(files, usr, WithSystemDirs("/src")))
+		if want := []Location{sysCall}; !reflect.DeepEqual(locs, want) {
+			t.Errorf("%s: RenameLocations() with the system dirs = %+v, want %+v", name, locs, want)
+		}
+
+		if got := RenameLocations(files, "c:@F@unknown"); len(got) != 0 {
+			t.Errorf("%s: RenameLocations() of the unknown symbol = %+v, want empty", name, got)
+		}
+	}
+}
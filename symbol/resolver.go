@@ -0,0 +1,189 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoSymbol is the cause of the error which Resolver returns when no symbol is at the position.
+var ErrNoSymbol = errors.New("no symbol at the position")
+
+// ErrNoSymbolNamed is the cause of the error which Resolver returns when no symbol has the name.
+var ErrNoSymbolNamed = errors.New("no symbol of the name")
+
+// maxResolverSuggestions is the maximum number of the suggestions of the NotFoundError which Resolver returns.
+const maxResolverSuggestions = 5
+
+// NotFoundError is the error which Resolver returns when no symbol has the name, with the symbols of the near
+// names such as the misspelling. The Cause is ErrNoSymbolNamed.
+type NotFoundError struct {
+	Name        string
+	Suggestions []Suggestion // ordered by the likelihood, see Table.Suggest
+}
+
+// Error implements the error interface.
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("%q: %v", e.Name, ErrNoSymbolNamed)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	names := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		names[i] = s.Name
+	}
+	return msg + ", did you mean " + strings.Join(names, ", ") + "?"
+}
+
+// Cause returns ErrNoSymbolNamed, for errors.Cause.
+func (e *NotFoundError) Cause() error {
+	return ErrNoSymbolNamed
+}
+
+// Resolver resolves the symbol at the position to its locations across the Table, for the editor requests
+// such as the LSP textDocument/definition. The zero value is ready to use.
+type Resolver struct{}
+
+// Definition returns the definitions of the symbol at the line and col of file. The position may be
+// the declaration, definition or reference of the symbol, in the source or the header.
+//
+// The definitions are collected from all Files of table, and the declarations are returned instead if no File
+// has the definition. The declarations are also returned along with the definition if the position is at
+// the definition itself, so the editor can jump from the definition to the declaration in the header.
+// The File which cannot see the definition records the referenced declaration as the definition, so such
+// declaration is ignored when the other File knows the definition.
+// The locations are deduplicated, and ordered by the file name, line and column.
+//
+// The error of the ErrNoSymbol cause is returned if no File knows the symbol at the position.
+func (r *Resolver) Definition(table *Table, file string, line, col uint32) ([]Location, error) {
+	defer observeSince(table.metricsOf(), MetricDefinitionSeconds, time.Now())
+
+	at := table.SymbolsAt(file, line, col)
+	if len(at) == 0 {
+		return nil, errors.Wrapf(ErrNoSymbol, "%s:%d:%d", file, line, col)
+	}
+
+	var infos []*Info
+	seen := make(map[ID]bool)
+	for _, sym := range at {
+		id := sym.ID()
+		if !seen[id] {
+			seen[id] = true
+			infos = append(infos, table.Symbols(id)...)
+		}
+	}
+	defs, decls := definitions(infos)
+
+	atDef := false
+	for _, def := range defs {
+		atDef = atDef || (def.FileName() == file && def.Line() == line && def.Col() == col)
+	}
+
+	locs := defs
+	if len(defs) == 0 || atDef {
+		locs = append(decls, defs...)
+	}
+
+	return dedupLocations(locs), nil
+}
+
+// DefinitionByName is like Definition, but resolves the symbols of the name instead of the position, such as
+// the go-to-definition of the identifier which the editor has but the index does not know at the position.
+// The name is compared case-sensitively with the name of the symbols, or the name qualified by the namespace
+// if name has "::". The declarations are returned instead if no File has the definition.
+//
+// The *NotFoundError which has the near names of Table.Suggest is returned if no symbol has the name.
+func (r *Resolver) DefinitionByName(table *Table, name string) ([]Location, error) {
+	defer observeSince(table.metricsOf(), MetricDefinitionSeconds, time.Now())
+
+	idx := table.suggestIndex()
+	var infos []*Info
+	seen := make(map[ID]bool)
+	for _, i := range idx.byName[strings.ToLower(name)] {
+		e := idx.entries[i]
+		if e.name != name && e.qualified != name {
+			continue
+		}
+		if id := e.info.ID(); !seen[id] {
+			seen[id] = true
+			infos = append(infos, table.Symbols(id)...)
+		}
+	}
+	if len(infos) == 0 {
+		return nil, &NotFoundError{Name: name, Suggestions: table.Suggest(name, maxResolverSuggestions)}
+	}
+
+	defs, decls := definitions(infos)
+	if len(defs) == 0 {
+		return decls, nil
+	}
+
+	return defs, nil
+}
+
+// definitions returns the deduplicated definitions and declarations of infos, which are the same symbol
+// recorded by the several Files.
+//
+// The File which cannot see the definition records the referenced declaration as the definition of the caller,
+// so such declaration is not the definition if the other File knows the definition.
+func definitions(infos []*Info) (defs, decls []Location) {
+	superseded := make(map[locationKey]bool)
+	for _, info := range infos {
+		def := info.Def()
+		for _, decl := range info.Decls() {
+			decls = append(decls, decl)
+			if !def.IsZero() && keyOf(decl) != keyOf(def) {
+				superseded[keyOf(decl)] = true
+			}
+		}
+		if !def.IsZero() {
+			defs = append(defs, def)
+		}
+	}
+
+	n := 0
+	for _, def := range defs {
+		if !superseded[keyOf(def)] {
+			defs[n] = def
+			n++
+		}
+	}
+
+	return dedupLocations(defs[:n]), dedupLocations(decls)
+}
+
+// locationKey identifies the Location by the file name, line and column, regardless of the offset and USR.
+type locationKey struct {
+	fileName  string
+	line, col uint32
+}
+
+// keyOf returns the locationKey of loc.
+func keyOf(loc Location) locationKey {
+	return locationKey{fileName: loc.FileName(), line: loc.Line(), col: loc.Col()}
+}
+
+// dedupLocations returns the locations which have the distinct file name, line and column, ordered by them.
+func dedupLocations(locs []Location) []Location {
+	seen := make(map[locationKey]bool, len(locs))
+	deduped := make([]Location, 0, len(locs))
+	for _, loc := range locs {
+		k := keyOf(loc)
+		if !seen[k] {
+			seen[k] = true
+			deduped = append(deduped, loc)
+		}
+	}
+	sort.Slice(deduped, func(i, j int) bool {
+		return deduped[i].Less(deduped[j])
+	})
+
+	return deduped
+}
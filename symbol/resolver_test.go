@@ -0,0 +1,154 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fixtureTable returns the Table of the fixture project:
+//
+//  /src/util.h:  int util(void);       // 1:5
+//                int missing(void);    // 2:5, never defined
+//  /src/util.c:  #include "util.h"
+//                int util(void) { ... } // 3:5
+//  /src/a.c:     #include "util.h"
+//                util();                // 5:3
+//                missing();             // 6:3
+//  /src/b.c:     #include "util.h"
+//                util();                // 7:3
+//
+// The declarations of util.h are recorded by every File, as clang visits the included header.
+func fixtureTable(serialized bool) *Table {
+	const (
+		util    = "c:@F@util"
+		missing = "c:@F@missing"
+	)
+	utilDecl := Location{fileName: "/src/util.h", line: 1, col: 5, offset: 4, usr: util}
+	missingDecl := Location{fileName: "/src/util.h", line: 2, col: 5, offset: 20, usr: missing}
+	utilDef := Location{fileName: "/src/util.c", line: 3, col: 5, offset: 23, usr: util}
+
+	newFile := func(name string) *File {
+		f := NewFile(name, nil)
+		f.AddDecl(utilDecl)
+		f.AddDecl(missingDecl)
+		return f
+	}
+	utilC := newFile("/src/util.c")
+	utilC.AddDefinition(utilDef, utilDef)
+	a := newFile("/src/a.c")
+	a.AddCaller(Location{fileName: "/src/a.c", line: 5, col: 3, offset: 40}, utilDecl, true)
+	a.AddCaller(Location{fileName: "/src/a.c", line: 6, col: 3, offset: 50}, missingDecl, true)
+	b := newFile("/src/b.c")
+	b.AddCaller(Location{fileName: "/src/b.c", line: 7, col: 3, offset: 60}, utilDecl, true)
+
+	files := []*File{utilC, a, b}
+	if serialized {
+		for i, f := range files {
+			files[i] = GetRootAsFile(mustSerializeBytes(f), 0)
+		}
+	}
+
+	return NewTable(files)
+}
+
+// positions returns the "file:line:col" form of locs.
+func positions(locs []Location) []string {
+	var pos []string
+	for _, loc := range locs {
+		pos = append(pos, fmt.Sprintf("%s:%d:%d", loc.FileName(), loc.Line(), loc.Col()))
+	}
+	return pos
+}
+
+func TestResolver_Definition(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		line, col uint32
+		want      []string
+	}{
+		{
+			name: "source call to the source definition",
+			file: "/src/a.c", line: 5, col: 3,
+			want: []string{"/src/util.c:3:5"},
+		},
+		{
+			name: "call in the other TU",
+			file: "/src/b.c", line: 7, col: 3,
+			want: []string{"/src/util.c:3:5"},
+		},
+		{
+			name: "header declaration indexed by several TUs to the source definition",
+			file: "/src/util.h", line: 1, col: 5,
+			want: []string{"/src/util.c:3:5"},
+		},
+		{
+			name: "source definition to the header declaration",
+			file: "/src/util.c", line: 3, col: 5,
+			want: []string{"/src/util.c:3:5", "/src/util.h:1:5"},
+		},
+		{
+			name: "call to the declaration without definition",
+			file: "/src/a.c", line: 6, col: 3,
+			want: []string{"/src/util.h:2:5"},
+		},
+		{
+			name: "header declaration without definition",
+			file: "/src/util.h", line: 2, col: 5,
+			want: []string{"/src/util.h:2:5"},
+		},
+	}
+	for _, serialized := range []bool{false, true} {
+		table := fixtureTable(serialized)
+		var r Resolver
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/serialized=%v", tt.name, serialized), func(t *testing.T) {
+				locs, err := r.Definition(table, tt.file, tt.line, tt.col)
+				if err != nil {
+					t.Fatalf("Definition() error = %v", err)
+				}
+				if got := positions(locs); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Definition() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+
+	var r Resolver
+	if _, err := r.Definition(fixtureTable(false), "/src/a.c", 1, 1); errors.Cause(err) != ErrNoSymbol {
+		t.Errorf("Definition() of no symbol error = %v, want the ErrNoSymbol cause", err)
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := fixtureTable(false)
+	util := ToID("c:@F@util")
+
+	if n := len(table.Files()); n != 3 {
+		t.Fatalf("len(Files()) = %d, want 3", n)
+	}
+	if n := len(table.Symbols(util)); n != 3 {
+		t.Errorf("len(Symbols(util)) = %d, want 3 of every File", n)
+	}
+	if n := len(table.SymbolsAt("/src/util.h", 1, 5)); n != 3 {
+		t.Errorf("len(SymbolsAt(util.h:1:5)) = %d, want 3 of every File", n)
+	}
+
+	table.Remove("/src/util.c")
+	if table.File("/src/util.c") != nil || len(table.Symbols(util)) != 2 {
+		t.Errorf("Remove() left the File: File() = %v, len(Symbols()) = %d", table.File("/src/util.c"), len(table.Symbols(util)))
+	}
+
+	f := NewFile("/src/a.c", nil)
+	table.Update(f)
+	if table.File("/src/a.c") != f || len(table.Symbols(util)) != 1 {
+		t.Errorf("Update() did not replace the File: len(Symbols()) = %d, want 1", len(table.Symbols(util)))
+	}
+}
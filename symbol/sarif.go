@@ -0,0 +1,206 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// SARIF schema and version of the log which WriteSARIF writes.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// sarifRootBaseID the uriBaseId of the paths relative to the root.
+	sarifRootBaseID = "SRCROOT"
+)
+
+// sarifLog represents the SARIF log, which has the subset of the SARIF 2.1.0 properties WriteSARIF uses.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   uint32 `json:"startLine"`
+	StartColumn uint32 `json:"startColumn,omitempty"`
+	EndLine     uint32 `json:"endLine,omitempty"`
+	EndColumn   uint32 `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion       `json:"deletedRegion"`
+	InsertedContent *sarifFileContent `json:"insertedContent,omitempty"`
+}
+
+type sarifFileContent struct {
+	Text string `json:"text"`
+}
+
+// WriteSARIF writes the diagnostics of files to w as the SARIF 2.1.0 log of the single run of toolName, which the
+// code scanning services read to annotate the pull requests. Each diagnostic is the result with the level of the
+// severity, the ruleId of the warning option such as "-Wunused-variable", and the region of the line and the
+// 1-based byte column of the location. The fix-its of the diagnostic are written as the single fix of the result.
+//
+// If root is not empty, the paths inside of root are written relative to it with the "SRCROOT" uriBaseId,
+// so the log does not depend on the checkout directory. The other paths are written as the "file" URIs.
+// The results are filtered, deduplicated and ordered as same as DiagnosticQuickfixItems, so the diagnostic of the
+// header which included by some files is written once.
+func WriteSARIF(w io.Writer, files []*File, toolName, root string) error {
+	if root != "" && !filepath.IsAbs(root) {
+		return errors.Errorf("root must be absolute: %q", root)
+	}
+
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: toolName}},
+		Results: []sarifResult{},
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			sarifRootBaseID: {URI: FileURI(root) + "/"},
+		}
+	}
+	var diags []*Diagnostic
+	for _, f := range files {
+		diags = append(diags, f.Diagnostics()...)
+	}
+	items, diags := diagnosticQuickfixItems(diags)
+	for i, item := range items {
+		result := sarifResult{
+			RuleID:  diags[i].Option(),
+			Level:   sarifLevel(item.Type),
+			Message: sarifMessage{Text: item.Text},
+		}
+		if fix, ok := sarifFixOf(diags[i].FixIts(), root); ok {
+			result.Fixes = []sarifFix{fix}
+		}
+		if item.Filename == "" {
+			// the diagnostic without the location, such as "too many errors emitted"
+			run.Results = append(run.Results, result)
+			continue
+		}
+		phys := sarifPhysicalLocation{ArtifactLocation: sarifArtifact(item.Filename, root)}
+		if item.Lnum > 0 {
+			phys.Region = &sarifRegion{StartLine: item.Lnum, StartColumn: item.Col}
+		}
+		result.Locations = []sarifLocation{{PhysicalLocation: phys}}
+		run.Results = append(run.Results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return errors.Wrap(err, "could not write the SARIF log")
+	}
+
+	return nil
+}
+
+// sarifFixOf returns the fix which applies fixIts, whose replacements are grouped by the file in the order of
+// the first fix-it of each file. It reports false if no fix-it has the file name.
+func sarifFixOf(fixIts []FixIt, root string) (sarifFix, bool) {
+	var fix sarifFix
+	changes := make(map[string]int) // the index of the artifact change of the file name
+	for i := range fixIts {
+		rng := fixIts[i].Range()
+		start, end := rng.Start(), rng.End()
+		if start.FileName() == "" {
+			continue
+		}
+		j, ok := changes[start.FileName()]
+		if !ok {
+			j = len(fix.ArtifactChanges)
+			changes[start.FileName()] = j
+			fix.ArtifactChanges = append(fix.ArtifactChanges, sarifArtifactChange{ArtifactLocation: sarifArtifact(start.FileName(), root)})
+		}
+		repl := sarifReplacement{
+			// the range of the fix-it is half-open, as same as the exclusive endColumn
+			DeletedRegion: sarifRegion{StartLine: start.Line(), StartColumn: start.rawCol(), EndLine: end.Line(), EndColumn: end.rawCol()},
+		}
+		if text := fixIts[i].Replacement(); text != "" {
+			repl.InsertedContent = &sarifFileContent{Text: text}
+		}
+		fix.ArtifactChanges[j].Replacements = append(fix.ArtifactChanges[j].Replacements, repl)
+	}
+
+	return fix, len(fix.ArtifactChanges) > 0
+}
+
+// sarifArtifact returns the artifact location of fileName, which is relative to root if fileName is inside of root.
+func sarifArtifact(fileName, root string) sarifArtifactLocation {
+	if root != "" {
+		if path, ok := projectRelativePath(root, filepath.Clean(fileName)); ok {
+			u := url.URL{Path: path}
+			return sarifArtifactLocation{URI: u.String(), URIBaseID: sarifRootBaseID}
+		}
+	}
+	return sarifArtifactLocation{URI: FileURI(fileName)}
+}
+
+// sarifLevel returns the SARIF level of the quickfix type letter, see quickfixType.
+func sarifLevel(typ string) string {
+	switch typ {
+	case "E":
+		return "error"
+	case "W":
+		return "warning"
+	}
+	return "note"
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// validateSARIF checks data against the required properties and the enums of the SARIF 2.1.0 schema which
+// WriteSARIF uses.
+func validateSARIF(t *testing.T, data []byte) {
+	t.Helper()
+	var log struct {
+		Schema  *string `json:"$schema"`
+		Version *string `json:"version"`
+		Runs    []struct {
+			Tool *struct {
+				Driver *struct {
+					Name *string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			OriginalURIBaseIDs map[string]struct {
+				URI *string `json:"uri"`
+			} `json:"originalUriBaseIds"`
+			Results *[]struct {
+				RuleID  *string `json:"ruleId"`
+				Level   string  `json:"level"`
+				Message *struct {
+					Text *string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation *struct {
+						ArtifactLocation *struct {
+							URI       *string `json:"uri"`
+							URIBaseID string  `json:"uriBaseId"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine   *int `json:"startLine"`
+							StartColumn *int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Fixes []struct {
+					ArtifactChanges []struct {
+						ArtifactLocation *struct {
+							URI *string `json:"uri"`
+						} `json:"artifactLocation"`
+						Replacements []struct {
+							DeletedRegion *struct {
+								StartLine *int `json:"startLine"`
+							} `json:"deletedRegion"`
+							InsertedContent *struct {
+								Text *string `json:"text"`
+							} `json:"insertedContent"`
+						} `json:"replacements"`
+					} `json:"artifactChanges"`
+				} `json:"fixes"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version == nil || *log.Version != "2.1.0" || log.Schema == nil {
+		t.Fatalf("invalid SARIF version or $schema")
+	}
+	if len(log.Runs) == 0 {
+		t.Fatalf("SARIF log has no runs")
+	}
+	for _, run := range log.Runs {
+		if run.Tool == nil || run.Tool.Driver == nil || run.Tool.Driver.Name == nil {
+			t.Errorf("run has no tool.driver.name")
+		}
+		if run.Results == nil {
+			t.Errorf("run has no results")
+			continue
+		}
+		for i, result := range *run.Results {
+			switch result.Level {
+			case "none", "note", "warning", "error":
+			default:
+				t.Errorf("results[%d].level = %q", i, result.Level)
+			}
+			if result.Message == nil || result.Message.Text == nil {
+				t.Errorf("results[%d] has no message.text", i)
+			}
+			for _, loc := range result.Locations {
+				phys := loc.PhysicalLocation
+				if phys == nil || phys.ArtifactLocation == nil || phys.ArtifactLocation.URI == nil {
+					t.Errorf("results[%d] has no physicalLocation.artifactLocation.uri", i)
+					continue
+				}
+				if id := phys.ArtifactLocation.URIBaseID; id != "" {
+					if _, ok := run.OriginalURIBaseIDs[id]; !ok {
+						t.Errorf("results[%d] has the undefined uriBaseId %q", i, id)
+					}
+				}
+				if r := phys.Region; r != nil && (r.StartLine == nil || *r.StartLine < 1 || (r.StartColumn != nil && *r.StartColumn < 1)) {
+					t.Errorf("results[%d] has the invalid region", i)
+				}
+			}
+			if result.RuleID != nil && *result.RuleID == "" {
+				t.Errorf("results[%d] has the empty ruleId", i)
+			}
+			for _, fix := range result.Fixes {
+				if len(fix.ArtifactChanges) == 0 {
+					t.Errorf("results[%d] has the fix without artifactChanges", i)
+				}
+				for _, change := range fix.ArtifactChanges {
+					if change.ArtifactLocation == nil || change.ArtifactLocation.URI == nil || len(change.Replacements) == 0 {
+						t.Errorf("results[%d] has the invalid artifactChange", i)
+					}
+					for _, repl := range change.Replacements {
+						if repl.DeletedRegion == nil || repl.DeletedRegion.StartLine == nil || *repl.DeletedRegion.StartLine < 1 {
+							t.Errorf("results[%d] has the replacement without deletedRegion.startLine", i)
+						}
+						if repl.InsertedContent != nil && repl.InsertedContent.Text == nil {
+							t.Errorf("results[%d] has the insertedContent without text", i)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// sarifRange returns the range of fileName between the 1-based columns start and end of line.
+func sarifRange(fileName string, line, start, end uint32) Range {
+	return Range{
+		start: Location{fileName: fileName, line: line, col: start},
+		end:   Location{fileName: fileName, line: line, col: end},
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	unused := NewDiagnostic(clang.Diagnostic_Warning, "unused variable 'n'", Location{fileName: "/repo/src/main.c", line: 5, col: 7})
+	unused.option = "-Wunused-variable"
+	semi := NewDiagnostic(clang.Diagnostic_Error, "expected ';' after expression", Location{fileName: "/repo/src/main.c", line: 8, col: 12})
+	semi.fixIts = []FixIt{{rng: sarifRange("/repo/src/main.c", 8, 12, 12), replacement: ";"}}
+	format := NewDiagnostic(clang.Diagnostic_Warning, "format specifies type 'long' but the argument has type 'int'", Location{fileName: "/repo/src/main.c", line: 9, col: 18})
+	format.option = "-Wformat"
+	format.fixIts = []FixIt{
+		{rng: sarifRange("/repo/src/main.c", 9, 11, 14), replacement: "%d"},
+		{rng: sarifRange("/repo/src/util.h", 3, 1, 5), replacement: ""},
+		{rng: sarifRange("/repo/src/main.c", 9, 24, 25), replacement: ""},
+	}
+	notFound := NewDiagnostic(clang.Diagnostic_Fatal, "'b.h' file not found", Location{fileName: "/repo/src/a.h", line: 1, col: 10})
+
+	mainFile := NewFile("/repo/src/main.c", nil)
+	for _, d := range []*Diagnostic{
+		unused,
+		semi,
+		format,
+		notFound,
+		NewDiagnostic(clang.Diagnostic_Note, "previous definition is here", Location{fileName: "/usr/include/stdio.h", line: 300, col: 5}),
+		NewDiagnostic(clang.Diagnostic_Ignored, "ignored", Location{fileName: "/repo/src/main.c", line: 1, col: 1}),
+		NewDiagnostic(clang.Diagnostic_Error, "too many errors emitted", Location{}),
+		unused,
+	} {
+		if err := mainFile.AddDiagnostic(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the diagnostic of the header which also included by util.c is written once
+	utilFile := NewFile("/repo/src/util.c", nil)
+	if err := utilFile.AddDiagnostic(notFound); err != nil {
+		t.Fatal(err)
+	}
+	files := []*File{roundTrip(mainFile), utilFile}
+
+	tests := []struct {
+		root, golden string
+	}{
+		{"/repo", "relative.sarif"},
+		{"", "absolute.sarif"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteSARIF(&buf, files, "clang-server", tt.root); err != nil {
+			t.Fatal(err)
+		}
+		validateSARIF(t, buf.Bytes())
+
+		golden := filepath.Join("testdata", "sarif", tt.golden)
+		if *update {
+			if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, nil, "clang-server", ""); err != nil {
+		t.Fatal(err)
+	}
+	validateSARIF(t, buf.Bytes())
+	if err := WriteSARIF(&buf, files, "clang-server", "repo"); err == nil {
+		t.Error("WriteSARIF() with the relative root error = nil, want the error")
+	}
+}
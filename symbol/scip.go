@@ -0,0 +1,533 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// SCIP protobuf field numbers, see https://github.com/sourcegraph/scip/blob/main/scip.proto.
+const (
+	scipIndexMetadata  = 1
+	scipIndexDocuments = 2
+
+	scipMetadataToolInfo             = 2
+	scipMetadataProjectRoot          = 3
+	scipMetadataTextDocumentEncoding = 4
+
+	scipToolInfoName    = 1
+	scipToolInfoVersion = 2
+
+	scipDocumentRelativePath     = 1
+	scipDocumentOccurrences      = 2
+	scipDocumentSymbols          = 3
+	scipDocumentLanguage         = 4
+	scipDocumentPositionEncoding = 6
+
+	scipOccurrenceRange       = 1
+	scipOccurrenceSymbol      = 2
+	scipOccurrenceSymbolRoles = 3
+
+	scipSymbolInformationSymbol        = 1
+	scipSymbolInformationDocumentation = 3
+	scipSymbolInformationDisplayName   = 6
+)
+
+// SCIP enum values.
+const (
+	scipTextEncodingUTF8      = 1 // TextEncoding.UTF8
+	scipPositionEncodingUTF8  = 1 // PositionEncoding.UTF8CodeUnitOffsetFromLineStart
+	scipRoleDefinition        = 0x1
+	scipRoleForwardDefinition = 0x40
+	scipWireVarint            = 0
+	scipWireBytes             = 2
+	scipSymbolScheme          = "clang-server"
+	scipSymbolPackage         = ". . ."
+)
+
+// SCIPOptions represents the options of ExportSCIP.
+type SCIPOptions struct {
+	// ProjectRoot absolute path of the project root. The documents are relative to it, and the files outside of it
+	// are not exported.
+	ProjectRoot string
+
+	// ToolName and ToolVersion describe the indexer in the metadata. The ToolName defaults to "clang-server".
+	ToolName    string
+	ToolVersion string
+}
+
+// SCIPStats represents the statistics of ExportSCIP.
+type SCIPStats struct {
+	Documents   int
+	Occurrences int
+	Symbols     int // number of SymbolInformation
+
+	SkippedExtent  int // occurrences skipped because the extent is unknown, such as the symbol without name
+	SkippedOutside int // occurrences skipped because the file is outside of the project root
+}
+
+// scipOccurrence represents an Occurrence of the SCIP Document.
+type scipOccurrence struct {
+	line, start, end int32 // 0-based line, and 0-based byte columns
+	symbol           string
+	roles            int32
+}
+
+func (o scipOccurrence) less(p scipOccurrence) bool {
+	switch {
+	case o.line != p.line:
+		return o.line < p.line
+	case o.start != p.start:
+		return o.start < p.start
+	case o.end != p.end:
+		return o.end < p.end
+	case o.symbol != p.symbol:
+		return o.symbol < p.symbol
+	}
+	return o.roles < p.roles
+}
+
+// scipDocument represents a SCIP Document being built.
+type scipDocument struct {
+	path        string // relative path
+	language    string
+	occurrences map[scipOccurrence]bool
+	symbols     []*Info // SymbolInformation of the symbols defined in the document
+}
+
+// scipExporter exports the Table to the SCIP index.
+type scipExporter struct {
+	table *Table
+	opts  SCIPOptions
+	stats SCIPStats
+
+	names   map[string]bool          // names of the Table Files
+	headers map[string]*scipDocument // buffered documents of the files which are not the Table File
+	emitted map[ID]bool              // symbols whose SymbolInformation is emitted
+	symbols map[ID]*scipSymbolData   // symbols resolved across the Table
+}
+
+// scipSymbolData represents a symbol resolved across the Table, since each File may know only a part of it.
+type scipSymbolData struct {
+	symbol string
+	info   *Info                // the Info which has the name
+	defs   map[locationKey]bool // definitions, see definitions
+}
+
+// ExportSCIP writes the SCIP index of the Files in t to w, in the protobuf binary of the scip.Index message.
+//
+// Each File of t is written as a Document, which has the Occurrences of the declarations, definition and callers
+// located in the File, and the SymbolInformation of the symbols defined in it. The Occurrences in the headers are
+// collected from all Files, and written as the Documents of the headers after the Files. The Documents of the Files
+// are streamed to w one by one, so only the headers are kept in memory.
+//
+// The range of the Occurrence spans the symbol name from the location, so the Occurrences of the symbol
+// without the name are skipped and counted in the SkippedExtent. The symbols are named by SCIPSymbol.
+func ExportSCIP(w io.Writer, t *Table, opts SCIPOptions) (SCIPStats, error) {
+	if opts.ToolName == "" {
+		opts.ToolName = "clang-server"
+	}
+	if !filepath.IsAbs(opts.ProjectRoot) {
+		return SCIPStats{}, errors.Errorf("project root must be absolute: %q", opts.ProjectRoot)
+	}
+	e := &scipExporter{
+		table:   t,
+		opts:    opts,
+		names:   make(map[string]bool),
+		headers: make(map[string]*scipDocument),
+		emitted: make(map[ID]bool),
+		symbols: make(map[ID]*scipSymbolData),
+	}
+	files := t.Files()
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	for _, f := range files {
+		e.names[filepath.Clean(f.Name())] = true
+	}
+
+	bw := bufio.NewWriter(w)
+	buf := proto.NewBuffer(nil)
+	write := func(field int, msg []byte) error {
+		buf.Reset()
+		buf.EncodeVarint(uint64(field<<3 | scipWireBytes))
+		buf.EncodeRawBytes(msg)
+		_, err := bw.Write(buf.Bytes())
+		return err
+	}
+
+	if err := write(scipIndexMetadata, e.metadata()); err != nil {
+		return e.stats, errors.Wrap(err, "could not write the metadata")
+	}
+	for _, f := range files {
+		doc := e.document(f)
+		if doc == nil {
+			continue
+		}
+		if err := write(scipIndexDocuments, e.encodeDocument(doc)); err != nil {
+			return e.stats, errors.Wrapf(err, "could not write the document of %s", f.Name())
+		}
+	}
+
+	paths := make([]string, 0, len(e.headers))
+	for path := range e.headers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := write(scipIndexDocuments, e.encodeDocument(e.headers[path])); err != nil {
+			return e.stats, errors.Wrapf(err, "could not write the document of %s", path)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return e.stats, errors.Wrap(err, "could not write the index")
+	}
+
+	return e.stats, nil
+}
+
+func (e *scipExporter) metadata() []byte {
+	tool := proto.NewBuffer(nil)
+	encodeSCIPString(tool, scipToolInfoName, e.opts.ToolName)
+	encodeSCIPString(tool, scipToolInfoVersion, e.opts.ToolVersion)
+
+	m := proto.NewBuffer(nil)
+	encodeSCIPBytes(m, scipMetadataToolInfo, tool.Bytes())
+	encodeSCIPString(m, scipMetadataProjectRoot, FileURI(e.opts.ProjectRoot))
+	encodeSCIPVarint(m, scipMetadataTextDocumentEncoding, scipTextEncodingUTF8)
+
+	return m.Bytes()
+}
+
+// document returns the Document of f, and buffers the Occurrences of f in the headers.
+// It returns nil if f is outside of the project root.
+func (e *scipExporter) document(f *File) *scipDocument {
+	name := filepath.Clean(f.Name())
+	path, ok := e.relativePath(name)
+	var doc *scipDocument
+	if ok {
+		doc = &scipDocument{
+			path:        path,
+			language:    scipLanguage(f.Language()),
+			occurrences: make(map[scipOccurrence]bool),
+		}
+	}
+
+	syms := f.Symbols()
+	sort.Slice(syms, func(i, j int) bool {
+		return syms[i].ID().String() < syms[j].ID().String()
+	})
+	for _, sym := range syms {
+		add := func(loc Location, roles int32) {
+			fileName := filepath.Clean(loc.FileName())
+			var target *scipDocument
+			switch {
+			case fileName == name:
+				target = doc
+			case e.names[fileName]:
+				// the other File has its own document
+				return
+			default:
+				target = e.header(fileName, f.Language())
+			}
+			if target == nil {
+				e.stats.SkippedOutside++
+				return
+			}
+			e.addOccurrence(target, sym, loc, roles)
+		}
+
+		data := e.symbol(sym)
+		def := sym.Def()
+		if !def.IsZero() {
+			// the definition may be the referenced declaration of the caller
+			roles := int32(scipRoleForwardDefinition)
+			if data.defs[keyOf(def)] {
+				roles = scipRoleDefinition
+			}
+			add(def, roles)
+		}
+		for _, decl := range sym.Decls() {
+			if !sameLocation(decl, def) {
+				add(decl, scipRoleForwardDefinition)
+			}
+		}
+		for _, caller := range sym.Callers() {
+			add(caller.Location(), 0)
+		}
+	}
+
+	return doc
+}
+
+// header returns the buffered Document of the header fileName, or nil if it is outside of the project root.
+// The language of the header is the language of the first File which includes it.
+func (e *scipExporter) header(fileName string, lang Language) *scipDocument {
+	if doc, ok := e.headers[fileName]; ok {
+		return doc
+	}
+	path, ok := e.relativePath(fileName)
+	if !ok {
+		return nil
+	}
+	doc := &scipDocument{
+		path:        path,
+		language:    scipLanguage(lang),
+		occurrences: make(map[scipOccurrence]bool),
+	}
+	e.headers[fileName] = doc
+
+	return doc
+}
+
+// relativePath returns the path of fileName relative to the project root.
+func (e *scipExporter) relativePath(fileName string) (string, bool) {
+	return projectRelativePath(e.opts.ProjectRoot, fileName)
+}
+
+// projectRelativePath returns the slash separated path of fileName relative to root, and whether fileName is
+// inside of root.
+func projectRelativePath(root, fileName string) (string, bool) {
+	path, err := filepath.Rel(root, fileName)
+	if err != nil || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(path), true
+}
+
+func (e *scipExporter) addOccurrence(doc *scipDocument, sym *Info, loc Location, roles int32) {
+	data := e.symbol(sym)
+	name := data.info.Name()
+	line, col := loc.Line(), loc.rawCol()
+	if name == "" || line == 0 || col == 0 {
+		e.stats.SkippedExtent++
+		return
+	}
+
+	occ := scipOccurrence{
+		line:   int32(line - 1),
+		start:  int32(col - 1),
+		end:    int32(col - 1 + uint32(len(name))),
+		symbol: data.symbol,
+		roles:  roles,
+	}
+	if doc.occurrences[occ] {
+		// the header location recorded by the several Files
+		return
+	}
+	doc.occurrences[occ] = true
+	e.stats.Occurrences++
+
+	// the SymbolInformation is in the document of the definition, or the first declaration without definition
+	if roles&scipRoleDefinition != 0 || (len(data.defs) == 0 && roles&scipRoleForwardDefinition != 0) {
+		if id := sym.ID(); !e.emitted[id] {
+			e.emitted[id] = true
+			doc.symbols = append(doc.symbols, data.info)
+		}
+	}
+}
+
+func (e *scipExporter) encodeDocument(doc *scipDocument) []byte {
+	occs := make([]scipOccurrence, 0, len(doc.occurrences))
+	for occ := range doc.occurrences {
+		occs = append(occs, occ)
+	}
+	sort.Slice(occs, func(i, j int) bool { return occs[i].less(occs[j]) })
+
+	b := proto.NewBuffer(nil)
+	encodeSCIPString(b, scipDocumentRelativePath, doc.path)
+	for _, occ := range occs {
+		o := proto.NewBuffer(nil)
+		// the packed range of [line, start, end], which is on the single line
+		r := proto.NewBuffer(nil)
+		r.EncodeVarint(uint64(occ.line))
+		r.EncodeVarint(uint64(occ.start))
+		r.EncodeVarint(uint64(occ.end))
+		encodeSCIPBytes(o, scipOccurrenceRange, r.Bytes())
+		encodeSCIPString(o, scipOccurrenceSymbol, occ.symbol)
+		encodeSCIPVarint(o, scipOccurrenceSymbolRoles, uint64(occ.roles))
+		encodeSCIPBytes(b, scipDocumentOccurrences, o.Bytes())
+	}
+	for _, sym := range doc.symbols {
+		s := proto.NewBuffer(nil)
+		encodeSCIPString(s, scipSymbolInformationSymbol, e.symbol(sym).symbol)
+		if sig := sym.Signature(); sig != "" {
+			encodeSCIPString(s, scipSymbolInformationDocumentation, "```"+scipFence(doc.language)+"\n"+sig+"\n```")
+		}
+		encodeSCIPString(s, scipSymbolInformationDisplayName, qualifiedName(sym))
+		encodeSCIPBytes(b, scipDocumentSymbols, s.Bytes())
+		e.stats.Symbols++
+	}
+	encodeSCIPString(b, scipDocumentLanguage, doc.language)
+	encodeSCIPVarint(b, scipDocumentPositionEncoding, scipPositionEncodingUTF8)
+	e.stats.Documents++
+
+	return b.Bytes()
+}
+
+// symbol returns the scipSymbolData of sym, which is resolved once per symbol.
+func (e *scipExporter) symbol(sym *Info) *scipSymbolData {
+	id := sym.ID()
+	if data, ok := e.symbols[id]; ok {
+		return data
+	}
+
+	infos := e.table.Symbols(id)
+	data := &scipSymbolData{
+		info: sym,
+		defs: make(map[locationKey]bool),
+	}
+	for _, info := range infos {
+		if info.Name() != "" {
+			data.info = info
+			break
+		}
+	}
+	defs, _ := definitions(infos)
+	for _, def := range defs {
+		data.defs[keyOf(def)] = true
+	}
+	var parent *Info
+	if usr := data.info.ParentUSR(); usr != "" {
+		for _, p := range e.table.Symbols(ToID(usr)) {
+			if p.Name() != "" {
+				parent = p
+				break
+			}
+		}
+	}
+	data.symbol = SCIPSymbol(data.info, parent)
+	e.symbols[id] = data
+
+	return data
+}
+
+// SCIPSymbol returns the global SCIP symbol of sym, such as
+//
+//	clang-server . . . ns/Vector#size(3fa2b1c0).
+//
+// The descriptors are the enclosing namespaces, the enclosing record of the member, and the name of sym.
+// The function and method have the disambiguator of their USR, so the overloads are distinguished.
+// The symbol without the name is described by its ID. The parent is the enclosing record of sym,
+// which may be nil.
+func SCIPSymbol(sym, parent *Info) string {
+	var b bytes.Buffer
+	b.WriteString(scipSymbolScheme + " " + scipSymbolPackage + " ")
+	if ns := sym.Namespace(); ns != "" {
+		for _, name := range strings.Split(ns, "::") {
+			b.WriteString(scipIdentifier(name) + "/")
+		}
+	}
+	if parent != nil {
+		b.WriteString(scipIdentifier(parent.Name()) + "#")
+	}
+
+	name := sym.Name()
+	if name == "" {
+		b.WriteString(scipIdentifier(sym.ID().String()) + ":")
+		return b.String()
+	}
+	name = scipIdentifier(name)
+	switch sym.SymbolKind() {
+	case SymbolKindNamespace, SymbolKindNamespaceAlias:
+		b.WriteString(name + "/")
+	case SymbolKindStruct, SymbolKindUnion, SymbolKindClass, SymbolKindEnum, SymbolKindTypedef, SymbolKindTypeAlias,
+		SymbolKindTypeAliasTemplate, SymbolKindClassTemplate, SymbolKindClassTemplatePartialSpecialization,
+		SymbolKindObjCInterface, SymbolKindObjCCategory, SymbolKindObjCProtocol:
+		b.WriteString(name + "#")
+	case SymbolKindFunction, SymbolKindFunctionTemplate, SymbolKindMethod, SymbolKindConstructor,
+		SymbolKindDestructor, SymbolKindConversionFunction, SymbolKindObjCInstanceMethod:
+		b.WriteString(name + "(" + sym.ID().String()[:8] + ").")
+	case SymbolKindParameter:
+		b.WriteString("(" + name + ")")
+	case SymbolKindTemplateTypeParameter, SymbolKindNonTypeTemplateParameter, SymbolKindTemplateTemplateParameter:
+		b.WriteString("[" + name + "]")
+	case SymbolKindMacro:
+		b.WriteString(name + "!")
+	default:
+		b.WriteString(name + ".")
+	}
+
+	return b.String()
+}
+
+// scipIdentifier returns the SCIP identifier of name, which is escaped by the backticks unless it is simple.
+func scipIdentifier(name string) string {
+	simple := name != ""
+	for _, r := range name {
+		if !(r == '_' || r == '+' || r == '-' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return name
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// qualifiedName returns the namespace qualified name of sym, such as "a::b::foo".
+func qualifiedName(sym *Info) string {
+	if ns := sym.Namespace(); ns != "" {
+		return ns + "::" + sym.Name()
+	}
+	return sym.Name()
+}
+
+// scipLanguage returns the SCIP language name of lang.
+func scipLanguage(lang Language) string {
+	switch lang {
+	case LanguageC:
+		return "C"
+	case LanguageCXX:
+		return "CPP"
+	case LanguageObjC:
+		return "ObjectiveC"
+	case LanguageObjCXX:
+		return "ObjectiveCPP"
+	}
+	return ""
+}
+
+// scipFence returns the markdown code fence language of the SCIP language.
+func scipFence(language string) string {
+	switch language {
+	case "CPP":
+		return "cpp"
+	case "ObjectiveC":
+		return "objc"
+	case "ObjectiveCPP":
+		return "objcpp"
+	}
+	return "c"
+}
+
+func encodeSCIPString(b *proto.Buffer, field int, s string) {
+	if s == "" {
+		return
+	}
+	b.EncodeVarint(uint64(field<<3 | scipWireBytes))
+	b.EncodeStringBytes(s)
+}
+
+func encodeSCIPBytes(b *proto.Buffer, field int, msg []byte) {
+	b.EncodeVarint(uint64(field<<3 | scipWireBytes))
+	b.EncodeRawBytes(msg)
+}
+
+func encodeSCIPVarint(b *proto.Buffer, field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.EncodeVarint(uint64(field<<3 | scipWireVarint))
+	b.EncodeVarint(v)
+}
@@ -0,0 +1,238 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// scipField represents a decoded protobuf field, which has either the varint or the bytes.
+type scipField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+func decodeSCIPFields(t *testing.T, buf []byte) []scipField {
+	t.Helper()
+	var fields []scipField
+	for len(buf) > 0 {
+		key := scipVarint(t, &buf)
+		f := scipField{num: int(key >> 3)}
+		switch key & 7 {
+		case scipWireVarint:
+			f.varint = scipVarint(t, &buf)
+		case scipWireBytes:
+			n := int(scipVarint(t, &buf))
+			if n > len(buf) {
+				t.Fatalf("field %d: length %d exceeds %d bytes", f.num, n, len(buf))
+			}
+			f.bytes, buf = buf[:n], buf[n:]
+		default:
+			t.Fatalf("unexpected wire type %d of field %d", key&7, f.num)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// scipVarint decodes the varint at the head of buf, and advances buf.
+func scipVarint(t *testing.T, buf *[]byte) uint64 {
+	t.Helper()
+	v, n := proto.DecodeVarint(*buf)
+	if n == 0 {
+		t.Fatalf("invalid varint: %x", *buf)
+	}
+	*buf = (*buf)[n:]
+	return v
+}
+
+// scipTestDocument represents a decoded SCIP Document for the comparison.
+type scipTestDocument struct {
+	path        string
+	language    string
+	occurrences []string // "line:start:end roles symbol"
+	symbols     []string // "symbol display_name"
+}
+
+func decodeSCIPDocument(t *testing.T, buf []byte) scipTestDocument {
+	var doc scipTestDocument
+	for _, f := range decodeSCIPFields(t, buf) {
+		switch f.num {
+		case scipDocumentRelativePath:
+			doc.path = string(f.bytes)
+		case scipDocumentLanguage:
+			doc.language = string(f.bytes)
+		case scipDocumentOccurrences:
+			var rng []uint64
+			var symbol string
+			var roles uint64
+			for _, o := range decodeSCIPFields(t, f.bytes) {
+				switch o.num {
+				case scipOccurrenceRange:
+					for r := o.bytes; len(r) > 0; {
+						rng = append(rng, scipVarint(t, &r))
+					}
+				case scipOccurrenceSymbol:
+					symbol = string(o.bytes)
+				case scipOccurrenceSymbolRoles:
+					roles = o.varint
+				}
+			}
+			if len(rng) != 3 {
+				t.Fatalf("%s: range = %v, want [line, start, end]", doc.path, rng)
+			}
+			doc.occurrences = append(doc.occurrences, fmt.Sprintf("%d:%d:%d %#x %s", rng[0], rng[1], rng[2], roles, symbol))
+		case scipDocumentSymbols:
+			var symbol, displayName string
+			for _, s := range decodeSCIPFields(t, f.bytes) {
+				switch s.num {
+				case scipSymbolInformationSymbol:
+					symbol = string(s.bytes)
+				case scipSymbolInformationDisplayName:
+					displayName = string(s.bytes)
+				}
+			}
+			doc.symbols = append(doc.symbols, symbol+" "+displayName)
+		}
+	}
+	return doc
+}
+
+func TestExportSCIP(t *testing.T) {
+	const (
+		addUSR    = "c:@F@add"
+		anonUSR   = "c:@F@anon"
+		printfUSR = "c:@F@printf"
+	)
+	header := Location{fileName: "/repo/include/util.h", line: 1, col: 5, usr: addUSR}
+	setFunction := func(f *File, usr, name string) {
+		sym := f.Symbol(ToID(usr))
+		sym.name, sym.kind = name, SymbolKindFunction
+		sym.resultType, sym.params = "int", nil
+	}
+
+	util := NewFile("/repo/util.c", nil)
+	def := Location{fileName: "/repo/util.c", line: 3, col: 5, usr: addUSR}
+	util.AddDefinition(def, def)
+	util.AddDecl(header)
+	setFunction(util, addUSR, "add")
+
+	mainc := NewFile("/repo/main.c", nil)
+	mainc.AddDecl(header)
+	// the caller records the header declaration as the definition of add
+	if err := mainc.AddCaller(Location{fileName: "/repo/main.c", line: 5, col: 10, usr: addUSR}, header, true); err != nil {
+		t.Fatal(err)
+	}
+	// the symbol without the name has no extent
+	if err := mainc.AddCaller(Location{fileName: "/repo/main.c", line: 6, col: 3, usr: anonUSR}, Location{}, true); err != nil {
+		t.Fatal(err)
+	}
+	mainc.AddDecl(Location{fileName: "/usr/include/stdio.h", line: 10, col: 5, usr: printfUSR})
+	setFunction(mainc, printfUSR, "printf")
+
+	var buf bytes.Buffer
+	stats, err := ExportSCIP(&buf, NewTable([]*File{util, mainc}), SCIPOptions{ProjectRoot: "/repo", ToolVersion: "0.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := decodeSCIPFields(t, buf.Bytes())
+	if len(fields) == 0 || fields[0].num != scipIndexMetadata {
+		t.Fatalf("the metadata is not the first field: %v", fields)
+	}
+	var meta []string
+	for _, f := range decodeSCIPFields(t, fields[0].bytes) {
+		switch f.num {
+		case scipMetadataToolInfo:
+			for _, tool := range decodeSCIPFields(t, f.bytes) {
+				meta = append(meta, string(tool.bytes))
+			}
+		case scipMetadataProjectRoot:
+			meta = append(meta, string(f.bytes))
+		}
+	}
+	if want := []string{"clang-server", "0.1.0", "file:///repo"}; !reflect.DeepEqual(meta, want) {
+		t.Errorf("metadata = %q, want %q", meta, want)
+	}
+
+	var docs []scipTestDocument
+	for _, f := range fields[1:] {
+		if f.num != scipIndexDocuments {
+			t.Fatalf("unexpected field %d of the index", f.num)
+		}
+		docs = append(docs, decodeSCIPDocument(t, f.bytes))
+	}
+
+	add := "clang-server . . . add(" + ToID(addUSR).String()[:8] + ")."
+	want := []scipTestDocument{
+		{
+			path:        "main.c",
+			language:    "C",
+			occurrences: []string{"4:9:12 0x0 " + add},
+		},
+		{
+			path:        "util.c",
+			language:    "C",
+			occurrences: []string{"2:4:7 0x1 " + add},
+			symbols:     []string{add + " add"},
+		},
+		{
+			// the declaration recorded by both Files is written once
+			path:        "include/util.h",
+			language:    "C",
+			occurrences: []string{"0:4:7 0x40 " + add},
+		},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("documents = %+v, want %+v", docs, want)
+	}
+
+	wantStats := SCIPStats{
+		Documents:      3,
+		Occurrences:    3,
+		Symbols:        1,
+		SkippedExtent:  1,
+		SkippedOutside: 1,
+	}
+	if stats != wantStats {
+		t.Errorf("stats = %+v, want %+v", stats, wantStats)
+	}
+}
+
+func TestExportSCIP_ProjectRoot(t *testing.T) {
+	if _, err := ExportSCIP(new(bytes.Buffer), NewTable(nil), SCIPOptions{ProjectRoot: "repo"}); err == nil {
+		t.Error("ExportSCIP() with the relative project root: want error")
+	}
+}
+
+func TestSCIPSymbol(t *testing.T) {
+	id := ToID("c:@N@ns@S@Vector@F@size#")
+	parent := &Info{name: "Vector", kind: SymbolKindStruct}
+	tests := []struct {
+		sym    *Info
+		parent *Info
+		want   string
+	}{
+		{&Info{id: id, name: "size", namespace: "ns", kind: SymbolKindMethod}, parent, "clang-server . . . ns/Vector#size(" + id.String()[:8] + ")."},
+		{&Info{name: "Vector", namespace: "ns::detail", kind: SymbolKindClass}, nil, "clang-server . . . ns/detail/Vector#"},
+		{&Info{name: "ns", kind: SymbolKindNamespace}, nil, "clang-server . . . ns/"},
+		{&Info{name: "n", kind: SymbolKindParameter}, nil, "clang-server . . . (n)"},
+		{&Info{name: "T", kind: SymbolKindTemplateTypeParameter}, nil, "clang-server . . . [T]"},
+		{&Info{name: "MAX", kind: SymbolKindMacro}, nil, "clang-server . . . MAX!"},
+		{&Info{name: "operator+=", kind: SymbolKindVariable}, nil, "clang-server . . . `operator+=`."},
+		{&Info{id: id}, nil, "clang-server . . . " + id.String() + ":"},
+	}
+	for _, tt := range tests {
+		if got := SCIPSymbol(tt.sym, tt.parent); got != tt.want {
+			t.Errorf("SCIPSymbol(%q) = %q, want %q", tt.sym.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SymbolIndex indexes the symbol names across the Files of the Table, for the workspace-wide symbol search.
+// It is the snapshot of the Table when the index is built, and safe for concurrent use.
+type SymbolIndex struct {
+	entries []symbolEntry
+	metrics Metrics // Metrics of the Table, nil uses the package Metrics
+}
+
+// symbolEntry represents a symbol in the SymbolIndex. The same symbol in the several Files is merged into
+// one entry, which has the definitions followed by the declarations of all Files.
+type symbolEntry struct {
+	info *Info
+	locs []Location
+}
+
+// SymbolMatch represents a symbol which matches the search query.
+type SymbolMatch struct {
+	Info     *Info
+	Location Location // definition, or the declaration if the symbol has no definition
+	Score    int      // fuzzy matching score of the name
+}
+
+// NewSymbolIndex builds the SymbolIndex of the symbols which have the name in the Files of t.
+func NewSymbolIndex(t *Table) *SymbolIndex {
+	var symbols [][]*Info
+	entries := make(map[ID]int)
+	for _, f := range t.Files() {
+		for _, sym := range f.Symbols() {
+			i, ok := entries[sym.ID()]
+			if !ok {
+				i = len(symbols)
+				entries[sym.ID()] = i
+				symbols = append(symbols, nil)
+			}
+			symbols[i] = append(symbols[i], sym)
+		}
+	}
+
+	t.mu.RLock()
+	idx := &SymbolIndex{metrics: t.metrics}
+	t.mu.RUnlock()
+	for _, infos := range symbols {
+		// the File which only references the symbol may not know its name
+		var named *Info
+		for _, info := range infos {
+			if info.Name() != "" {
+				named = info
+				break
+			}
+		}
+		if named == nil {
+			continue
+		}
+		defs, decls := definitions(infos)
+		idx.entries = append(idx.entries, symbolEntry{info: named, locs: append(defs, decls...)})
+	}
+
+	return idx
+}
+
+// Search returns the symbols whose name fuzzy matches the query.
+//
+// The matches are sorted by descending score, and ties are broken by the name and location.
+// If limit is greater than zero, the results are truncated to at most limit matches.
+func (idx *SymbolIndex) Search(query string, limit int) []SymbolMatch {
+	return idx.search(query, limit, func(Location) bool { return true })
+}
+
+// SearchInPath is like Search, but returns only the symbols which defined or declared under the pathPrefix
+// directory, and the Location of the match is the definition or declaration under it.
+// The pathPrefix matches the whole path components, so "/src/foo" does not match "/src/foobar/a.c".
+//
+// The symbols are filtered before the limit is applied, so the higher ranked symbols outside the pathPrefix
+// do not reduce the results.
+func (idx *SymbolIndex) SearchInPath(query, pathPrefix string, limit int) []SymbolMatch {
+	prefix := filepath.Clean(pathPrefix)
+	return idx.search(query, limit, func(loc Location) bool {
+		return hasPathPrefix(loc.FileName(), prefix)
+	})
+}
+
+func (idx *SymbolIndex) search(query string, limit int, filter func(Location) bool) []SymbolMatch {
+	defer observeSince(metricsOf(idx.metrics), MetricSearchSeconds, time.Now())
+
+	var matches []SymbolMatch
+	for _, e := range idx.entries {
+		score, ok := Score(query, e.info.Name())
+		if !ok {
+			continue
+		}
+		for _, loc := range e.locs {
+			if filter(loc) {
+				matches = append(matches, SymbolMatch{Info: e.info, Location: loc, Score: score})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if an, bn := a.Info.Name(), b.Info.Name(); an != bn {
+			return an < bn
+		}
+		return a.Location.Less(b.Location)
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
+// hasPathPrefix reports whether fileName is prefix itself or under the prefix directory.
+func hasPathPrefix(fileName, prefix string) bool {
+	if !strings.HasPrefix(fileName, prefix) {
+		return false
+	}
+	return len(fileName) == len(prefix) || strings.HasSuffix(prefix, string(filepath.Separator)) || fileName[len(prefix)] == filepath.Separator
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSymbolIndex_SearchInPath(t *testing.T) {
+	newFile := func(name string, syms ...Location) *File {
+		f := NewFile(name, nil)
+		for _, loc := range syms {
+			f.AddDefinition(loc, loc)
+			f.Symbol(ToID(loc.usr)).name = loc.usr[len("c:@F@"):]
+		}
+		return f
+	}
+	lib := newFile("/repo/lib/parse.c",
+		Location{fileName: "/repo/lib/parse.c", line: 1, col: 5, usr: "c:@F@parseFile"},
+	)
+	app := newFile("/repo/app/main.c",
+		Location{fileName: "/repo/app/main.c", line: 3, col: 5, usr: "c:@F@parse_file_config"},
+		Location{fileName: "/repo/app/main.c", line: 9, col: 5, usr: "c:@F@main"},
+	)
+	// the header in app declares the symbol which defined in lib
+	app.AddDecl(Location{fileName: "/repo/app/include/lib.h", line: 2, col: 5, usr: "c:@F@parseFile"})
+	other := newFile("/repo/application/other.c",
+		Location{fileName: "/repo/application/other.c", line: 1, col: 5, usr: "c:@F@parseFiles"},
+	)
+	idx := NewSymbolIndex(NewTable([]*File{lib, app, other}))
+
+	type match struct {
+		name string
+		pos  string
+	}
+	matches := func(ms []SymbolMatch) []match {
+		var got []match
+		for _, m := range ms {
+			got = append(got, match{m.Info.Name(), positions([]Location{m.Location})[0]})
+		}
+		return got
+	}
+
+	all := matches(idx.Search("parsefile", 0))
+	want := []match{
+		{"parseFile", "/repo/lib/parse.c:1:5"},
+		{"parseFiles", "/repo/application/other.c:1:5"},
+		{"parse_file_config", "/repo/app/main.c:3:5"},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("Search() = %v, want %v", all, want)
+	}
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []match
+	}{
+		{"/repo/lib", 0, []match{{"parseFile", "/repo/lib/parse.c:1:5"}}},
+		// the higher ranked parseFile is only declared under the prefix, and parseFiles is outside of it
+		{"/repo/app", 0, []match{
+			{"parseFile", "/repo/app/include/lib.h:2:5"},
+			{"parse_file_config", "/repo/app/main.c:3:5"},
+		}},
+		{"/repo/app/", 1, []match{{"parseFile", "/repo/app/include/lib.h:2:5"}}},
+		{"/repo/app/main.c", 1, []match{{"parse_file_config", "/repo/app/main.c:3:5"}}},
+		{"/repo/ap", 0, nil},
+	}
+	for _, tt := range tests {
+		if got := matches(idx.SearchInPath("parsefile", tt.prefix, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchInPath(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
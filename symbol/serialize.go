@@ -0,0 +1,114 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/hashutil"
+)
+
+// builderPool pool of the flatbuffers.Builder for serialization.
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return flatbuffers.NewBuilder(0)
+	},
+}
+
+// serializeBuffer holds the scratch buffers which reused across the vectors during a File serialization.
+type serializeBuffer struct {
+	offsets []flatbuffers.UOffsetT
+	hex     [2 * hashutil.Size]byte
+}
+
+// offsetsOf returns the empty offsets slice which has the capacity of at least n.
+// The returned slice is valid until the next call, so the vector must be ended before that.
+func (b *serializeBuffer) offsetsOf(n int) []flatbuffers.UOffsetT {
+	if cap(b.offsets) < n {
+		b.offsets = make([]flatbuffers.UOffsetT, 0, n)
+	}
+	return b.offsets[:0]
+}
+
+// createHash serializes the hexadecimal encoded hash h as the string, without the intermediate string.
+func (b *serializeBuffer) createHash(builder *flatbuffers.Builder, h [hashutil.Size]byte) flatbuffers.UOffsetT {
+	n := hashutil.Encode(b.hex[:], h[:])
+	return builder.CreateByteString(b.hex[:n])
+}
+
+// SerializeAll serializes the files in parallel using workers goroutines, and returns
+// the flatbuffers binaries in the same order as files.
+// If workers is less than or equal to zero, runtime.NumCPU() is used.
+//
+// Each file is serialized with its own pooled builder, so the files builder is not used.
+// If any file exceeds the flatbuffers size limit, the error of the ErrTooLarge cause of the first such file in
+// files is returned, instead of panicking in the worker goroutine.
+func SerializeAll(files []*File, workers int) ([][]byte, error) {
+	for i, f := range files {
+		if f == nil {
+			return nil, errors.Errorf("files[%d] is nil", i)
+		}
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	bufs := make([][]byte, len(files))
+	errs := make([]error, len(files))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				bufs[j], errs[j] = serializeBytes(files[j])
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "files[%d] %s", i, files[i].Name())
+		}
+	}
+
+	return bufs, nil
+}
+
+// serializeBytes serializes f with the pooled builder, and returns the copy of finished bytes.
+// It returns the error of the ErrTooLarge cause if f exceeds the flatbuffers size limit.
+func serializeBytes(f *File) ([]byte, error) {
+	builder := builderPool.Get().(*flatbuffers.Builder)
+	defer builderPool.Put(builder)
+
+	start := time.Now()
+	builder.Reset()
+	if err := f.serializeChecked(builder, f.serializedSymbols(), true, true, maxSerializedSize); err != nil {
+		return nil, err
+	}
+	observeSerialize(builder, start)
+
+	finished := builder.FinishedBytes()
+	buf := make([]byte, len(finished))
+	copy(buf, finished)
+
+	return buf, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+)
+
+// newTestFiles returns the n files which each has symbols symbols.
+func newTestFiles(n, symbols int) []*File {
+	files := make([]*File, n)
+	for i := range files {
+		name := fmt.Sprintf("/src/file%d.c", i)
+		f := NewFile(name, []string{"-std=c11", "-I/src/include"})
+		for j := 0; j < symbols; j++ {
+			usr := fmt.Sprintf("c:@F@func%d_%d", i, j)
+			decl := Location{fileName: "/src/include/decl.h", line: uint32(j + 1), col: 5, offset: uint32(j * 40), usr: usr}
+			def := Location{fileName: name, line: uint32(j*10 + 1), col: 5, offset: uint32(j * 400), usr: usr}
+			f.AddDefinition(decl, def)
+			f.AddCaller(Location{fileName: name, line: uint32(j*10 + 5), col: 3, offset: uint32(j*400 + 80)}, def, true)
+		}
+		files[i] = f
+	}
+	return files
+}
+
+func TestSerializeAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []*File
+		workers int
+		wantErr bool
+	}{
+		{name: "empty", files: nil, workers: 4},
+		{name: "single worker", files: newTestFiles(10, 5), workers: 1},
+		{name: "more workers than files", files: newTestFiles(3, 5), workers: 8},
+		{name: "default workers", files: newTestFiles(50, 5), workers: 0},
+		{name: "nil file", files: []*File{NewFile("a.c", nil), nil}, workers: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bufs, err := SerializeAll(tt.files, tt.workers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SerializeAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(bufs) != len(tt.files) {
+				t.Fatalf("len(SerializeAll()) = %d, want %d", len(bufs), len(tt.files))
+			}
+			for i, buf := range bufs {
+				if got := GetRootAsFile(buf, 0); !got.Equal(tt.files[i]) {
+					t.Errorf("SerializeAll()[%d] is not equal to files[%d] %s", i, i, tt.files[i].Name())
+				}
+			}
+		})
+	}
+
+	t.Run("too large file", func(t *testing.T) {
+		files := newTestFiles(8, 5)
+		limit := 0
+		for _, f := range files {
+			if n := len(mustSerializeBytes(f)); n > limit {
+				limit = n
+			}
+		}
+		large := NewFile("large.c", nil)
+		large.AddTranslationUnit(make([]byte, limit))
+		files[5] = large
+		defer setSerializedLimit(limit)()
+
+		// the error is returned instead of panicking in the worker goroutine
+		bufs, err := SerializeAll(files, 4)
+		if errors.Cause(err) != ErrTooLarge || bufs != nil {
+			t.Fatalf("SerializeAll() = %d bufs, %v, want the ErrTooLarge cause", len(bufs), err)
+		}
+		if !strings.Contains(err.Error(), "files[5] large.c") {
+			t.Errorf("SerializeAll() error = %v, want the name of files[5]", err)
+		}
+	})
+}
+
+func BenchmarkSerializeAll(b *testing.B) {
+	files := newTestFiles(1000, 20)
+
+	b.Run("Serial", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, f := range files {
+				mustSerializeBytes(f)
+			}
+		}
+	})
+	b.Run("Parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := SerializeAll(files, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchmarkFile returns the synthetic file of each benchmark size.
+func benchmarkFile(symbols int, tu bool) *File {
+	f := newTestFiles(1, symbols)[0]
+	f.SetReproducible(true)
+	for i := 0; i < symbols/10+1; i++ {
+		f.addHeader(fmt.Sprintf("/src/include/header%d.h", i), time.Unix(1500000000, 0))
+	}
+	if tu {
+		f.AddTranslationUnit(bytes.Repeat([]byte("translation unit"), 4096))
+	}
+	return f
+}
+
+var benchmarkSizes = []struct {
+	name    string
+	symbols int
+}{
+	{"Small", 10},
+	{"Medium", 200},
+	{"Large", 2000},
+}
+
+func TestSerialize_Checksum(t *testing.T) {
+	// pins the serialized bytes, so the serialization optimizations must not change the output
+	const want = "1e25d129e5c26e8742b6f1f85efc4e225fbcb311d11362cf0e6ae99d3d6cd765"
+
+	f := benchmarkFile(200, true)
+	for i := 0; i < 2; i++ {
+		sum := sha256.Sum256(mustSerializeBytes(f))
+		if got := hex.EncodeToString(sum[:]); got != want {
+			t.Errorf("#%d: checksum = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func BenchmarkSerialize(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		for _, tu := range []bool{false, true} {
+			name := size.name
+			if tu {
+				name += "WithTU"
+			}
+			b.Run(name, func(b *testing.B) {
+				f := benchmarkFile(size.symbols, tu)
+				builder := flatbuffers.NewBuilder(0)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					builder.Reset()
+					f.serialize(builder)
+				}
+			})
+		}
+	}
+}
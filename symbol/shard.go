@@ -0,0 +1,152 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+)
+
+// ErrTooLarge is the cause of the error which returned when the serialized File exceeds the flatbuffers size limit.
+var ErrTooLarge = errors.New("serialized File exceeds the flatbuffers size limit")
+
+// maxSerializedSize maximum number of bytes of the serialized File.
+// The flatbuffers builder cannot grow the buffer beyond 1 GiB, even though the offsets address 2 GiB.
+// The tests lower it to exercise the sharding without allocating gigabytes.
+var maxSerializedSize = 1 << 30
+
+// flatbuffersGrowPanic is the panic value of the flatbuffers builder which cannot grow the buffer any further.
+const flatbuffersGrowPanic = "cannot grow buffer beyond 2 gigabytes"
+
+// shardSymbolOverhead number of bytes which a symbol may occupy in a shard in addition to its measured size,
+// which are the offset in the symbols vector and the alignment padding.
+const shardSymbolOverhead = 4 + 8
+
+// ShardManifest describes the shards of the File which serialized by SerializeSharded.
+type ShardManifest struct {
+	Name   string
+	Shards []Shard
+}
+
+// Shard describes a shard of the File.
+type Shard struct {
+	Symbols int // number of symbols in the shard
+	Size    int // size of the serialized shard
+}
+
+// SerializeSharded serializes f into one or more flatbuffers binaries which each fits in the flatbuffers size limit,
+// by splitting the symbols vector across them. The first shard holds the flags, translation unit, headers and
+// includes in addition to its symbols, and the rest hold only the name and symbols. The File which fits in a single buffer
+// results in one shard, which is identical to the Serialize result.
+//
+// The returned manifest describes the shards, and LoadShards presents them as one File.
+// The error of the ErrTooLarge cause is returned if a single symbol, or the first shard without any symbols,
+// exceeds the limit.
+func (f *File) SerializeSharded() (ShardManifest, [][]byte, error) {
+	return f.serializeSharded(maxSerializedSize)
+}
+
+// serializeSharded serializes f into the shards of at most limit bytes each.
+func (f *File) serializeSharded(limit int) (ShardManifest, [][]byte, error) {
+	symbols := f.serializedSymbols()
+	builder := flatbuffers.NewBuilder(0)
+
+	// measure the sizes of the shards without symbols, and of each symbol
+	if err := f.serializeChecked(builder, nil, true, true, limit); err != nil {
+		return ShardManifest{}, nil, err
+	}
+	firstBase := int(builder.Offset())
+	builder.Reset()
+	if err := f.serializeChecked(builder, nil, false, true, limit); err != nil {
+		return ShardManifest{}, nil, err
+	}
+	restBase := int(builder.Offset())
+
+	// the symbols are split greedily, so the shards are filled in the serialization order
+	var bounds []int // end index of each shard
+	size := firstBase
+	buf := new(serializeBuffer)
+	for i, info := range symbols {
+		builder.Reset()
+		info.serialize(builder, f.packedLocations, buf)
+		n := int(builder.Offset()) + shardSymbolOverhead
+
+		start := 0
+		if len(bounds) > 0 {
+			start = bounds[len(bounds)-1]
+		}
+		if size+n > limit && i > start {
+			bounds = append(bounds, i)
+			size = restBase
+		}
+		if size+n > limit {
+			return ShardManifest{}, nil, errors.Wrapf(ErrTooLarge, "symbol %s: %d bytes exceeds the limit of %d bytes", info.ID(), n, limit)
+		}
+		size += n
+	}
+	bounds = append(bounds, len(symbols))
+
+	m := ShardManifest{
+		Name:   f.Name(),
+		Shards: make([]Shard, 0, len(bounds)),
+	}
+	shards := make([][]byte, 0, len(bounds))
+	start := 0
+	for i, end := range bounds {
+		builder.Reset()
+		if err := f.serializeChecked(builder, symbols[start:end], i == 0, true, limit); err != nil {
+			return ShardManifest{}, nil, errors.Wrapf(err, "shard %d", i)
+		}
+		finished := builder.FinishedBytes()
+		shard := make([]byte, len(finished))
+		copy(shard, finished)
+
+		shards = append(shards, shard)
+		m.Shards = append(m.Shards, Shard{Symbols: end - start, Size: len(shard)})
+		start = end
+	}
+
+	return m, shards, nil
+}
+
+// LoadShards returns the in-memory File of the shards which serialized by SerializeSharded, as if the File
+// was serialized into one buffer and unmarshaled. The shards must be in the order of the manifest.
+// Each shard is validated by OpenFile, and the error of it is returned.
+func LoadShards(m ShardManifest, shards [][]byte) (*File, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("no shards")
+	}
+	if len(shards) != len(m.Shards) {
+		return nil, errors.Errorf("got %d shards, manifest describes %d", len(shards), len(m.Shards))
+	}
+
+	var f *File
+	for i, buf := range shards {
+		if len(buf) != m.Shards[i].Size {
+			return nil, errors.Errorf("shard %d: got %d bytes, manifest describes %d", i, len(buf), m.Shards[i].Size)
+		}
+		shard, err := OpenFile(buf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "shard %d", i)
+		}
+		if name := shard.Name(); name != m.Name {
+			return nil, errors.Errorf("shard %d: got name %q, manifest describes %q", i, name, m.Name)
+		}
+		if n := safeLength(shard.file.Table(), fileSymbolsSlot); n != m.Shards[i].Symbols {
+			return nil, errors.Errorf("shard %d: got %d symbols, manifest describes %d", i, n, m.Shards[i].Symbols)
+		}
+
+		if i == 0 {
+			f = shard
+			f.Unmarshal()
+			continue
+		}
+		for _, s := range shard.Symbols() {
+			f.indexSymbol(s)
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// shardFile returns the File which has n function symbols with the callers, and a translation unit of tuBytes.
+func shardFile(n, tuBytes int) *File {
+	f := NewFile("main.c", []string{"-std=c11"})
+	f.SetReproducible(true)
+	f.AddBatch(batchRecords(n))
+	f.AddTranslationUnit(bytes.Repeat([]byte{'t'}, tuBytes))
+	f.addHeader("main.h", time.Unix(1500000000, 0))
+	return f
+}
+
+// setSerializedLimit lowers the serialized size limit to n bytes, and returns the function which restores it.
+func setSerializedLimit(n int) func() {
+	orig := maxSerializedSize
+	maxSerializedSize = n
+	return func() { maxSerializedSize = orig }
+}
+
+func TestFile_SerializeChecked(t *testing.T) {
+	defer setSerializedLimit(64 << 10)()
+
+	f := shardFile(10, 1024)
+	builder, err := f.SerializeChecked()
+	if err != nil {
+		t.Fatalf("SerializeChecked() error = %v", err)
+	}
+	if !bytes.Equal(builder.FinishedBytes(), mustSerializeBytes(f)) {
+		t.Error("SerializeChecked() bytes differ from the Serialize bytes")
+	}
+
+	for _, tt := range []struct {
+		name      string
+		f         *File
+		component string
+	}{
+		{"translation unit", shardFile(10, 128<<10), "translation unit"},
+		{"symbols", shardFile(2000, 1024), "symbols"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.f.SerializeChecked()
+			if errors.Cause(err) != ErrTooLarge {
+				t.Fatalf("SerializeChecked() error = %v, want the ErrTooLarge cause", err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.component+":") {
+				t.Errorf("SerializeChecked() error = %q, want the %q component", err, tt.component)
+			}
+		})
+	}
+}
+
+func TestFile_SerializeSharded(t *testing.T) {
+	t.Run("single", func(t *testing.T) {
+		f := shardFile(10, 1024)
+		m, shards, err := f.SerializeSharded()
+		if err != nil {
+			t.Fatalf("SerializeSharded() error = %v", err)
+		}
+		if len(shards) != 1 || len(m.Shards) != 1 || m.Shards[0].Symbols != 10 {
+			t.Fatalf("SerializeSharded() manifest = %+v, want a single shard of 10 symbols", m)
+		}
+		if !bytes.Equal(shards[0], mustSerializeBytes(f)) {
+			t.Error("single shard differs from the Serialize bytes")
+		}
+	})
+
+	t.Run("split", func(t *testing.T) {
+		const limit = 64 << 10
+		defer setSerializedLimit(limit)()
+
+		f := shardFile(2000, 1024)
+		m, shards, err := f.SerializeSharded()
+		if err != nil {
+			t.Fatalf("SerializeSharded() error = %v", err)
+		}
+		if len(shards) < 2 {
+			t.Fatalf("SerializeSharded() = %d shards, want split", len(shards))
+		}
+		if m.Name != "main.c" || len(m.Shards) != len(shards) {
+			t.Fatalf("SerializeSharded() manifest = %+v", m)
+		}
+		total := 0
+		for i, shard := range shards {
+			if len(shard) > limit || m.Shards[i].Size != len(shard) {
+				t.Errorf("shard %d: %d bytes, manifest %d, limit %d", i, len(shard), m.Shards[i].Size, limit)
+			}
+			total += m.Shards[i].Symbols
+		}
+		if total != 2000 {
+			t.Errorf("manifest describes %d symbols, want 2000", total)
+		}
+
+		got, err := LoadShards(m, shards)
+		if err != nil {
+			t.Fatalf("LoadShards() error = %v", err)
+		}
+		if !got.Equal(f) {
+			t.Error("LoadShards() File is not equal to the sharded File")
+		}
+		if len(got.Headers()) != 1 || len(got.Flags()) != 1 {
+			t.Errorf("LoadShards() = %d headers and %d flags, want 1 and 1", len(got.Headers()), len(got.Flags()))
+		}
+
+		if _, err := LoadShards(m, shards[1:]); err == nil {
+			t.Error("LoadShards() of the missing shard error = nil")
+		}
+		// the corrupted shard is rejected before allocating for its symbols
+		corrupted := append([][]byte(nil), shards...)
+		corrupted[1] = append([]byte(nil), shards[1]...)
+		setVectorLength(corrupted[1], symbol.GetRootAsFile(corrupted[1], 0).Table(), fileSymbolsSlot, 1<<30)
+		if _, err := LoadShards(m, corrupted); errors.Cause(err) != ErrVectorTooLong {
+			t.Errorf("LoadShards() of the corrupted shard error = %v, want ErrVectorTooLong", err)
+		}
+		m.Shards[1].Symbols++
+		if _, err := LoadShards(m, shards); err == nil {
+			t.Error("LoadShards() of the mismatched manifest error = nil")
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		defer setSerializedLimit(64 << 10)()
+
+		_, _, err := shardFile(10, 128<<10).SerializeSharded()
+		if errors.Cause(err) != ErrTooLarge || !strings.HasPrefix(err.Error(), "translation unit:") {
+			t.Errorf("SerializeSharded() error = %v, want the ErrTooLarge cause of translation unit", err)
+		}
+	})
+}
+
+func TestFile_SerializeHeaderIndex(t *testing.T) {
+	f := shardFile(10, 1024)
+	buf, err := f.SerializeHeaderIndex()
+	if err != nil {
+		t.Fatalf("SerializeHeaderIndex() error = %v", err)
+	}
+	if full := mustSerializeBytes(f); len(buf) >= len(full) {
+		t.Errorf("SerializeHeaderIndex() = %d bytes, want smaller than %d bytes of Serialize", len(buf), len(full))
+	}
+
+	got := GetRootAsFile(buf, 0)
+	if len(got.TranslationUnit()) != 0 || !got.TranslationUnitOmitted() {
+		t.Errorf("TranslationUnit() = %d bytes, TranslationUnitOmitted() = %v, want omitted", len(got.TranslationUnit()), got.TranslationUnitOmitted())
+	}
+	if len(got.Headers()) != 1 || !reflect.DeepEqual(got.Flags(), f.Flags()) {
+		t.Errorf("Headers() = %d, Flags() = %q, want as same as the File", len(got.Headers()), got.Flags())
+	}
+	if n := len(got.Symbols()); n != 10 {
+		t.Fatalf("len(Symbols()) = %d, want 10", n)
+	}
+	for _, sym := range f.Symbols() {
+		gotSym := got.Symbol(sym.ID())
+		if gotSym == nil {
+			t.Errorf("Symbol(%s) = nil", sym.ID())
+			continue
+		}
+		if g, w := positions(gotSym.Decls()), positions(sym.Decls()); !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: Decls() = %v, want %v", sym.ID(), g, w)
+		}
+		if g, w := positions([]Location{gotSym.Def()}), positions([]Location{sym.Def()}); !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: Def() = %v, want %v", sym.ID(), g, w)
+		}
+		if n := len(gotSym.Callers()); n != 0 {
+			t.Errorf("%s: len(Callers()) = %d, want 0", sym.ID(), n)
+		}
+	}
+
+	// the trimmed serialization does not drop the callers of f itself
+	if n := len(f.Symbol(ToID("c:@F@func0")).Callers()); n != 1 {
+		t.Errorf("len(Callers()) of the File = %d, want 1", n)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"strings"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// isFunctionKind reports whether the kind is the function like declaration cursor.
+func isFunctionKind(kind clang.CursorKind) bool {
+	switch kind {
+	case clang.Cursor_FunctionDecl,
+		clang.Cursor_CXXMethod,
+		clang.Cursor_Constructor,
+		clang.Cursor_Destructor,
+		clang.Cursor_ConversionFunction,
+		clang.Cursor_FunctionTemplate,
+		clang.Cursor_ObjCInstanceMethodDecl,
+		clang.Cursor_ObjCClassMethodDecl:
+		return true
+	default:
+		return false
+	}
+}
+
+// isClassKind reports whether the kind is the class like declaration cursor which can have base classes.
+func isClassKind(kind clang.CursorKind) bool {
+	switch kind {
+	case clang.Cursor_ClassDecl,
+		clang.Cursor_StructDecl,
+		clang.Cursor_ClassTemplate,
+		clang.Cursor_ClassTemplatePartialSpecialization:
+		return true
+	default:
+		return false
+	}
+}
+
+// setCursor sets the name, type, namespace, comment range, parent, parameter index, selector, base classes and virtual methods information of the declaration cursor to info.
+func (info *Info) setCursor(cursor clang.Cursor) {
+	info.name = cursor.Spelling()
+	info.typ = cursor.Type().Spelling()
+	info.namespace = cursorNamespace(cursor)
+	info.commentRange = FromSourceRange(cursor.CommentRange())
+	info.parentUSR = cursor.SemanticParent().USR()
+
+	switch kind := cursor.Kind(); {
+	case kind == clang.Cursor_ParmDecl:
+		info.paramIndex = cursorParamIndex(cursor)
+		return
+	case kind == clang.Cursor_ObjCInstanceMethodDecl:
+		// the spelling of the Objective-C method is the selector
+		info.selector = cursor.Spelling()
+	case isClassKind(kind):
+		info.bases = cursorBases(cursor)
+		return
+	case !isFunctionKind(kind):
+		return
+	}
+
+	info.resultType = cursor.ResultType().Spelling()
+	info.variadic = cursor.IsVariadic()
+	if cursor.Kind() == clang.Cursor_CXXMethod {
+		info.isVirtual = cursor.CXXMethod_IsVirtual()
+		info.overrides = cursorOverrides(cursor)
+	}
+
+	// NumArguments returns -1 for the function template
+	n := cursor.NumArguments()
+	if n <= 0 {
+		return
+	}
+	info.params = make([]*Param, 0, n)
+	for i := uint32(0); i < uint32(n); i++ {
+		arg := cursor.Argument(i)
+		info.params = append(info.params, &Param{
+			name: arg.Spelling(),
+			typ:  arg.Type().Spelling(),
+		})
+	}
+}
+
+// cursorBases returns the USRs of the base classes of the class cursor.
+func cursorBases(cursor clang.Cursor) []string {
+	var bases []string
+	cursor.Visit(func(child, parent clang.Cursor) clang.ChildVisitResult {
+		if child.Kind() == clang.Cursor_CXXBaseSpecifier {
+			if usr := child.Type().Declaration().USR(); usr != "" {
+				bases = append(bases, usr)
+			}
+		}
+		return clang.ChildVisit_Continue
+	})
+
+	return bases
+}
+
+// cursorParamIndex returns the index of the parameter cursor in the arguments of its function.
+// It returns zero if the function does not list the parameter, such as the parameter of the function template.
+func cursorParamIndex(cursor clang.Cursor) uint32 {
+	parent := cursor.SemanticParent()
+	n := parent.NumArguments()
+	for i := uint32(0); int32(i) < n; i++ {
+		if parent.Argument(i).Equal(cursor) {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// cursorOverrides returns the USRs of the methods which the method cursor overrides.
+func cursorOverrides(cursor clang.Cursor) []string {
+	overridden := cursor.OverriddenCursors()
+	if len(overridden) == 0 {
+		return nil
+	}
+	defer clang.Dispose(overridden)
+
+	overrides := make([]string, 0, len(overridden))
+	for _, c := range overridden {
+		if usr := c.USR(); usr != "" {
+			overrides = append(overrides, usr)
+		}
+	}
+
+	return overrides
+}
+
+// cursorNamespace returns the enclosing namespace chain of cursor, such as "a::b".
+// The anonymous namespace is spelled as "(anonymous namespace)".
+func cursorNamespace(cursor clang.Cursor) string {
+	var names []string
+	for parent := cursor.SemanticParent(); !parent.IsNull(); parent = parent.SemanticParent() {
+		switch parent.Kind() {
+		case clang.Cursor_TranslationUnit:
+			return joinNamespace(names)
+		case clang.Cursor_Namespace:
+			name := parent.Spelling()
+			if name == "" {
+				name = "(anonymous namespace)"
+			}
+			names = append(names, name)
+		}
+	}
+
+	return joinNamespace(names)
+}
+
+// joinNamespace joins the namespace names which ordered from innermost to outermost.
+func joinNamespace(names []string) string {
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "::")
+}
+
+// Signature return the one-line readable signature of the symbol, such as
+//
+//  int foo(const char *s, int n)
+//
+// For non-function symbols, Signature returns the type spelling.
+func (info *Info) Signature() string {
+	resultType := info.ResultType()
+	if resultType == "" {
+		return info.Type()
+	}
+
+	params := info.Params()
+	args := make([]string, 0, len(params)+1)
+	for _, param := range params {
+		args = append(args, joinDecl(param.Type(), param.Name()))
+	}
+	if info.Variadic() {
+		args = append(args, "...")
+	}
+
+	return joinDecl(resultType, info.Name()) + "(" + strings.Join(args, ", ") + ")"
+}
+
+// joinDecl joins the type spelling and the name as the C declarator.
+// The name is attached directly to the pointer or reference type, such as "char *s".
+func joinDecl(typ, name string) string {
+	switch {
+	case name == "":
+		return typ
+	case strings.HasSuffix(typ, "*"), strings.HasSuffix(typ, "&"):
+		return typ + name
+	default:
+		return typ + " " + name
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import "testing"
+
+func TestInfo_Signature(t *testing.T) {
+	tests := []struct {
+		name string
+		info *Info
+		want string
+	}{
+		{
+			name: "two args",
+			info: &Info{
+				name:       "foo",
+				typ:        "int (const char *, int)",
+				resultType: "int",
+				params: []*Param{
+					{name: "s", typ: "const char *"},
+					{name: "n", typ: "int"},
+				},
+			},
+			want: "int foo(const char *s, int n)",
+		},
+		{
+			name: "variadic",
+			info: &Info{
+				name:       "printf",
+				typ:        "int (const char *, ...)",
+				resultType: "int",
+				params: []*Param{
+					{name: "format", typ: "const char *"},
+				},
+				variadic: true,
+			},
+			want: "int printf(const char *format, ...)",
+		},
+		{
+			name: "unnamed param",
+			info: &Info{
+				name:       "bar",
+				typ:        "void (int)",
+				resultType: "void",
+				params:     []*Param{{typ: "int"}},
+			},
+			want: "void bar(int)",
+		},
+		{
+			name: "non-function",
+			info: &Info{name: "count", typ: "unsigned long"},
+			want: "unsigned long",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Signature(); got != tt.want {
+				t.Errorf("Signature() = %q, want %q", got, tt.want)
+			}
+
+			f := NewFile("main.c", nil)
+			tt.info.id = ToID("c:@F@" + tt.info.name)
+			f.symbols[tt.info.id] = tt.info
+			sym := roundTrip(f).Symbols()[0]
+			if got := sym.Signature(); got != tt.want {
+				t.Errorf("roundtrip: Signature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_SymbolsInNamespace(t *testing.T) {
+	f := NewFile("main.cpp", nil)
+	for _, info := range []*Info{
+		{id: ToID("c:@N@a@N@b@F@foo#"), name: "foo", namespace: "a::b"},
+		{id: ToID("c:@N@a@F@bar#"), name: "bar", namespace: "a"},
+		{id: ToID("c:@F@main#"), name: "main"},
+	} {
+		f.symbols[info.id] = info
+	}
+
+	tests := []struct {
+		name string
+		ns   string
+		want string
+	}{
+		{name: "nested namespace", ns: "a::b", want: "foo"},
+		{name: "outer namespace", ns: "a", want: "bar"},
+		{name: "global", ns: "", want: "main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, file := range []*File{f, roundTrip(f)} {
+				syms := file.SymbolsInNamespace(tt.ns)
+				if len(syms) != 1 {
+					t.Fatalf("len(SymbolsInNamespace(%q)) = %d, want 1", tt.ns, len(syms))
+				}
+				if got := syms[0].Name(); got != tt.want {
+					t.Errorf("SymbolsInNamespace(%q)[0].Name() = %q, want %q", tt.ns, got, tt.want)
+				}
+				if got := syms[0].Namespace(); got != tt.ns {
+					t.Errorf("Namespace() = %q, want %q", got, tt.ns)
+				}
+			}
+		})
+	}
+}
+
+func TestJoinNamespace(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{names: nil, want: ""},
+		{names: []string{"a"}, want: "a"},
+		{names: []string{"b", "a"}, want: "a::b"},
+		{names: []string{"(anonymous namespace)", "c", "a"}, want: "a::c::(anonymous namespace)"},
+	}
+	for _, tt := range tests {
+		if got := joinNamespace(tt.names); got != tt.want {
+			t.Errorf("joinNamespace(%v) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
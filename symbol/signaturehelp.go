@@ -0,0 +1,235 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"github.com/go-clang/v3.9/clang"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// SignatureParameter represents a parameter of the Signature.
+type SignatureParameter struct {
+	// Label spelling of the parameter, such as "int a".
+	Label string
+	// Start byte offset of the parameter in the Signature label.
+	Start int
+	// End byte offset of the end of the parameter in the Signature label.
+	End int
+}
+
+// Signature represents a signature of the overload candidate.
+type Signature struct {
+	// Label readable signature of the function, such as "int foo(int a, char *b)".
+	Label string
+	// Parameters parameters of the function.
+	Parameters []SignatureParameter
+	// Documentation brief comment of the function.
+	Documentation string
+}
+
+// overloadCandidate represents the Signature parsed from the overload candidate of clang code completion.
+type overloadCandidate struct {
+	signature Signature
+	priority  uint32
+	active    int // index of the current parameter, or -1
+}
+
+// parseOverloadCandidate parses the completion string of the overload candidate cs.
+func parseOverloadCandidate(cs completionString) overloadCandidate {
+	c := overloadCandidate{priority: cs.Priority(), active: -1}
+
+	var resultType string
+	var label []byte
+	var params []SignatureParameter
+	for i := uint32(0); i < cs.NumChunks(); i++ {
+		text := cs.ChunkText(i)
+		switch kind := cs.ChunkKind(i); kind {
+		case clang.CompletionChunk_ResultType:
+			resultType += text
+		case clang.CompletionChunk_Informative, clang.CompletionChunk_Optional:
+			// not a part of the signature
+		case clang.CompletionChunk_Placeholder, clang.CompletionChunk_CurrentParameter:
+			if kind == clang.CompletionChunk_CurrentParameter {
+				c.active = len(params)
+			}
+			params = append(params, SignatureParameter{Label: text, Start: len(label), End: len(label) + len(text)})
+			label = append(label, text...)
+		default:
+			label = append(label, text...)
+		}
+	}
+
+	if resultType != "" {
+		prefix := resultType + " "
+		label = append([]byte(prefix), label...)
+		for i := range params {
+			params[i].Start += len(prefix)
+			params[i].End += len(prefix)
+		}
+	}
+
+	c.signature = Signature{
+		Label:         string(label),
+		Parameters:    params,
+		Documentation: cs.BriefComment(),
+	}
+
+	return c
+}
+
+// serialize serializes the s data to flatbuffers.UOffsetT.
+func (s *Signature) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	label := builder.CreateString(s.Label)
+	var doc flatbuffers.UOffsetT
+	if s.Documentation != "" {
+		doc = builder.CreateString(s.Documentation)
+	}
+
+	var paramsVecOffset flatbuffers.UOffsetT
+	if paramsNum := len(s.Parameters); paramsNum > 0 {
+		paramsOffsets := make([]flatbuffers.UOffsetT, paramsNum)
+		for i, p := range s.Parameters {
+			plabel := builder.CreateString(p.Label)
+			symbol.SignatureParameterStart(builder)
+			symbol.SignatureParameterAddLabel(builder, plabel)
+			symbol.SignatureParameterAddStart(builder, uint32(p.Start))
+			symbol.SignatureParameterAddEnd(builder, uint32(p.End))
+			paramsOffsets[i] = symbol.SignatureParameterEnd(builder)
+		}
+		symbol.SignatureStartParametersVector(builder, paramsNum)
+		for i := paramsNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(paramsOffsets[i])
+		}
+		paramsVecOffset = builder.EndVector(paramsNum)
+	}
+
+	symbol.SignatureStart(builder)
+	symbol.SignatureAddLabel(builder, label)
+	symbol.SignatureAddParameters(builder, paramsVecOffset)
+	symbol.SignatureAddDocumentation(builder, doc)
+
+	return symbol.SignatureEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// SignatureHelp represents a signatures of the function call at the cursor, which powers the argument hint.
+//
+//  table SignatureHelp {
+//    Signatures: [Signature];
+//    ActiveSignature: uint;
+//    ActiveParameter: uint;
+//  }
+type SignatureHelp struct {
+	signatureHelp *symbol.SignatureHelp
+}
+
+// SymbolSignatureHelp type alias of symbol.SignatureHelp.
+type SymbolSignatureHelp = symbol.SignatureHelp
+
+// GetRootAsSignatureHelp gets the root of SignatureHelp flatbuffers binary.
+func GetRootAsSignatureHelp(buf []byte, offset flatbuffers.UOffsetT) *SignatureHelp {
+	return &SignatureHelp{
+		signatureHelp: symbol.GetRootAsSignatureHelp(buf, offset),
+	}
+}
+
+// Signatures return the signatures of the overload candidates.
+func (s *SignatureHelp) Signatures() []Signature {
+	n := s.signatureHelp.SignaturesLength()
+	sigs := make([]Signature, n)
+
+	obj := new(symbol.Signature)
+	param := new(symbol.SignatureParameter)
+	for i := 0; i < n; i++ {
+		if !s.signatureHelp.Signatures(obj, i) {
+			continue
+		}
+		sig := Signature{
+			Label:         string(obj.Label()),
+			Documentation: string(obj.Documentation()),
+		}
+		if m := obj.ParametersLength(); m > 0 {
+			sig.Parameters = make([]SignatureParameter, m)
+			for j := 0; j < m; j++ {
+				if obj.Parameters(param, j) {
+					sig.Parameters[j] = SignatureParameter{
+						Label: string(param.Label()),
+						Start: int(param.Start()),
+						End:   int(param.End()),
+					}
+				}
+			}
+		}
+		sigs[i] = sig
+	}
+
+	return sigs
+}
+
+// ActiveSignature return the index of the most likely signature.
+func (s *SignatureHelp) ActiveSignature() int {
+	return int(s.signatureHelp.ActiveSignature())
+}
+
+// ActiveParameter return the index of the parameter at the cursor in the active signature.
+func (s *SignatureHelp) ActiveParameter() int {
+	return int(s.signatureHelp.ActiveParameter())
+}
+
+// MarshalSignatureHelp returns the flatbuffers binary encoding of the SignatureHelp, which converted from
+// the overload candidates of clang.CodeCompleteResults v.
+// The other results are ignored, so the Signatures is empty if the cursor is not inside the call parentheses.
+func (c *CodeCompleteResults) MarshalSignatureHelp(v *clang.CodeCompleteResults) *flatbuffers.Builder {
+	if v == nil {
+		return nil
+	}
+
+	return marshalSignatureHelp(toCompletionResults(v))
+}
+
+// marshalSignatureHelp returns the flatbuffers binary encoding of the SignatureHelp of the overload candidates in results.
+// The candidate of the highest priority is the active signature.
+func marshalSignatureHelp(results []completionResult) *flatbuffers.Builder {
+	var candidates []overloadCandidate
+	for _, res := range results {
+		if res.cursorKind != clang.Cursor_OverloadCandidate {
+			continue
+		}
+		candidates = append(candidates, parseOverloadCandidate(res.cs))
+	}
+
+	var activeSignature, activeParameter int
+	for i, c := range candidates {
+		if c.priority < candidates[activeSignature].priority {
+			activeSignature = i
+		}
+	}
+	if len(candidates) > 0 && candidates[activeSignature].active >= 0 {
+		activeParameter = candidates[activeSignature].active
+	}
+
+	builder := flatbuffers.NewBuilder(0)
+
+	sigsNum := len(candidates)
+	sigsOffsets := make([]flatbuffers.UOffsetT, sigsNum)
+	for i := range candidates {
+		sigsOffsets[i] = candidates[i].signature.serialize(builder)
+	}
+	symbol.SignatureHelpStartSignaturesVector(builder, sigsNum)
+	for i := sigsNum - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(sigsOffsets[i])
+	}
+	sigsVecOffset := builder.EndVector(sigsNum)
+
+	symbol.SignatureHelpStart(builder)
+	symbol.SignatureHelpAddSignatures(builder, sigsVecOffset)
+	symbol.SignatureHelpAddActiveSignature(builder, uint32(activeSignature))
+	symbol.SignatureHelpAddActiveParameter(builder, uint32(activeParameter))
+	builder.Finish(symbol.SignatureHelpEnd(builder))
+
+	return builder
+}
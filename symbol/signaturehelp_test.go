@@ -0,0 +1,128 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// fakeOverloadCandidate returns the overload candidate of the function which named word,
+// whose parameter of index current is the current parameter.
+func fakeOverloadCandidate(word, result string, params []string, current int, priority uint32) completionResult {
+	chunks := []fakeChunk{
+		{kind: clang.CompletionChunk_ResultType, text: result},
+		{kind: clang.CompletionChunk_Text, text: word},
+		{kind: clang.CompletionChunk_LeftParen, text: "("},
+	}
+	for i, param := range params {
+		if i > 0 {
+			chunks = append(chunks, fakeChunk{kind: clang.CompletionChunk_Comma, text: ", "})
+		}
+		var kind clang.CompletionChunkKind = clang.CompletionChunk_Placeholder
+		if i == current {
+			kind = clang.CompletionChunk_CurrentParameter
+		}
+		chunks = append(chunks, fakeChunk{kind: kind, text: param})
+	}
+	chunks = append(chunks, fakeChunk{kind: clang.CompletionChunk_RightParen, text: ")"})
+
+	return completionResult{
+		cursorKind: clang.Cursor_OverloadCandidate,
+		cs:         fakeCompletionString{chunks: chunks, priority: priority, comment: word + " overload"},
+	}
+}
+
+func TestMarshalSignatureHelp(t *testing.T) {
+	tests := []struct {
+		name                string
+		results             []completionResult
+		wantLabels          []string
+		wantActiveSignature int
+		wantActiveParameter int
+	}{
+		{
+			name: "first parameter",
+			results: []completionResult{
+				fakeOverloadCandidate("max", "int", []string{"int a", "int b"}, 0, 50),
+			},
+			wantLabels:          []string{"int max(int a, int b)"},
+			wantActiveSignature: 0,
+			wantActiveParameter: 0,
+		},
+		{
+			name: "last parameter",
+			results: []completionResult{
+				fakeOverloadCandidate("max", "int", []string{"int a", "int b"}, 1, 50),
+			},
+			wantLabels:          []string{"int max(int a, int b)"},
+			wantActiveSignature: 0,
+			wantActiveParameter: 1,
+		},
+		{
+			name: "overloads",
+			results: []completionResult{
+				fakeOverloadCandidate("clamp", "float", []string{"float v", "float lo", "float hi"}, 1, 60),
+				fakeFunction("clamp", "int", 30),
+				fakeOverloadCandidate("clamp", "int", []string{"int v", "int lo", "int hi"}, 2, 40),
+			},
+			wantLabels:          []string{"float clamp(float v, float lo, float hi)", "int clamp(int v, int lo, int hi)"},
+			wantActiveSignature: 1,
+			wantActiveParameter: 2,
+		},
+		{
+			name:    "not in call",
+			results: []completionResult{fakeFunction("clamp", "int", 30)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			help := GetRootAsSignatureHelp(marshalSignatureHelp(tt.results).FinishedBytes(), 0)
+
+			sigs := help.Signatures()
+			var labels []string
+			for _, sig := range sigs {
+				labels = append(labels, sig.Label)
+				for _, p := range sig.Parameters {
+					if got := sig.Label[p.Start:p.End]; got != p.Label {
+						t.Errorf("label[%d:%d] = %q, want parameter %q", p.Start, p.End, got, p.Label)
+					}
+				}
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("Signatures() labels = %q, want %q", labels, tt.wantLabels)
+			}
+			if got := help.ActiveSignature(); got != tt.wantActiveSignature {
+				t.Errorf("ActiveSignature() = %d, want %d", got, tt.wantActiveSignature)
+			}
+			if got := help.ActiveParameter(); got != tt.wantActiveParameter {
+				t.Errorf("ActiveParameter() = %d, want %d", got, tt.wantActiveParameter)
+			}
+		})
+	}
+}
+
+func TestParseOverloadCandidate(t *testing.T) {
+	res := fakeOverloadCandidate("memcpy", "void *", []string{"void *dst", "const void *src", "size_t n"}, 1, 50)
+	got := parseOverloadCandidate(res.cs)
+
+	want := Signature{
+		Label: "void * memcpy(void *dst, const void *src, size_t n)",
+		Parameters: []SignatureParameter{
+			{Label: "void *dst", Start: 14, End: 23},
+			{Label: "const void *src", Start: 25, End: 40},
+			{Label: "size_t n", Start: 42, End: 50},
+		},
+		Documentation: "memcpy overload",
+	}
+	if !reflect.DeepEqual(got.signature, want) {
+		t.Errorf("parseOverloadCandidate() = %+v, want %+v", got.signature, want)
+	}
+	if got.active != 1 {
+		t.Errorf("active = %d, want 1", got.active)
+	}
+}
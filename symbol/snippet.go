@@ -0,0 +1,79 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+// SnippetSyntax represents a syntax of the completion snippet.
+type SnippetSyntax int
+
+const (
+	// SnippetPlain writes the snippet as plain text, and the placeholders are left as is.
+	//  foo(int a, char *b)
+	SnippetPlain SnippetSyntax = iota
+	// SnippetVim writes the snippet in the UltiSnips syntax.
+	//  foo(${1:int a}, ${2:char *b})$0
+	SnippetVim
+	// SnippetLSP writes the snippet in the Language Server Protocol snippet syntax.
+	//  foo(${1:int a}, ${2:char *b})$0
+	SnippetLSP
+)
+
+var (
+	// lspSnippetEscaper escapes the characters which have the special meaning in LSP snippet.
+	lspSnippetEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`)
+	// vimSnippetEscaper escapes the characters which have the special meaning in UltiSnips snippet.
+	// UltiSnips also interpolates the backquoted text as shell code.
+	vimSnippetEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`, "`", "\\`")
+)
+
+// escape escapes the literal text s in the syntax.
+func (syntax SnippetSyntax) escape(s string) string {
+	switch syntax {
+	case SnippetVim:
+		return vimSnippetEscaper.Replace(s)
+	case SnippetLSP:
+		return lspSnippetEscaper.Replace(s)
+	default:
+		return s
+	}
+}
+
+// buildSnippet assembles the snippet of cs in the syntax.
+// The placeholder chunks are numbered in order, and the final tabstop is placed at the end.
+func buildSnippet(cs completionString, syntax SnippetSyntax) string {
+	var buf []byte
+	tabstop := 0
+	for i := uint32(0); i < cs.NumChunks(); i++ {
+		text := cs.ChunkText(i)
+		switch cs.ChunkKind(i) {
+		case clang.CompletionChunk_ResultType, clang.CompletionChunk_Informative, clang.CompletionChunk_Optional:
+			// not inserted
+		case clang.CompletionChunk_Placeholder, clang.CompletionChunk_CurrentParameter:
+			if syntax == SnippetPlain {
+				buf = append(buf, text...)
+				continue
+			}
+			tabstop++
+			buf = append(buf, "${"...)
+			buf = strconv.AppendInt(buf, int64(tabstop), 10)
+			buf = append(buf, ':')
+			buf = append(buf, syntax.escape(text)...)
+			buf = append(buf, '}')
+		default:
+			buf = append(buf, syntax.escape(text)...)
+		}
+	}
+	if syntax != SnippetPlain {
+		buf = append(buf, "$0"...)
+	}
+
+	return string(buf)
+}
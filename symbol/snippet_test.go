@@ -0,0 +1,92 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestBuildSnippet(t *testing.T) {
+	var (
+		zeroArg = []fakeChunk{
+			{kind: clang.CompletionChunk_ResultType, text: "void"},
+			{kind: clang.CompletionChunk_TypedText, text: "clear"},
+			{kind: clang.CompletionChunk_LeftParen, text: "("},
+			{kind: clang.CompletionChunk_RightParen, text: ")"},
+		}
+		multiArg = []fakeChunk{
+			{kind: clang.CompletionChunk_ResultType, text: "int"},
+			{kind: clang.CompletionChunk_TypedText, text: "foo"},
+			{kind: clang.CompletionChunk_LeftParen, text: "("},
+			{kind: clang.CompletionChunk_Placeholder, text: "int a"},
+			{kind: clang.CompletionChunk_Comma, text: ", "},
+			{kind: clang.CompletionChunk_Placeholder, text: "char *b"},
+			{kind: clang.CompletionChunk_RightParen, text: ")"},
+		}
+		template = []fakeChunk{
+			{kind: clang.CompletionChunk_TypedText, text: "vector"},
+			{kind: clang.CompletionChunk_LeftAngle, text: "<"},
+			{kind: clang.CompletionChunk_Placeholder, text: "typename T"},
+			{kind: clang.CompletionChunk_Comma, text: ", "},
+			{kind: clang.CompletionChunk_Placeholder, text: "typename Alloc"},
+			{kind: clang.CompletionChunk_RightAngle, text: ">"},
+		}
+		variadic = []fakeChunk{
+			{kind: clang.CompletionChunk_ResultType, text: "int"},
+			{kind: clang.CompletionChunk_TypedText, text: "printf"},
+			{kind: clang.CompletionChunk_LeftParen, text: "("},
+			{kind: clang.CompletionChunk_Placeholder, text: "const char *format"},
+			{kind: clang.CompletionChunk_Comma, text: ", "},
+			{kind: clang.CompletionChunk_Placeholder, text: "..."},
+			{kind: clang.CompletionChunk_RightParen, text: ")"},
+		}
+		special = []fakeChunk{
+			{kind: clang.CompletionChunk_TypedText, text: "tmpl"},
+			{kind: clang.CompletionChunk_LeftParen, text: "("},
+			{kind: clang.CompletionChunk_Placeholder, text: "const char *fmt = \"${x}\\n`\""},
+			{kind: clang.CompletionChunk_RightParen, text: ")"},
+		}
+	)
+
+	tests := []struct {
+		name   string
+		chunks []fakeChunk
+		syntax SnippetSyntax
+		want   string
+	}{
+		{name: "zero-arg plain", chunks: zeroArg, syntax: SnippetPlain, want: "clear()"},
+		{name: "zero-arg vim", chunks: zeroArg, syntax: SnippetVim, want: "clear()$0"},
+		{name: "zero-arg lsp", chunks: zeroArg, syntax: SnippetLSP, want: "clear()$0"},
+		{name: "multi-arg plain", chunks: multiArg, syntax: SnippetPlain, want: "foo(int a, char *b)"},
+		{name: "multi-arg vim", chunks: multiArg, syntax: SnippetVim, want: "foo(${1:int a}, ${2:char *b})$0"},
+		{name: "multi-arg lsp", chunks: multiArg, syntax: SnippetLSP, want: "foo(${1:int a}, ${2:char *b})$0"},
+		{name: "template lsp", chunks: template, syntax: SnippetLSP, want: "vector<${1:typename T}, ${2:typename Alloc}>$0"},
+		{name: "variadic lsp", chunks: variadic, syntax: SnippetLSP, want: "printf(${1:const char *format}, ${2:...})$0"},
+		{name: "escape plain", chunks: special, syntax: SnippetPlain, want: "tmpl(const char *fmt = \"${x}\\n`\")"},
+		{name: "escape vim", chunks: special, syntax: SnippetVim, want: "tmpl(${1:const char *fmt = \"\\${x\\}\\\\n\\`\"})$0"},
+		{name: "escape lsp", chunks: special, syntax: SnippetLSP, want: "tmpl(${1:const char *fmt = \"\\${x\\}\\\\n`\"})$0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := fakeCompletionString{chunks: tt.chunks}
+			if got := buildSnippet(cs, tt.syntax); got != tt.want {
+				t.Errorf("buildSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeCompleteResults_Marshal_Snippet(t *testing.T) {
+	results := []completionResult{fakeFunction("size", "size_type", 34)}
+	items := marshalResults(CompleteOptions{Snippet: SnippetLSP}, results).Results()
+	if len(items) != 1 {
+		t.Fatalf("len(Results()) = %d, want 1", len(items))
+	}
+	if got, want := items[0].Snippet(), "size()$0"; got != want {
+		t.Errorf("Snippet() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"github.com/go-clang/v3.9/clang"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// FileStats represents the statistics of the File.
+type FileStats struct {
+	Symbols int
+	Headers int
+	Bytes   int // size of the serialized File, zero if it exceeds the flatbuffers size limit
+
+	// Kinds the number of symbols per Info.Kind. The symbols of the unknown kind are counted as zero.
+	Kinds map[clang.CursorKind]int
+}
+
+// Stats returns the statistics of f.
+// The flatbuffers backed f is counted from the tables without Unmarshal, and the in-memory f is serialized
+// to compute the size.
+func (f *File) Stats() FileStats {
+	f.hydrate()
+	stats := FileStats{Kinds: make(map[clang.CursorKind]int)}
+
+	if len(f.symbols) > 0 || f.file == nil {
+		for _, sym := range f.symbols {
+			stats.Kinds[sym.Kind()]++
+		}
+		stats.Symbols = len(f.symbols)
+		stats.Headers = len(f.headers)
+		if buf, err := serializeBytes(f); err == nil {
+			stats.Bytes = len(buf)
+		}
+		return stats
+	}
+
+	obj := new(symbol.Info)
+	n := safeLength(f.file.Table(), fileSymbolsSlot)
+	for i := 0; i < n; i++ {
+		if f.file.Symbols(obj, i) {
+			stats.Kinds[SymbolKind(obj.Kind()).CursorKind()]++
+		}
+	}
+	stats.Symbols = n
+	stats.Headers = safeLength(f.file.Table(), fileHeadersSlot)
+	stats.Bytes = len(f.file.Table().Bytes)
+
+	return stats
+}
+
+// AggregateResult represents the statistics aggregated across the Files.
+type AggregateResult struct {
+	Files   int
+	Symbols int
+	Headers int
+	Bytes   int // total size of the serialized Files
+
+	// Kinds the number of symbols per Info.Kind.
+	Kinds map[clang.CursorKind]int
+}
+
+// AggregateStats returns the sum of the Stats of files, such as for the index-wide dashboards.
+func AggregateStats(files []*File) AggregateResult {
+	res := AggregateResult{Kinds: make(map[clang.CursorKind]int)}
+	for _, f := range files {
+		stats := f.Stats()
+		res.Files++
+		res.Symbols += stats.Symbols
+		res.Headers += stats.Headers
+		res.Bytes += stats.Bytes
+		for kind, n := range stats.Kinds {
+			res.Kinds[kind] += n
+		}
+	}
+
+	return res
+}
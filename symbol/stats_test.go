@@ -0,0 +1,63 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestAggregateStats(t *testing.T) {
+	fn := func(file, usr string) Location {
+		return Location{fileName: file, line: 1, col: 6, usr: usr, kind: clang.Cursor_FunctionDecl}
+	}
+	class := func(file, usr string) Location {
+		return Location{fileName: file, line: 3, col: 7, usr: usr, kind: clang.Cursor_ClassDecl}
+	}
+
+	a := NewFile("a.cc", nil)
+	a.SetReproducible(true)
+	a.AddDecl(fn("a.cc", "c:@F@a"))
+	a.AddDecl(class("a.cc", "c:@S@A"))
+	a.addHeader("/src/a.h", time.Unix(1500000000, 0))
+
+	b := NewFile("b.cc", nil)
+	b.SetReproducible(true)
+	b.AddDecl(fn("b.cc", "c:@F@b"))
+	b.AddDecl(fn("b.cc", "c:@F@b2"))
+	b.AddDecl(Location{fileName: "b.cc", line: 5, col: 1, usr: "c:@F@unknown"})
+	b.addHeader("/src/a.h", time.Unix(1500000000, 0))
+	b.addHeader("/src/b.h", time.Unix(1500000000, 0))
+
+	c := NewFile("c.cc", nil)
+	c.SetReproducible(true)
+	c.AddDecl(class("c.cc", "c:@S@C"))
+
+	// the flatbuffers backed File is counted as same as the in-memory one.
+	// the Files are reproducible, since the serialized size depends on the order of the symbols
+	rb := roundTrip(b)
+	if got, want := rb.Stats(), b.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() of the round trip File = %+v, want %+v", got, want)
+	}
+
+	got := AggregateStats([]*File{a, rb, c})
+	want := AggregateResult{
+		Files:   3,
+		Symbols: 6,
+		Headers: 3,
+		Bytes:   len(mustSerializeBytes(a)) + len(rb.file.Table().Bytes) + len(mustSerializeBytes(c)),
+		Kinds: map[clang.CursorKind]int{
+			clang.Cursor_FunctionDecl: 3,
+			clang.Cursor_ClassDecl:    2,
+			0:                         1,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateStats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// StreamSymbols writes the symbols of f to w, one "usr\tname\tfile:line:col\tkind" line per symbol, which the
+// fzf-style pickers read. The location is the definition, or the first declaration if the symbol has no
+// definition, and the symbols without both are omitted. The column is the 1-based byte column and the kind is
+// the SymbolKind name.
+//
+// The lines are ordered by the name, and then by the USR.
+func (f *File) StreamSymbols(w io.Writer) error {
+	type line struct {
+		usr, name string
+		loc       Location
+		kind      SymbolKind
+	}
+	var lines []line
+	for _, sym := range f.Symbols() {
+		loc := sym.Def()
+		if loc.IsZero() {
+			decls := sym.Decls()
+			if len(decls) == 0 {
+				continue
+			}
+			loc = decls[0]
+		}
+		usr := loc.USR()
+		if usr == "" {
+			usr = sym.ID().String()
+		}
+		lines = append(lines, line{usr: usr, name: sym.Name(), loc: loc, kind: sym.SymbolKind()})
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].name != lines[j].name {
+			return lines[i].name < lines[j].name
+		}
+		return lines[i].usr < lines[j].usr
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		fmt.Fprintf(bw, "%s\t%s\t%s:%d:%d\t%s\n", l.usr, l.name, l.loc.FileName(), l.loc.Line(), l.loc.rawCol(), l.kind)
+	}
+	if err := bw.Flush(); err != nil {
+		return errors.Wrapf(err, "could not write the symbols of %s", f.Name())
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+	"github.com/pkg/errors"
+)
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestFile_StreamSymbols(t *testing.T) {
+	f := NewFile("/src/main.c", nil)
+	for _, sym := range []struct {
+		usr, name string
+		kind      clang.CursorKind
+		decl, def Location
+	}{
+		{usr: "c:@F@main", name: "main", kind: clang.Cursor_FunctionDecl,
+			def: Location{fileName: "/src/main.c", line: 10, col: 5}},
+		{usr: "c:@F@add", name: "add", kind: clang.Cursor_FunctionDecl,
+			decl: Location{fileName: "/src/util.h", line: 3, col: 5},
+			def:  Location{fileName: "/src/util.c", line: 7, col: 5}},
+		// no definition
+		{usr: "c:@S@point", name: "point", kind: clang.Cursor_StructDecl,
+			decl: Location{fileName: "/src/util.h", line: 1, col: 8}},
+		// the same name
+		{usr: "c:main.c@F@add", name: "add", kind: clang.Cursor_FunctionDecl,
+			def: Location{fileName: "/src/main.c", line: 4, col: 12}},
+	} {
+		for _, loc := range []*Location{&sym.decl, &sym.def} {
+			if loc.fileName != "" {
+				loc.usr = sym.usr
+			}
+		}
+		f.addSymbol(sym.usr, sym.kind, sym.decl, sym.def).name = sym.name
+	}
+	// the symbol recorded only by the caller is omitted
+	if err := f.AddCaller(Location{fileName: "/src/main.c", line: 11, col: 3, usr: "c:@F@printf"}, Location{}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "fzf", "symbols.tsv")
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		var buf bytes.Buffer
+		if err := f.StreamSymbols(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if *update {
+			if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: %s mismatch:\ngot:\n%s\nwant:\n%s", name, golden, buf.Bytes(), want)
+		}
+	}
+
+	errWrite := errors.New("closed pipe")
+	if err := f.StreamSymbols(errWriter{errWrite}); errors.Cause(err) != errWrite {
+		t.Errorf("StreamSymbols() error = %v, want %v", err, errWrite)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestDistance is the upper bound of the edit distance of the suggestions.
+const maxSuggestDistance = 3
+
+// Suggestion represents a symbol whose name is near the name which is not found, see Table.Suggest.
+type Suggestion struct {
+	Info     *Info
+	Name     string     // name of the symbol, which is qualified by the namespace if the query is qualified
+	Kind     SymbolKind // kind of the symbol
+	Location Location   // definition, or the declaration if the symbol has no definition
+	Distance int        // case-insensitive edit distance between the query and Name
+	Score    int        // fuzzy matching score of the query against Name, zero if it does not match
+}
+
+// suggestIndex is the snapshot of the symbol names of the Table for Suggest, which is built on demand and
+// rebuilt when the Files of the Table are changed.
+type suggestIndex struct {
+	gen     uint64 // generation of the Table when the index is built
+	entries []suggestEntry
+	byName  map[string][]int // lowercase name and qualified name -> index of entries
+}
+
+// suggestEntry represents a symbol in the suggestIndex, which has the lowercase names precomputed.
+type suggestEntry struct {
+	info           *Info
+	loc            Location
+	name           string
+	qualified      string
+	lowerName      []rune
+	lowerQualified []rune
+}
+
+// newSuggestIndex builds the suggestIndex of t at the generation gen.
+func newSuggestIndex(t *Table, gen uint64) *suggestIndex {
+	idx := &suggestIndex{gen: gen, byName: make(map[string][]int)}
+	for _, e := range NewSymbolIndex(t).entries {
+		if len(e.locs) == 0 {
+			continue
+		}
+		name := e.info.Name()
+		qualified := name
+		if ns := e.info.Namespace(); ns != "" {
+			qualified = ns + "::" + name
+		}
+		entry := suggestEntry{
+			info:           e.info,
+			loc:            e.locs[0],
+			name:           name,
+			qualified:      qualified,
+			lowerName:      []rune(strings.ToLower(name)),
+			lowerQualified: []rune(strings.ToLower(qualified)),
+		}
+		i := len(idx.entries)
+		idx.entries = append(idx.entries, entry)
+		idx.byName[string(entry.lowerName)] = append(idx.byName[string(entry.lowerName)], i)
+		if qualified != name {
+			idx.byName[string(entry.lowerQualified)] = append(idx.byName[string(entry.lowerQualified)], i)
+		}
+	}
+
+	return idx
+}
+
+// suggestIndex returns the suggestIndex of the current Files of t.
+func (t *Table) suggestIndex() *suggestIndex {
+	t.mu.RLock()
+	gen := t.gen
+	t.mu.RUnlock()
+
+	t.suggestMu.Lock()
+	defer t.suggestMu.Unlock()
+	if t.suggest == nil || t.suggest.gen != gen {
+		t.suggest = newSuggestIndex(t, gen)
+	}
+
+	return t.suggest
+}
+
+// Suggest returns at most max symbols whose names are near name, for the "did you mean" of the failed lookup
+// such as the go-to-definition or the symbol search.
+//
+// The names are compared case-insensitively by the edit distance, which counts the transposition of the
+// adjacent characters as one edit, and bounded by the length of name up to 3 edits. The name which has "::"
+// is compared with the names qualified by the namespace. The suggestions are ordered by the distance, and
+// then by the descending fuzzy score of Score, the name and the location.
+//
+// The lowercase names are precomputed when Suggest is called first after the Files of t are changed, and the
+// distance computation exits early beyond the bound, so it is cheap enough to call on every failed lookup.
+func (t *Table) Suggest(name string, max int) []Suggestion {
+	if name == "" || max <= 0 {
+		return nil
+	}
+
+	query := []rune(strings.ToLower(name))
+	bound := 1 + (len(query)-1)/4
+	if bound > maxSuggestDistance {
+		bound = maxSuggestDistance
+	}
+	qualified := strings.Contains(name, "::")
+
+	var dist editDistance
+	var suggestions []Suggestion
+	for _, e := range t.suggestIndex().entries {
+		candidate, target := e.lowerName, e.name
+		if qualified {
+			candidate, target = e.lowerQualified, e.qualified
+		}
+		d, ok := dist.bounded(query, candidate, bound)
+		if !ok {
+			continue
+		}
+		score, _ := Score(name, target)
+		suggestions = append(suggestions, Suggestion{
+			Info:     e.info,
+			Name:     target,
+			Kind:     e.info.SymbolKind(),
+			Location: e.loc,
+			Distance: d,
+			Score:    score,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Location.Less(b.Location)
+	})
+
+	if len(suggestions) > max {
+		suggestions = suggestions[:max]
+	}
+
+	return suggestions
+}
+
+// boundedDistance returns the optimal string alignment distance between a and b, which is the Levenshtein
+// distance counting the transposition of the adjacent characters as one edit.
+// It reports false as soon as the distance is known to exceed bound.
+func boundedDistance(a, b []rune, bound int) (int, bool) {
+	var d editDistance
+	return d.bounded(a, b, bound)
+}
+
+// editDistance computes boundedDistance reusing the rows of the dynamic programming across the calls.
+type editDistance struct {
+	rows [3][]int
+}
+
+// bounded is like boundedDistance. Only the cells within bound of the diagonal are computed, as the others
+// exceed bound.
+func (e *editDistance) bounded(a, b []rune, bound int) (int, bool) {
+	if d := len(a) - len(b); d > bound || -d > bound {
+		return 0, false
+	}
+
+	for i := range e.rows {
+		if cap(e.rows[i]) < len(b)+1 {
+			e.rows[i] = make([]int, len(b)+1)
+		}
+		e.rows[i] = e.rows[i][:len(b)+1]
+	}
+	// prev2, prev and cur are the rows of a[:i-2], a[:i-1] and a[:i] against the prefixes of b, whose cells
+	// next to the band are set to the value beyond bound, as they are read by the cells in the band
+	over := bound + 1
+	prev2, prev, cur := e.rows[0], e.rows[1], e.rows[2]
+	for j := 0; j <= len(b) && j <= over; j++ {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		lo, hi := maxInt(1, i-bound), minInt(len(b), i+bound)
+		if lo == 1 {
+			cur[0] = i
+		} else {
+			cur[lo-1] = over
+		}
+		rowMin := cur[lo-1]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = minInt(d, prev2[j-2]+1)
+			}
+			cur[j] = d
+			rowMin = minInt(rowMin, d)
+		}
+		if hi < len(b) {
+			cur[hi+1] = over
+		}
+		if rowMin > bound {
+			return 0, false
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	if d := prev[len(b)]; d <= bound {
+		return d, true
+	}
+	return 0, false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,233 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+	"github.com/pkg/errors"
+)
+
+// suggestTestFile returns the File of name which defines the functions of names, whose namespace is ns.
+// The functions of decls are declared only.
+func suggestTestFile(name, ns string, names []string, decls ...string) *File {
+	f := NewFile(name, nil)
+	add := func(i int, fn string, def bool) {
+		usr := "c:@F@" + fn
+		if ns != "" {
+			usr = "c:@N@" + ns + "@F@" + fn
+		}
+		loc := Location{fileName: name, line: uint32(i + 1), col: 5, offset: uint32(i * 40), usr: usr, kind: clang.Cursor_FunctionDecl}
+		if def {
+			f.AddDefinition(loc, loc)
+		} else {
+			f.AddDecl(loc)
+		}
+		sym := f.Symbol(ToID(usr))
+		sym.name, sym.namespace = fn, ns
+	}
+	for i, fn := range names {
+		add(i, fn, true)
+	}
+	for i, fn := range decls {
+		add(len(names)+i, fn, false)
+	}
+
+	return f
+}
+
+func suggestTestTable() *Table {
+	return NewTable([]*File{
+		suggestTestFile("/src/string.c", "", []string{"strcpy", "strncpy", "strlcpy", "memcpy", "strlen"}),
+		suggestTestFile("/src/mutex.c", "", []string{"pthread_mutex_lock", "pthread_mutex_unlock"}, "pthread_mutex_init"),
+		suggestTestFile("/src/value.cc", "", []string{"getValue", "initialize"}),
+		suggestTestFile("/src/app.cc", "app", []string{"GetValue", "SetValue"}),
+	})
+}
+
+// suggested returns the "name:distance" form of suggestions.
+func suggested(suggestions []Suggestion) []string {
+	var s []string
+	for _, sug := range suggestions {
+		s = append(s, fmt.Sprintf("%s:%d", sug.Name, sug.Distance))
+	}
+	return s
+}
+
+func TestTable_Suggest(t *testing.T) {
+	table := suggestTestTable()
+
+	tests := []struct {
+		name  string
+		query string
+		max   int
+		want  []string
+	}{
+		{
+			name:  "extra character, ties broken by the name",
+			query: "strcpyy",
+			max:   5,
+			want:  []string{"strcpy:1", "strlcpy:2", "strncpy:2"},
+		},
+		{
+			name:  "transposition is one edit",
+			query: "pthread_mutex_lcok",
+			max:   5,
+			want:  []string{"pthread_mutex_lock:1", "pthread_mutex_unlock:3"},
+		},
+		{
+			name:  "missing character",
+			query: "pthread_mutx_init",
+			max:   5,
+			want:  []string{"pthread_mutex_init:1"},
+		},
+		{
+			name:  "case-insensitive, ties broken by the fuzzy score",
+			query: "getvalue",
+			max:   5,
+			want:  []string{"getValue:0", "GetValue:0", "SetValue:1"},
+		},
+		{
+			name:  "british spelling",
+			query: "initialise",
+			max:   5,
+			want:  []string{"initialize:1"},
+		},
+		{
+			name:  "qualified name",
+			query: "app::GetValeu",
+			max:   5,
+			want:  []string{"app::GetValue:1", "app::SetValue:2"},
+		},
+		{
+			name:  "truncated to max",
+			query: "strcpyy",
+			max:   1,
+			want:  []string{"strcpy:1"},
+		},
+		{
+			name:  "short name is bounded to one edit",
+			query: "stlen",
+			max:   5,
+			want:  []string{"strlen:1"},
+		},
+		{
+			name:  "too far",
+			query: "vector",
+			max:   5,
+			want:  nil,
+		},
+		{
+			name:  "zero max",
+			query: "strcpy",
+			max:   0,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggested(table.Suggest(tt.query, tt.max)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %v, want %v", tt.query, tt.max, got, tt.want)
+			}
+		})
+	}
+
+	// the kind and the definition, or the declaration of the symbol which has no definition
+	for _, sug := range append(table.Suggest("pthread_mutex_lcok", 5), table.Suggest("pthread_mutx_init", 5)...) {
+		want := map[string]uint32{"pthread_mutex_lock": 1, "pthread_mutex_unlock": 2, "pthread_mutex_init": 3}[sug.Name]
+		if sug.Kind != SymbolKindFunction || sug.Location.FileName() != "/src/mutex.c" || sug.Location.Line() != want {
+			t.Errorf("Suggest() of %s = %v %s:%d, want function /src/mutex.c:%d", sug.Name, sug.Kind, sug.Location.FileName(), sug.Location.Line(), want)
+		}
+	}
+
+	// the index is rebuilt for the updated Files
+	table.Update(suggestTestFile("/src/string.c", "", []string{"strcpy_s"}))
+	if got, want := suggested(table.Suggest("strcpyy", 5)), []string{"strcpy_s:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest() after Update = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		bound int
+		want  int
+		ok    bool
+	}{
+		{"", "", 0, 0, true},
+		{"abc", "abc", 0, 0, true},
+		{"abc", "acb", 1, 1, true},
+		{"kitten", "sitting", 3, 3, true},
+		{"kitten", "sitting", 2, 0, false},
+		{"ca", "abc", 3, 3, true},
+		{"short", "muchlongername", 3, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := boundedDistance([]rune(tt.a), []rune(tt.b), tt.bound)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("boundedDistance(%q, %q, %d) = %d, %t, want %d, %t", tt.a, tt.b, tt.bound, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolver_DefinitionByName(t *testing.T) {
+	table := suggestTestTable()
+	var r Resolver
+
+	for name, want := range map[string][]string{
+		"strcpy":             {"/src/string.c:1:5"},
+		"app::GetValue":      {"/src/app.cc:1:5"},
+		"GetValue":           {"/src/app.cc:1:5"},
+		"pthread_mutex_init": {"/src/mutex.c:3:5"},
+	} {
+		locs, err := r.DefinitionByName(table, name)
+		if err != nil {
+			t.Errorf("DefinitionByName(%q) error = %v", name, err)
+			continue
+		}
+		if got := positions(locs); !reflect.DeepEqual(got, want) {
+			t.Errorf("DefinitionByName(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	_, err := r.DefinitionByName(table, "strcpyy")
+	if errors.Cause(err) != ErrNoSymbolNamed {
+		t.Fatalf("DefinitionByName(strcpyy) error = %v, want the ErrNoSymbolNamed cause", err)
+	}
+	nf, ok := err.(*NotFoundError)
+	if !ok {
+		t.Fatalf("DefinitionByName(strcpyy) error = %T, want *NotFoundError", err)
+	}
+	if got, want := suggested(nf.Suggestions), []string{"strcpy:1", "strlcpy:2", "strncpy:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggestions = %v, want %v", got, want)
+	}
+	if msg := err.Error(); !strings.HasSuffix(msg, "did you mean strcpy, strlcpy, strncpy?") {
+		t.Errorf("Error() = %q, want the suggestions", msg)
+	}
+
+	// the lookup is case-sensitive
+	if _, err := r.DefinitionByName(table, "getvalue"); errors.Cause(err) != ErrNoSymbolNamed {
+		t.Errorf("DefinitionByName(getvalue) error = %v, want the ErrNoSymbolNamed cause", err)
+	}
+}
+
+func BenchmarkTable_Suggest(b *testing.B) {
+	names := make([]string, 10000)
+	for i := range names {
+		names[i] = fmt.Sprintf("module%d_function_name%d", i%100, i)
+	}
+	table := NewTable([]*File{suggestTestFile("/src/big.c", "", names)})
+	table.Suggest("warmup", 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		table.Suggest("module42_functoin_name4242", 5)
+	}
+}
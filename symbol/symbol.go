@@ -11,6 +11,7 @@ import (
 )
 
 // FromCursor return the location of symbol from cursor.
+// The location of the declaration cursor which has no definition, such as "class Foo;", is the forward declaration.
 func FromCursor(cursor clang.Cursor) Location {
 	if cursor.IsNull() {
 		return Location{}
@@ -21,14 +22,23 @@ func FromCursor(cursor clang.Cursor) Location {
 		usr = cursor.DisplayName()
 	}
 
-	file, line, col, offset := cursor.Location().FileLocation()
+	loc := fromSourceLocation(cursor.Location())
+	loc.usr = usr
+	loc.kind = cursor.Kind()
+	loc.isForward = loc.kind.IsDeclaration() && cursor.Definition().IsNull()
+
+	return loc
+}
+
+// fromSourceLocation return the location of the clang.SourceLocation sl, without the USR.
+func fromSourceLocation(sl clang.SourceLocation) Location {
+	file, line, col, offset := sl.FileLocation()
 
 	return Location{
 		fileName: file.Name(),
 		line:     line,
 		col:      col,
 		offset:   offset,
-		usr:      usr,
 	}
 }
 
@@ -41,3 +51,11 @@ func NewClangClient(cc *grpc.ClientConn) symbol.ClangClient {
 func RegisterClangServer(s *grpc.Server, srv symbol.ClangServer) {
 	symbol.RegisterClangServer(s, srv)
 }
+
+// boolToByte converts the b to flatbuffers bool representation.
+func boolToByte(b bool) byte {
+	if b {
+		return byte(1)
+	}
+	return byte(0)
+}
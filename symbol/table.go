@@ -0,0 +1,218 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Table is the project-wide table of the Files, which indexes the symbols across the Files.
+//
+// The symbols of the header are recorded by every File which includes the header, so a symbol may be in
+// several Files, and each of them may know only a part of the declarations and definition.
+// It is safe for concurrent use.
+type Table struct {
+	mu sync.RWMutex
+
+	files   map[FileID]*File
+	symbols map[ID][]FileID // symbol ID -> Files which have the symbol
+	ids     map[FileID][]ID // File -> symbol IDs of the File
+	gen     uint64          // incremented by each File addition and removal
+
+	metrics Metrics // nil uses the package Metrics
+
+	suggestMu sync.Mutex    // guards suggest
+	suggest   *suggestIndex // built on demand by Suggest
+}
+
+// NewTable builds the Table from files.
+func NewTable(files []*File) *Table {
+	t := &Table{
+		files:   make(map[FileID]*File, len(files)),
+		symbols: make(map[ID][]FileID),
+		ids:     make(map[FileID][]ID, len(files)),
+	}
+	for _, f := range files {
+		t.add(f)
+	}
+
+	return t
+}
+
+// SetMetrics sets the Metrics which records the File additions and removals of t, and the queries of t such as
+// Resolver.Definition and the SymbolIndex built from t. The nil m uses the package Metrics, see SetMetrics.
+func (t *Table) SetMetrics(m Metrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics = m
+}
+
+// metricsOf returns the Metrics of t.
+func (t *Table) metricsOf() Metrics {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return metricsOf(t.metrics)
+}
+
+// tableFileID returns the FileID of the File name in the Table.
+func tableFileID(name string) FileID {
+	return ToFileID(filepath.Clean(name))
+}
+
+// Update adds f to the Table, replacing the File which has the same name.
+// The File of ProvenanceCtags does not replace the File of clang, since the clang results are precise.
+func (t *Table) Update(f *File) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fid := tableFileID(f.Name())
+	if old, ok := t.files[fid]; ok && f.Provenance() == ProvenanceCtags && old.Provenance() != ProvenanceCtags {
+		return
+	}
+	t.remove(fid)
+	t.add(f)
+}
+
+// Remove removes the File of name from the Table.
+func (t *Table) Remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(tableFileID(name))
+}
+
+// File returns the File of name, or nil if the Table has no such File.
+func (t *Table) File(name string) *File {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.files[tableFileID(name)]
+}
+
+// Files returns all Files of the Table, ordered by the FileID.
+func (t *Table) Files() []*File {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.filesOf(t.sortedFileIDs())
+}
+
+// Symbols returns the symbols of id in all Files which have it, ordered by the FileID of the File.
+// If any File of clang has the symbol, the symbols of the Files of ProvenanceCtags are omitted.
+func (t *Table) Symbols(id ID) []*Info {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var symbols, ctags []*Info
+	for _, f := range t.filesOf(t.symbols[id]) {
+		sym := f.Symbol(id)
+		switch {
+		case sym == nil:
+		case f.Provenance() == ProvenanceCtags:
+			ctags = append(ctags, sym)
+		default:
+			symbols = append(symbols, sym)
+		}
+	}
+	if len(symbols) == 0 {
+		return ctags
+	}
+
+	return symbols
+}
+
+// SymbolsAt returns the symbols which declared, defined or referenced at the line and col of fileName in any File,
+// ordered by the FileID of the File. The position in the header may be found in the several Files which
+// include the header, so the same symbol may be returned more than once.
+func (t *Table) SymbolsAt(fileName string, line, col uint32) []*Info {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var symbols []*Info
+	for _, f := range t.filesOf(t.sortedFileIDs()) {
+		if sym := f.LookupSymbolAt(fileName, line, col); sym != nil {
+			symbols = append(symbols, sym)
+		}
+	}
+
+	return symbols
+}
+
+// sortedFileIDs returns the FileIDs of all Files in order.
+func (t *Table) sortedFileIDs() []FileID {
+	fids := make([]FileID, 0, len(t.files))
+	for fid := range t.files {
+		fids = append(fids, fid)
+	}
+	sort.Slice(fids, func(i, j int) bool {
+		return bytes.Compare(fids[i][:], fids[j][:]) < 0
+	})
+
+	return fids
+}
+
+// filesOf returns the Files of fids.
+func (t *Table) filesOf(fids []FileID) []*File {
+	files := make([]*File, 0, len(fids))
+	for _, fid := range fids {
+		files = append(files, t.files[fid])
+	}
+
+	return files
+}
+
+func (t *Table) add(f *File) {
+	metricsOf(t.metrics).IncCounter(MetricTableFilesAdded, 1)
+	t.gen++
+	fid := tableFileID(f.Name())
+	t.files[fid] = f
+
+	syms := f.Symbols()
+	ids := make([]ID, 0, len(syms))
+	for _, sym := range syms {
+		id := sym.ID()
+		ids = append(ids, id)
+		fids := t.symbols[id]
+		// keep the FileIDs sorted, so the symbols are returned in the FileID order
+		i := sort.Search(len(fids), func(i int) bool {
+			return bytes.Compare(fids[i][:], fid[:]) >= 0
+		})
+		fids = append(fids, FileID{})
+		copy(fids[i+1:], fids[i:])
+		fids[i] = fid
+		t.symbols[id] = fids
+	}
+	t.ids[fid] = ids
+}
+
+func (t *Table) remove(fid FileID) {
+	if _, ok := t.files[fid]; !ok {
+		return
+	}
+	metricsOf(t.metrics).IncCounter(MetricTableFilesRemoved, 1)
+	t.gen++
+
+	for _, id := range t.ids[fid] {
+		fids := t.symbols[id]
+		for i := range fids {
+			if fids[i] == fid {
+				fids = append(fids[:i], fids[i+1:]...)
+				break
+			}
+		}
+		if len(fids) == 0 {
+			delete(t.symbols, id)
+		} else {
+			t.symbols[id] = fids
+		}
+	}
+	delete(t.ids, fid)
+	delete(t.files, fid)
+}
@@ -0,0 +1,317 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transport provides the request and response messages of the symbol queries, such as the gRPC service
+// exposes. The messages are plain structs for the transport only, and the symbol package keeps the flatbuffers
+// as the storage format. The enums of clang are carried as the plain integers, so the messages do not depend on
+// libclang.
+package transport
+
+import (
+	"github.com/go-clang/v3.9/clang"
+	"github.com/zchee/clang-server/symbol"
+)
+
+// Location represents the symbol.Location.
+type Location struct {
+	File      string `json:"file"`
+	Line      uint32 `json:"line"`
+	Col       uint32 `json:"col"` // in the column base, see symbol.SetColumnBase
+	Offset    uint32 `json:"offset,omitempty"`
+	USR       string `json:"usr,omitempty"`
+	IsForward bool   `json:"is_forward,omitempty"`
+}
+
+// FromLocation converts the symbol.Location to Location.
+func FromLocation(loc symbol.Location) Location {
+	return Location{
+		File:      loc.FileName(),
+		Line:      loc.Line(),
+		Col:       loc.Col(),
+		Offset:    loc.Offset(),
+		USR:       loc.USR(),
+		IsForward: loc.IsForward(),
+	}
+}
+
+// ToSymbol converts l to the in-memory symbol.Location.
+func (l Location) ToSymbol() symbol.Location {
+	return symbol.NewLocation(l.File, l.Line, l.Col,
+		symbol.WithOffset(l.Offset),
+		symbol.WithUSR(l.USR),
+		symbol.WithForward(l.IsForward),
+	)
+}
+
+// FromLocations converts locs to the Location slice.
+func FromLocations(locs []symbol.Location) []Location {
+	if len(locs) == 0 {
+		return nil
+	}
+
+	ls := make([]Location, len(locs))
+	for i, loc := range locs {
+		ls[i] = FromLocation(loc)
+	}
+
+	return ls
+}
+
+// ToSymbolLocations converts locs to the symbol.Location slice.
+func ToSymbolLocations(locs []Location) []symbol.Location {
+	if len(locs) == 0 {
+		return nil
+	}
+
+	ls := make([]symbol.Location, len(locs))
+	for i, loc := range locs {
+		ls[i] = loc.ToSymbol()
+	}
+
+	return ls
+}
+
+// UnsavedFile represents the symbol.UnsavedFile.
+type UnsavedFile struct {
+	Name     string `json:"name"`
+	Contents []byte `json:"contents,omitempty"`
+	Version  int64  `json:"version,omitempty"`
+}
+
+// FromUnsavedFiles converts files to the UnsavedFile slice.
+func FromUnsavedFiles(files symbol.UnsavedFiles) []UnsavedFile {
+	if len(files) == 0 {
+		return nil
+	}
+
+	ufs := make([]UnsavedFile, len(files))
+	for i, u := range files {
+		ufs[i] = UnsavedFile{Name: u.Name, Contents: u.Contents, Version: int64(u.Version)}
+	}
+
+	return ufs
+}
+
+// ToSymbolUnsavedFiles converts files to the symbol.UnsavedFiles.
+func ToSymbolUnsavedFiles(files []UnsavedFile) symbol.UnsavedFiles {
+	if len(files) == 0 {
+		return nil
+	}
+
+	ufs := make(symbol.UnsavedFiles, len(files))
+	for i, u := range files {
+		ufs[i] = symbol.UnsavedFile{Name: u.Name, Contents: u.Contents, Version: int(u.Version)}
+	}
+
+	return ufs
+}
+
+// Diagnostic represents the symbol.Diagnostic.
+type Diagnostic struct {
+	Severity uint32   `json:"severity"` // clang.DiagnosticSeverity
+	Message  string   `json:"message"`
+	Location Location `json:"location"`
+}
+
+// FromDiagnostic converts the symbol.Diagnostic to Diagnostic.
+func FromDiagnostic(d *symbol.Diagnostic) Diagnostic {
+	return Diagnostic{
+		Severity: uint32(d.Severity()),
+		Message:  d.Message(),
+		Location: FromLocation(d.Location()),
+	}
+}
+
+// ToSymbol converts d to the in-memory symbol.Diagnostic.
+func (d Diagnostic) ToSymbol() *symbol.Diagnostic {
+	return symbol.NewDiagnostic(clang.DiagnosticSeverity(d.Severity), d.Message, d.Location.ToSymbol())
+}
+
+// CompleteItem represents the symbol.CompleteItem.
+// The SortText is not carried, because it is derived from the Priority and Word.
+type CompleteItem struct {
+	Word          string `json:"word"`
+	Abbr          string `json:"abbr,omitempty"`
+	Menu          string `json:"menu,omitempty"`
+	Info          string `json:"info,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	Icase         bool   `json:"icase,omitempty"`
+	Dup           bool   `json:"dup,omitempty"`
+	Priority      uint32 `json:"priority,omitempty"`
+	Snippet       string `json:"snippet,omitempty"`
+	SnippetSyntax int32  `json:"snippet_syntax,omitempty"` // symbol.SnippetSyntax
+	Availability  uint32 `json:"availability,omitempty"`   // clang.AvailabilityKind
+	Deprecated    bool   `json:"deprecated,omitempty"`
+	CursorKind    uint32 `json:"cursor_kind,omitempty"` // clang.CursorKind
+	Overloads     uint32 `json:"overloads,omitempty"`
+	UserData      string `json:"user_data,omitempty"`
+}
+
+// FromCompleteItem converts the symbol.CompleteItem to CompleteItem.
+func FromCompleteItem(c *symbol.CompleteItem) CompleteItem {
+	return CompleteItem{
+		Word:          c.Word(),
+		Abbr:          c.Abbr(),
+		Menu:          c.Menu(),
+		Info:          c.Info(),
+		Kind:          c.Kind(),
+		Icase:         c.Icase(),
+		Dup:           c.Dup(),
+		Priority:      c.Priority(),
+		Snippet:       c.Snippet(),
+		SnippetSyntax: int32(c.SnippetSyntax()),
+		Availability:  uint32(c.Availability()),
+		Deprecated:    c.Deprecated(),
+		CursorKind:    uint32(c.CursorKind()),
+		Overloads:     c.Overloads(),
+		UserData:      c.UserData(),
+	}
+}
+
+// ToSymbol converts c to the in-memory symbol.CompleteItem.
+func (c CompleteItem) ToSymbol() *symbol.CompleteItem {
+	return symbol.NewCompleteItem(c.Word,
+		symbol.WithAbbr(c.Abbr),
+		symbol.WithMenu(c.Menu),
+		symbol.WithInfo(c.Info),
+		symbol.WithKind(c.Kind),
+		symbol.WithIcase(c.Icase),
+		symbol.WithDup(c.Dup),
+		symbol.WithPriority(c.Priority),
+		symbol.WithSnippet(c.Snippet, symbol.SnippetSyntax(c.SnippetSyntax)),
+		symbol.WithAvailability(clang.AvailabilityKind(c.Availability)),
+		symbol.WithDeprecated(c.Deprecated),
+		symbol.WithCursorKind(clang.CursorKind(c.CursorKind)),
+		symbol.WithOverloads(c.Overloads),
+		symbol.WithUserData(c.UserData),
+	)
+}
+
+// ----------------------------------------------------------------------------
+
+// DefinitionRequest represents a request of the definition of the symbol at the location.
+type DefinitionRequest struct {
+	File    string        `json:"file"`
+	Line    uint32        `json:"line"`
+	Col     uint32        `json:"col"` // in the column base, see symbol.SetColumnBase
+	Unsaved []UnsavedFile `json:"unsaved,omitempty"`
+}
+
+// Location returns the symbol.Location of the request.
+func (r *DefinitionRequest) Location() symbol.Location {
+	return symbol.NewLocation(r.File, r.Line, r.Col)
+}
+
+// UnsavedFiles returns the unsaved files of the request.
+func (r *DefinitionRequest) UnsavedFiles() symbol.UnsavedFiles {
+	return ToSymbolUnsavedFiles(r.Unsaved)
+}
+
+// DefinitionResponse represents a response of the DefinitionRequest.
+type DefinitionResponse struct {
+	Locations []Location `json:"locations"`
+}
+
+// NewDefinitionResponse returns the DefinitionResponse of locs, such as symbol.Resolver.Definition returns.
+func NewDefinitionResponse(locs []symbol.Location) *DefinitionResponse {
+	return &DefinitionResponse{Locations: FromLocations(locs)}
+}
+
+// ReferencesRequest represents a request of the references of the symbol at the location.
+type ReferencesRequest struct {
+	File    string        `json:"file"`
+	Line    uint32        `json:"line"`
+	Col     uint32        `json:"col"` // in the column base, see symbol.SetColumnBase
+	Unsaved []UnsavedFile `json:"unsaved,omitempty"`
+}
+
+// Location returns the symbol.Location of the request.
+func (r *ReferencesRequest) Location() symbol.Location {
+	return symbol.NewLocation(r.File, r.Line, r.Col)
+}
+
+// UnsavedFiles returns the unsaved files of the request.
+func (r *ReferencesRequest) UnsavedFiles() symbol.UnsavedFiles {
+	return ToSymbolUnsavedFiles(r.Unsaved)
+}
+
+// ReferencesResponse represents a response of the ReferencesRequest.
+type ReferencesResponse struct {
+	Locations []Location `json:"locations"`
+}
+
+// NewReferencesResponse returns the ReferencesResponse of locs.
+func NewReferencesResponse(locs []symbol.Location) *ReferencesResponse {
+	return &ReferencesResponse{Locations: FromLocations(locs)}
+}
+
+// CompleteRequest represents a request of the completion at the location.
+type CompleteRequest struct {
+	File    string        `json:"file"`
+	Line    uint32        `json:"line"`
+	Col     uint32        `json:"col"` // in the column base, see symbol.SetColumnBase
+	Unsaved []UnsavedFile `json:"unsaved,omitempty"`
+}
+
+// FromCompletionRequest converts the flatbuffers symbol.CompletionRequest to CompleteRequest.
+func FromCompletionRequest(r *symbol.CompletionRequest) *CompleteRequest {
+	loc := r.Location()
+	return &CompleteRequest{
+		File:    loc.FileName(),
+		Line:    loc.Line(),
+		Col:     loc.Col(),
+		Unsaved: FromUnsavedFiles(r.UnsavedFiles()),
+	}
+}
+
+// ToSymbol converts r to the flatbuffers symbol.CompletionRequest.
+func (r *CompleteRequest) ToSymbol() *symbol.CompletionRequest {
+	buf := symbol.CreateCompletionRequest(r.File, r.Line, r.Col, ToSymbolUnsavedFiles(r.Unsaved)).FinishedBytes()
+	return symbol.GetRootAsCompletionRequest(buf, 0)
+}
+
+// CompleteResponse represents the symbol.CodeCompleteResults, which is the response of the CompleteRequest.
+type CompleteResponse struct {
+	Items       []CompleteItem `json:"items"`
+	Truncated   bool           `json:"truncated,omitempty"`
+	Total       int            `json:"total"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	Context     uint64         `json:"context,omitempty"` // symbol.CompletionContext
+}
+
+// FromCodeCompleteResults converts the symbol.CodeCompleteResults to CompleteResponse.
+func FromCodeCompleteResults(c *symbol.CodeCompleteResults) *CompleteResponse {
+	results := c.Results()
+	resp := &CompleteResponse{
+		Items:     make([]CompleteItem, len(results)),
+		Truncated: c.Truncated(),
+		Total:     c.Total(),
+		Context:   uint64(c.Context()),
+	}
+	for i := range results {
+		resp.Items[i] = FromCompleteItem(&results[i])
+	}
+	for _, d := range c.Diagnostics() {
+		if d != nil {
+			resp.Diagnostics = append(resp.Diagnostics, FromDiagnostic(d))
+		}
+	}
+
+	return resp
+}
+
+// ToSymbol converts r to the flatbuffers symbol.CodeCompleteResults.
+func (r *CompleteResponse) ToSymbol() *symbol.CodeCompleteResults {
+	items := make([]*symbol.CompleteItem, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = item.ToSymbol()
+	}
+	var diags []*symbol.Diagnostic
+	for _, d := range r.Diagnostics {
+		diags = append(diags, d.ToSymbol())
+	}
+
+	buf := symbol.CreateCodeCompleteResults(items, r.Truncated, r.Total, diags, symbol.CompletionContext(r.Context)).FinishedBytes()
+	return symbol.GetRootAsCodeCompleteResults(buf, 0)
+}
@@ -0,0 +1,175 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transport
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zchee/clang-server/symbol"
+)
+
+// checkComplete reports the zero fields of v, so the test fixture sets every field and the new field which is
+// not mapped by the converters fails the round trip.
+func checkComplete(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	var walk func(path string, v reflect.Value)
+	walk = func(path string, v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() {
+				t.Errorf("%s: %s is nil", name, path)
+				return
+			}
+			walk(path, v.Elem())
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				walk(path+"."+v.Type().Field(i).Name, v.Field(i))
+			}
+		case reflect.Slice:
+			if v.Len() == 0 {
+				t.Errorf("%s: %s is empty", name, path)
+			}
+			for i := 0; i < v.Len(); i++ {
+				walk(path, v.Index(i))
+			}
+		default:
+			if reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface()) {
+				t.Errorf("%s: %s is zero", name, path)
+			}
+		}
+	}
+	walk("", reflect.ValueOf(v))
+}
+
+var (
+	testLocation = Location{
+		File:      "/src/main.c",
+		Line:      10,
+		Col:       5,
+		Offset:    120,
+		USR:       "c:@F@main",
+		IsForward: true,
+	}
+	testDiagnostic = Diagnostic{
+		Severity: 3,
+		Message:  "use of undeclared identifier 'x'",
+		Location: testLocation,
+	}
+	testCompleteItem = CompleteItem{
+		Word:          "open",
+		Abbr:          "open(const char *path, int flags)",
+		Menu:          "int",
+		Info:          "open a file",
+		Kind:          "f",
+		Icase:         true,
+		Dup:           true,
+		Priority:      50,
+		Snippet:       "open(${1:path}, ${2:flags})$0",
+		SnippetSyntax: 1,
+		Availability:  1,
+		Deprecated:    true,
+		CursorKind:    8,
+		Overloads:     2,
+		UserData:      "9:c:@F@open",
+	}
+	testUnsaved = []UnsavedFile{{Name: "/src/main.c", Contents: []byte("int main() {}"), Version: 3}}
+)
+
+func TestLocation(t *testing.T) {
+	checkComplete(t, "Location", testLocation)
+	if got := FromLocation(testLocation.ToSymbol()); got != testLocation {
+		t.Errorf("FromLocation(ToSymbol()) = %+v, want %+v", got, testLocation)
+	}
+
+	locs := []Location{testLocation, {File: "/src/util.h", Line: 1, Col: 1}}
+	if got := FromLocations(ToSymbolLocations(locs)); !reflect.DeepEqual(got, locs) {
+		t.Errorf("FromLocations(ToSymbolLocations()) = %+v, want %+v", got, locs)
+	}
+
+	defer symbol.SetColumnBase(1)
+	symbol.SetColumnBase(0)
+	if got := FromLocation(testLocation.ToSymbol()); got != testLocation {
+		t.Errorf("column base 0: FromLocation(ToSymbol()) = %+v, want %+v", got, testLocation)
+	}
+}
+
+func TestDiagnostic(t *testing.T) {
+	checkComplete(t, "Diagnostic", testDiagnostic)
+	if got := FromDiagnostic(testDiagnostic.ToSymbol()); got != testDiagnostic {
+		t.Errorf("FromDiagnostic(ToSymbol()) = %+v, want %+v", got, testDiagnostic)
+	}
+}
+
+func TestCompleteItem(t *testing.T) {
+	checkComplete(t, "CompleteItem", testCompleteItem)
+	if got := FromCompleteItem(testCompleteItem.ToSymbol()); got != testCompleteItem {
+		t.Errorf("FromCompleteItem(ToSymbol()) = %+v, want %+v", got, testCompleteItem)
+	}
+
+	// the symbol to transport direction keeps every field which CompleteItem.Equal compares
+	item := testCompleteItem.ToSymbol()
+	if got := FromCompleteItem(item).ToSymbol(); !got.Equal(*item) {
+		t.Errorf("ToSymbol(FromCompleteItem()) = %+v, want %+v", got, item)
+	}
+}
+
+func TestCompleteRequest(t *testing.T) {
+	req := &CompleteRequest{File: testLocation.File, Line: testLocation.Line, Col: testLocation.Col, Unsaved: testUnsaved}
+	checkComplete(t, "CompleteRequest", req)
+	if got := FromCompletionRequest(req.ToSymbol()); !reflect.DeepEqual(got, req) {
+		t.Errorf("FromCompletionRequest(ToSymbol()) = %+v, want %+v", got, req)
+	}
+
+	defer symbol.SetColumnBase(1)
+	symbol.SetColumnBase(0)
+	if got := FromCompletionRequest(req.ToSymbol()); !reflect.DeepEqual(got, req) {
+		t.Errorf("column base 0: FromCompletionRequest(ToSymbol()) = %+v, want %+v", got, req)
+	}
+}
+
+func TestCompleteResponse(t *testing.T) {
+	second := testCompleteItem
+	second.Word, second.Priority = "close", 40
+	resp := &CompleteResponse{
+		Items:       []CompleteItem{testCompleteItem, second},
+		Truncated:   true,
+		Total:       10,
+		Diagnostics: []Diagnostic{testDiagnostic},
+		Context:     1 << 3,
+	}
+	checkComplete(t, "CompleteResponse", resp)
+	if got := FromCodeCompleteResults(resp.ToSymbol()); !reflect.DeepEqual(got, resp) {
+		t.Errorf("FromCodeCompleteResults(ToSymbol()) = %+v, want %+v", got, resp)
+	}
+}
+
+func TestQueryMessages(t *testing.T) {
+	def := &DefinitionRequest{File: testLocation.File, Line: testLocation.Line, Col: testLocation.Col, Unsaved: testUnsaved}
+	checkComplete(t, "DefinitionRequest", def)
+	ref := &ReferencesRequest{File: testLocation.File, Line: testLocation.Line, Col: testLocation.Col, Unsaved: testUnsaved}
+	checkComplete(t, "ReferencesRequest", ref)
+
+	for name, r := range map[string]interface {
+		Location() symbol.Location
+		UnsavedFiles() symbol.UnsavedFiles
+	}{"DefinitionRequest": def, "ReferencesRequest": ref} {
+		want := Location{File: testLocation.File, Line: testLocation.Line, Col: testLocation.Col}
+		if got := FromLocation(r.Location()); got != want {
+			t.Errorf("%s.Location() = %+v, want %+v", name, got, want)
+		}
+		if got := FromUnsavedFiles(r.UnsavedFiles()); !reflect.DeepEqual(got, testUnsaved) {
+			t.Errorf("%s.UnsavedFiles() = %+v, want %+v", name, got, testUnsaved)
+		}
+	}
+
+	locs := ToSymbolLocations([]Location{testLocation})
+	if got := NewDefinitionResponse(locs).Locations; !reflect.DeepEqual(got, []Location{testLocation}) {
+		t.Errorf("NewDefinitionResponse().Locations = %+v, want %+v", got, []Location{testLocation})
+	}
+	if got := NewReferencesResponse(locs).Locations; !reflect.DeepEqual(got, []Location{testLocation}) {
+		t.Errorf("NewReferencesResponse().Locations = %+v, want %+v", got, []Location{testLocation})
+	}
+}
@@ -5,12 +5,21 @@
 package symbol
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
-	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-clang/v3.9/clang"
 	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+	"github.com/zchee/clang-server/internal/hashutil"
+	"github.com/zchee/clang-server/internal/pathutil"
 	"github.com/zchee/clang-server/internal/symbol"
 )
 
@@ -23,16 +32,51 @@ import (
 //    TranslationUnit: string;
 //    Symbols: [Info];
 //    Headers: [Header];
-//    Includes: [string];
+//    Includes: [Include];
+//    TUOmitted: bool;
+//    Metadata: [Meta];
+//    Language: ubyte;
+//    Unsaved: bool;
+//    Diagnostics: [Diagnostic];
 //  }
+//
+// The read-only methods, which are Name, Flags, Language, TranslationUnit, Symbols, Symbol, SymbolsInNamespace,
+// Subclasses, Overriders, Parameters, Headers, LookupSymbolAt, SymbolsInRange and Equal, are safe for concurrent use,
+// including on the lazily unmarshaled File. The other methods mutate the File, and must not be called
+// concurrently with any other method.
 type File struct {
 	name            string
 	flags           []string
 	translationUnit []byte
+	tuPath          string // spilled translation unit file
 	locations       map[Location]ID
 	symbols         map[ID]*Info
 	headers         []*Header
-
+	includes        []*Include
+	meta            map[string]string
+	language        Language
+	unsaved         bool
+	diagnostics     []*Diagnostic
+
+	canonicalHeaderPath bool
+	usrPathRewriter     func(string) string
+	maxTUBytes          int
+	tuOmitted           bool
+	reproducible        bool
+	packedLocations     bool
+	lazy                bool                   // symbols are hydrated on demand, see UnmarshalLazy
+	lazyMu              sync.RWMutex           // guards lazy, and symbols and locations while lazy
+	lineIndex           map[string][]lineEntry // built on demand by SymbolsInRange, reset by the mutations
+	lineMu              sync.Mutex             // guards lineIndex
+	flattenAnonymous    bool
+	requireKnownSymbol  bool
+	kindFilter          map[clang.CursorKind]bool
+	modTimeFunc         func(string) time.Time
+	modTimes            map[string]time.Time
+	frozen              bool
+	frozenSymbols       []*Info // the symbols in the serialization order, snapshotted by Freeze
+
+	arena   arena // allocates the symbols of addSymbol and AddCaller
 	builder *flatbuffers.Builder
 
 	file *symbol.File
@@ -42,10 +86,12 @@ type File struct {
 type SymbolFile = symbol.File
 
 // NewFile return the new File.
+// The Language is detected from name and flags by DetectLanguage.
 func NewFile(name string, flags []string) *File {
 	return &File{
 		name:      name,
 		flags:     flags,
+		language:  DetectLanguage(name, flags),
 		locations: make(map[Location]ID),
 		symbols:   make(map[ID]*Info),
 		builder:   flatbuffers.NewBuilder(0),
@@ -61,19 +107,28 @@ func GetRootAsFile(buf []byte, offset flatbuffers.UOffsetT) *File {
 
 // Name return the filename.
 func (f *File) Name() string {
-	if f.name != "" {
+	if f.name != "" || f.file == nil {
 		return f.name
 	}
 	return string(f.file.Name())
 }
 
+// NameBytes is like Name, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (f *File) NameBytes() []byte {
+	if f.name != "" || f.file == nil {
+		return []byte(f.name)
+	}
+	return f.file.Name()
+}
+
 // Flags return the compiler flags.
 func (f *File) Flags() []string {
-	if len(f.flags) > 0 {
+	if len(f.flags) > 0 || f.file == nil {
 		return f.flags
 	}
 
-	n := f.file.FlagsLength()
+	n := safeLength(f.file.Table(), fileFlagsSlot)
 	flags := make([]string, n)
 	for i := 0; i < n; i++ {
 		flags[i] = string(f.file.Flags(i))
@@ -82,492 +137,3031 @@ func (f *File) Flags() []string {
 	return flags
 }
 
+// Language return the source language, or LanguageUnknown if it is undeterminable.
+// The language of the File serialized without the language is detected from its name and flags.
+func (f *File) Language() Language {
+	if f.language != LanguageUnknown || f.file == nil {
+		return f.language
+	}
+	if lang := Language(f.file.Language()); lang != LanguageUnknown {
+		return lang
+	}
+	return DetectLanguage(f.Name(), f.Flags())
+}
+
+// SetLanguage sets the source language, such as the language of the clang translation unit,
+// which overrides the language detected by NewFile.
+func (f *File) SetLanguage(lang Language) {
+	f.language = lang
+}
+
+// FlagsBytes is like Flags, but returns the flatbuffers bytes of each flag without copying.
+// The returned slices are valid only while the backing buffer lives, and must not be modified.
+func (f *File) FlagsBytes() [][]byte {
+	if len(f.flags) > 0 || f.file == nil {
+		flags := make([][]byte, len(f.flags))
+		for i, flag := range f.flags {
+			flags[i] = []byte(flag)
+		}
+		return flags
+	}
+
+	n := safeLength(f.file.Table(), fileFlagsSlot)
+	flags := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		flags[i] = f.file.Flags(i)
+	}
+
+	return flags
+}
+
 // TranslationUnit return the libclang translation unit data.
+// The spilled data is read back from the disk, and nil is returned if it cannot be read.
 func (f *File) TranslationUnit() []byte {
-	if len(f.translationUnit) > 0 {
+	if f.tuPath != "" {
+		buf, err := ioutil.ReadFile(f.tuPath)
+		if err != nil {
+			return nil
+		}
+		return buf
+	}
+	if len(f.translationUnit) > 0 || f.file == nil {
 		return f.translationUnit
 	}
 	return f.file.TranslationUnit()
 }
 
 // Symbols return the C/C++ files symbols.
+//
+// The symbols of the flatbuffers representation are allocated in one batch, so keeping any of them keeps the
+// whole batch alive. Use AcquireSymbols for the short-lived iteration which keeps none of them.
 func (f *File) Symbols() []*Info {
-	if len(f.symbols) > 0 {
-		symbols := make([]*Info, len(f.symbols))
+	f.hydrate()
+	if len(f.symbols) > 0 || f.file == nil {
+		symbols := make([]*Info, 0, len(f.symbols))
 		for _, v := range f.symbols {
 			symbols = append(symbols, v)
 		}
 		return symbols
 	}
 
-	n := f.file.SymbolsLength()
+	return f.fileSymbols()
+}
+
+// fileSymbols returns the symbols of the flatbuffers representation in f.
+func (f *File) fileSymbols() []*Info {
+	// the wrappers and tables are allocated at once, and each element has its own table
+	n := safeLength(f.file.Table(), fileSymbolsSlot)
 	symbols := make([]*Info, n)
+	f.decodeSymbols(symbols, make([]infoSlot, n))
 
-	for i := 0; i < n; i++ {
-		obj := new(symbol.Info)
-		if f.file.Symbols(obj, i) {
-			symbols[i] = &Info{info: obj}
+	return symbols
+}
+
+// infoSlot is the Info wrapper which allocated together with its table.
+type infoSlot struct {
+	Info
+	obj symbol.Info
+}
+
+// decodeSymbols decodes the symbols of the flatbuffers representation in f into symbols, using the zeroed slots.
+// Both symbols and slots have the length of the symbols vector.
+func (f *File) decodeSymbols(symbols []*Info, slots []infoSlot) {
+	for i := range symbols {
+		s := &slots[i]
+		if f.file.Symbols(&s.obj, i) {
+			s.info = &s.obj
+			symbols[i] = &s.Info
+		}
+	}
+}
+
+// SymbolsInNamespace return the symbols which enclosed by the namespace ns.
+// The empty ns returns the symbols in the global namespace.
+func (f *File) SymbolsInNamespace(ns string) []*Info {
+	var symbols []*Info
+	for _, sym := range f.Symbols() {
+		if sym.Namespace() == ns {
+			symbols = append(symbols, sym)
+		}
+	}
+
+	return symbols
+}
+
+// Subclasses return the symbols which derived directly from the base class of baseUSR.
+func (f *File) Subclasses(baseUSR string) []*Info {
+	baseUSR = f.rewriteUSR(baseUSR)
+	var symbols []*Info
+	for _, sym := range f.Symbols() {
+		for _, base := range sym.Bases() {
+			if base == baseUSR {
+				symbols = append(symbols, sym)
+				break
+			}
+		}
+	}
+
+	return symbols
+}
+
+// Parameters return the parameter symbols of the function of funcUSR, sorted by the ParamIndex.
+func (f *File) Parameters(funcUSR string) []*Info {
+	funcUSR = f.rewriteUSR(funcUSR)
+	var symbols []*Info
+	for _, sym := range f.Symbols() {
+		if sym.SymbolKind() == SymbolKindParameter && sym.ParentUSR() == funcUSR {
+			symbols = append(symbols, sym)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].ParamIndex() < symbols[j].ParamIndex()
+	})
+
+	return symbols
+}
+
+// LookupSymbolAt return the symbol which declared, defined or referenced at the line and col of fileName,
+// or nil if not found. The col is in the column base, see SetColumnBase.
+func (f *File) LookupSymbolAt(fileName string, line, col uint32) *Info {
+	at := func(loc Location) bool {
+		return loc.Line() == line && loc.Col() == col && loc.FileName() == fileName
+	}
+	for _, sym := range f.Symbols() {
+		for _, decl := range sym.Decls() {
+			if at(decl) {
+				return sym
+			}
+		}
+		if at(sym.Def()) {
+			return sym
+		}
+		for _, caller := range sym.Callers() {
+			if at(caller.Location()) {
+				return sym
+			}
+		}
+	}
+
+	return nil
+}
+
+// ObjCMethods return the Objective-C instance method symbols of the interface of interfaceUSR, sorted by the
+// ObjCSelector. The methods of the @implementation have the interface as the parent, but the methods of the
+// category have the category, so the category USR returns them.
+func (f *File) ObjCMethods(interfaceUSR string) []*Info {
+	interfaceUSR = f.rewriteUSR(interfaceUSR)
+	var symbols []*Info
+	for _, sym := range f.Symbols() {
+		if sym.SymbolKind() == SymbolKindObjCInstanceMethod && sym.ParentUSR() == interfaceUSR {
+			symbols = append(symbols, sym)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].ObjCSelector() < symbols[j].ObjCSelector()
+	})
+
+	return symbols
+}
+
+// Overriders return the method symbols which override directly the method of methodUSR.
+func (f *File) Overriders(methodUSR string) []*Info {
+	methodUSR = f.rewriteUSR(methodUSR)
+	var symbols []*Info
+	for _, sym := range f.Symbols() {
+		for _, usr := range sym.Overrides() {
+			if usr == methodUSR {
+				symbols = append(symbols, sym)
+				break
+			}
 		}
 	}
 
 	return symbols
 }
 
+// SetMeta sets the tool-specific metadata value of key, such as the compiler version or the index build host.
+// The metadata is serialized with the File, and the value of the same key is replaced.
+func (f *File) SetMeta(key, value string) {
+	if f.meta == nil {
+		f.meta = make(map[string]string)
+	}
+	f.meta[key] = value
+}
+
+// Meta return the metadata value of key, and whether f has the key.
+func (f *File) Meta(key string) (string, bool) {
+	if len(f.meta) > 0 || f.file == nil {
+		value, ok := f.meta[key]
+		return value, ok
+	}
+
+	// the metadata is serialized in the order of the key
+	n := safeLength(f.file.Table(), fileMetadataSlot)
+	obj := new(symbol.Meta)
+	i := sort.Search(n, func(i int) bool {
+		return f.file.Metadata(obj, i) && string(obj.Key()) >= key
+	})
+	if i < n && f.file.Metadata(obj, i) && string(obj.Key()) == key {
+		return string(obj.Value()), true
+	}
+
+	return "", false
+}
+
+// metadata returns the copy of all metadata of f.
+func (f *File) metadata() map[string]string {
+	if len(f.meta) > 0 || f.file == nil {
+		meta := make(map[string]string, len(f.meta))
+		for key, value := range f.meta {
+			meta[key] = value
+		}
+		return meta
+	}
+
+	n := safeLength(f.file.Table(), fileMetadataSlot)
+	meta := make(map[string]string, n)
+	obj := new(symbol.Meta)
+	for i := 0; i < n; i++ {
+		if f.file.Metadata(obj, i) {
+			meta[string(obj.Key())] = string(obj.Value())
+		}
+	}
+
+	return meta
+}
+
+// serializeMetadata serializes the metadata of f sorted by the key, as the key of the Meta table requires.
+func (f *File) serializeMetadata(builder *flatbuffers.Builder, buf *serializeBuffer) flatbuffers.UOffsetT {
+	meta := f.metadata()
+	if len(meta) == 0 {
+		return 0
+	}
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	offsets := buf.offsetsOf(len(keys))
+	for _, key := range keys {
+		keyOffset := builder.CreateString(key)
+		valueOffset := builder.CreateString(meta[key])
+		symbol.MetaStart(builder)
+		symbol.MetaAddKey(builder, keyOffset)
+		symbol.MetaAddValue(builder, valueOffset)
+		offsets = append(offsets, symbol.MetaEnd(builder))
+	}
+	symbol.FileStartMetadataVector(builder, len(offsets))
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+
+	return builder.EndVector(len(offsets))
+}
+
 // Headers return the C/C++ files included header files.
+// The decoded headers share one allocation, so keeping any of them keeps all of them alive.
 func (f *File) Headers() []*Header {
-	if len(f.headers) > 0 {
+	if len(f.headers) > 0 || f.file == nil {
 		return f.headers
 	}
 
-	n := f.file.HeadersLength()
+	n := safeLength(f.file.Table(), fileHeadersSlot)
 	headers := make([]*Header, n)
+	slots := make([]struct {
+		Header
+		obj symbol.Header
+	}, n)
 
 	for i := 0; i < n; i++ {
-		obj := new(symbol.Header)
-		if f.file.Headers(obj, i) {
-			headers[i] = &Header{header: obj}
+		s := &slots[i]
+		if f.file.Headers(&s.obj, i) {
+			s.header = &s.obj
+			headers[i] = &s.Header
 		}
 	}
 
 	return headers
 }
 
-// AddTranslationUnit add TranslationUnit data to File.
-func (f *File) AddTranslationUnit(buf []byte) {
-	f.translationUnit = buf
+// SetReproducible sets whether the Serialize produces the same bytes regardless of the insertion order.
+// The symbols are sorted by ID and the callers of each symbol are sorted by the location before serializing,
+// so the output does not depend on the visiting order of the translation units.
+func (f *File) SetReproducible(reproducible bool) {
+	f.reproducible = reproducible
 }
 
-// AddSymbol adds the symbol data into File.
-func (f *File) addSymbol(loc, def Location) {
-	id := ToID(loc.usr)
+// SetPackedLocations sets whether the Serialize stores the declarations, definition and callers of the symbols
+// as the compact varint encoded blob instead of the Location tables.
+// The packed locations are decoded lazily by the Info accessors, so the readers need not know the representation.
+func (f *File) SetPackedLocations(packed bool) {
+	f.packedLocations = packed
+}
 
-	sym, ok := f.symbols[id]
-	if !ok {
-		sym = &Info{id: id}
+// SetKindFilter sets the cursor kinds of the symbols which recorded into File, such as only the
+// Cursor_FunctionDecl for the functions-only index. The empty allow records all kinds.
+//
+// The kind is taken from the Location which returned by FromCursor. The symbol of the unknown kind,
+// such as the Location which not created from the cursor, is always recorded.
+func (f *File) SetKindFilter(allow []clang.CursorKind) {
+	if len(allow) == 0 {
+		f.kindFilter = nil
+		return
 	}
-	sym.decls = append(sym.decls, loc)
 
-	if def.isExist() {
-		sym.def = def
+	f.kindFilter = make(map[clang.CursorKind]bool, len(allow))
+	for _, kind := range allow {
+		f.kindFilter[kind] = true
 	}
-
-	f.locations[loc] = id
-	f.symbols[id] = sym
-}
-
-// AddDecl add decl data into File.
-func (f *File) AddDecl(loc Location) {
-	f.addSymbol(loc, Location{})
 }
 
-// AddDefinition add definition data into File.
-func (f *File) AddDefinition(loc, def Location) {
-	f.addSymbol(loc, def)
+// allowKind reports whether the symbol of kind is recorded by the kind filter.
+func (f *File) allowKind(kind clang.CursorKind) bool {
+	return f.kindFilter == nil || kind == 0 || f.kindFilter[kind]
 }
 
-// notExistHeaderName return the not exist header name magic words.
-func notExistHeaderName(headPath string) string {
-	// adding magic to filename to not confuse it with real files
-	return "IDoNotReallyExist-" + filepath.Base(headPath)
+// SetMaxTranslationUnitBytes sets the maximum size of the TranslationUnit data which stored in File.
+// The larger TranslationUnit is omitted by AddTranslationUnit. The zero n means no limit.
+func (f *File) SetMaxTranslationUnitBytes(n int) {
+	f.maxTUBytes = n
 }
 
-// AddHeader add header data into File.
-func (f *File) AddHeader(includePath string, headerFile clang.File) {
-	hdr := new(Header)
-	if headerFile.Name() == "" {
-		hdr.fileid = ToFileID(notExistHeaderName(filepath.Clean(headerFile.Name())))
-		hdr.mtime = time.Now()
+// AddTranslationUnit add TranslationUnit data to File.
+// If buf exceeds the size set by SetMaxTranslationUnitBytes, buf is not stored and
+// TranslationUnitOmitted reports true. In either case the previously spilled TranslationUnit is removed.
+func (f *File) AddTranslationUnit(buf []byte) error {
+	if err := f.checkFrozen("translation unit"); err != nil {
+		return err
+	}
+	if f.maxTUBytes > 0 && len(buf) > f.maxTUBytes {
+		f.translationUnit = nil
+		f.tuOmitted = true
 	} else {
-		hdr.fileid = ToFileID(filepath.Clean(headerFile.Name()))
-		hdr.mtime = headerFile.Time()
+		f.translationUnit = buf
+		f.tuOmitted = false
 	}
 
-	f.headers = append(f.headers, hdr)
+	return f.removeSpilled()
 }
 
-// AddCaller add caller data into File.
-func (f *File) AddCaller(sym, def Location, funcCall bool) {
-	id := ToID(sym.usr)
+// SpillTranslationUnit writes the in-memory TranslationUnit data to the temporary file in dir, and keeps only the
+// reference to it, so that indexing many large files does not hold all the data in memory.
+// The TranslationUnit reads it back on demand. The temporary file is removed by Close.
+func (f *File) SpillTranslationUnit(dir string) error {
+	if len(f.translationUnit) == 0 {
+		return nil
+	}
 
-	syms, ok := f.symbols[id]
-	if !ok {
-		syms = &Info{id: id}
+	tmp, err := ioutil.TempFile(dir, "clang-server-tu-")
+	if err != nil {
+		return errors.Wrap(err, "could not create the translation unit file")
+	}
+	if _, err := tmp.Write(f.translationUnit); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "could not write the translation unit to %s", tmp.Name())
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "could not close %s", tmp.Name())
 	}
 
-	syms.callers = append(syms.callers, &Caller{
-		location: sym,
-		funcCall: funcCall,
-	})
+	f.removeSpilled()
+	f.tuPath = tmp.Name()
+	f.translationUnit = nil
 
-	f.symbols[id] = syms
+	return nil
 }
 
-// Unmarshal parses the flatbuffers representation in f.
-func (f *File) Unmarshal() {
-	f.name = string(f.file.Name())
-	f.translationUnit = f.file.TranslationUnit()
-	f.symbols = make(map[ID]*Info)
-	for _, s := range f.Symbols() {
-		f.symbols[s.ID()] = &Info{
-			id:      s.ID(),
-			decls:   s.Decls(),
-			def:     s.Def(),
-			callers: s.Callers(),
-			info:    s.info,
-		}
+// removeSpilled removes the spilled TranslationUnit file if exists.
+func (f *File) removeSpilled() error {
+	if f.tuPath == "" {
+		return nil
 	}
-	headers := f.Headers()
-	f.headers = make([]*Header, len(headers))
-	for _, hdr := range headers {
-		f.headers = append(f.headers, hdr)
+	path := f.tuPath
+	f.tuPath = ""
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "could not remove the translation unit file %s", path)
 	}
+
+	return nil
 }
 
-// Serialize serializes the File.
-func (f *File) Serialize() *flatbuffers.Builder {
-	if f.builder == nil {
-		f.builder = flatbuffers.NewBuilder(0)
+// Close releases the resources of f, such as the file of the spilled TranslationUnit.
+// The spilled TranslationUnit data is lost after Close. It is safe to call Close more than once.
+func (f *File) Close() error {
+	return f.removeSpilled()
+}
+
+// TranslationUnitOmitted reports whether the TranslationUnit data was omitted because it exceeded the size limit.
+func (f *File) TranslationUnitOmitted() bool {
+	if f.file == nil {
+		return f.tuOmitted
 	}
+	return f.file.TUOmitted() != 0
+}
 
-	fname := f.builder.CreateString(f.Name())
-	tu := f.builder.CreateByteString(f.TranslationUnit())
+// SetUnsaved sets whether the content of f was read from the unsaved buffer of the editor instead of the disk,
+// such as the clang.UnsavedFile passed to the parser, so the consumers know the symbols may differ from the disk.
+func (f *File) SetUnsaved(unsaved bool) {
+	f.unsaved = unsaved
+}
 
-	flagNum := len(f.flags)
-	flagOffsets := make([]flatbuffers.UOffsetT, 0, flagNum)
-	for _, flag := range f.flags {
-		flagOffsets = append(flagOffsets, f.builder.CreateString(flag))
+// IsUnsaved reports whether the content of f was read from the unsaved buffer, see SetUnsaved.
+func (f *File) IsUnsaved() bool {
+	if f.file == nil {
+		return f.unsaved
 	}
-	symbol.FileStartFlagsVector(f.builder, flagNum)
-	for i := flagNum - 1; i >= 0; i-- {
-		f.builder.PrependUOffsetT(flagOffsets[i])
+	return f.file.Unsaved() != 0
+}
+
+// addSymbol adds the symbol data of usr into File, and returns the added symbol.
+// The decl and def are recorded only if exist, and nil is returned if kind is filtered out by SetKindFilter.
+func (f *File) addSymbol(usr string, kind clang.CursorKind, decl, def Location) *Info {
+	if !f.allowKind(kind) {
+		return nil
 	}
-	flagVecOffset := f.builder.EndVector(flagNum)
 
-	symbols := f.symbols
-	symbolNum := len(symbols)
-	symbolOffsets := make([]flatbuffers.UOffsetT, 0, symbolNum)
-	for _, info := range symbols {
-		symbolOffsets = append(symbolOffsets, info.serialize(f.builder))
+	f.hydrate()
+	f.lineIndex = nil
+	id := ToID(f.rewriteUSR(usr))
+	decl.kind, def.kind = 0, 0
+	decl.usr = f.rewriteUSR(decl.usr)
+	def.usr = f.rewriteUSR(def.usr)
+
+	sym, ok := f.symbols[id]
+	if !ok {
+		sym = f.arena.newInfo()
+		sym.id = id
 	}
-	symbol.FileStartSymbolsVector(f.builder, symbolNum)
-	for i := symbolNum - 1; i >= 0; i-- {
-		f.builder.PrependUOffsetT(symbolOffsets[i])
+	if sym.kind == SymbolKindUnknown {
+		sym.kind = ToSymbolKind(kind)
 	}
-	symbolVecOffset := f.builder.EndVector(symbolNum)
 
-	hdrs := f.headers
-	hdrNum := len(hdrs)
-	hdrOffsets := make([]flatbuffers.UOffsetT, 0, hdrNum)
-	for _, hdr := range hdrs {
-		hdrOffsets = append(hdrOffsets, hdr.serialize(f.builder))
+	if !decl.IsZero() {
+		if sym.decls == nil {
+			sym.decls = f.arena.newLocations()
+		}
+		sym.decls = append(sym.decls, decl)
+		f.locations[decl] = id
 	}
-	symbol.FileStartHeadersVector(f.builder, hdrNum)
-	for i := hdrNum - 1; i >= 0; i-- {
-		f.builder.PrependUOffsetT(hdrOffsets[i])
+	if !def.IsZero() {
+		sym.def = def
+		sym.defMtime = f.modTime(def.FileName())
 	}
-	headerVecOffset := f.builder.EndVector(hdrNum)
 
-	symbol.FileStart(f.builder)
-	symbol.FileAddName(f.builder, fname)
-	symbol.FileAddFlags(f.builder, flagVecOffset)
-	symbol.FileAddTranslationUnit(f.builder, tu)
-	symbol.FileAddSymbols(f.builder, symbolVecOffset)
-	symbol.FileAddHeaders(f.builder, headerVecOffset)
+	f.symbols[id] = sym
 
-	f.builder.Finish(symbol.FileEnd(f.builder))
+	return sym
+}
 
-	return f.builder
+// SetModTimeFunc sets the function which returns the modified time of the file, which used for the symbol DefModTime.
+// The default is the mtime of os.Stat, and the zero time if the file does not exist.
+func (f *File) SetModTimeFunc(fn func(filename string) time.Time) {
+	f.modTimeFunc = fn
+	f.modTimes = nil
 }
 
-// ----------------------------------------------------------------------------
+// modTime returns the modified time of filename. The result is cached per File, since the
+// definitions are often in the same few files.
+func (f *File) modTime(filename string) time.Time {
+	if mtime, ok := f.modTimes[filename]; ok {
+		return mtime
+	}
 
-// Info represents a location of C/C++ cursor symbol information.
-//
-//  table Info {
-//    ID: string;
-//    Decls: [Location];
-//    Def: Location;
-//  }
-type Info struct {
-	id      ID
-	decls   []Location
-	def     Location
-	callers []*Caller
+	var mtime time.Time
+	if f.modTimeFunc != nil {
+		mtime = f.modTimeFunc(filename)
+	} else if fi, err := os.Stat(filename); err == nil {
+		mtime = fi.ModTime()
+	}
+	if f.modTimes == nil {
+		f.modTimes = make(map[string]time.Time)
+	}
+	f.modTimes[filename] = mtime
 
-	info *symbol.Info
+	return mtime
 }
 
-// SymbolInfo type alias of symbol.Info.
-type SymbolInfo = symbol.Info
+// AddDecl add decl data into File.
+func (f *File) AddDecl(loc Location) error {
+	if err := f.checkFrozen("decl"); err != nil {
+		return err
+	}
+	f.addSymbol(loc.usr, loc.kind, loc, Location{})
 
-// serialize serializes the Info.
-func (info *Info) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
-	id := builder.CreateString(info.id.String())
+	return nil
+}
 
-	declsNum := len(info.decls)
-	var declVecOffset flatbuffers.UOffsetT
-	if declsNum > 0 {
-		declsOffsets := make([]flatbuffers.UOffsetT, 0, declsNum)
-		for _, decl := range info.decls {
-			declsOffsets = append(declsOffsets, decl.serialize(builder))
-		}
-		symbol.InfoStartDeclsVector(builder, declsNum)
-		for i := declsNum - 1; i >= 0; i-- {
-			builder.PrependUOffsetT(declsOffsets[i])
-		}
-		declVecOffset = builder.EndVector(declsNum)
-	}
+// RecordKind represents a kind of the SymbolRecord.
+type RecordKind int
+
+const (
+	// RecordDecl the record is added by AddDecl.
+	RecordDecl RecordKind = iota
+	// RecordDefinition the record is added by AddDefinition.
+	RecordDefinition
+	// RecordCaller the record is added by AddCaller.
+	RecordCaller
+)
 
-	defOffset := info.def.serialize(builder)
+// SymbolRecord represents a symbol data which added by AddBatch.
+type SymbolRecord struct {
+	Kind     RecordKind
+	Location Location
+	Def      Location // definition of RecordDefinition, or callee of RecordCaller
+	FuncCall bool     // whether the RecordCaller is the function call
+}
 
-	callersNum := len(info.callers)
-	var callerVecOffset flatbuffers.UOffsetT
-	if callersNum > 0 {
-		callersOffsets := make([]flatbuffers.UOffsetT, 0, callersNum)
-		for _, caller := range info.callers {
-			callersOffsets = append(callersOffsets, caller.serialize(builder))
+// AddBatch adds records into File, as same as calling AddDecl, AddDefinition and AddCaller for each record
+// in order. The maps are grown for records at once, so it is faster than the individual calls for the large
+// translation unit. It stops at the first error of AddCaller, and returns it.
+func (f *File) AddBatch(records []SymbolRecord) error {
+	if err := f.checkFrozen("batch"); err != nil {
+		return err
+	}
+	f.Grow(len(records))
+	for i, r := range records {
+		var err error
+		switch r.Kind {
+		case RecordDecl:
+			err = f.AddDecl(r.Location)
+		case RecordDefinition:
+			err = f.AddDefinition(r.Location, r.Def)
+		case RecordCaller:
+			err = f.AddCaller(r.Location, r.Def, r.FuncCall)
 		}
-		symbol.InfoStartCallersVector(builder, callersNum)
-		for i := callersNum - 1; i >= 0; i-- {
-			builder.PrependUOffsetT(callersOffsets[i])
+		if err != nil {
+			return errors.Wrapf(err, "records[%d]", i)
 		}
-		callerVecOffset = builder.EndVector(callersNum)
 	}
 
-	symbol.InfoStart(builder)
-	symbol.InfoAddID(builder, id)
-	symbol.InfoAddDecls(builder, declVecOffset)
-	symbol.InfoAddDef(builder, defOffset)
-	symbol.InfoAddCallers(builder, callerVecOffset)
-
-	return symbol.InfoEnd(builder)
-}
-
-// ID return the symbol ID which hashed blake2b.
-func (info *Info) ID() ID {
-	return ToID(string(info.info.ID()))
+	return nil
 }
 
-// Decls return the symbol declarations information.
-func (info *Info) Decls() []Location {
-	n := info.info.DeclsLength()
-	decls := make([]Location, n)
-
-	for i := 0; i < n; i++ {
-		obj := new(symbol.Location)
-		if info.info.Decls(obj, i) {
-			decls[i] = Location{location: obj}
-		}
+// Grow grows the symbols and locations of f for another n records, so the following adds do not rehash the maps.
+func (f *File) Grow(n int) {
+	f.hydrate()
+	if n <= 0 {
+		return
 	}
 
-	return decls
+	symbols := make(map[ID]*Info, len(f.symbols)+n)
+	for id, info := range f.symbols {
+		symbols[id] = info
+	}
+	locations := make(map[Location]ID, len(f.locations)+n)
+	for loc, id := range f.locations {
+		locations[loc] = id
+	}
+	f.symbols, f.locations = symbols, locations
 }
 
-// Def return the symbol definition information.
-func (info *Info) Def() Location {
-	obj := new(symbol.Location)
-	info.info.Def(obj)
+// AddCursor records the name and type information of the declaration cursor into File.
+func (f *File) AddCursor(cursor clang.Cursor) error {
+	if err := f.checkFrozen("cursor"); err != nil {
+		return err
+	}
+	usr := cursor.USR()
+	if usr == "" {
+		return nil
+	}
+	info := f.addSymbol(usr, cursor.Kind(), Location{}, Location{})
+	if info == nil {
+		return nil
+	}
+	info.setCursor(cursor)
+	for i, base := range info.bases {
+		info.bases[i] = f.rewriteUSR(base)
+	}
+	for i, method := range info.overrides {
+		info.overrides[i] = f.rewriteUSR(method)
+	}
+	info.parentUSR = f.rewriteUSR(info.parentUSR)
+	if f.flattenAnonymous {
+		f.reparentAnonymous(info)
+	}
 
-	return Location{location: obj}
+	return nil
 }
 
-// Callers return the symbol callers information.
-func (info *Info) Callers() []*Caller {
-	n := info.info.CallersLength()
-	callers := make([]*Caller, n)
+// SetFlattenAnonymous sets whether the members of the anonymous struct or union are recorded as the members of
+// the nearest named parent, as same as they are accessible on it. It affects the ParentUSR of the symbols
+// which added by AddCursor after calling it.
+func (f *File) SetFlattenAnonymous(flatten bool) {
+	f.flattenAnonymous = flatten
+}
 
-	for i := 0; i < n; i++ {
-		obj := new(symbol.Caller)
-		if info.info.Callers(obj, i) {
-			callers[i] = &Caller{caller: obj}
+// reparentAnonymous replaces the anonymous record parent of info with the nearest named parent.
+// The parents are resolved from the recorded symbols, which clang visits before the members.
+func (f *File) reparentAnonymous(info *Info) {
+	for info.parentUSR != "" {
+		parent, ok := f.symbols[ToID(info.parentUSR)]
+		if !ok || parent == info || !parent.isAnonymousRecord() {
+			return
 		}
+		info.parentUSR = parent.parentUSR
 	}
-
-	return callers
 }
 
-// ----------------------------------------------------------------------------
-
-// Header represents a location of include header file.
+// SetUSRPathRewriter sets the function which rewrites the USRs before computing the symbol ID.
 //
-//  table Header {
-//    FileID: string (id: 0, required, key); // -> []byte
-//    Mtime: long (id: 1); // time.Time.Unix(): int64
-//  }
-type Header struct {
-	fileid FileID
-	mtime  time.Time
+// The USR of the file-local symbol, such as the static function, embeds the absolute path of the file,
+// which makes the index machine-specific. The rewriter can rebase it to the project-relative path
+// to share the index across the machines. The default is identity.
+//
+// Note that the rebased USRs of the different projects may collide, such as two "src/util.c" static
+// "helper" functions, so the rewritten index must not be mixed with the other projects.
+func (f *File) SetUSRPathRewriter(fn func(usr string) string) {
+	f.usrPathRewriter = fn
+}
 
-	header *symbol.Header
+// rewriteUSR rewrites usr by the USR path rewriter.
+func (f *File) rewriteUSR(usr string) string {
+	if f.usrPathRewriter == nil || usr == "" {
+		return usr
+	}
+	return f.usrPathRewriter(usr)
+}
+
+// AddDefinition add definition data into File.
+func (f *File) AddDefinition(loc, def Location) error {
+	if err := f.checkFrozen("definition"); err != nil {
+		return err
+	}
+	f.addSymbol(loc.usr, loc.kind, loc, def)
+
+	return nil
+}
+
+// notExistHeaderName return the not exist header name magic words.
+func notExistHeaderName(headPath string) string {
+	// adding magic to filename to not confuse it with real files
+	return "IDoNotReallyExist-" + filepath.Base(headPath)
+}
+
+// SetCanonicalHeaderPath sets whether the AddHeader canonicalizes the header path to
+// the absolute and symbolic links resolved path before computing the FileID.
+// It is useful to collapse the same header which referenced by both absolute and relative paths.
+func (f *File) SetCanonicalHeaderPath(canonical bool) {
+	f.canonicalHeaderPath = canonical
+}
+
+// AddHeader add header data into File.
+func (f *File) AddHeader(includePath string, headerFile clang.File) error {
+	if err := f.checkFrozen("header"); err != nil {
+		return err
+	}
+	var mtime time.Time
+	name := headerFile.Name()
+	if name != "" {
+		mtime = headerFile.Time()
+	}
+	f.addHeader(name, mtime)
+
+	return nil
+}
+
+// addHeader adds the header which named name into File.
+// The header which has same FileID as the already added header is ignored.
+func (f *File) addHeader(name string, mtime time.Time) {
+	hdr := &Header{path: name}
+	if name == "" {
+		hdr.fileid = ToFileID(notExistHeaderName(filepath.Clean(name)))
+		hdr.mtime = time.Now()
+	} else {
+		path := filepath.Clean(name)
+		if f.canonicalHeaderPath {
+			path = pathutil.Canonical(path)
+		}
+		hdr.fileid = ToFileID(path)
+		hdr.mtime = mtime
+		hdr.exists = true
+	}
+
+	for _, h := range f.headers {
+		if h.fileid == hdr.fileid {
+			return
+		}
+	}
+
+	f.headers = append(f.headers, hdr)
+}
+
+// AddInclude add the #include directive data into File.
+// The spelling is the header name as written in the directive, such as "foo.h", and loc is the location of
+// the directive. The includedFile is the file which the directive includes, which is empty if not found.
+func (f *File) AddInclude(spelling string, loc Location, includedFile clang.File) error {
+	if err := f.checkFrozen("include"); err != nil {
+		return err
+	}
+	f.addInclude(spelling, loc, includedFile.Name())
+
+	return nil
+}
+
+// addInclude adds the #include directive of loc which includes the file named name into File.
+// The directive which has same location as the already added directive is ignored.
+func (f *File) addInclude(spelling string, loc Location, name string) {
+	inc := &Include{spelling: spelling, location: loc.value()}
+	inc.location.kind = 0
+	if name != "" {
+		path := filepath.Clean(name)
+		if f.canonicalHeaderPath {
+			path = pathutil.Canonical(path)
+		}
+		inc.fileid = ToFileID(path)
+	}
+
+	for _, i := range f.includes {
+		if sameLocation(i.location, inc.location) {
+			return
+		}
+	}
+
+	f.includes = append(f.includes, inc)
+}
+
+// AddDiagnostic add the diagnostic of the clang parse into File, such as the diagnostic of FromDiagnostic.
+func (f *File) AddDiagnostic(d *Diagnostic) error {
+	if err := f.checkFrozen("diagnostic"); err != nil {
+		return err
+	}
+	if d != nil {
+		f.diagnostics = append(f.diagnostics, d)
+	}
+
+	return nil
+}
+
+// Diagnostics return the diagnostics of the clang parse of the File, in the order of AddDiagnostic.
+// Like Headers, the decoded diagnostics are kept alive together.
+func (f *File) Diagnostics() []*Diagnostic {
+	if len(f.diagnostics) > 0 || f.file == nil {
+		return f.diagnostics
+	}
+
+	n := safeLength(f.file.Table(), fileDiagnosticsSlot)
+	diags := make([]*Diagnostic, n)
+	slots := make([]struct {
+		Diagnostic
+		obj symbol.Diagnostic
+	}, n)
+
+	for i := 0; i < n; i++ {
+		s := &slots[i]
+		if f.file.Diagnostics(&s.obj, i) {
+			s.diagnostic = &s.obj
+			diags[i] = &s.Diagnostic
+		}
+	}
+
+	return diags
+}
+
+// Includes return the #include directives of the File, in the order of the indexing.
+// Unlike Headers, which are the dependencies of the File, they are the locations of the directives.
+func (f *File) Includes() []*Include {
+	if len(f.includes) > 0 || f.file == nil {
+		return f.includes
+	}
+
+	n := safeLength(f.file.Table(), fileIncludesSlot)
+	includes := make([]*Include, n)
+	slots := make([]struct {
+		Include
+		obj symbol.Include
+	}, n)
+
+	for i := 0; i < n; i++ {
+		s := &slots[i]
+		if f.file.Includes(&s.obj, i) {
+			s.include = &s.obj
+			includes[i] = &s.Include
+		}
+	}
+
+	return includes
+}
+
+// AddCaller add caller data into File.
+//
+// The sym is the location of call-site, and def is the location of the callee definition.
+// If def exists, the callee symbol is keyed by the def USR and def is recorded as its definition,
+// so the symbol is resolvable even when the File only calls it.
+//
+// If SetRequireKnownSymbolForCaller is enabled, the error of the ErrUnknownSymbol cause is returned
+// for the callee which is not added yet, instead of creating it.
+func (f *File) AddCaller(sym, def Location, funcCall bool) error {
+	return f.addCaller(sym, def, funcCall, nil)
+}
+
+// AddCallArguments is like AddCaller of the function call, but also records args, which are the start locations
+// of the arguments of the call in order, such as for the inlay hints of the parameter names.
+func (f *File) AddCallArguments(sym, def Location, args []Location) error {
+	return f.addCaller(sym, def, true, args)
+}
+
+// addCaller adds the caller of sym which has args into File, see AddCaller.
+func (f *File) addCaller(sym, def Location, funcCall bool, args []Location) error {
+	if err := f.checkFrozen("caller"); err != nil {
+		return err
+	}
+	usr := sym.usr
+	if !def.IsZero() && def.usr != "" {
+		usr = def.usr
+	}
+	if f.requireKnownSymbol {
+		f.hydrate()
+		if _, ok := f.symbols[ToID(f.rewriteUSR(usr))]; !ok {
+			return errors.Wrapf(ErrUnknownSymbol, "caller at %s:%d:%d: %q", sym.FileName(), sym.Line(), sym.Col(), usr)
+		}
+	}
+
+	info := f.addSymbol(usr, def.kind, Location{}, def)
+	if info == nil {
+		return nil
+	}
+	sym.usr = f.rewriteUSR(sym.usr)
+	sym.kind = 0
+	caller := f.arena.newCaller()
+	caller.location, caller.funcCall = sym, funcCall
+	for _, arg := range args {
+		arg = arg.value()
+		arg.usr, arg.kind = "", 0
+		caller.args = append(caller.args, arg)
+	}
+	info.callers = append(info.callers, caller)
+
+	f.locations[sym] = info.id
+
+	return nil
+}
+
+// ErrFrozen is the cause of the error which the Add methods return for the File frozen by Freeze.
+var ErrFrozen = errors.New("file is frozen")
+
+// Freeze ends the collecting phase of f and snapshots the symbols into the serialization order, so the
+// serialization can be done at the barrier of the pipeline after the parsing.
+//
+// The Add methods of the frozen File return the error of the ErrFrozen cause instead of recording. The symbols
+// are sorted by ID and the callers by the location as same as SetReproducible, and Serialize and SerializeChecked
+// use the new builder for each call, so the frozen File is always serialized to the same bytes.
+// It is safe to call Freeze more than once.
+func (f *File) Freeze() {
+	if f.frozen {
+		return
+	}
+	reproducible := f.reproducible
+	f.reproducible = true
+	f.frozenSymbols = f.serializedSymbols()
+	f.reproducible = reproducible
+	f.frozen = true
+}
+
+// Frozen reports whether f is frozen by Freeze.
+func (f *File) Frozen() bool {
+	return f.frozen
+}
+
+// checkFrozen returns the error of the ErrFrozen cause if f is frozen, which names what was added.
+func (f *File) checkFrozen(what string) error {
+	if !f.frozen {
+		return nil
+	}
+	return errors.Wrapf(ErrFrozen, "could not add the %s to %s", what, f.Name())
+}
+
+// ErrUnknownSymbol is the cause of the error which AddCaller returns for the unknown callee,
+// see SetRequireKnownSymbolForCaller.
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
+// SetRequireKnownSymbolForCaller sets whether AddCaller requires the callee symbol was added by the declaration
+// or definition before. By default, AddCaller creates the callee symbol which has only the callers, and Validate
+// reports it later.
+func (f *File) SetRequireKnownSymbolForCaller(require bool) {
+	f.requireKnownSymbol = require
+}
+
+// Validate reports whether the symbols of f are consistent.
+//
+// The symbol which has only callers is suspicious, because it cannot be resolved to
+// any declaration. It is treated as valid if the callee definition was provided.
+func (f *File) Validate() error {
+	for _, sym := range f.Symbols() {
+		if len(sym.Callers()) == 0 || len(sym.Decls()) > 0 {
+			continue
+		}
+		if def := sym.Def(); def.IsZero() {
+			return errors.Errorf("symbol %s: has callers but neither declaration nor definition", sym.ID())
+		}
+	}
+
+	return nil
+}
+
+// Equal reports whether f and o have the same semantic content.
+// The symbols are compared by ID regardless of the serialized order.
+func (f *File) Equal(o *File) bool {
+	if f.Name() != o.Name() || !stringsEqual(f.Flags(), o.Flags()) || f.Language() != o.Language() || !bytes.Equal(f.TranslationUnit(), o.TranslationUnit()) || f.TranslationUnitOmitted() != o.TranslationUnitOmitted() || f.IsUnsaved() != o.IsUnsaved() {
+		return false
+	}
+	meta, ometa := f.metadata(), o.metadata()
+	if len(meta) != len(ometa) {
+		return false
+	}
+	for key, value := range meta {
+		if ovalue, ok := ometa[key]; !ok || value != ovalue {
+			return false
+		}
+	}
+
+	syms, osyms := f.Symbols(), o.Symbols()
+	if len(syms) != len(osyms) {
+		return false
+	}
+	byID := make(map[ID]*Info, len(osyms))
+	for _, sym := range osyms {
+		byID[sym.ID()] = sym
+	}
+	for _, sym := range syms {
+		osym, ok := byID[sym.ID()]
+		if !ok || !sym.equal(osym) {
+			return false
+		}
+	}
+
+	hdrs, ohdrs := f.Headers(), o.Headers()
+	if len(hdrs) != len(ohdrs) {
+		return false
+	}
+	for i, hdr := range hdrs {
+		if hdr.FileID() != ohdrs[i].FileID() || hdr.Mtime() != ohdrs[i].Mtime() || hdr.Path() != ohdrs[i].Path() || hdr.Exists() != ohdrs[i].Exists() {
+			return false
+		}
+	}
+
+	incs, oincs := f.Includes(), o.Includes()
+	if len(incs) != len(oincs) {
+		return false
+	}
+	for i, inc := range incs {
+		if !inc.equal(oincs[i]) {
+			return false
+		}
+	}
+
+	diags, odiags := f.Diagnostics(), o.Diagnostics()
+	if len(diags) != len(odiags) {
+		return false
+	}
+	for i, d := range diags {
+		if !d.equal(odiags[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stringsEqual reports whether a and b are the same length and contain the same strings.
+// A nil argument is equivalent to an empty slice.
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Unmarshal parses the flatbuffers representation in f.
+func (f *File) Unmarshal() {
+	f.unmarshalFields()
+	f.lazy = false
+	f.symbols = nil
+	f.Reindex()
+}
+
+// UnmarshalLazy is like Unmarshal, but the symbols are copied out of the flatbuffers representation on demand.
+//
+// Symbol hydrates only the looked up symbol, so looking up a few symbols of the large File is cheap.
+// The bulk operations, such as Symbols, Equal, Serialize and the mutations, hydrate all symbols
+// transparently, so the results are identical to Unmarshal.
+func (f *File) UnmarshalLazy() {
+	f.unmarshalFields()
+	f.lazy = true
+	f.symbols = make(map[ID]*Info)
+	f.locations = make(map[Location]ID)
+}
+
+// unmarshalFields parses the flatbuffers representation in f except the symbols.
+func (f *File) unmarshalFields() {
+	f.lineIndex = nil
+	f.name = string(f.file.Name())
+	f.flags = f.Flags()
+	f.language = f.Language()
+	f.translationUnit = f.file.TranslationUnit()
+	f.tuOmitted = f.TranslationUnitOmitted()
+	f.unsaved = f.IsUnsaved()
+	f.meta = f.metadata()
+	headers := f.Headers()
+	f.headers = make([]*Header, 0, len(headers))
+	for _, hdr := range headers {
+		f.headers = append(f.headers, hdr)
+	}
+	includes := f.Includes()
+	f.includes = make([]*Include, 0, len(includes))
+	for _, inc := range includes {
+		f.includes = append(f.includes, inc)
+	}
+	diags := f.Diagnostics()
+	f.diagnostics = make([]*Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		f.diagnostics = append(f.diagnostics, d)
+	}
+}
+
+// hydrate copies all symbols out of the flatbuffers representation if f is lazily unmarshaled.
+// The symbols which already hydrated by Symbol are kept, so the returned pointers remain valid.
+// It is safe for concurrent use, and the symbols are hydrated only once.
+func (f *File) hydrate() {
+	f.lazyMu.RLock()
+	lazy := f.lazy
+	f.lazyMu.RUnlock()
+	if !lazy {
+		return
+	}
+
+	f.lazyMu.Lock()
+	defer f.lazyMu.Unlock()
+	if !f.lazy {
+		return
+	}
+	f.lazy = false
+
+	loaded := f.symbols
+	f.symbols = make(map[ID]*Info, safeLength(f.file.Table(), fileSymbolsSlot))
+	f.locations = make(map[Location]ID)
+	for _, s := range f.fileSymbols() {
+		f.indexSymbol(s)
+	}
+	for id, info := range loaded {
+		f.symbols[id] = info
+	}
+}
+
+// Symbol returns the symbol of id, or nil if f has no such symbol.
+//
+// The symbols of the flatbuffers representation are looked up by the binary search, since the reproducible
+// File sorts them by ID. The unsorted symbols fall back to the linear scan.
+func (f *File) Symbol(id ID) *Info {
+	f.lazyMu.RLock()
+	info, ok := f.symbols[id]
+	lazy := f.lazy
+	f.lazyMu.RUnlock()
+	if ok {
+		return info
+	}
+	if f.file == nil || (!lazy && len(f.symbols) > 0) {
+		return nil
+	}
+
+	obj := f.lookupSymbol(id)
+	if obj == nil {
+		return nil
+	}
+	info = &Info{info: obj}
+	if !lazy {
+		return info
+	}
+
+	f.lazyMu.Lock()
+	defer f.lazyMu.Unlock()
+	// the symbol may be hydrated by the other goroutine meanwhile
+	if sym, ok := f.symbols[id]; ok {
+		return sym
+	}
+	info = info.detach()
+	f.symbols[id] = info
+	for _, decl := range info.decls {
+		f.locations[decl] = id
+	}
+	for _, caller := range info.callers {
+		f.locations[caller.location] = id
+	}
+
+	return info
+}
+
+// lookupSymbol returns the flatbuffers table of the symbol of id, or nil if not found.
+func (f *File) lookupSymbol(id ID) *symbol.Info {
+	var buf [2 * hashutil.Size]byte
+	key := buf[:hashutil.Encode(buf[:], id[:])]
+
+	n := safeLength(f.file.Table(), fileSymbolsSlot)
+	obj := new(symbol.Info)
+	i := sort.Search(n, func(i int) bool {
+		return f.file.Symbols(obj, i) && bytes.Compare(obj.ID(), key) >= 0
+	})
+	if i < n && f.file.Symbols(obj, i) && bytes.Equal(obj.ID(), key) {
+		return obj
+	}
+
+	for i := 0; i < n; i++ {
+		if f.file.Symbols(obj, i) && bytes.Equal(obj.ID(), key) {
+			return obj
+		}
+	}
+
+	return nil
+}
+
+// Reindex rebuilds the in-memory symbols and locations of f from the current representation, which is
+// the in-memory symbols if any, otherwise the flatbuffers binary.
+// It makes the lookups consistent after the symbols were mutated manually. Reindex is idempotent.
+func (f *File) Reindex() {
+	f.hydrate()
+	f.lineIndex = nil
+	syms := f.Symbols()
+	f.locations = make(map[Location]ID)
+	f.symbols = make(map[ID]*Info, len(syms))
+	for _, s := range syms {
+		f.indexSymbol(s)
+	}
+}
+
+// indexSymbol adds s to the in-memory symbols and locations of f. The flatbuffers backed s is detached,
+// and merged into the existing symbol of the same ID if any.
+func (f *File) indexSymbol(s *Info) {
+	info := s
+	if s.info != nil {
+		info = s.detach()
+	}
+	if sym, ok := f.symbols[info.id]; ok {
+		sym.merge(info)
+		info = sym
+	} else {
+		f.symbols[info.id] = info
+	}
+	for _, decl := range info.decls {
+		f.locations[decl] = info.id
+	}
+	for _, caller := range info.callers {
+		f.locations[caller.location] = info.id
+	}
+}
+
+// Serialize serializes the File.
+// It panics if the File exceeds the flatbuffers size limit, use SerializeChecked or SerializeSharded
+// for the File which may be that large.
+func (f *File) Serialize() *flatbuffers.Builder {
+	if f.builder == nil || f.frozen {
+		f.builder = flatbuffers.NewBuilder(0)
+	}
+	f.serialize(f.builder)
+
+	return f.builder
+}
+
+// SerializeChecked is like Serialize, but returns the error of the ErrTooLarge cause instead of panicking
+// if the File exceeds the flatbuffers size limit. The error names the offending component,
+// such as the translation unit or the symbols.
+func (f *File) SerializeChecked() (*flatbuffers.Builder, error) {
+	start := time.Now()
+	if f.builder == nil || f.frozen {
+		f.builder = flatbuffers.NewBuilder(0)
+	}
+	if err := f.serializeChecked(f.builder, f.serializedSymbols(), true, true, maxSerializedSize); err != nil {
+		return nil, err
+	}
+	observeSerialize(f.builder, start)
+
+	return f.builder, nil
+}
+
+// SerializeHeaderIndex returns the compact declaration-only serialization of f, such as the index of the header.
+// It omits the TranslationUnit and the callers of the symbols, so the File read from it has the symbols without
+// the callers, and reports TranslationUnitOmitted. The other fields are serialized as same as Serialize.
+func (f *File) SerializeHeaderIndex() ([]byte, error) {
+	start := time.Now()
+	symbols := f.serializedSymbols()
+	trimmed := make([]*Info, len(symbols))
+	for i, info := range symbols {
+		sym := *info
+		sym.callers = nil
+		trimmed[i] = &sym
+	}
+
+	builder := flatbuffers.NewBuilder(0)
+	if err := f.serializeChecked(builder, trimmed, true, false, maxSerializedSize); err != nil {
+		return nil, err
+	}
+	observeSerialize(builder, start)
+
+	return builder.FinishedBytes(), nil
+}
+
+// serialize serializes the File into builder, and finishes the builder.
+// It panics with the error of the ErrTooLarge cause if the File exceeds the flatbuffers size limit,
+// see SerializeChecked.
+func (f *File) serialize(builder *flatbuffers.Builder) {
+	start := time.Now()
+	if err := f.serializeChecked(builder, f.serializedSymbols(), true, true, maxSerializedSize); err != nil {
+		panic(err)
+	}
+	observeSerialize(builder, start)
+}
+
+// observeSerialize records the size and duration of the File serialization which finished builder since start
+// into the package Metrics.
+func observeSerialize(builder *flatbuffers.Builder, start time.Time) {
+	m := metricsOf(nil)
+	observeSince(m, MetricSerializeSeconds, start)
+	m.ObserveHistogram(MetricSerializeBytes, float64(len(builder.FinishedBytes())))
+}
+
+// serializedSymbols returns the symbols of f in the serialization order.
+func (f *File) serializedSymbols() []*Info {
+	if f.frozen {
+		return f.frozenSymbols
+	}
+	f.hydrate()
+	symbols := make([]*Info, 0, len(f.symbols))
+	for _, info := range f.symbols {
+		symbols = append(symbols, info)
+	}
+	if f.reproducible {
+		sort.Slice(symbols, func(i, j int) bool {
+			return bytes.Compare(symbols[i].id[:], symbols[j].id[:]) < 0
+		})
+		for _, info := range symbols {
+			info.sortCallers()
+		}
+	}
+
+	return symbols
+}
+
+// serializeChecked serializes the File which has symbols into builder, and finishes the builder.
+// If full is false, the flags, translation unit, headers and includes are omitted, as the second and later shards.
+// If withTU is false, only the translation unit is omitted and the File is marked as TUOmitted.
+//
+// It returns the error of the ErrTooLarge cause which names the offending component if the builder exceeds
+// limit bytes, or flatbuffers cannot grow the builder any further.
+func (f *File) serializeChecked(builder *flatbuffers.Builder, symbols []*Info, full, withTU bool, limit int) (err error) {
+	component := "name"
+	defer func() {
+		if r := recover(); r != nil {
+			if r != flatbuffersGrowPanic {
+				panic(r)
+			}
+			err = errors.Wrapf(ErrTooLarge, "%s: cannot grow the builder of %d bytes", component, len(builder.Bytes))
+		}
+	}()
+	check := func() error {
+		if n := int(builder.Offset()); n > limit {
+			return errors.Wrapf(ErrTooLarge, "%s: %d bytes exceeds the limit of %d bytes", component, n, limit)
+		}
+		return nil
+	}
+
+	buf := new(serializeBuffer)
+
+	var fname flatbuffers.UOffsetT
+	if f.name == "" && f.file != nil {
+		fname = builder.CreateByteString(f.file.Name())
+	} else {
+		fname = builder.CreateString(f.name)
+	}
+
+	var tu, flagVecOffset flatbuffers.UOffsetT
+	if full {
+		if withTU {
+			component = "translation unit"
+			tu = builder.CreateByteString(f.TranslationUnit())
+			if err := check(); err != nil {
+				return err
+			}
+		}
+
+		component = "flags"
+		flagVecOffset = f.serializeFlags(builder, buf)
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	component = "symbols"
+	symbolNum := len(symbols)
+	symbolOffsets := make([]flatbuffers.UOffsetT, 0, symbolNum)
+	for _, info := range symbols {
+		symbolOffsets = append(symbolOffsets, info.serialize(builder, f.packedLocations, buf))
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	symbol.FileStartSymbolsVector(builder, symbolNum)
+	for i := symbolNum - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(symbolOffsets[i])
+	}
+	symbolVecOffset := builder.EndVector(symbolNum)
+
+	var headerVecOffset, includeVecOffset, metaVecOffset, diagVecOffset flatbuffers.UOffsetT
+	if full {
+		component = "headers"
+		hdrs := f.headers
+		hdrNum := len(hdrs)
+		hdrOffsets := make([]flatbuffers.UOffsetT, 0, hdrNum)
+		for _, hdr := range hdrs {
+			hdrOffsets = append(hdrOffsets, hdr.serialize(builder))
+		}
+		symbol.FileStartHeadersVector(builder, hdrNum)
+		for i := hdrNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(hdrOffsets[i])
+		}
+		headerVecOffset = builder.EndVector(hdrNum)
+
+		component = "includes"
+		includeVecOffset = f.serializeIncludes(builder, buf)
+
+		component = "metadata"
+		metaVecOffset = f.serializeMetadata(builder, buf)
+
+		component = "diagnostics"
+		diagVecOffset = f.serializeDiagnostics(builder, buf)
+	}
+
+	component = "file"
+	symbol.FileStart(builder)
+	symbol.FileAddName(builder, fname)
+	if full {
+		symbol.FileAddFlags(builder, flagVecOffset)
+		symbol.FileAddTranslationUnit(builder, tu)
+	}
+	symbol.FileAddSymbols(builder, symbolVecOffset)
+	if full {
+		symbol.FileAddHeaders(builder, headerVecOffset)
+		symbol.FileAddIncludes(builder, includeVecOffset)
+		symbol.FileAddTUOmitted(builder, boolToByte(f.tuOmitted || !withTU))
+		symbol.FileAddMetadata(builder, metaVecOffset)
+		symbol.FileAddLanguage(builder, byte(f.Language()))
+		symbol.FileAddUnsaved(builder, boolToByte(f.unsaved))
+		symbol.FileAddDiagnostics(builder, diagVecOffset)
+	}
+
+	builder.Finish(symbol.FileEnd(builder))
+
+	return check()
+}
+
+// serializeDiagnostics serializes the diagnostics of f.
+// It returns zero offset if f has no diagnostics, so the field is omitted.
+func (f *File) serializeDiagnostics(builder *flatbuffers.Builder, buf *serializeBuffer) flatbuffers.UOffsetT {
+	diags := f.Diagnostics()
+	if len(diags) == 0 {
+		return 0
+	}
+
+	offsets := buf.offsetsOf(len(diags))
+	for _, d := range diags {
+		offsets = append(offsets, d.serialize(builder))
+	}
+	symbol.FileStartDiagnosticsVector(builder, len(offsets))
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+
+	return builder.EndVector(len(offsets))
+}
+
+// serializeIncludes serializes the #include directives of f.
+// It returns zero offset if f has no directives, so the field is omitted.
+func (f *File) serializeIncludes(builder *flatbuffers.Builder, buf *serializeBuffer) flatbuffers.UOffsetT {
+	incs := f.Includes()
+	if len(incs) == 0 {
+		return 0
+	}
+
+	offsets := buf.offsetsOf(len(incs))
+	for _, inc := range incs {
+		offsets = append(offsets, inc.serialize(builder))
+	}
+	symbol.FileStartIncludesVector(builder, len(offsets))
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+
+	return builder.EndVector(len(offsets))
+}
+
+// serializeFlags serializes the flags of f. The flags of the flatbuffers backed f are copied as is,
+// without converting to the strings.
+func (f *File) serializeFlags(builder *flatbuffers.Builder, buf *serializeBuffer) flatbuffers.UOffsetT {
+	var flagOffsets []flatbuffers.UOffsetT
+	if len(f.flags) == 0 && f.file != nil {
+		n := safeLength(f.file.Table(), fileFlagsSlot)
+		flagOffsets = buf.offsetsOf(n)
+		for i := 0; i < n; i++ {
+			flagOffsets = append(flagOffsets, builder.CreateByteString(f.file.Flags(i)))
+		}
+	} else {
+		flagOffsets = buf.offsetsOf(len(f.flags))
+		for _, flag := range f.flags {
+			flagOffsets = append(flagOffsets, builder.CreateString(flag))
+		}
+	}
+
+	flagNum := len(flagOffsets)
+	symbol.FileStartFlagsVector(builder, flagNum)
+	for i := flagNum - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(flagOffsets[i])
+	}
+
+	return builder.EndVector(flagNum)
+}
+
+// serializeStrings serializes strs to the vector which started by startVector.
+// It returns zero offset if strs is empty, so the field is omitted.
+func serializeStrings(builder *flatbuffers.Builder, strs []string, startVector func(*flatbuffers.Builder, int) flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	n := len(strs)
+	if n == 0 {
+		return 0
+	}
+
+	offsets := make([]flatbuffers.UOffsetT, n)
+	for i, s := range strs {
+		offsets[i] = builder.CreateString(s)
+	}
+	startVector(builder, n)
+	for i := n - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+
+	return builder.EndVector(n)
+}
+
+// ----------------------------------------------------------------------------
+
+// Info represents a location of C/C++ cursor symbol information.
+//
+//  table Info {
+//    ID: string;
+//    Decls: [Location];
+//    Def: Location;
+//    Callers: [Caller];
+//    Name: string;
+//    Type: string;
+//    ResultType: string;
+//    Params: [Param];
+//    Variadic: bool;
+//    Namespace: string;
+//    Bases: [string];
+//    IsVirtual: bool;
+//    Overrides: [string];
+//    PackedLocations: [ubyte];
+//    PackedStrings: [string];
+//    DefMtime: long;
+//    CommentRange: Range;
+//    Kind: uint;
+//    ParentUSR: string;
+//    ParamIndex: uint;
+//    ObjCSelector: string;
+//  }
+type Info struct {
+	id           ID
+	kind         SymbolKind
+	decls        []Location
+	def          Location
+	defMtime     time.Time
+	callers      []*Caller
+	commentRange Range
+
+	name       string
+	typ        string
+	resultType string
+	params     []*Param
+	variadic   bool
+	namespace  string
+	bases      []string
+	isVirtual  bool
+	overrides  []string
+	parentUSR  string
+	paramIndex uint32
+	selector   string
+
+	info *symbol.Info
+}
+
+// SymbolInfo type alias of symbol.Info.
+type SymbolInfo = symbol.Info
+
+// serialize serializes the Info.
+// If packed is true, the declarations, definition and callers are serialized to the PackedLocations.
+// The scratch buffers of buf are reused for the vectors.
+func (info *Info) serialize(builder *flatbuffers.Builder, packed bool, buf *serializeBuffer) flatbuffers.UOffsetT {
+	id := buf.createHash(builder, info.id)
+
+	var packedOffset, packedStringsOffset flatbuffers.UOffsetT
+	if packed {
+		packedBuf, strs := packLocations(info.decls, info.def, info.callers)
+		packedOffset, packedStringsOffset = serializePackedLocations(builder, packedBuf, strs)
+	}
+
+	declsNum := len(info.decls)
+	var declVecOffset flatbuffers.UOffsetT
+	if declsNum > 0 && !packed {
+		declsOffsets := buf.offsetsOf(declsNum)
+		for _, decl := range info.decls {
+			declsOffsets = append(declsOffsets, decl.serialize(builder))
+		}
+		symbol.InfoStartDeclsVector(builder, declsNum)
+		for i := declsNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(declsOffsets[i])
+		}
+		declVecOffset = builder.EndVector(declsNum)
+	}
+
+	var defOffset flatbuffers.UOffsetT
+	if !info.def.IsZero() && !packed {
+		defOffset = info.def.serialize(builder)
+	}
+
+	callersNum := len(info.callers)
+	var callerVecOffset flatbuffers.UOffsetT
+	if callersNum > 0 && !packed {
+		callersOffsets := buf.offsetsOf(callersNum)
+		for _, caller := range info.callers {
+			callersOffsets = append(callersOffsets, caller.serialize(builder))
+		}
+		symbol.InfoStartCallersVector(builder, callersNum)
+		for i := callersNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(callersOffsets[i])
+		}
+		callerVecOffset = builder.EndVector(callersNum)
+	}
+
+	var nameOffset, typOffset, resultTypeOffset, namespaceOffset, parentUSROffset, selectorOffset flatbuffers.UOffsetT
+	if info.name != "" {
+		nameOffset = builder.CreateString(info.name)
+	}
+	if info.typ != "" {
+		typOffset = builder.CreateString(info.typ)
+	}
+	if info.resultType != "" {
+		resultTypeOffset = builder.CreateString(info.resultType)
+	}
+	if info.namespace != "" {
+		namespaceOffset = builder.CreateString(info.namespace)
+	}
+	if info.parentUSR != "" {
+		parentUSROffset = builder.CreateString(info.parentUSR)
+	}
+	if info.selector != "" {
+		selectorOffset = builder.CreateString(info.selector)
+	}
+
+	paramsNum := len(info.params)
+	var paramVecOffset flatbuffers.UOffsetT
+	if paramsNum > 0 {
+		paramsOffsets := buf.offsetsOf(paramsNum)
+		for _, param := range info.params {
+			paramsOffsets = append(paramsOffsets, param.serialize(builder))
+		}
+		symbol.InfoStartParamsVector(builder, paramsNum)
+		for i := paramsNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(paramsOffsets[i])
+		}
+		paramVecOffset = builder.EndVector(paramsNum)
+	}
+
+	var commentRangeOffset flatbuffers.UOffsetT
+	if !info.commentRange.IsEmpty() {
+		commentRangeOffset = info.commentRange.serialize(builder)
+	}
+
+	basesVecOffset := serializeStrings(builder, info.bases, symbol.InfoStartBasesVector)
+	overridesVecOffset := serializeStrings(builder, info.overrides, symbol.InfoStartOverridesVector)
+
+	symbol.InfoStart(builder)
+	symbol.InfoAddID(builder, id)
+	symbol.InfoAddDecls(builder, declVecOffset)
+	symbol.InfoAddDef(builder, defOffset)
+	symbol.InfoAddCallers(builder, callerVecOffset)
+	symbol.InfoAddName(builder, nameOffset)
+	symbol.InfoAddType(builder, typOffset)
+	symbol.InfoAddResultType(builder, resultTypeOffset)
+	symbol.InfoAddParams(builder, paramVecOffset)
+	symbol.InfoAddVariadic(builder, boolToByte(info.variadic))
+	symbol.InfoAddNamespace(builder, namespaceOffset)
+	symbol.InfoAddBases(builder, basesVecOffset)
+	symbol.InfoAddIsVirtual(builder, boolToByte(info.isVirtual))
+	symbol.InfoAddOverrides(builder, overridesVecOffset)
+	symbol.InfoAddPackedLocations(builder, packedOffset)
+	symbol.InfoAddPackedStrings(builder, packedStringsOffset)
+	if !info.defMtime.IsZero() {
+		symbol.InfoAddDefMtime(builder, info.defMtime.Unix())
+	}
+	symbol.InfoAddCommentRange(builder, commentRangeOffset)
+	symbol.InfoAddKind(builder, uint32(info.kind))
+	symbol.InfoAddParentUSR(builder, parentUSROffset)
+	symbol.InfoAddParamIndex(builder, info.paramIndex)
+	symbol.InfoAddObjCSelector(builder, selectorOffset)
+
+	return symbol.InfoEnd(builder)
+}
+
+// detach returns the in-memory copy of the flatbuffers backed info.
+func (info *Info) detach() *Info {
+	d := &Info{
+		id:           info.ID(),
+		kind:         info.SymbolKind(),
+		def:          info.Def().value(),
+		defMtime:     info.DefModTime(),
+		commentRange: info.CommentRange().value(),
+		name:         info.Name(),
+		typ:          info.Type(),
+		resultType:   info.ResultType(),
+		variadic:     info.Variadic(),
+		namespace:    info.Namespace(),
+		bases:        info.Bases(),
+		isVirtual:    info.IsVirtual(),
+		overrides:    info.Overrides(),
+		parentUSR:    info.ParentUSR(),
+		paramIndex:   info.ParamIndex(),
+		selector:     info.ObjCSelector(),
+	}
+	for _, param := range info.Params() {
+		d.params = append(d.params, &Param{
+			name: param.Name(),
+			typ:  param.Type(),
+		})
+	}
+	for _, decl := range info.Decls() {
+		d.decls = append(d.decls, decl.value())
+	}
+	for _, caller := range info.Callers() {
+		c := &Caller{
+			location: caller.Location().value(),
+			funcCall: caller.FuncCall(),
+		}
+		for _, arg := range caller.Args() {
+			c.args = append(c.args, arg.value())
+		}
+		d.callers = append(d.callers, c)
+	}
+
+	return d
+}
+
+// merge merges the declarations, definition and callers of the in-memory o which has the same ID into info.
+func (info *Info) merge(o *Info) {
+	info.decls = append(info.decls, o.decls...)
+	info.callers = append(info.callers, o.callers...)
+	if info.def.IsZero() {
+		info.def = o.def
+		info.defMtime = o.defMtime
+	}
+	if info.kind == SymbolKindUnknown {
+		info.kind = o.kind
+	}
+}
+
+// equal reports whether info and o have the same declarations, definition, callers and type information.
+func (info *Info) equal(o *Info) bool {
+	if !info.equalType(o) {
+		return false
+	}
+
+	decls, odecls := info.Decls(), o.Decls()
+	if len(decls) != len(odecls) {
+		return false
+	}
+	for i := range decls {
+		if decls[i].value() != odecls[i].value() {
+			return false
+		}
+	}
+
+	if info.Def().value() != o.Def().value() || info.DefModTime().Unix() != o.DefModTime().Unix() {
+		return false
+	}
+	if info.CommentRange().value() != o.CommentRange().value() {
+		return false
+	}
+
+	callers, ocallers := info.Callers(), o.Callers()
+	if len(callers) != len(ocallers) {
+		return false
+	}
+	for i := range callers {
+		if callers[i].Location().value() != ocallers[i].Location().value() || callers[i].FuncCall() != ocallers[i].FuncCall() {
+			return false
+		}
+		args, oargs := callers[i].Args(), ocallers[i].Args()
+		if len(args) != len(oargs) {
+			return false
+		}
+		for j := range args {
+			if args[j].value() != oargs[j].value() {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// equalType reports whether info and o have the same kind and type information, regardless of the locations.
+func (info *Info) equalType(o *Info) bool {
+	if info.SymbolKind() != o.SymbolKind() || info.Name() != o.Name() || info.Type() != o.Type() || info.ResultType() != o.ResultType() || info.Variadic() != o.Variadic() || info.Namespace() != o.Namespace() || info.ParentUSR() != o.ParentUSR() || info.ParamIndex() != o.ParamIndex() || info.ObjCSelector() != o.ObjCSelector() {
+		return false
+	}
+	if !stringsEqual(info.Bases(), o.Bases()) || info.IsVirtual() != o.IsVirtual() || !stringsEqual(info.Overrides(), o.Overrides()) {
+		return false
+	}
+	params, oparams := info.Params(), o.Params()
+	if len(params) != len(oparams) {
+		return false
+	}
+	for i := range params {
+		if params[i].Name() != oparams[i].Name() || params[i].Type() != oparams[i].Type() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ID return the symbol ID which hashed blake2b.
+func (info *Info) ID() ID {
+	if info.info == nil {
+		return info.id
+	}
+	return ID(decodeHash(info.info.ID()))
+}
+
+// Kind return the cursor kind of the symbol declaration, which converted from the SymbolKind.
+// It is zero if the symbol is added without the kind, such as the Location which not created by FromCursor.
+func (info *Info) Kind() clang.CursorKind {
+	return info.SymbolKind().CursorKind()
+}
+
+// SymbolKind return the kind of the symbol declaration, which persisted in the index.
+// It is SymbolKindUnknown if the symbol is added without the kind or the kind is not the declaration.
+func (info *Info) SymbolKind() SymbolKind {
+	if info.info == nil {
+		return info.kind
+	}
+	return SymbolKind(info.info.Kind())
+}
+
+// Decls return the symbol declarations information.
+func (info *Info) Decls() []Location {
+	if info.info == nil {
+		return info.decls
+	}
+	if info.isPacked() {
+		return info.packedDecoder().decls()
+	}
+
+	n := safeLength(info.info.Table(), infoDeclsSlot)
+	decls := make([]Location, n)
+	objs := make([]symbol.Location, n)
+
+	for i := 0; i < n; i++ {
+		if info.info.Decls(&objs[i], i) {
+			decls[i] = Location{location: &objs[i]}
+		}
+	}
+
+	return decls
+}
+
+// ForwardDecls return the forward declarations of the symbol in Decls.
+func (info *Info) ForwardDecls() []Location {
+	var forwards []Location
+	for _, decl := range info.Decls() {
+		if decl.IsForward() {
+			forwards = append(forwards, decl)
+		}
+	}
+
+	return forwards
+}
+
+// Def return the symbol definition information.
+// The returned Location is empty if the symbol has no definition.
+func (info *Info) Def() Location {
+	if info.info == nil {
+		return info.def
+	}
+	if info.isPacked() {
+		d := info.packedDecoder()
+		d.decls()
+		return d.def()
+	}
+
+	obj := info.info.Def(nil)
+	if obj == nil {
+		return Location{}
+	}
+
+	return Location{location: obj}
+}
+
+// DefModTime return the modified time of the file which contains the symbol definition when it was indexed.
+// The returned time is zero if the symbol has no definition or the time is unknown.
+func (info *Info) DefModTime() time.Time {
+	if info.info == nil {
+		return info.defMtime
+	}
+	if mtime := info.info.DefMtime(); mtime != 0 {
+		return time.Unix(mtime, 0)
+	}
+	return time.Time{}
+}
+
+// CommentRange return the source range of the symbol documentation comment.
+// The returned Range is empty if the symbol has no documentation comment.
+func (info *Info) CommentRange() Range {
+	if info.info == nil {
+		return info.commentRange
+	}
+
+	obj := info.info.CommentRange(nil)
+	if obj == nil {
+		return Range{}
+	}
+
+	return Range{rng: obj}
+}
+
+// DefinitionFile return the FileID of the file which contains the symbol definition.
+// It reports false if the symbol has no definition.
+func (info *Info) DefinitionFile() (FileID, bool) {
+	def := info.Def()
+	if def.IsZero() {
+		return FileID{}, false
+	}
+	return def.FileID(), true
+}
+
+// Callers return the symbol callers information.
+//
+// The callers of the flatbuffers representation are allocated in one batch, so keeping any of them keeps the
+// whole batch alive. Use AcquireCallers for the short-lived iteration which keeps none of them.
+func (info *Info) Callers() []*Caller {
+	if info.info == nil {
+		return info.callers
+	}
+	if info.isPacked() {
+		d := info.packedDecoder()
+		d.decls()
+		d.def()
+		return d.callers()
+	}
+
+	n := safeLength(info.info.Table(), infoCallersSlot)
+	callers := make([]*Caller, n)
+	info.decodeCallers(callers, make([]callerSlot, n))
+
+	return callers
+}
+
+// callerSlot is the Caller wrapper which allocated together with its table.
+type callerSlot struct {
+	Caller
+	obj symbol.Caller
+}
+
+// decodeCallers decodes the callers vector of the flatbuffers representation in info into callers, using the
+// zeroed slots. Both callers and slots have the length of the callers vector.
+func (info *Info) decodeCallers(callers []*Caller, slots []callerSlot) {
+	for i := range callers {
+		s := &slots[i]
+		if info.info.Callers(&s.obj, i) {
+			s.caller = &s.obj
+			callers[i] = &s.Caller
+		}
+	}
+}
+
+// CallersSorted return the symbol callers information sorted by the caller location.
+// Unlike Callers, the order does not depend on the insertion order.
+func (info *Info) CallersSorted() []*Caller {
+	callers := append([]*Caller(nil), info.Callers()...)
+	sort.SliceStable(callers, func(i, j int) bool {
+		li := callers[i].Location()
+		return li.Less(callers[j].Location())
+	})
+
+	return callers
+}
+
+// sortCallers sorts the in-memory callers by the location stably.
+// The callers at the same location are ordered by the FuncCall, so the order is independent of the insertion order.
+func (info *Info) sortCallers() {
+	sort.Stable(callersByLocation(info.callers))
+}
+
+// callersByLocation implements sort.Interface for the in-memory callers, without the allocation of sort.SliceStable.
+type callersByLocation []*Caller
+
+func (c callersByLocation) Len() int      { return len(c) }
+func (c callersByLocation) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c callersByLocation) Less(i, j int) bool {
+	li, lj := c[i].location, c[j].location
+	if li.value() != lj.value() {
+		return li.Less(lj)
+	}
+	return !c[i].funcCall && c[j].funcCall
+}
+
+// Name return the spelling of the symbol.
+func (info *Info) Name() string {
+	if info.info == nil {
+		return info.name
+	}
+	return string(info.info.Name())
+}
+
+// NameBytes is like Name, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (info *Info) NameBytes() []byte {
+	if info.info == nil {
+		return []byte(info.name)
+	}
+	return info.info.Name()
+}
+
+// Type return the type spelling of the symbol.
+func (info *Info) Type() string {
+	if info.info == nil {
+		return info.typ
+	}
+	return string(info.info.Type())
+}
+
+// TypeBytes is like Type, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (info *Info) TypeBytes() []byte {
+	if info.info == nil {
+		return []byte(info.typ)
+	}
+	return info.info.Type()
+}
+
+// ResultType return the result type spelling of the function symbol.
+// It is empty if the symbol is not a function.
+func (info *Info) ResultType() string {
+	if info.info == nil {
+		return info.resultType
+	}
+	return string(info.info.ResultType())
+}
+
+// ResultTypeBytes is like ResultType, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (info *Info) ResultTypeBytes() []byte {
+	if info.info == nil {
+		return []byte(info.resultType)
+	}
+	return info.info.ResultType()
+}
+
+// Params return the parameters of the function symbol.
+// The decoded parameters share one allocation, so keeping any of them keeps all of them alive.
+func (info *Info) Params() []*Param {
+	if info.info == nil {
+		return info.params
+	}
+
+	n := safeLength(info.info.Table(), infoParamsSlot)
+	params := make([]*Param, n)
+	slots := make([]struct {
+		Param
+		obj symbol.Param
+	}, n)
+
+	for i := 0; i < n; i++ {
+		s := &slots[i]
+		if info.info.Params(&s.obj, i) {
+			s.param = &s.obj
+			params[i] = &s.Param
+		}
+	}
+
+	return params
+}
+
+// Variadic reports whether the function symbol takes the variable arguments.
+func (info *Info) Variadic() bool {
+	if info.info == nil {
+		return info.variadic
+	}
+	return info.info.Variadic() != 0
+}
+
+// Namespace return the enclosing namespace chain of the symbol, such as "a::b".
+// It is empty if the symbol is in the global namespace.
+func (info *Info) Namespace() string {
+	if info.info == nil {
+		return info.namespace
+	}
+	return string(info.info.Namespace())
+}
+
+// NamespaceBytes is like Namespace, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (info *Info) NamespaceBytes() []byte {
+	if info.info == nil {
+		return []byte(info.namespace)
+	}
+	return info.info.Namespace()
+}
+
+// Bases return the USRs of the base classes.
+func (info *Info) Bases() []string {
+	if info.info == nil {
+		return info.bases
+	}
+
+	n := safeLength(info.info.Table(), infoBasesSlot)
+	if n == 0 {
+		return nil
+	}
+	bases := make([]string, n)
+	for i := 0; i < n; i++ {
+		bases[i] = string(info.info.Bases(i))
+	}
+
+	return bases
+}
+
+// IsVirtual reports whether the method symbol is virtual.
+func (info *Info) IsVirtual() bool {
+	if info.info == nil {
+		return info.isVirtual
+	}
+	return info.info.IsVirtual() != 0
+}
+
+// Overrides return the USRs of the methods which the method symbol overrides.
+func (info *Info) Overrides() []string {
+	if info.info == nil {
+		return info.overrides
+	}
+
+	n := safeLength(info.info.Table(), infoOverridesSlot)
+	if n == 0 {
+		return nil
+	}
+	overrides := make([]string, n)
+	for i := 0; i < n; i++ {
+		overrides[i] = string(info.info.Overrides(i))
+	}
+
+	return overrides
+}
+
+// ParentUSR return the USR of the semantic parent of the symbol, such as the enclosing struct of the field.
+// It is empty if the parent is the translation unit.
+func (info *Info) ParentUSR() string {
+	if info.info == nil {
+		return info.parentUSR
+	}
+	return string(info.info.ParentUSR())
+}
+
+// ParamIndex return the zero-based index of the parameter symbol in its function, which is the ParentUSR.
+// It is zero if the symbol is not the parameter.
+func (info *Info) ParamIndex() uint32 {
+	if info.info == nil {
+		return info.paramIndex
+	}
+	return info.info.ParamIndex()
+}
+
+// ObjCSelector return the selector of the Objective-C method symbol, such as "initWithName:age:".
+// It is empty if the symbol is not the Objective-C method.
+func (info *Info) ObjCSelector() string {
+	if info.info == nil {
+		return info.selector
+	}
+	return string(info.info.ObjCSelector())
+}
+
+// isAnonymousRecord reports whether info is the anonymous struct, union or class.
+func (info *Info) isAnonymousRecord() bool {
+	switch info.Kind() {
+	case clang.Cursor_StructDecl, clang.Cursor_UnionDecl, clang.Cursor_ClassDecl:
+		return info.Name() == ""
+	default:
+		return false
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// Param represents a parameter of function symbol.
+//
+//  table Param {
+//    Name: string;
+//    Type: string;
+//  }
+type Param struct {
+	name string
+	typ  string
+
+	param *symbol.Param
+}
+
+// SymbolParam type alias of symbol.Param.
+type SymbolParam = symbol.Param
+
+// Name return the spelling of parameter. It is empty if the parameter is unnamed.
+func (p *Param) Name() string {
+	if p.param == nil {
+		return p.name
+	}
+	return string(p.param.Name())
+}
+
+// NameBytes is like Name, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (p *Param) NameBytes() []byte {
+	if p.param == nil {
+		return []byte(p.name)
+	}
+	return p.param.Name()
+}
+
+// Type return the type spelling of parameter.
+func (p *Param) Type() string {
+	if p.param == nil {
+		return p.typ
+	}
+	return string(p.param.Type())
+}
+
+// TypeBytes is like Type, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (p *Param) TypeBytes() []byte {
+	if p.param == nil {
+		return []byte(p.typ)
+	}
+	return p.param.Type()
+}
+
+// serialize serializes the Param.
+func (p *Param) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	var nameOffset flatbuffers.UOffsetT
+	if name := p.Name(); name != "" {
+		nameOffset = builder.CreateString(name)
+	}
+	typOffset := builder.CreateString(p.Type())
+
+	symbol.ParamStart(builder)
+	symbol.ParamAddName(builder, nameOffset)
+	symbol.ParamAddType(builder, typOffset)
+
+	return symbol.ParamEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// Header represents a location of include header file.
+//
+//  table Header {
+//    FileID: string (id: 0, required, key); // -> []byte
+//    Mtime: long (id: 1); // time.Time.Unix(): int64
+//    Path: string (id: 2); // -> []byte
+//    Exists: bool = true (id: 3); // -> byte
+//  }
+type Header struct {
+	fileid FileID
+	mtime  time.Time
+	path   string
+	exists bool
+
+	header *symbol.Header
 }
 
 // SymbolHeader type alias of symbol.Header.
 type SymbolHeader = symbol.Header
 
-// FileID return the header FileID.
-func (h *Header) FileID() FileID {
-	return ToFileID(string(h.header.FileID()))
+// FileID return the header FileID.
+func (h *Header) FileID() FileID {
+	if h.header == nil {
+		return h.fileid
+	}
+	return FileID(decodeHash(h.header.FileID()))
+}
+
+// Mtime return the header modified time.
+func (h *Header) Mtime() int64 {
+	if h.header == nil {
+		return h.mtime.Unix()
+	}
+	return h.header.Mtime()
+}
+
+// Path return the original spelling of the header path, before any canonicalization.
+func (h *Header) Path() string {
+	if h.header == nil {
+		return h.path
+	}
+	return string(h.header.Path())
+}
+
+// PathBytes is like Path, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (h *Header) PathBytes() []byte {
+	if h.header == nil {
+		return []byte(h.path)
+	}
+	return h.header.Path()
+}
+
+// Exists reports whether the header file was found when the File was parsed.
+// The missing header has the synthetic FileID and the empty Path.
+//
+// The File serialized by the older versions has no Exists field, and the missing header of it is reported by the
+// synthetic FileID which they wrote instead.
+func (h *Header) Exists() bool {
+	if h.header == nil {
+		return h.exists
+	}
+	if tab := h.header.Table(); tab.Offset(headerExistsSlot) == 0 {
+		// the default true is also omitted by the current version, which writes the real FileID for it
+		return h.FileID() != legacyNotExistHeaderID()
+	}
+	return h.header.Exists() != 0
+}
+
+// legacyNotExistHeaderID returns the synthetic FileID which the older versions wrote for every missing header.
+func legacyNotExistHeaderID() FileID {
+	return ToFileID(notExistHeaderName(filepath.Clean("")))
+}
+
+// serialize serializes the h data to flatbuffers.UOffsetT.
+func (h *Header) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	fid := builder.CreateString(h.FileID().String())
+	var path flatbuffers.UOffsetT
+	if p := h.Path(); p != "" {
+		path = builder.CreateString(p)
+	}
+
+	symbol.HeaderStart(builder)
+
+	symbol.HeaderAddFileID(builder, fid)
+	symbol.HeaderAddMtime(builder, h.Mtime())
+	symbol.HeaderAddPath(builder, path)
+	symbol.HeaderAddExists(builder, boolToByte(h.Exists()))
+
+	return symbol.HeaderEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// Include represents a #include directive of the File.
+//
+//  table Include {
+//    Spelling: string; // -> []byte
+//    Location: Location (required);
+//    FileID: string; // -> []byte
+//  }
+type Include struct {
+	spelling string
+	location Location
+	fileid   FileID
+
+	include *symbol.Include
+}
+
+// SymbolInclude type alias of symbol.Include.
+type SymbolInclude = symbol.Include
+
+// Spelling return the header name as written in the directive, such as "foo.h".
+func (i *Include) Spelling() string {
+	if i.include == nil {
+		return i.spelling
+	}
+	return string(i.include.Spelling())
+}
+
+// Location return the location of the directive.
+func (i *Include) Location() Location {
+	if i.include == nil {
+		return i.location
+	}
+	loc := i.include.Location(nil)
+	if loc == nil {
+		return Location{}
+	}
+	return Location{location: loc}
+}
+
+// FileID return the FileID of the included file, which is same as the FileID of the Header.
+// It reports false if the included file was not found when the File was parsed.
+func (i *Include) FileID() (FileID, bool) {
+	var fid FileID
+	if i.include == nil {
+		fid = i.fileid
+	} else {
+		fid = FileID(decodeHash(i.include.FileID()))
+	}
+	return fid, fid != FileID{}
+}
+
+// equal reports whether i and o are the same directive.
+func (i *Include) equal(o *Include) bool {
+	fid, _ := i.FileID()
+	ofid, _ := o.FileID()
+	return i.Spelling() == o.Spelling() && i.Location().value() == o.Location().value() && fid == ofid
+}
+
+// serialize serializes the i data to flatbuffers.UOffsetT.
+func (i *Include) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	var spelling, fid flatbuffers.UOffsetT
+	if s := i.Spelling(); s != "" {
+		spelling = builder.CreateString(s)
+	}
+	loc := i.Location()
+	locOffset := loc.serialize(builder)
+	if id, ok := i.FileID(); ok {
+		fid = builder.CreateString(id.String())
+	}
+
+	symbol.IncludeStart(builder)
+
+	symbol.IncludeAddSpelling(builder, spelling)
+	symbol.IncludeAddLocation(builder, locOffset)
+	symbol.IncludeAddFileID(builder, fid)
+
+	return symbol.IncludeEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// Caller represents a location of caller function.
+//
+//  table Caller {
+//    Location: Location (required);
+//    FuncCall: bool = false; // -> byte
+//    Args: [Location];
+//  }
+type Caller struct {
+	location Location
+	funcCall bool
+	args     []Location
+
+	caller *symbol.Caller
+}
+
+// SymbolCaller type alias of symbol.Caller.
+type SymbolCaller = symbol.Caller
+
+// Location return the location of caller function.
+func (c *Caller) Location() Location {
+	if c.caller == nil {
+		return c.location
+	}
+
+	obj := new(symbol.Location)
+	c.caller.Location(obj)
+
+	return Location{location: obj}
+}
+
+// FuncCall reports whether caller is function call.
+func (c *Caller) FuncCall() bool {
+	if c.caller == nil {
+		return c.funcCall
+	}
+	return c.caller.FuncCall() != 0
+}
+
+// Args return the start locations of the arguments of the function call, which are recorded by AddCallArguments.
+// It is empty if the arguments are not recorded, such as the caller which is not the function call.
+func (c *Caller) Args() []Location {
+	if c.caller == nil {
+		return c.args
+	}
+
+	n := safeLength(c.caller.Table(), callerArgsSlot)
+	if n == 0 {
+		return nil
+	}
+	args := make([]Location, n)
+	objs := make([]symbol.Location, n)
+	for i := 0; i < n; i++ {
+		if c.caller.Args(&objs[i], i) {
+			args[i] = Location{location: &objs[i]}
+		}
+	}
+
+	return args
+}
+
+// serialize serializes the c data to flatbuffers.UOffsetT.
+func (c *Caller) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	loc := c.Location()
+	locOffset := loc.serialize(builder)
+
+	var argsOffset flatbuffers.UOffsetT
+	if args := c.Args(); len(args) > 0 {
+		offsets := make([]flatbuffers.UOffsetT, len(args))
+		for i, arg := range args {
+			offsets[i] = arg.serialize(builder)
+		}
+		symbol.CallerStartArgsVector(builder, len(offsets))
+		for i := len(offsets) - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(offsets[i])
+		}
+		argsOffset = builder.EndVector(len(offsets))
+	}
+
+	symbol.CallerStart(builder)
+
+	symbol.CallerAddLocation(builder, locOffset)
+	symbol.CallerAddFuncCall(builder, boolToByte(c.FuncCall()))
+	symbol.CallerAddArgs(builder, argsOffset)
+
+	return symbol.CallerEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// Range represents a source range between the two locations.
+//
+//  table Range {
+//    Start: Location (required);
+//    End: Location (required);
+//  }
+type Range struct {
+	start Location
+	end   Location
+
+	rng *symbol.Range
+}
+
+// SymbolRange type alias of symbol.Range.
+type SymbolRange = symbol.Range
+
+// FromSourceRange return the Range of the clang.SourceRange r.
+func FromSourceRange(r clang.SourceRange) Range {
+	if r.IsNull() {
+		return Range{}
+	}
+	return Range{
+		start: fromSourceLocation(r.Start()),
+		end:   fromSourceLocation(r.End()),
+	}
+}
+
+// Start return the start location of the range.
+func (r *Range) Start() Location {
+	if r.rng == nil {
+		return r.start
+	}
+
+	obj := new(symbol.Location)
+	r.rng.Start(obj)
+
+	return Location{location: obj}
+}
+
+// End return the end location of the range.
+func (r *Range) End() Location {
+	if r.rng == nil {
+		return r.end
+	}
+
+	obj := new(symbol.Location)
+	r.rng.End(obj)
+
+	return Location{location: obj}
+}
+
+// IsEmpty reports whether the range has no start location.
+func (r *Range) IsEmpty() bool {
+	start := r.Start()
+	return start.IsZero()
 }
 
-// Mtime return the header modified time.
-func (h *Header) Mtime() int64 {
-	return h.header.Mtime()
+// value returns the copy of r which detached from the flatbuffers table.
+func (r Range) value() Range {
+	return Range{
+		start: r.Start().value(),
+		end:   r.End().value(),
+	}
+}
+
+// serialize serializes the r data to flatbuffers.UOffsetT.
+func (r *Range) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	start, end := r.Start(), r.End()
+	startOffset := start.serialize(builder)
+	endOffset := end.serialize(builder)
+
+	symbol.RangeStart(builder)
+
+	symbol.RangeAddStart(builder, startOffset)
+	symbol.RangeAddEnd(builder, endOffset)
+
+	return symbol.RangeEnd(builder)
+}
+
+// ----------------------------------------------------------------------------
+
+// Location location of symbol.
+// TODO(zchee): method receiver is pointer for location?
+//
+//  table Location {
+//    FileName: string;
+//    Line: uint;
+//    Col: uint = 0;
+//    Offset: uint;
+//    USR: string;
+//    IsForward: bool;
+//  }
+type Location struct {
+	fileName  string
+	line      uint32
+	col       uint32
+	offset    uint32
+	usr       string
+	isForward bool
+
+	kind clang.CursorKind // kind of the cursor, only used by the kind filter and not stored
+
+	location *symbol.Location
+}
+
+// SymbolLocation type alias of symbol.Location.
+type SymbolLocation = symbol.Location
+
+// FileName return the filename of location.
+func (l *Location) FileName() string {
+	if l.location == nil {
+		return l.fileName
+	}
+	return string(l.location.FileName())
+}
+
+// FileNameBytes is like FileName, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (l *Location) FileNameBytes() []byte {
+	if l.location == nil {
+		return []byte(l.fileName)
+	}
+	return l.location.FileName()
+}
+
+// FileID return the FileID of the location filename.
+func (l *Location) FileID() FileID {
+	return ToFileID(filepath.Clean(l.FileName()))
+}
+
+// Line return the line number of symbol location.
+func (l *Location) Line() uint32 {
+	if l.location == nil {
+		return l.line
+	}
+	return l.location.Line()
+}
+
+// columnBase is the base of the column numbers which Location.Col returns. The columns are stored 1-based
+// as libclang reports, and converted on access. It is accessed atomically, see loadColumnBase.
+var columnBase uint32 = 1
+
+// SetColumnBase sets the base of the column numbers to 0 or 1, which is applied to the Col of the Locations,
+// the col of CreateLocation, and the col arguments of the lookups such as LookupSymbolAt.
+// The default is 1, which is the libclang column. The line numbers are always 1-based, and the stored
+// and serialized columns are always 1-based regardless of the base.
+//
+// It is the process-wide setting for the consumer which expects the 0-based columns, such as Emacs,
+// and is meant to be set once at the initialization, before any Location is created or read. Changing it
+// later is race-free, but the columns which were already converted in the other base are not updated.
+// It panics if base is neither 0 nor 1.
+func SetColumnBase(base int) {
+	if base != 0 && base != 1 {
+		panic(fmt.Sprintf("symbol: invalid column base %d", base))
+	}
+	atomic.StoreUint32(&columnBase, uint32(base))
+}
+
+// loadColumnBase returns the column base set by SetColumnBase.
+func loadColumnBase() uint32 {
+	return atomic.LoadUint32(&columnBase)
+}
+
+// Col return the column number of symbol location in the column base, see SetColumnBase.
+// The unknown column stays zero.
+func (l *Location) Col() uint32 {
+	col := l.rawCol()
+	if col == 0 {
+		return 0
+	}
+	return col - 1 + loadColumnBase()
+}
+
+// rawCol returns the stored 1-based column number of l.
+func (l *Location) rawCol() uint32 {
+	if l.location == nil {
+		return l.col
+	}
+	return l.location.Col()
+}
+
+// fromColumnBase converts col in the column base to the stored 1-based column number.
+func fromColumnBase(col uint32) uint32 {
+	return col + 1 - loadColumnBase()
+}
+
+// Offset return the byte offset of symbol location.
+func (l *Location) Offset() uint32 {
+	if l.location == nil {
+		return l.offset
+	}
+	return l.location.Offset()
+}
+
+// USR return the Unified Symbol Resolution of symbol.
+func (l *Location) USR() string {
+	if l.location == nil {
+		return l.usr
+	}
+	return string(l.location.USR())
+}
+
+// USRBytes is like USR, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (l *Location) USRBytes() []byte {
+	if l.location == nil {
+		return []byte(l.usr)
+	}
+	return l.location.USR()
+}
+
+// IsForward reports whether the location is the forward declaration, such as "class Foo;",
+// which has no definition.
+func (l *Location) IsForward() bool {
+	if l.location == nil {
+		return l.isForward
+	}
+	return l.location.IsForward() != 0
+}
+
+// serialize serializes the l data to flatbuffers.UOffsetT.
+func (l *Location) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	var fname, usr flatbuffers.UOffsetT
+	if l.location == nil {
+		fname = builder.CreateString(l.fileName)
+		usr = builder.CreateString(l.usr)
+	} else {
+		fname = builder.CreateByteString(l.location.FileName())
+		usr = builder.CreateByteString(l.location.USR())
+	}
+
+	symbol.LocationStart(builder)
+
+	symbol.LocationAddFileName(builder, fname)
+	symbol.LocationAddLine(builder, l.Line())
+	symbol.LocationAddCol(builder, l.rawCol())
+	symbol.LocationAddOffset(builder, l.Offset())
+	symbol.LocationAddUSR(builder, usr)
+	symbol.LocationAddIsForward(builder, boolToByte(l.IsForward()))
+
+	return symbol.LocationEnd(builder)
+}
+
+// value returns the copy of l which detached from the flatbuffers table.
+func (l Location) value() Location {
+	if l.location == nil {
+		return l
+	}
+
+	return Location{
+		fileName:  l.FileName(),
+		line:      l.Line(),
+		col:       l.rawCol(),
+		offset:    l.Offset(),
+		usr:       l.USR(),
+		isForward: l.IsForward(),
+	}
+}
+
+// Less reports whether l is positioned before o.
+// The locations are ordered by file name, line, column and then byte offset.
+func (l *Location) Less(o Location) bool {
+	if l.location != nil && o.location != nil {
+		if c := bytes.Compare(l.location.FileName(), o.location.FileName()); c != 0 {
+			return c < 0
+		}
+	} else if lf, of := l.FileName(), o.FileName(); lf != of {
+		return lf < of
+	}
+	if ll, ol := l.Line(), o.Line(); ll != ol {
+		return ll < ol
+	}
+	if lc, oc := l.rawCol(), o.rawCol(); lc != oc {
+		return lc < oc
+	}
+	return l.Offset() < o.Offset()
+}
+
+// IsZero reports whether l is the zero Location, which has neither the flatbuffers table nor any field.
+// The Def of the symbol which has no definition is the zero Location.
+func (l *Location) IsZero() bool {
+	return l.location == nil && l.fileName == "" && l.line == 0 && l.col == 0 && l.offset == 0 && l.usr == "" && !l.isForward
+}
+
+// LocationOption represents a option of NewLocation.
+type LocationOption func(*Location)
+
+// WithOffset sets the byte offset of the Location.
+func WithOffset(offset uint32) LocationOption {
+	return func(l *Location) { l.offset = offset }
+}
+
+// WithUSR sets the USR of the symbol at the Location.
+func WithUSR(usr string) LocationOption {
+	return func(l *Location) { l.usr = usr }
+}
+
+// WithForward sets whether the Location is the forward declaration.
+func WithForward(forward bool) LocationOption {
+	return func(l *Location) { l.isForward = forward }
+}
+
+// NewLocation returns the in-memory Location of filename, line and col configured by opts,
+// such as the location which received from the client. The col is in the column base, see SetColumnBase.
+func NewLocation(filename string, line, col uint32, opts ...LocationOption) Location {
+	l := Location{fileName: filename, line: line, col: fromColumnBase(col)}
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return l
 }
 
-// serialize serializes the h data to flatbuffers.UOffsetT.
-func (h *Header) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
-	fid := builder.CreateString(h.fileid.String())
+// CreateLocation creates location data using flatbuffers binary. The col is in the column base, see SetColumnBase.
+func CreateLocation(filename string, line, col uint32) *flatbuffers.Builder {
+	builder := flatbuffers.NewBuilder(0)
+	builder.Finish(createLocation(builder, filename, line, fromColumnBase(col)))
 
-	symbol.HeaderStart(builder)
+	return builder
+}
 
-	symbol.HeaderAddFileID(builder, fid)
-	symbol.HeaderAddMtime(builder, h.mtime.Unix())
+// createLocation serializes the location of filename, line and col to flatbuffers.UOffsetT.
+func createLocation(builder *flatbuffers.Builder, filename string, line, col uint32) flatbuffers.UOffsetT {
+	fname := builder.CreateString(filename)
 
-	return symbol.HeaderEnd(builder)
+	symbol.LocationStart(builder)
+	symbol.LocationAddFileName(builder, fname)
+	symbol.LocationAddLine(builder, line)
+	symbol.LocationAddCol(builder, col)
+
+	return symbol.LocationEnd(builder)
 }
 
 // ----------------------------------------------------------------------------
 
-// Caller represents a location of caller function.
+// Diagnostic represents a diagnostic which produced by the clang parse.
 //
-//  table Caller {
-//    Location: Location (required);
-//    FuncCall: bool = false; // -> byte
+//  table Diagnostic {
+//    Severity: uint; // clang.DiagnosticSeverity(uint32)
+//    Message: string; // -> []byte
+//    Location: Location;
+//    Option: string; // -> []byte
+//    FixIts: [FixIt];
 //  }
-type Caller struct {
+type Diagnostic struct {
+	severity clang.DiagnosticSeverity
+	message  string
 	location Location
-	funcCall bool
+	option   string
+	fixIts   []FixIt
 
-	caller *symbol.Caller
+	diagnostic *symbol.Diagnostic
 }
 
-// SymbolCaller type alias of symbol.Caller.
-type SymbolCaller = symbol.Caller
+// SymbolDiagnostic type alias of symbol.Diagnostic.
+type SymbolDiagnostic = symbol.Diagnostic
 
-// Location return the location of caller function.
-func (c *Caller) Location() Location {
-	obj := new(symbol.Location)
-	c.caller.Location(obj)
+// FromDiagnostic return the Diagnostic of the clang diagnostic d, which has the option enabling d and the fix-its.
+func FromDiagnostic(d clang.Diagnostic) *Diagnostic {
+	file, line, col, offset := d.Location().FileLocation()
+	_, option := d.Option() // the disabling option, and the enabling option
 
-	return Location{location: obj}
-}
+	n := d.NumFixIts()
+	fixIts := make([]FixIt, 0, n)
+	for i := uint32(0); i < n; i++ {
+		rng, replacement := d.FixIt(i)
+		fixIts = append(fixIts, FixIt{rng: FromSourceRange(rng), replacement: replacement})
+	}
 
-// FuncCall reports whether caller is function call.
-func (c *Caller) FuncCall() bool {
-	return c.caller.FuncCall() != 0
+	return &Diagnostic{
+		severity: d.Severity(),
+		message:  d.Spelling(),
+		location: Location{
+			fileName: file.Name(),
+			line:     line,
+			col:      col,
+			offset:   offset,
+		},
+		option: option,
+		fixIts: fixIts,
+	}
 }
 
-// serialize serializes the c data to flatbuffers.UOffsetT.
-func (c *Caller) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
-	locOffset := c.location.serialize(builder)
-
-	symbol.CallerStart(builder)
+// NewDiagnostic returns the in-memory Diagnostic, such as the diagnostic which received from the other process.
+func NewDiagnostic(severity clang.DiagnosticSeverity, message string, loc Location) *Diagnostic {
+	return &Diagnostic{
+		severity: severity,
+		message:  message,
+		location: loc,
+	}
+}
 
-	symbol.CallerAddLocation(builder, locOffset)
-	funcCall := byte(0)
-	if c.funcCall {
-		funcCall = byte(1)
+// Severity return the severity of the diagnostic.
+func (d *Diagnostic) Severity() clang.DiagnosticSeverity {
+	if d.diagnostic == nil {
+		return d.severity
 	}
-	symbol.CallerAddFuncCall(builder, funcCall)
+	return clang.DiagnosticSeverity(d.diagnostic.Severity())
+}
 
-	return symbol.CallerEnd(builder)
+// Message return the text of the diagnostic.
+func (d *Diagnostic) Message() string {
+	if d.diagnostic == nil {
+		return d.message
+	}
+	return string(d.diagnostic.Message())
 }
 
-// ----------------------------------------------------------------------------
+// MessageBytes is like Message, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (d *Diagnostic) MessageBytes() []byte {
+	if d.diagnostic == nil {
+		return []byte(d.message)
+	}
+	return d.diagnostic.Message()
+}
 
-// Location location of symbol.
-// TODO(zchee): method receiver is pointer for location?
-//
-//  table Location {
-//    FileName: string;
-//    Line: uint;
-//    Col: uint = 0;
-//    Offset: uint;
-//    USR: string;
-//  }
-type Location struct {
-	fileName string
-	line     uint32
-	col      uint32
-	offset   uint32
-	usr      string
+// Location return the location of the diagnostic.
+func (d *Diagnostic) Location() Location {
+	if d.diagnostic == nil {
+		return d.location
+	}
+	loc := d.diagnostic.Location(nil)
+	if loc == nil {
+		return Location{}
+	}
+	return Location{location: loc}
+}
 
-	location *symbol.Location
+// Option return the command-line option which enables the diagnostic, such as "-Wunused-variable".
+// It is empty if the diagnostic cannot be disabled, such as the error.
+func (d *Diagnostic) Option() string {
+	if d.diagnostic == nil {
+		return d.option
+	}
+	return string(d.diagnostic.Option())
 }
 
-// SymbolLocation type alias of symbol.Location.
-type SymbolLocation = symbol.Location
+// FixIts return the fix-it hints of the diagnostic, which should be applied together.
+func (d *Diagnostic) FixIts() []FixIt {
+	if d.diagnostic == nil {
+		return d.fixIts
+	}
 
-// FileName return the filename of location.
-func (l *Location) FileName() string {
-	if l.location == nil {
-		return l.fileName
+	n := safeLength(d.diagnostic.Table(), diagnosticFixItsSlot)
+	if n == 0 {
+		return nil
+	}
+	fixIts := make([]FixIt, n)
+	objs := make([]symbol.FixIt, n)
+	for i := 0; i < n; i++ {
+		if d.diagnostic.FixIts(&objs[i], i) {
+			fixIts[i].fixIt = &objs[i]
+		}
 	}
-	return string(l.location.FileName())
-}
 
-// Line return the line number of symbol location.
-func (l *Location) Line() uint32 {
-	return l.location.Line()
+	return fixIts
 }
 
-// Col return the column number of symbol location.
-func (l *Location) Col() uint32 {
-	return l.location.Col()
-}
+// equal reports whether d and o have the same content.
+func (d *Diagnostic) equal(o *Diagnostic) bool {
+	if d.Severity() != o.Severity() || d.Message() != o.Message() || d.Option() != o.Option() || d.Location().value() != o.Location().value() {
+		return false
+	}
+	fixIts, ofixIts := d.FixIts(), o.FixIts()
+	if len(fixIts) != len(ofixIts) {
+		return false
+	}
+	for i := range fixIts {
+		if fixIts[i].value() != ofixIts[i].value() {
+			return false
+		}
+	}
 
-// Offset return the byte offset of symbol location.
-func (l *Location) Offset() uint32 {
-	return l.location.Offset()
+	return true
 }
 
-// USR return the Unified Symbol Resolution of symbol.
-func (l *Location) USR() string {
-	if l.location == nil {
-		return l.usr
+// serialize serializes the d data to flatbuffers.UOffsetT.
+func (d *Diagnostic) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	msg := builder.CreateString(d.Message())
+	loc := d.Location()
+	locOffset := loc.serialize(builder)
+	var optionOffset, fixItsOffset flatbuffers.UOffsetT
+	if option := d.Option(); option != "" {
+		optionOffset = builder.CreateString(option)
+	}
+	if fixIts := d.FixIts(); len(fixIts) > 0 {
+		offsets := make([]flatbuffers.UOffsetT, len(fixIts))
+		for i := range fixIts {
+			offsets[i] = fixIts[i].serialize(builder)
+		}
+		symbol.DiagnosticStartFixItsVector(builder, len(offsets))
+		for i := len(offsets) - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(offsets[i])
+		}
+		fixItsOffset = builder.EndVector(len(offsets))
 	}
-	return string(l.location.USR())
-}
 
-// serialize serializes the l data to flatbuffers.UOffsetT.
-func (l *Location) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
-	fname := builder.CreateString(l.fileName)
-	usr := builder.CreateString(l.usr)
+	symbol.DiagnosticStart(builder)
 
-	symbol.LocationStart(builder)
+	symbol.DiagnosticAddSeverity(builder, uint32(d.Severity()))
+	symbol.DiagnosticAddMessage(builder, msg)
+	symbol.DiagnosticAddLocation(builder, locOffset)
+	symbol.DiagnosticAddOption(builder, optionOffset)
+	symbol.DiagnosticAddFixIts(builder, fixItsOffset)
 
-	symbol.LocationAddFileName(builder, fname)
-	symbol.LocationAddLine(builder, l.line)
-	symbol.LocationAddCol(builder, l.col)
-	symbol.LocationAddOffset(builder, l.offset)
-	symbol.LocationAddUSR(builder, usr)
+	return symbol.DiagnosticEnd(builder)
+}
 
-	return symbol.LocationEnd(builder)
+// ----------------------------------------------------------------------------
+
+// FixIt represents a fix-it hint of the diagnostic, which replaces the source range with the replacement text.
+//
+//  table FixIt {
+//    Range: Range (required);
+//    Replacement: string; // -> []byte
+//  }
+type FixIt struct {
+	rng         Range
+	replacement string
+
+	fixIt *symbol.FixIt
 }
 
-// TODO(zchee): avoid reflection
-func (l *Location) isExist() bool {
-	return !reflect.DeepEqual(l, Location{})
+// SymbolFixIt type alias of symbol.FixIt.
+type SymbolFixIt = symbol.FixIt
+
+// Range return the half-open source range to replace, whose start and end are same for the insertion.
+func (fix *FixIt) Range() Range {
+	if fix.fixIt == nil {
+		return fix.rng
+	}
+	rng := fix.fixIt.Range(nil)
+	if rng == nil {
+		return Range{}
+	}
+	return Range{rng: rng}
 }
 
-// CreateLocation creates location data using flatbuffers binary.
-func CreateLocation(filename string, line, col uint32) *flatbuffers.Builder {
-	l := Location{
-		fileName: filename,
-		line:     line,
-		col:      col,
+// Replacement return the text which replaces the range, which is empty for the removal.
+func (fix *FixIt) Replacement() string {
+	if fix.fixIt == nil {
+		return fix.replacement
 	}
-	builder := flatbuffers.NewBuilder(0)
+	return string(fix.fixIt.Replacement())
+}
 
-	fname := builder.CreateString(l.fileName)
+// value returns the copy of fix which detached from the flatbuffers table.
+func (fix FixIt) value() FixIt {
+	return FixIt{rng: fix.Range().value(), replacement: fix.Replacement()}
+}
 
-	symbol.LocationStart(builder)
-	symbol.LocationAddFileName(builder, fname)
-	symbol.LocationAddLine(builder, l.line)
-	symbol.LocationAddCol(builder, l.col)
-	builder.Finish(symbol.LocationEnd(builder))
+// serialize serializes the fix data to flatbuffers.UOffsetT.
+func (fix *FixIt) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	rng := fix.Range()
+	rngOffset := rng.serialize(builder)
+	replacement := builder.CreateString(fix.Replacement())
 
-	return builder
+	symbol.FixItStart(builder)
+
+	symbol.FixItAddRange(builder, rngOffset)
+	symbol.FixItAddReplacement(builder, replacement)
+
+	return symbol.FixItEnd(builder)
 }
 
 // ----------------------------------------------------------------------------
@@ -582,15 +3176,35 @@ func CreateLocation(filename string, line, col uint32) *flatbuffers.Builder {
 //    Kind: string; // -> []byte
 //    Icase: bool; // -> byte
 //    Dup: bool; // -> byte
+//    Priority: uint; // clang.CompletionString.Priority: uint32
+//    Snippet: string; // -> []byte
+//    Availability: uint; // clang.AvailabilityKind(uint32)
+//    Deprecated: bool; // -> byte
+//    CursorKind: uint; // clang.CursorKind(uint32)
+//    SnippetSyntax: ubyte; // SnippetSyntax
+//    Overloads: uint; // number of the folded overloads
+//    UserData: string; // -> []byte
+//    SortText: string; // -> []byte
 //  }
 type CompleteItem struct {
-	word  string
-	abbr  string
-	menu  string
-	info  string
-	kind  string
-	icase bool
-	dup   bool
+	word     string
+	abbr     string
+	menu     string
+	info     string
+	kind     string
+	icase    bool
+	dup      bool
+	priority uint32
+	snippet  string
+
+	availability  clang.AvailabilityKind
+	deprecated    bool
+	cursorKind    clang.CursorKind
+	snippetSyntax SnippetSyntax
+	overloads     uint32
+	userData      string
+
+	signature string // not serialized
 
 	completeItems *symbol.CompleteItem
 }
@@ -600,41 +3214,219 @@ type SymbolCompleteItem = symbol.CompleteItem
 
 // Word return the text that will inserted, mandatory.
 func (c *CompleteItem) Word() string {
+	if c.completeItems == nil {
+		return c.word
+	}
 	return string(c.completeItems.Word())
 }
 
+// WordBytes is like Word, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) WordBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.word)
+	}
+	return c.completeItems.Word()
+}
+
 // Abbr return the abbreviation of "word", when not empty it is used in the menu instead of "word".
 func (c *CompleteItem) Abbr() string {
+	if c.completeItems == nil {
+		return c.abbr
+	}
 	return string(c.completeItems.Abbr())
 }
 
+// AbbrBytes is like Abbr, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) AbbrBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.abbr)
+	}
+	return c.completeItems.Abbr()
+}
+
 // Menu return the extra text for the popup menu, displayed after "word" or "abbr".
 func (c *CompleteItem) Menu() string {
+	if c.completeItems == nil {
+		return c.menu
+	}
 	return string(c.completeItems.Menu())
 }
 
+// MenuBytes is like Menu, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) MenuBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.menu)
+	}
+	return c.completeItems.Menu()
+}
+
 // Info return the more information about the item, can be displayed in a preview window.
 func (c *CompleteItem) Info() string {
+	if c.completeItems == nil {
+		return c.info
+	}
 	return string(c.completeItems.Info())
 }
 
+// InfoBytes is like Info, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) InfoBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.info)
+	}
+	return c.completeItems.Info()
+}
+
 // Kind return the single letter indicating the type of completion.
 func (c *CompleteItem) Kind() string {
+	if c.completeItems == nil {
+		return c.kind
+	}
 	return string(c.completeItems.Kind())
 }
 
+// KindBytes is like Kind, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) KindBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.kind)
+	}
+	return c.completeItems.Kind()
+}
+
 // Icase return the more information about the item, can be displayed in a preview window.
 func (c *CompleteItem) Icase() bool {
+	if c.completeItems == nil {
+		return c.icase
+	}
 	return c.completeItems.Icase() != byte(0)
 }
 
 // Dup return the when non-zero this match will be added even when an item with the same word is already present.
 func (c *CompleteItem) Dup() bool {
+	if c.completeItems == nil {
+		return c.dup
+	}
 	return c.completeItems.Dup() != byte(0)
 }
 
-// Marshal returns the flatbuffers binary encoding of cs.
+// Priority return the clang completion priority of the item.
+// The smaller value indicates the more likely completion.
+func (c *CompleteItem) Priority() uint32 {
+	if c.completeItems == nil {
+		return c.priority
+	}
+	return c.completeItems.Priority()
+}
+
+// Snippet return the text that will inserted with the snippet placeholders, such as "foo(${1:int a})$0".
+// The syntax is selected by CompleteOptions.Snippet.
+func (c *CompleteItem) Snippet() string {
+	if c.completeItems == nil {
+		return c.snippet
+	}
+	return string(c.completeItems.Snippet())
+}
+
+// SnippetBytes is like Snippet, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) SnippetBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.snippet)
+	}
+	return c.completeItems.Snippet()
+}
+
+// Availability return the availability of the completion result.
+func (c *CompleteItem) Availability() clang.AvailabilityKind {
+	if c.completeItems == nil {
+		return c.availability
+	}
+	return clang.AvailabilityKind(c.completeItems.Availability())
+}
+
+// Deprecated reports whether the completion result is deprecated.
+// The client can render the deprecated items dimmed.
+func (c *CompleteItem) Deprecated() bool {
+	if c.completeItems == nil {
+		return c.deprecated
+	}
+	return c.completeItems.Deprecated() != byte(0)
+}
+
+// Overloads return the number of the overloads which folded into the item by CompleteOptions.GroupOverloads.
+func (c *CompleteItem) Overloads() uint32 {
+	if c.completeItems == nil {
+		return c.overloads
+	}
+	return c.completeItems.Overloads()
+}
+
+// CursorKind return the cursor kind of the completion result.
+func (c *CompleteItem) CursorKind() clang.CursorKind {
+	if c.completeItems == nil {
+		return c.cursorKind
+	}
+	return clang.CursorKind(c.completeItems.CursorKind())
+}
+
+// SnippetSyntax return the syntax of the snippet.
+func (c *CompleteItem) SnippetSyntax() SnippetSyntax {
+	if c.completeItems == nil {
+		return c.snippetSyntax
+	}
+	return SnippetSyntax(c.completeItems.SnippetSyntax())
+}
+
+// UserData return the encoded UserData payload, which is attached by CompleteOptions.UserData.
+// It can be decoded by DecodeUserData.
+func (c *CompleteItem) UserData() string {
+	if c.completeItems == nil {
+		return c.userData
+	}
+	return string(c.completeItems.UserData())
+}
+
+// UserDataBytes is like UserData, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) UserDataBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(c.userData)
+	}
+	return c.completeItems.UserData()
+}
+
+// SortText return the sort key of the item, which is the zero-padded priority followed by the lowercased word.
+// The items which differ only in case of the word are sorted adjacently, while the Word keeps its case.
+func (c *CompleteItem) SortText() string {
+	if c.completeItems == nil {
+		return completeSortText(c.priority, c.word)
+	}
+	return string(c.completeItems.SortText())
+}
+
+// SortTextBytes is like SortText, but returns the flatbuffers bytes without copying.
+// The returned slice is valid only while the backing buffer lives, and must not be modified.
+func (c *CompleteItem) SortTextBytes() []byte {
+	if c.completeItems == nil {
+		return []byte(completeSortText(c.priority, c.word))
+	}
+	return c.completeItems.SortText()
+}
+
+// Marshal returns the flatbuffers binary encoding of cs with the default CompleteOptions.
+// The kind is empty because cs does not know its cursor kind.
 func (c *CompleteItem) Marshal(builder *flatbuffers.Builder, cs clang.CompletionString) flatbuffers.UOffsetT {
+	c.parse(completionResult{cs: cs}, CompleteOptions{})
+	return c.serialize(builder)
+}
+
+// parse parses the completion result res into c with opts.
+func (c *CompleteItem) parse(res completionResult, opts CompleteOptions) {
+	cs := res.cs
 	numChunks := int(cs.NumChunks())
 
 	var word, typ, placeholder string
@@ -650,11 +3442,46 @@ func (c *CompleteItem) Marshal(builder *flatbuffers.Builder, cs clang.Completion
 		}
 	}
 
-	uword := builder.CreateString(word)
-	uabbr := builder.CreateString(placeholder)
-	umenu := builder.CreateString("")
-	uinfo := builder.CreateString(placeholder)
-	ukind := builder.CreateString(typ)
+	signature := placeholder
+	if typ != "" {
+		signature = typ + " " + placeholder
+	}
+
+	c.signature = signature
+	c.word = word
+	c.abbr = placeholder
+	c.info = completeInfo(signature, cs.BriefComment(), opts.maxCommentLength())
+	c.menu = typ
+	c.kind = opts.kindFunc()(res.cursorKind)
+	c.icase = true
+	c.dup = true
+	c.priority = cs.Priority()
+	c.snippet = buildSnippet(cs, opts.Snippet)
+	c.snippetSyntax = opts.Snippet
+	c.cursorKind = res.cursorKind
+	c.availability = cs.Availability()
+	c.deprecated = c.availability == clang.Availability_Deprecated
+	if c.deprecated {
+		c.menu = appendMenu(c.menu, deprecatedMenu)
+	}
+	if opts.UserData {
+		c.userData = c.buildUserData(opts)
+	}
+}
+
+// serialize serializes the c data to flatbuffers.UOffsetT.
+func (c *CompleteItem) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	uword := builder.CreateString(c.word)
+	uabbr := builder.CreateString(c.abbr)
+	umenu := builder.CreateString(c.menu)
+	uinfo := builder.CreateString(c.info)
+	ukind := builder.CreateString(c.kind)
+	usnippet := builder.CreateString(c.snippet)
+	var uuserData flatbuffers.UOffsetT
+	if c.userData != "" {
+		uuserData = builder.CreateString(c.userData)
+	}
+	usortText := builder.CreateString(c.SortText())
 
 	symbol.CompleteItemStart(builder)
 	symbol.CompleteItemAddWord(builder, uword)
@@ -662,8 +3489,17 @@ func (c *CompleteItem) Marshal(builder *flatbuffers.Builder, cs clang.Completion
 	symbol.CompleteItemAddMenu(builder, umenu)
 	symbol.CompleteItemAddInfo(builder, uinfo)
 	symbol.CompleteItemAddKind(builder, ukind)
-	symbol.CompleteItemAddIcase(builder, byte(1))
-	symbol.CompleteItemAddDup(builder, byte(1))
+	symbol.CompleteItemAddIcase(builder, boolToByte(c.icase))
+	symbol.CompleteItemAddDup(builder, boolToByte(c.dup))
+	symbol.CompleteItemAddPriority(builder, c.priority)
+	symbol.CompleteItemAddSnippet(builder, usnippet)
+	symbol.CompleteItemAddAvailability(builder, uint32(c.availability))
+	symbol.CompleteItemAddDeprecated(builder, boolToByte(c.deprecated))
+	symbol.CompleteItemAddCursorKind(builder, uint32(c.cursorKind))
+	symbol.CompleteItemAddSnippetSyntax(builder, byte(c.snippetSyntax))
+	symbol.CompleteItemAddOverloads(builder, c.overloads)
+	symbol.CompleteItemAddUserData(builder, uuserData)
+	symbol.CompleteItemAddSortText(builder, usortText)
 
 	return symbol.CompleteItemEnd(builder)
 }
@@ -672,8 +3508,15 @@ func (c *CompleteItem) Marshal(builder *flatbuffers.Builder, cs clang.Completion
 //
 //  table CodeCompleteResults {
 //    Results: [CompleteItem];
+//    Truncated: bool;
+//    Total: uint;
+//    Diagnostics: [Diagnostic];
+//    Context: ulong;
 //  }
 type CodeCompleteResults struct {
+	// Options options of Marshal.
+	Options CompleteOptions
+
 	codeCompleteResults *symbol.CodeCompleteResults
 }
 
@@ -687,22 +3530,44 @@ func NewCodeCompleteResults(v *symbol.CodeCompleteResults) *CodeCompleteResults
 	}
 }
 
+// CreateCodeCompleteResults creates the CodeCompleteResults data of items using flatbuffers binary,
+// such as the results which received from the other process. The items are written as is, without the filtering
+// and sorting of Marshal.
+func CreateCodeCompleteResults(items []*CompleteItem, truncated bool, total int, diags []*Diagnostic, context CompletionContext) *flatbuffers.Builder {
+	return serializeCompleteItems(items, truncated, total, diags, context)
+}
+
+// GetRootAsCodeCompleteResults gets the root of CodeCompleteResults flatbuffers binary.
+func GetRootAsCodeCompleteResults(buf []byte, offset flatbuffers.UOffsetT) *CodeCompleteResults {
+	return NewCodeCompleteResults(symbol.GetRootAsCodeCompleteResults(buf, offset))
+}
+
 // Results return the slice of CompleteItem.
 func (c *CodeCompleteResults) Results() []CompleteItem {
 	n := int(c.codeCompleteResults.ResultsLength())
 	itemList := make([]CompleteItem, n)
 
+	// the fields are copied out, so the table is reused
+	obj := new(symbol.CompleteItem)
 	for i := 0; i < n; i++ {
-		obj := new(symbol.CompleteItem)
 		if c.codeCompleteResults.Results(obj, i) {
 			itemList[i] = CompleteItem{
-				word:  string(obj.Word()),
-				abbr:  string(obj.Abbr()),
-				menu:  string(obj.Menu()),
-				info:  string(obj.Info()),
-				kind:  string(obj.Kind()),
-				icase: true,
-				dup:   true,
+				word:     string(obj.Word()),
+				abbr:     string(obj.Abbr()),
+				menu:     string(obj.Menu()),
+				info:     string(obj.Info()),
+				kind:     string(obj.Kind()),
+				icase:    obj.Icase() != byte(0),
+				dup:      obj.Dup() != byte(0),
+				priority: obj.Priority(),
+				snippet:  string(obj.Snippet()),
+
+				availability:  clang.AvailabilityKind(obj.Availability()),
+				deprecated:    obj.Deprecated() != byte(0),
+				cursorKind:    clang.CursorKind(obj.CursorKind()),
+				snippetSyntax: SnippetSyntax(obj.SnippetSyntax()),
+				overloads:     obj.Overloads(),
+				userData:      string(obj.UserData()),
 			}
 		}
 	}
@@ -710,22 +3575,169 @@ func (c *CodeCompleteResults) Results() []CompleteItem {
 	return itemList
 }
 
+// Truncated reports whether the results were truncated by CompleteOptions.MaxResults,
+// and the next page is available.
+func (c *CodeCompleteResults) Truncated() bool {
+	return c.codeCompleteResults.Truncated() != 0
+}
+
+// Total return the number of the results before the pagination.
+func (c *CodeCompleteResults) Total() int {
+	return int(c.codeCompleteResults.Total())
+}
+
+// Context return the completion context flags which libclang reports, such as the member access.
+func (c *CodeCompleteResults) Context() CompletionContext {
+	return CompletionContext(c.codeCompleteResults.Context())
+}
+
+// Diagnostics return the diagnostics of the completion parse, which explain why the results are empty.
+// Like File.Diagnostics, the decoded diagnostics are kept alive together.
+func (c *CodeCompleteResults) Diagnostics() []*Diagnostic {
+	n := c.codeCompleteResults.DiagnosticsLength()
+	diags := make([]*Diagnostic, n)
+	slots := make([]struct {
+		Diagnostic
+		obj symbol.Diagnostic
+	}, n)
+
+	for i := 0; i < n; i++ {
+		s := &slots[i]
+		if c.codeCompleteResults.Diagnostics(&s.obj, i) {
+			s.diagnostic = &s.obj
+			diags[i] = &s.Diagnostic
+		}
+	}
+
+	return diags
+}
+
+// FilterAndMarshal returns the flatbuffers binary encoding of clang.CodeCompleteResults v,
+// which contains only items whose word matches the typed prefix.
+// The results are filtered before building the flatbuffers, so the binary size also shrinks.
+func (c *CodeCompleteResults) FilterAndMarshal(v *clang.CodeCompleteResults, prefix string, opts FilterOptions) *flatbuffers.Builder {
+	if v == nil {
+		return nil
+	}
+
+	return c.marshal(toCompletion(v), func(items []*CompleteItem) []*CompleteItem {
+		return filterCompleteItems(items, prefix, opts)
+	})
+}
+
 // Marshal returns the flatbuffers binary encoding of clang.CodeCompleteResults v.
 func (c *CodeCompleteResults) Marshal(v *clang.CodeCompleteResults) *flatbuffers.Builder {
 	if v == nil {
 		return nil
 	}
 
-	builder := flatbuffers.NewBuilder(0)
-	resultsNum := int(v.NumResults())
-	if resultsNum == 0 {
-		return builder
+	return c.marshal(toCompletion(v), nil)
+}
+
+// MarshalItems returns the flatbuffers binary encoding of the items combined with the results of
+// clang.CodeCompleteResults v. The v may be nil to encode only the items.
+// The items are sorted, deduplicated and paginated together with the clang results by the Options.
+// The items are copied, so they are not modified by grouping the overloads.
+func (c *CodeCompleteResults) MarshalItems(v *clang.CodeCompleteResults, items []*CompleteItem) *flatbuffers.Builder {
+	var comp completion
+	if v != nil {
+		comp = toCompletion(v)
 	}
+	comp.items = items
 
-	resultsOffsets := make([]flatbuffers.UOffsetT, resultsNum)
-	for i, res := range v.Results() {
+	return c.marshal(comp, nil)
+}
+
+// completion represents the outcome of clang code completion.
+type completion struct {
+	results []completionResult
+	items   []*CompleteItem // not parsed items of the other sources
+	diags   []*Diagnostic
+	context CompletionContext
+}
+
+// toCompletion converts v to completion.
+func toCompletion(v *clang.CodeCompleteResults) completion {
+	return completion{
+		results: toCompletionResults(v),
+		diags:   toDiagnostics(v),
+		context: CompletionContext(v.Contexts()),
+	}
+}
+
+// toCompletionResults converts the results of v to completionResult.
+func toCompletionResults(v *clang.CodeCompleteResults) []completionResult {
+	results := v.Results()
+	rs := make([]completionResult, len(results))
+	for i, res := range results {
+		rs[i] = completionResult{
+			cursorKind: res.CursorKind(),
+			cs:         res.CompletionString(),
+		}
+	}
+
+	return rs
+}
+
+// toDiagnostics converts the diagnostics of v to Diagnostic.
+func toDiagnostics(v *clang.CodeCompleteResults) []*Diagnostic {
+	n := v.NumDiagnostics()
+	diags := make([]*Diagnostic, 0, n)
+	for i := uint32(0); i < n; i++ {
+		d := v.Diagnostic(i)
+		diags = append(diags, FromDiagnostic(d))
+		d.Dispose()
+	}
+
+	return diags
+}
+
+// marshal returns the flatbuffers binary encoding of comp.
+// If filter is not nil, the parsed items are filtered by filter before sorting.
+func (c *CodeCompleteResults) marshal(comp completion, filter func([]*CompleteItem) []*CompleteItem) *flatbuffers.Builder {
+	items := make([]*CompleteItem, 0, len(comp.results))
+	for _, res := range comp.results {
+		if c.Options.excludes(res) || (c.Options.FilterByContext && !comp.context.Allows(res.cursorKind)) {
+			continue
+		}
 		item := new(CompleteItem)
-		resultsOffsets[i] = item.Marshal(builder, res.CompletionString())
+		item.parse(res, c.Options)
+		if !c.Options.KeepUnavailable && !item.isAvailable() {
+			continue
+		}
+		items = append(items, item)
+	}
+	for _, extra := range comp.items {
+		item := *extra
+		items = append(items, &item)
+	}
+	if filter != nil {
+		items = filter(items)
+	}
+	if !c.Options.NoDedup {
+		items = dedupCompleteItems(items)
+	}
+	if c.Options.GroupOverloads {
+		items = groupOverloads(items)
+	}
+	if !c.Options.NoSort {
+		sortCompleteItems(items)
+	}
+
+	total := len(items)
+	items, truncated := paginate(items, c.Options.Offset, c.Options.MaxResults)
+
+	return serializeCompleteItems(items, truncated, total, limitDiagnostics(comp.diags, c.Options), comp.context)
+}
+
+// serializeCompleteItems serializes the items, diags and context to CodeCompleteResults flatbuffers binary.
+func serializeCompleteItems(items []*CompleteItem, truncated bool, total int, diags []*Diagnostic, context CompletionContext) *flatbuffers.Builder {
+	builder := flatbuffers.NewBuilder(0)
+
+	resultsNum := len(items)
+	resultsOffsets := make([]flatbuffers.UOffsetT, resultsNum)
+	for i, item := range items {
+		resultsOffsets[i] = item.serialize(builder)
 	}
 	symbol.CodeCompleteResultsStartResultsVector(builder, resultsNum)
 	for i := resultsNum - 1; i >= 0; i-- {
@@ -733,8 +3745,25 @@ func (c *CodeCompleteResults) Marshal(v *clang.CodeCompleteResults) *flatbuffers
 	}
 	resultsVecOffset := builder.EndVector(resultsNum)
 
+	var diagsVecOffset flatbuffers.UOffsetT
+	if diagsNum := len(diags); diagsNum > 0 {
+		diagsOffsets := make([]flatbuffers.UOffsetT, diagsNum)
+		for i, d := range diags {
+			diagsOffsets[i] = d.serialize(builder)
+		}
+		symbol.CodeCompleteResultsStartDiagnosticsVector(builder, diagsNum)
+		for i := diagsNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(diagsOffsets[i])
+		}
+		diagsVecOffset = builder.EndVector(diagsNum)
+	}
+
 	symbol.CodeCompleteResultsStart(builder)
 	symbol.CodeCompleteResultsAddResults(builder, resultsVecOffset)
+	symbol.CodeCompleteResultsAddTruncated(builder, boolToByte(truncated))
+	symbol.CodeCompleteResultsAddTotal(builder, uint32(total))
+	symbol.CodeCompleteResultsAddDiagnostics(builder, diagsVecOffset)
+	symbol.CodeCompleteResultsAddContext(builder, uint64(context))
 	builder.Finish(symbol.CodeCompleteResultsEnd(builder))
 
 	return builder
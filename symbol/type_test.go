@@ -0,0 +1,1532 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-clang/v3.9/clang"
+	"github.com/pkg/errors"
+)
+
+// mustSerializeBytes is like serializeBytes, but panics on the error.
+func mustSerializeBytes(f *File) []byte {
+	buf, err := serializeBytes(f)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// roundTrip serializes f and returns the File which parsed from the flatbuffers binary.
+func roundTrip(f *File) *File {
+	buf := f.Serialize()
+	return GetRootAsFile(buf.FinishedBytes(), 0)
+}
+
+// findSymbol returns the symbol of usr in f.
+func findSymbol(f *File, usr string) *Info {
+	id := ToID(usr)
+	for _, sym := range f.Symbols() {
+		if sym.ID() == id {
+			return sym
+		}
+	}
+	return nil
+}
+
+func TestFile_AddCaller(t *testing.T) {
+	const usr = "c:@F@foo"
+	callSite := Location{fileName: "main.c", line: 10, col: 3, offset: 120}
+	def := Location{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: usr}
+
+	type args struct {
+		sym      Location
+		def      Location
+		funcCall bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		lookup      string
+		wantDef     Location
+		wantValid   bool
+		wantCallers int
+	}{
+		{
+			name:        "call only with definition",
+			args:        args{sym: callSite, def: def, funcCall: true},
+			lookup:      usr,
+			wantDef:     def,
+			wantValid:   true,
+			wantCallers: 1,
+		},
+		{
+			name:        "call only without definition",
+			args:        args{sym: Location{fileName: "main.c", line: 11, col: 3, usr: usr}, funcCall: true},
+			lookup:      usr,
+			wantDef:     Location{},
+			wantValid:   false,
+			wantCallers: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("main.c", nil)
+			f.AddCaller(tt.args.sym, tt.args.def, tt.args.funcCall)
+
+			if err := f.Validate(); (err == nil) != tt.wantValid {
+				t.Errorf("Validate() = %v, wantValid %v", err, tt.wantValid)
+			}
+
+			out := roundTrip(f)
+			if err := out.Validate(); (err == nil) != tt.wantValid {
+				t.Errorf("roundtrip: Validate() = %v, wantValid %v", err, tt.wantValid)
+			}
+
+			sym := findSymbol(out, tt.lookup)
+			if sym == nil {
+				t.Fatalf("symbol %q not found", tt.lookup)
+			}
+			if got := len(sym.Decls()); got != 0 {
+				t.Errorf("len(Decls()) = %d, want 0", got)
+			}
+			if got := sym.Def().value(); !reflect.DeepEqual(got, tt.wantDef) {
+				t.Errorf("Def() = %+v, want %+v", got, tt.wantDef)
+			}
+
+			callers := sym.Callers()
+			if len(callers) != tt.wantCallers {
+				t.Fatalf("len(Callers()) = %d, want %d", len(callers), tt.wantCallers)
+			}
+			if got := callers[0].Location().value(); !reflect.DeepEqual(got, tt.args.sym) {
+				t.Errorf("Callers()[0].Location() = %+v, want %+v", got, tt.args.sym)
+			}
+			if got := callers[0].FuncCall(); got != tt.args.funcCall {
+				t.Errorf("Callers()[0].FuncCall() = %v, want %v", got, tt.args.funcCall)
+			}
+		})
+	}
+}
+
+func TestFile_SetRequireKnownSymbolForCaller(t *testing.T) {
+	const usr = "c:@F@foo"
+	def := Location{fileName: "main.c", line: 1, col: 6, usr: usr}
+	callSite := Location{fileName: "main.c", line: 10, col: 3}
+
+	f := NewFile("main.c", nil)
+	f.SetRequireKnownSymbolForCaller(true)
+	err := f.AddCaller(callSite, def, true)
+	if errors.Cause(err) != ErrUnknownSymbol {
+		t.Fatalf("AddCaller() of the unknown symbol error = %v, want the ErrUnknownSymbol cause", err)
+	}
+	if n := len(f.Symbols()); n != 0 {
+		t.Errorf("AddCaller() of the unknown symbol created %d symbols, want 0", n)
+	}
+	if err := f.AddBatch([]SymbolRecord{{Kind: RecordCaller, Location: callSite, Def: def}}); errors.Cause(err) != ErrUnknownSymbol {
+		t.Errorf("AddBatch() of the unknown symbol error = %v, want the ErrUnknownSymbol cause", err)
+	}
+
+	f.AddDefinition(def, def)
+	if err := f.AddCaller(callSite, def, true); err != nil {
+		t.Fatalf("AddCaller() of the known symbol error = %v", err)
+	}
+	if sym := findSymbol(f, usr); sym == nil || len(sym.Callers()) != 1 {
+		t.Errorf("AddCaller() of the known symbol = %+v, want 1 caller", sym)
+	}
+
+	// the default creates the symbol
+	f = NewFile("main.c", nil)
+	if err := f.AddCaller(callSite, def, true); err != nil || findSymbol(f, usr) == nil {
+		t.Errorf("AddCaller() by default = %v, want the created symbol", err)
+	}
+}
+
+func TestFile_Unmarshal(t *testing.T) {
+	decl := Location{fileName: "foo.h", line: 1, col: 5, offset: 4, usr: "c:@F@foo"}
+	def := Location{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: "c:@F@foo"}
+	callSite := Location{fileName: "main.c", line: 10, col: 3, offset: 120}
+
+	f := NewFile("main.c", []string{"-std=c11"})
+	f.AddDefinition(decl, def)
+	f.AddCaller(callSite, def, true)
+
+	out := roundTrip(f)
+	out.Unmarshal()
+
+	if got, want := out.Name(), f.Name(); got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+	if got, want := out.Flags(), f.Flags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Flags() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(out.symbols, f.symbols) {
+		t.Errorf("symbols = %+v, want %+v", out.symbols, f.symbols)
+	}
+	if !reflect.DeepEqual(out.locations, f.locations) {
+		t.Errorf("locations = %+v, want %+v", out.locations, f.locations)
+	}
+}
+
+func TestInfo_DefinitionFile(t *testing.T) {
+	const usr = "c:@S@Foo@F@bar#"
+	decl := Location{fileName: "include/foo.h", line: 3, col: 8, offset: 40, usr: usr}
+	def := Location{fileName: "src/foo.cpp", line: 12, col: 11, offset: 230, usr: usr}
+
+	tests := []struct {
+		name   string
+		def    Location
+		want   FileID
+		wantOk bool
+	}{
+		{
+			name:   "out-of-line definition",
+			def:    def,
+			want:   ToFileID("src/foo.cpp"),
+			wantOk: true,
+		},
+		{
+			name:   "declaration only",
+			def:    Location{},
+			want:   FileID{},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("src/foo.cpp", nil)
+			f.AddDefinition(decl, tt.def)
+
+			for _, file := range []*File{f, roundTrip(f)} {
+				sym := findSymbol(file, usr)
+				got, ok := sym.DefinitionFile()
+				if got != tt.want || ok != tt.wantOk {
+					t.Errorf("DefinitionFile() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOk)
+				}
+				if tt.wantOk && got == decl.FileID() {
+					t.Errorf("DefinitionFile() = %v, same as the declaration file", got)
+				}
+			}
+		})
+	}
+}
+
+func TestFile_Equal(t *testing.T) {
+	decl := Location{fileName: "foo.h", line: 1, col: 5, offset: 4, usr: "c:@F@foo"}
+	def := Location{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: "c:@F@foo"}
+	callSite := Location{fileName: "main.c", line: 10, col: 3, offset: 120}
+
+	newFile := func(def Location) *File {
+		f := NewFile("main.c", []string{"-std=c11"})
+		f.AddDefinition(decl, def)
+		f.AddCaller(callSite, def, true)
+		return f
+	}
+
+	tests := []struct {
+		name string
+		a, b *File
+		want bool
+	}{
+		{name: "same", a: newFile(def), b: newFile(def), want: true},
+		{name: "serialized", a: newFile(def), b: roundTrip(newFile(def)), want: true},
+		{name: "different def", a: newFile(def), b: newFile(Location{fileName: "bar.c", line: 2, col: 5, offset: 20, usr: "c:@F@foo"}), want: false},
+		{name: "different flags", a: newFile(def), b: NewFile("main.c", nil), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_addHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symbol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	abs := filepath.Join(dir, "foo.h")
+	if err := ioutil.WriteFile(abs, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.h")
+	if err := os.Symlink(abs, link); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		canonical bool
+		paths     []string
+		want      int
+	}{
+		{name: "absolute and relative", canonical: true, paths: []string{abs, rel}, want: 1},
+		{name: "absolute and symlink", canonical: true, paths: []string{abs, link}, want: 1},
+		{name: "not canonicalized", canonical: false, paths: []string{abs, rel, link}, want: 3},
+		{name: "same spelling", canonical: false, paths: []string{abs, abs}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("main.c", nil)
+			f.SetCanonicalHeaderPath(tt.canonical)
+			mtime := time.Unix(1500000000, 0)
+			for _, path := range tt.paths {
+				f.addHeader(path, mtime)
+			}
+
+			hdrs := roundTrip(f).Headers()
+			if len(hdrs) != tt.want {
+				t.Fatalf("len(Headers()) = %d, want %d", len(hdrs), tt.want)
+			}
+			if got := hdrs[0].Path(); got != tt.paths[0] {
+				t.Errorf("Headers()[0].Path() = %q, want original spelling %q", got, tt.paths[0])
+			}
+			if got := hdrs[0].Mtime(); got != mtime.Unix() {
+				t.Errorf("Headers()[0].Mtime() = %d, want %d", got, mtime.Unix())
+			}
+		})
+	}
+}
+
+func TestHeader_Exists(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "existing header", path: "/usr/include/stdio.h", want: true},
+		{name: "missing header", path: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("main.c", nil)
+			f.addHeader(tt.path, time.Unix(1500000000, 0))
+			if got := f.headers[0].Exists(); got != tt.want {
+				t.Errorf("Exists() = %v, want %v", got, tt.want)
+			}
+
+			hdrs := roundTrip(f).Headers()
+			if len(hdrs) != 1 {
+				t.Fatalf("len(Headers()) = %d, want 1", len(hdrs))
+			}
+			if got := hdrs[0].Exists(); got != tt.want {
+				t.Errorf("serialized Exists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_addInclude(t *testing.T) {
+	f := NewFile("/src/main.c", nil)
+	f.addHeader("/src/foo.h", time.Unix(1500000000, 0))
+	foo := Location{fileName: "/src/main.c", line: 3, col: 1, offset: 40}
+	f.addInclude("foo.h", foo, "/src/foo.h")
+	f.addInclude("foo.h", foo, "/src/foo.h") // visited again
+	missing := Location{fileName: "/src/main.c", line: 4, col: 1, offset: 56}
+	f.addInclude("missing.h", missing, "")
+
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		incs := f.Includes()
+		if len(incs) != 2 {
+			t.Fatalf("%s: len(Includes()) = %d, want 2", name, len(incs))
+		}
+
+		if got := incs[0].Spelling(); got != "foo.h" {
+			t.Errorf("%s: Spelling() = %q, want %q", name, got, "foo.h")
+		}
+		if got := incs[0].Location().value(); got != foo {
+			t.Errorf("%s: Location() = %+v, want %+v", name, got, foo)
+		}
+		// the FileID of the included file is the FileID of the Header
+		if fid, ok := incs[0].FileID(); !ok || fid != f.Headers()[0].FileID() {
+			t.Errorf("%s: FileID() = %s, %v, want %s", name, fid, ok, f.Headers()[0].FileID())
+		}
+
+		if got := incs[1].Location().value(); got != missing {
+			t.Errorf("%s: missing Location() = %+v, want %+v", name, got, missing)
+		}
+		if fid, ok := incs[1].FileID(); ok {
+			t.Errorf("%s: missing FileID() = %s, want not found", name, fid)
+		}
+	}
+
+	if o := roundTrip(f); !f.Equal(o) {
+		t.Error("Equal() of the round trip = false, want true")
+	}
+	o := NewFile("/src/main.c", nil)
+	o.addHeader("/src/foo.h", time.Unix(1500000000, 0))
+	o.addInclude("foo.h", Location{fileName: "/src/main.c", line: 2, col: 1, offset: 20}, "/src/foo.h")
+	o.addInclude("missing.h", missing, "")
+	if f.Equal(o) {
+		t.Error("Equal() of the moved directive = true, want false")
+	}
+}
+
+func TestInfo_CallersSorted(t *testing.T) {
+	const usr = "c:@F@foo"
+	def := Location{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: usr}
+	callSites := []Location{
+		{fileName: "main.c", line: 20, col: 3, offset: 300},
+		{fileName: "bar.c", line: 7, col: 9, offset: 80},
+		{fileName: "main.c", line: 10, col: 12, offset: 130},
+		{fileName: "main.c", line: 10, col: 3, offset: 121},
+	}
+	want := []Location{callSites[1], callSites[3], callSites[2], callSites[0]}
+
+	f := NewFile("main.c", nil)
+	for _, loc := range callSites {
+		f.AddCaller(loc, def, true)
+	}
+
+	for _, file := range []*File{f, roundTrip(f)} {
+		sym := findSymbol(file, usr)
+
+		got := make([]Location, 0, len(want))
+		for _, caller := range sym.CallersSorted() {
+			got = append(got, caller.Location().value())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("CallersSorted() = %+v, want %+v", got, want)
+		}
+
+		// Callers keeps the insertion order
+		if got := sym.Callers()[0].Location().value(); got != callSites[0] {
+			t.Errorf("Callers()[0] = %+v, want %+v", got, callSites[0])
+		}
+	}
+}
+
+func TestFile_SetReproducible(t *testing.T) {
+	defs := []Location{
+		{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: "c:@F@foo"},
+		{fileName: "bar.c", line: 4, col: 5, offset: 40, usr: "c:@F@bar"},
+	}
+	callSites := []Location{
+		{fileName: "main.c", line: 20, col: 3, offset: 300},
+		{fileName: "bar.c", line: 7, col: 9, offset: 80},
+		{fileName: "main.c", line: 10, col: 3, offset: 121},
+	}
+
+	// index builds the File visiting the call sites in the order of perm
+	index := func(perm []int) []byte {
+		f := NewFile("main.c", nil)
+		f.SetReproducible(true)
+		for _, i := range perm {
+			for _, def := range defs {
+				f.AddCaller(callSites[i], def, true)
+			}
+		}
+		return f.Serialize().FinishedBytes()
+	}
+
+	want := index([]int{0, 1, 2})
+	for _, perm := range [][]int{{2, 1, 0}, {1, 0, 2}, {2, 0, 1}} {
+		if got := index(perm); !reflect.DeepEqual(got, want) {
+			t.Errorf("serialized bytes of the insertion order %v differ", perm)
+		}
+	}
+}
+
+func TestFile_Freeze(t *testing.T) {
+	defs := []Location{
+		{fileName: "foo.c", line: 2, col: 5, offset: 20, usr: "c:@F@foo"},
+		{fileName: "bar.c", line: 4, col: 5, offset: 40, usr: "c:@F@bar"},
+	}
+	callSites := []Location{
+		{fileName: "main.c", line: 20, col: 3, offset: 300},
+		{fileName: "bar.c", line: 7, col: 9, offset: 80},
+		{fileName: "main.c", line: 10, col: 3, offset: 121},
+	}
+
+	// index builds the frozen File visiting the definitions and call sites in the order of perm
+	index := func(perm []int) *File {
+		f := NewFile("main.c", []string{"-DDEBUG"})
+		for _, i := range perm {
+			if i < len(defs) {
+				if err := f.AddDefinition(defs[i], defs[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, def := range defs {
+				if err := f.AddCaller(callSites[i], def, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		f.addHeader("/usr/include/stdio.h", time.Unix(1500000000, 0))
+		f.SetMeta("host", "builder")
+		f.Freeze()
+		return f
+	}
+
+	f := index([]int{0, 1, 2})
+	if !f.Frozen() {
+		t.Fatal("Frozen() = false, want true")
+	}
+	want := f.Serialize().FinishedBytes()
+	if got := f.Serialize().FinishedBytes(); !bytes.Equal(got, want) {
+		t.Error("serialized bytes of the second Serialize differ")
+	}
+	if b, err := f.SerializeChecked(); err != nil || !bytes.Equal(b.FinishedBytes(), want) {
+		t.Errorf("SerializeChecked() = %v, want the same bytes as Serialize", err)
+	}
+	for _, perm := range [][]int{{2, 1, 0}, {1, 0, 2}, {2, 0, 1}} {
+		if got := index(perm).Serialize().FinishedBytes(); !bytes.Equal(got, want) {
+			t.Errorf("serialized bytes of the insertion order %v differ", perm)
+		}
+	}
+
+	loc := Location{fileName: "main.c", line: 30, col: 1, offset: 400, usr: "c:@F@baz"}
+	for name, add := range map[string]func() error{
+		"AddDecl":            func() error { return f.AddDecl(loc) },
+		"AddDefinition":      func() error { return f.AddDefinition(loc, loc) },
+		"AddCaller":          func() error { return f.AddCaller(loc, defs[0], true) },
+		"AddBatch":           func() error { return f.AddBatch([]SymbolRecord{{Kind: RecordDecl, Location: loc}}) },
+		"AddCursor":          func() error { return f.AddCursor(clang.Cursor{}) },
+		"AddHeader":          func() error { return f.AddHeader("baz.h", clang.File{}) },
+		"AddInclude":         func() error { return f.AddInclude("baz.h", loc, clang.File{}) },
+		"AddTranslationUnit": func() error { return f.AddTranslationUnit([]byte("tu")) },
+	} {
+		if err := add(); errors.Cause(err) != ErrFrozen {
+			t.Errorf("%s() of the frozen File error = %v, want the ErrFrozen cause", name, err)
+		}
+	}
+	f.Freeze()
+	if got := f.Serialize().FinishedBytes(); !bytes.Equal(got, want) {
+		t.Error("the failed adds changed the serialized bytes")
+	}
+	if got := roundTrip(f); !got.Equal(f) || got.Symbol(ToID("c:@F@baz")) != nil {
+		t.Error("the round-tripped frozen File is not equal to the original")
+	}
+}
+
+func TestFile_Subclasses(t *testing.T) {
+	const (
+		baseUSR    = "c:@S@Base"
+		derivedUSR = "c:@S@Derived"
+		otherUSR   = "c:@S@Other"
+	)
+
+	f := NewFile("main.cpp", nil)
+	f.AddDecl(Location{fileName: "main.cpp", line: 1, col: 7, offset: 6, usr: baseUSR})
+	f.AddDecl(Location{fileName: "main.cpp", line: 2, col: 7, offset: 20, usr: derivedUSR})
+	f.AddDecl(Location{fileName: "main.cpp", line: 3, col: 7, offset: 50, usr: otherUSR})
+	findSymbol(f, derivedUSR).bases = []string{baseUSR}
+
+	for _, file := range []*File{f, roundTrip(f)} {
+		derived := findSymbol(file, derivedUSR)
+		if got, want := derived.Bases(), []string{baseUSR}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Bases() = %v, want %v", got, want)
+		}
+		if got := findSymbol(file, baseUSR).Bases(); len(got) != 0 {
+			t.Errorf("Bases() of the base class = %v, want empty", got)
+		}
+
+		subs := file.Subclasses(baseUSR)
+		if len(subs) != 1 || subs[0].ID() != ToID(derivedUSR) {
+			t.Errorf("Subclasses(%q) = %v, want [%s]", baseUSR, subs, derivedUSR)
+		}
+		if subs := file.Subclasses(derivedUSR); len(subs) != 0 {
+			t.Errorf("Subclasses(%q) = %v, want empty", derivedUSR, subs)
+		}
+	}
+}
+
+func TestFile_Overriders(t *testing.T) {
+	const (
+		baseUSR    = "c:@S@Base@F@draw#"
+		derivedUSR = "c:@S@Derived@F@draw#"
+	)
+
+	f := NewFile("main.cpp", nil)
+	f.AddDecl(Location{fileName: "main.cpp", line: 2, col: 16, offset: 30, usr: baseUSR})
+	f.AddDecl(Location{fileName: "main.cpp", line: 5, col: 8, offset: 80, usr: derivedUSR})
+	base := findSymbol(f, baseUSR)
+	base.isVirtual = true
+	derived := findSymbol(f, derivedUSR)
+	derived.isVirtual = true
+	derived.overrides = []string{baseUSR}
+
+	for _, file := range []*File{f, roundTrip(f)} {
+		derived := findSymbol(file, derivedUSR)
+		if !derived.IsVirtual() {
+			t.Error("IsVirtual() = false, want true")
+		}
+		if got, want := derived.Overrides(), []string{baseUSR}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Overrides() = %v, want %v", got, want)
+		}
+		if got := findSymbol(file, baseUSR).Overrides(); len(got) != 0 {
+			t.Errorf("Overrides() of the base method = %v, want empty", got)
+		}
+
+		overriders := file.Overriders(baseUSR)
+		if len(overriders) != 1 || overriders[0].ID() != ToID(derivedUSR) {
+			t.Errorf("Overriders(%q) = %v, want [%s]", baseUSR, overriders, derivedUSR)
+		}
+	}
+}
+
+func TestFile_SetMaxTranslationUnitBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int
+		tu          []byte
+		wantTU      []byte
+		wantOmitted bool
+	}{
+		{name: "no limit", max: 0, tu: []byte("CPCH-large"), wantTU: []byte("CPCH-large"), wantOmitted: false},
+		{name: "small", max: 8, tu: []byte("CPCH"), wantTU: []byte("CPCH"), wantOmitted: false},
+		{name: "exact", max: 4, tu: []byte("CPCH"), wantTU: []byte("CPCH"), wantOmitted: false},
+		{name: "oversized", max: 4, tu: []byte("CPCH-large"), wantTU: []byte{}, wantOmitted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("main.c", nil)
+			f.SetMaxTranslationUnitBytes(tt.max)
+			f.AddTranslationUnit(tt.tu)
+
+			for _, file := range []*File{f, roundTrip(f)} {
+				if got := file.TranslationUnit(); len(got) != len(tt.wantTU) || string(got) != string(tt.wantTU) {
+					t.Errorf("TranslationUnit() = %q, want %q", got, tt.wantTU)
+				}
+				if got := file.TranslationUnitOmitted(); got != tt.wantOmitted {
+					t.Errorf("TranslationUnitOmitted() = %v, want %v", got, tt.wantOmitted)
+				}
+			}
+		})
+	}
+}
+
+func TestFile_SetUSRPathRewriter(t *testing.T) {
+	// the USR of the static function embeds the absolute path of the file
+	index := func(root string, rewrite bool) *File {
+		usr := "c:" + root + "/src/util.c@F@helper"
+		f := NewFile(root+"/src/util.c", nil)
+		if rewrite {
+			f.SetUSRPathRewriter(func(usr string) string {
+				return strings.Replace(usr, "c:"+root+"/", "c:", 1)
+			})
+		}
+		decl := Location{fileName: root + "/src/util.c", line: 3, col: 13, offset: 40, usr: usr}
+		f.AddDefinition(decl, decl)
+		f.AddCaller(Location{fileName: root + "/src/util.c", line: 9, col: 5, offset: 120}, decl, true)
+		return roundTrip(f)
+	}
+	ids := func(f *File) []ID {
+		var ids []ID
+		for _, sym := range f.Symbols() {
+			ids = append(ids, sym.ID())
+		}
+		return ids
+	}
+
+	alice, bob := index("/home/alice/project", false), index("/Users/bob/work/project", false)
+	if reflect.DeepEqual(ids(alice), ids(bob)) {
+		t.Fatal("the symbol IDs agree without rewriting")
+	}
+
+	alice, bob = index("/home/alice/project", true), index("/Users/bob/work/project", true)
+	if got, want := ids(alice), ids(bob); !reflect.DeepEqual(got, want) {
+		t.Errorf("symbol IDs = %v and %v, want agreed", got, want)
+	}
+	if got, want := ids(alice), []ID{ToID("c:src/util.c@F@helper")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("symbol IDs = %v, want %v", got, want)
+	}
+	def := alice.Symbols()[0].Def()
+	if got, want := def.USR(), "c:src/util.c@F@helper"; got != want {
+		t.Errorf("Def().USR() = %q, want %q", got, want)
+	}
+}
+
+func TestFile_Reindex(t *testing.T) {
+	const usr = "c:@F@foo"
+	decl := Location{fileName: "foo.h", line: 1, col: 6, offset: 5, usr: usr}
+	callSite := Location{fileName: "main.c", line: 10, col: 3, offset: 120}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(decl)
+
+	// mutate the symbol manually without updating the locations
+	info := findSymbol(f, usr)
+	moved := Location{fileName: "bar.h", line: 3, col: 6, offset: 40, usr: usr}
+	info.decls = append(info.decls, moved)
+	info.callers = append(info.callers, &Caller{location: callSite, funcCall: true})
+	if _, ok := f.locations[moved]; ok {
+		t.Fatal("locations has the manually added declaration before Reindex")
+	}
+
+	for i := 0; i < 2; i++ {
+		f.Reindex()
+		for _, loc := range []Location{decl, moved, callSite} {
+			if id, ok := f.locations[loc]; !ok || id != ToID(usr) {
+				t.Errorf("Reindex #%d: locations[%+v] = %v, %v, want the symbol ID", i, loc, id, ok)
+			}
+		}
+		if n := len(f.locations); n != 3 {
+			t.Errorf("Reindex #%d: len(locations) = %d, want 3", i, n)
+		}
+		if got := findSymbol(f, usr); got != info {
+			t.Errorf("Reindex #%d: the in-memory symbol was replaced", i)
+		}
+	}
+
+	// flatbuffers backed File
+	out := roundTrip(f)
+	out.Reindex()
+	if id, ok := out.locations[callSite]; !ok || id != ToID(usr) {
+		t.Errorf("flatbuffers Reindex: locations[%+v] = %v, %v, want the symbol ID", callSite, id, ok)
+	}
+	if got := out.symbols[ToID(usr)]; got == nil || len(got.decls) != 2 {
+		t.Errorf("flatbuffers Reindex: symbols[%s] = %+v, want 2 declarations", usr, got)
+	}
+}
+
+func TestInfo_DefModTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symbol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defFile := filepath.Join(dir, "foo.c")
+	if err := ioutil.WriteFile(defFile, []byte("int foo(void) { return 0; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1500000000, 0)
+	if err := os.Chtimes(defFile, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFile("main.c", nil)
+	f.AddDefinition(Location{fileName: "foo.h", line: 1, col: 5, usr: "c:@F@foo"}, Location{fileName: defFile, line: 1, col: 5, usr: "c:@F@foo"})
+	f.AddDecl(Location{fileName: "bar.h", line: 1, col: 5, usr: "c:@F@bar"})
+
+	for name, f := range map[string]*File{"in-memory": f, "roundtrip": roundTrip(f)} {
+		if got := findSymbol(f, "c:@F@foo").DefModTime(); !got.Equal(mtime) {
+			t.Errorf("%s: DefModTime() = %v, want %v", name, got, mtime)
+		}
+		if got := findSymbol(f, "c:@F@bar").DefModTime(); !got.IsZero() {
+			t.Errorf("%s: DefModTime() without definition = %v, want zero", name, got)
+		}
+	}
+
+	f = NewFile("main.c", nil)
+	f.SetModTimeFunc(func(filename string) time.Time {
+		if filename != "gen.c" {
+			t.Errorf("SetModTimeFunc called with %q, want gen.c", filename)
+		}
+		return mtime
+	})
+	f.AddDefinition(Location{fileName: "gen.h", line: 1, col: 5, usr: "c:@F@gen"}, Location{fileName: "gen.c", line: 1, col: 5, usr: "c:@F@gen"})
+	if got := findSymbol(f, "c:@F@gen").DefModTime(); !got.Equal(mtime) {
+		t.Errorf("DefModTime() with SetModTimeFunc = %v, want %v", got, mtime)
+	}
+}
+
+func TestInfo_CommentRange(t *testing.T) {
+	comment := Range{
+		start: Location{fileName: "foo.h", line: 1, col: 1, offset: 0},
+		end:   Location{fileName: "foo.h", line: 3, col: 4, offset: 52},
+	}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(Location{fileName: "foo.h", line: 4, col: 5, offset: 57, usr: "c:@F@foo"})
+	f.AddDecl(Location{fileName: "foo.h", line: 6, col: 5, offset: 80, usr: "c:@F@bar"})
+	// foo is documented, such as setCursor records the cursor.CommentRange
+	findSymbol(f, "c:@F@foo").commentRange = comment
+
+	for name, f := range map[string]*File{"in-memory": f, "roundtrip": roundTrip(f)} {
+		got := findSymbol(f, "c:@F@foo").CommentRange()
+		if got.IsEmpty() {
+			t.Errorf("%s: CommentRange() of the documented function is empty", name)
+		}
+		if got.value() != comment {
+			t.Errorf("%s: CommentRange() = %+v, want %+v", name, got.value(), comment)
+		}
+		if got := findSymbol(f, "c:@F@bar").CommentRange(); !got.IsEmpty() {
+			t.Errorf("%s: CommentRange() without comment = %+v, want empty", name, got.value())
+		}
+	}
+}
+
+func TestInfo_ForwardDecls(t *testing.T) {
+	forward := Location{fileName: "foo.h", line: 1, col: 7, offset: 6, usr: "c:@S@Foo", isForward: true}
+	decl := Location{fileName: "foo.h", line: 3, col: 7, offset: 18, usr: "c:@S@Foo"}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(forward)
+	f.AddDecl(decl)
+
+	packed := NewFile("main.c", nil)
+	packed.AddDecl(forward)
+	packed.AddDecl(decl)
+	packed.SetPackedLocations(true)
+
+	for name, f := range map[string]*File{"in-memory": f, "roundtrip": roundTrip(f), "packed": roundTrip(packed)} {
+		decls := findSymbol(f, "c:@S@Foo").Decls()
+		if len(decls) != 2 {
+			t.Fatalf("%s: len(Decls()) = %d, want 2", name, len(decls))
+		}
+		if !decls[0].IsForward() {
+			t.Errorf("%s: IsForward() of the forward declaration = false, want true", name)
+		}
+		if decls[1].IsForward() {
+			t.Errorf("%s: IsForward() of the full declaration = true, want false", name)
+		}
+
+		forwards := findSymbol(f, "c:@S@Foo").ForwardDecls()
+		if len(forwards) != 1 || forwards[0].value() != forward {
+			t.Errorf("%s: ForwardDecls() = %+v, want [%+v]", name, forwards, forward)
+		}
+	}
+}
+
+func TestFile_SetKindFilter(t *testing.T) {
+	fn := Location{fileName: "main.c", line: 1, col: 6, offset: 5, usr: "c:@F@main", kind: clang.Cursor_FunctionDecl}
+	local := Location{fileName: "main.c", line: 2, col: 6, offset: 19, usr: "c:main.c@19@F@main@x", kind: clang.Cursor_VarDecl}
+	ref := Location{fileName: "main.c", line: 3, col: 9, offset: 30, kind: clang.Cursor_DeclRefExpr}
+
+	f := NewFile("main.c", nil)
+	f.SetKindFilter([]clang.CursorKind{clang.Cursor_FunctionDecl})
+	f.AddDefinition(fn, fn)
+	f.AddDecl(local)
+	f.AddCaller(ref, local, false)
+
+	if findSymbol(f, fn.usr) == nil {
+		t.Error("function is not added with the function-only filter")
+	}
+	if findSymbol(f, local.usr) != nil {
+		t.Error("local variable is added with the function-only filter")
+	}
+	if len(f.Symbols()) != 1 {
+		t.Errorf("len(Symbols()) = %d, want 1", len(f.Symbols()))
+	}
+	if got := findSymbol(roundTrip(f), fn.usr).Def(); got.value() != (Location{fileName: "main.c", line: 1, col: 6, offset: 5, usr: "c:@F@main"}) {
+		t.Errorf("Def() = %+v, want the function location without the kind", got.value())
+	}
+
+	f.SetKindFilter(nil)
+	f.AddDecl(local)
+	if findSymbol(f, local.usr) == nil {
+		t.Error("local variable is not added after clearing the filter")
+	}
+}
+
+func TestFile_SetFlattenAnonymous(t *testing.T) {
+	const (
+		outer = "c:@S@Value"
+		union = "c:@S@Value@Ua"
+		field = "c:@S@Value@Ua@FI@i"
+	)
+	newFile := func(flatten bool) *File {
+		f := NewFile("value.h", nil)
+		f.SetFlattenAnonymous(flatten)
+		// struct Value { union { int i; }; };, in the visiting order of clang
+		for _, sym := range []struct {
+			usr             string
+			kind            clang.CursorKind
+			name, parentUSR string
+		}{
+			{usr: outer, kind: clang.Cursor_StructDecl, name: "Value"},
+			{usr: union, kind: clang.Cursor_UnionDecl, parentUSR: outer},
+			{usr: field, kind: clang.Cursor_FieldDecl, name: "i", parentUSR: union},
+		} {
+			usr := sym.usr
+			info := f.addSymbol(usr, sym.kind, Location{fileName: "value.h", line: 1, usr: usr}, Location{})
+			info.name, info.parentUSR = sym.name, sym.parentUSR
+			if f.flattenAnonymous {
+				f.reparentAnonymous(info)
+			}
+		}
+		return f
+	}
+
+	if got := findSymbol(roundTrip(newFile(true)), field).ParentUSR(); got != outer {
+		t.Errorf("ParentUSR() of the anonymous union member = %q, want %q", got, outer)
+	}
+	if got := findSymbol(newFile(true), union).ParentUSR(); got != outer {
+		t.Errorf("ParentUSR() of the anonymous union = %q, want %q", got, outer)
+	}
+	if got := findSymbol(newFile(false), field).ParentUSR(); got != union {
+		t.Errorf("ParentUSR() without flattening = %q, want %q", got, union)
+	}
+}
+
+func TestFile_Parameters(t *testing.T) {
+	const fn = "c:@F@add"
+	f := NewFile("add.c", nil)
+	// int add(int a, int b);, the parameters are added in the reverse order to test the sorting
+	for _, sym := range []struct {
+		usr        string
+		kind       clang.CursorKind
+		name       string
+		paramIndex uint32
+	}{
+		{usr: fn, kind: clang.Cursor_FunctionDecl, name: "add"},
+		{usr: "c:add.c@13@F@add@b", kind: clang.Cursor_ParmDecl, name: "b", paramIndex: 1},
+		{usr: "c:add.c@13@F@add@a", kind: clang.Cursor_ParmDecl, name: "a", paramIndex: 0},
+	} {
+		info := f.addSymbol(sym.usr, sym.kind, Location{fileName: "add.c", line: 1, usr: sym.usr}, Location{})
+		info.name, info.paramIndex = sym.name, sym.paramIndex
+		if sym.kind == clang.Cursor_ParmDecl {
+			info.parentUSR = fn
+		}
+	}
+
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		params := f.Parameters(fn)
+		if len(params) != 2 {
+			t.Fatalf("%s: len(Parameters()) = %d, want 2", name, len(params))
+		}
+		for i, want := range []string{"a", "b"} {
+			if params[i].Name() != want || params[i].ParamIndex() != uint32(i) {
+				t.Errorf("%s: Parameters()[%d] = %q at %d, want %q at %d", name, i, params[i].Name(), params[i].ParamIndex(), want, i)
+			}
+		}
+	}
+	if params := f.Parameters("c:@F@sub"); len(params) != 0 {
+		t.Errorf("Parameters() of the unknown function = %d symbols, want 0", len(params))
+	}
+}
+
+// TestFile_ConcurrentRead should be run with -race, since the lazy hydration races only on the memory.
+func TestFile_ConcurrentRead(t *testing.T) {
+	const (
+		goroutines = 16
+		symbols    = 50
+	)
+	src := NewFile("main.c", []string{"-std=c11"})
+	src.AddBatch(batchRecords(symbols))
+	buf := mustSerializeBytes(src)
+
+	for name, newFile := range map[string]func() *File{
+		"flatbuffers": func() *File { return GetRootAsFile(buf, 0) },
+		"lazy": func() *File {
+			f := GetRootAsFile(buf, 0)
+			f.UnmarshalLazy()
+			return f
+		},
+		"in-memory": func() *File {
+			f := GetRootAsFile(buf, 0)
+			f.Unmarshal()
+			return f
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFile()
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			for g := 0; g < goroutines; g++ {
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < symbols; i++ {
+						// the goroutines start from the different symbols to interleave the lookups and hydration
+						n := (g*7 + i) % symbols
+						usr := fmt.Sprintf("c:@F@func%d", n)
+						if f.Name() != "main.c" || len(f.Flags()) != 1 {
+							t.Errorf("Name() = %q, Flags() = %v", f.Name(), f.Flags())
+						}
+						if sym := f.Symbol(ToID(usr)); sym == nil {
+							t.Errorf("Symbol(%s) = nil", usr)
+						} else if def := sym.Def(); def.Line() != uint32(n+1) {
+							t.Errorf("Symbol(%s).Def().Line() = %d, want %d", usr, def.Line(), n+1)
+						}
+						if sym := f.LookupSymbolAt("main.c", uint32(symbols+n+1), 3); sym == nil || sym.ID() != ToID(usr) {
+							t.Errorf("LookupSymbolAt(main.c:%d:3) = %+v, want %s", symbols+n+1, sym, usr)
+						}
+						if g%4 == 0 {
+							if got := len(f.Symbols()); got != symbols {
+								t.Errorf("len(Symbols()) = %d, want %d", got, symbols)
+							}
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestFile_Meta(t *testing.T) {
+	f := NewFile("main.c", nil)
+	f.SetMeta("compiler", "clang version 3.9.1")
+	f.SetMeta("host", "build-1")
+	f.SetMeta("host", "build-2")
+	f.SetMeta("empty", "")
+	want := map[string]string{"compiler": "clang version 3.9.1", "host": "build-2", "empty": ""}
+
+	unmarshaled := roundTrip(f)
+	unmarshaled.Unmarshal()
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f), "unmarshaled": unmarshaled} {
+		for key, value := range want {
+			if got, ok := f.Meta(key); !ok || got != value {
+				t.Errorf("%s: Meta(%q) = %q, %v, want %q, true", name, key, got, ok, value)
+			}
+		}
+		if got, ok := f.Meta("missing"); ok {
+			t.Errorf("%s: Meta(missing) = %q, true, want not found", name, got)
+		}
+	}
+
+	o := NewFile("main.c", nil)
+	o.SetMeta("compiler", "clang version 3.9.1")
+	if f.Equal(o) {
+		t.Error("Equal() = true for the different metadata")
+	}
+}
+
+func TestFile_SetUnsaved(t *testing.T) {
+	for _, unsaved := range []bool{true, false} {
+		f := NewFile("main.c", nil)
+		f.SetUnsaved(unsaved)
+
+		unmarshaled := roundTrip(f)
+		unmarshaled.Unmarshal()
+		for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f), "unmarshaled": unmarshaled, "reserialized": roundTrip(unmarshaled)} {
+			if got := f.IsUnsaved(); got != unsaved {
+				t.Errorf("%s: IsUnsaved() = %v, want %v", name, got, unsaved)
+			}
+		}
+	}
+
+	f, o := NewFile("main.c", nil), NewFile("main.c", nil)
+	f.SetUnsaved(true)
+	if f.Equal(o) {
+		t.Error("Equal() = true for the different unsaved flag")
+	}
+	if eq, err := FilesEqual(mustSerializeBytes(f), mustSerializeBytes(o)); eq || errors.Cause(err) != ErrFilesDiffer {
+		t.Errorf("FilesEqual() = %t, %v, want the ErrFilesDiffer cause", eq, err)
+	}
+}
+
+func TestFile_AddDiagnostic(t *testing.T) {
+	d := NewDiagnostic(clang.Diagnostic_Warning, "format specifies type 'long' but the argument has type 'int'", Location{fileName: "main.c", line: 9, col: 18})
+	d.option = "-Wformat"
+	d.fixIts = []FixIt{
+		{rng: Range{start: Location{fileName: "main.c", line: 9, col: 11}, end: Location{fileName: "main.c", line: 9, col: 14}}, replacement: "%d"},
+		{rng: Range{start: Location{fileName: "main.c", line: 9, col: 24}, end: Location{fileName: "main.c", line: 9, col: 25}}},
+	}
+	f := NewFile("main.c", nil)
+	for _, d := range []*Diagnostic{d, NewDiagnostic(clang.Diagnostic_Error, "too many errors emitted", Location{})} {
+		if err := f.AddDiagnostic(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unmarshaled := roundTrip(f)
+	unmarshaled.Unmarshal()
+	for name, got := range map[string]*File{"round trip": roundTrip(f), "unmarshaled": unmarshaled, "reserialized": roundTrip(unmarshaled)} {
+		if !got.Equal(f) {
+			t.Errorf("%s: Equal() = false", name)
+		}
+		diags := got.Diagnostics()
+		if len(diags) != 2 {
+			t.Fatalf("%s: len(Diagnostics()) = %d, want 2", name, len(diags))
+		}
+		if opt := diags[0].Option(); opt != "-Wformat" {
+			t.Errorf("%s: Option() = %q, want %q", name, opt, "-Wformat")
+		}
+		fixIts := diags[0].FixIts()
+		if len(fixIts) != 2 {
+			t.Fatalf("%s: len(FixIts()) = %d, want 2", name, len(fixIts))
+		}
+		for i := range fixIts {
+			if fixIts[i].value() != d.fixIts[i].value() {
+				t.Errorf("%s: FixIts()[%d] = %+v, want %+v", name, i, fixIts[i].value(), d.fixIts[i].value())
+			}
+		}
+		if opt, n := diags[1].Option(), len(diags[1].FixIts()); opt != "" || n != 0 {
+			t.Errorf("%s: Option(), len(FixIts()) = %q, %d, want empty", name, opt, n)
+		}
+	}
+
+	o := NewFile("main.c", nil)
+	if f.Equal(o) {
+		t.Error("Equal() = true for the different diagnostics")
+	}
+	if eq, err := FilesEqual(mustSerializeBytes(f), mustSerializeBytes(o)); eq || errors.Cause(err) != ErrFilesDiffer {
+		t.Errorf("FilesEqual() = %t, %v, want the ErrFilesDiffer cause", eq, err)
+	}
+
+	f.Freeze()
+	if err := f.AddDiagnostic(d); errors.Cause(err) != ErrFrozen {
+		t.Errorf("AddDiagnostic() of the frozen File error = %v, want ErrFrozen", err)
+	}
+}
+
+func TestFile_ObjCMethods(t *testing.T) {
+	const iface = "c:objc(cs)Person"
+	f := NewFile("Person.m", nil)
+	// @interface Person - (id)initWithName:(NSString *)name age:(int)age; - (void)greet; @end
+	for _, sym := range []struct {
+		usr      string
+		kind     clang.CursorKind
+		selector string
+		parent   string
+	}{
+		{usr: iface, kind: clang.Cursor_ObjCInterfaceDecl},
+		{usr: "c:objc(cs)Person(im)initWithName:age:", kind: clang.Cursor_ObjCInstanceMethodDecl, selector: "initWithName:age:", parent: iface},
+		{usr: "c:objc(cs)Person(im)greet", kind: clang.Cursor_ObjCInstanceMethodDecl, selector: "greet", parent: iface},
+		{usr: "c:objc(cy)Person@Extra", kind: clang.Cursor_ObjCCategoryDecl},
+		{usr: "c:objc(cs)Person(im)wave", kind: clang.Cursor_ObjCInstanceMethodDecl, selector: "wave", parent: "c:objc(cy)Person@Extra"},
+		{usr: "c:objc(pl)Greeter", kind: clang.Cursor_ObjCProtocolDecl},
+	} {
+		info := f.addSymbol(sym.usr, sym.kind, Location{fileName: "Person.m", line: 1, usr: sym.usr}, Location{})
+		info.name, info.selector, info.parentUSR = sym.selector, sym.selector, sym.parent
+	}
+
+	for name, f := range map[string]*File{"in-memory": f, "round trip": roundTrip(f)} {
+		methods := f.ObjCMethods(iface)
+		if len(methods) != 2 {
+			t.Fatalf("%s: len(ObjCMethods()) = %d, want 2", name, len(methods))
+		}
+		for i, want := range []string{"greet", "initWithName:age:"} {
+			m := methods[i]
+			if m.ObjCSelector() != want || m.ParentUSR() != iface || m.SymbolKind() != SymbolKindObjCInstanceMethod {
+				t.Errorf("%s: ObjCMethods()[%d] = %q of %q (%s), want %q of %q (%s)", name, i, m.ObjCSelector(), m.ParentUSR(), m.SymbolKind(), want, iface, SymbolKindObjCInstanceMethod)
+			}
+		}
+		if methods := f.ObjCMethods("c:objc(cy)Person@Extra"); len(methods) != 1 || methods[0].ObjCSelector() != "wave" {
+			t.Errorf("%s: ObjCMethods() of the category = %d symbols, want the wave method", name, len(methods))
+		}
+		for usr, want := range map[string]SymbolKind{
+			iface:                    SymbolKindObjCInterface,
+			"c:objc(cy)Person@Extra": SymbolKindObjCCategory,
+			"c:objc(pl)Greeter":      SymbolKindObjCProtocol,
+		} {
+			if got := findSymbol(f, usr).SymbolKind(); got != want {
+				t.Errorf("%s: SymbolKind() of %s = %s, want %s", name, usr, got, want)
+			}
+		}
+	}
+}
+
+func TestFile_SpillTranslationUnit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symbol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tu := []byte(strings.Repeat("translation unit ", 1024))
+	f := NewFile("main.c", nil)
+	f.AddTranslationUnit(tu)
+
+	if err := f.SpillTranslationUnit(dir); err != nil {
+		t.Fatal(err)
+	}
+	if f.translationUnit != nil {
+		t.Errorf("in-memory translation unit is kept after spilling: %d bytes", len(f.translationUnit))
+	}
+	if got := f.TranslationUnit(); string(got) != string(tu) {
+		t.Errorf("TranslationUnit() after spilling = %d bytes, want %d bytes", len(got), len(tu))
+	}
+	if got := roundTrip(f).TranslationUnit(); string(got) != string(tu) {
+		t.Errorf("roundtrip: TranslationUnit() = %d bytes, want %d bytes", len(got), len(tu))
+	}
+
+	spilled := f.tuPath
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(spilled); !os.IsNotExist(err) {
+		t.Errorf("spilled file %s exists after Close: %v", spilled, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+
+	// the oversized translation unit replaces the spilled one
+	f = NewFile("main.c", nil)
+	f.AddTranslationUnit(tu)
+	if err := f.SpillTranslationUnit(dir); err != nil {
+		t.Fatal(err)
+	}
+	spilled = f.tuPath
+	f.SetMaxTranslationUnitBytes(len(tu) - 1)
+	if err := f.AddTranslationUnit(tu); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(spilled); !os.IsNotExist(err) {
+		t.Errorf("spilled file %s exists after adding the oversized translation unit: %v", spilled, err)
+	}
+	if got := f.TranslationUnit(); len(got) != 0 || !f.TranslationUnitOmitted() {
+		t.Errorf("TranslationUnit(), TranslationUnitOmitted() = %d bytes, %v, want empty, true", len(got), f.TranslationUnitOmitted())
+	}
+}
+
+func TestLocation_Bytes(t *testing.T) {
+	loc := Location{fileName: "/src/foo.h", line: 3, col: 5, offset: 24, usr: "c:@F@foo"}
+	f := NewFile("main.c", []string{"-I/src", "-std=c11"})
+	f.AddDecl(loc)
+
+	for name, f := range map[string]*File{"in-memory": f, "roundtrip": roundTrip(f)} {
+		if got := string(f.NameBytes()); got != f.Name() {
+			t.Errorf("%s: NameBytes() = %q, want %q", name, got, f.Name())
+		}
+		flags := f.FlagsBytes()
+		for i, flag := range f.Flags() {
+			if string(flags[i]) != flag {
+				t.Errorf("%s: FlagsBytes()[%d] = %q, want %q", name, i, flags[i], flag)
+			}
+		}
+
+		decl := findSymbol(f, "c:@F@foo").Decls()[0]
+		if got := string(decl.FileNameBytes()); got != loc.fileName {
+			t.Errorf("%s: FileNameBytes() = %q, want %q", name, got, loc.fileName)
+		}
+		if got := string(decl.USRBytes()); got != loc.usr {
+			t.Errorf("%s: USRBytes() = %q, want %q", name, got, loc.usr)
+		}
+		if got := ToIDBytes(decl.USRBytes()); got != ToID(loc.usr) {
+			t.Errorf("%s: ToIDBytes(USRBytes()) = %s, want %s", name, got, ToID(loc.usr))
+		}
+	}
+}
+
+func BenchmarkLocation_USR(b *testing.B) {
+	const (
+		n   = 100000
+		usr = "c:@N@std@S@vector>#T#T@F@push_back#&1t0.0#"
+	)
+	f := NewFile("main.c", nil)
+	for i := 0; i < n; i++ {
+		f.AddDecl(Location{fileName: "/src/vector.h", line: uint32(i + 1), col: 5, usr: usr})
+	}
+	decls := findSymbol(roundTrip(f), usr).Decls()
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range decls {
+				_ = ToID(decls[j].USR())
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range decls {
+				_ = ToIDBytes(decls[j].USRBytes())
+			}
+		}
+	})
+}
+
+func TestLocation_IsZero(t *testing.T) {
+	tests := []struct {
+		name string
+		loc  Location
+		want bool
+	}{
+		{"zero", Location{}, true},
+		{"fileName", Location{fileName: "foo.h"}, false},
+		{"line", Location{line: 1}, false},
+		{"col", Location{col: 1}, false},
+		{"offset", Location{offset: 1}, false},
+		{"usr", Location{usr: "c:@F@foo"}, false},
+		{"isForward", Location{isForward: true}, false},
+		{"location", Location{location: new(SymbolLocation)}, false},
+		{"all", Location{fileName: "foo.h", line: 1, col: 1, offset: 1, usr: "c:@F@foo", isForward: true}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.loc.IsZero(); got != tt.want {
+			t.Errorf("%s: IsZero() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	f := NewFile("main.c", nil)
+	f.AddDecl(Location{fileName: "foo.h", line: 1, col: 6, usr: "c:@F@foo"})
+	if def := findSymbol(roundTrip(f), "c:@F@foo").Def(); !def.IsZero() {
+		t.Errorf("Def() without definition = %+v, want zero", def)
+	}
+}
+
+func BenchmarkLocation_IsZero(b *testing.B) {
+	loc := Location{fileName: "foo.h", line: 1, col: 6, offset: 5, usr: "c:@F@foo"}
+
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = reflect.DeepEqual(loc, Location{})
+		}
+	})
+	b.Run("IsZero", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = loc.IsZero()
+		}
+	})
+}
+
+func TestFile_Symbols_Aliasing(t *testing.T) {
+	f := NewFile("main.c", nil)
+	for i := 0; i < 3; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		for j := 0; j < 2; j++ {
+			f.AddDecl(Location{fileName: "main.c", line: uint32(10*i + j + 1), usr: usr})
+			f.AddCaller(Location{fileName: "main.c", line: uint32(100*i + j + 50)}, Location{usr: usr}, j == 0)
+		}
+	}
+	rf := roundTrip(f)
+
+	// the returned elements must not share the table, even after the other elements are decoded
+	symbols := rf.Symbols()
+	ids := make(map[ID]bool)
+	lines := make(map[uint32]bool)
+	for _, sym := range symbols {
+		ids[sym.ID()] = true
+		decls, callers := sym.Decls(), sym.Callers()
+		for i := range decls {
+			lines[decls[i].Line()] = true
+		}
+		for _, caller := range callers {
+			loc := caller.Location()
+			lines[loc.Line()] = true
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("Symbols() returned %d distinct IDs, want 3", len(ids))
+	}
+	if len(lines) != 12 {
+		t.Errorf("Decls() and Callers() returned %d distinct lines, want 12", len(lines))
+	}
+	for _, sym := range symbols {
+		if want := f.symbols[sym.ID()]; want == nil || !sym.equal(want) {
+			t.Errorf("symbol %s differs from the original after decoding the others", sym.ID())
+		}
+	}
+}
+
+func TestFile_UnmarshalLazy(t *testing.T) {
+	src := NewFile("main.c", []string{"-std=c11"})
+	src.SetReproducible(true)
+	for i := 0; i < 20; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		loc := Location{fileName: "main.c", line: uint32(i + 1), col: 6, usr: usr}
+		src.AddDefinition(loc, loc)
+		src.AddCaller(Location{fileName: "main.c", line: uint32(i + 100), col: 3}, loc, true)
+	}
+	src.addHeader("/src/main.h", time.Unix(1500000000, 0))
+	buf := mustSerializeBytes(src)
+
+	newFiles := func() (eager, lazy *File) {
+		eager, lazy = GetRootAsFile(buf, 0), GetRootAsFile(buf, 0)
+		eager.Unmarshal()
+		lazy.UnmarshalLazy()
+		eager.SetReproducible(true)
+		lazy.SetReproducible(true)
+		return eager, lazy
+	}
+
+	t.Run("lookup", func(t *testing.T) {
+		eager, lazy := newFiles()
+		for _, usr := range []string{"c:@F@func0", "c:@F@func7", "c:@F@func19", "c:@F@missing"} {
+			want, got := eager.Symbol(ToID(usr)), lazy.Symbol(ToID(usr))
+			if (want == nil) != (got == nil) || (want != nil && !got.equal(want)) {
+				t.Errorf("Symbol(%s) = %+v, want %+v", usr, got, want)
+			}
+		}
+		if len(lazy.symbols) != 3 {
+			t.Errorf("lazy File hydrated %d symbols, want only the 3 looked up", len(lazy.symbols))
+		}
+		if got := lazy.Symbol(ToID("c:@F@func7")); got != lazy.Symbol(ToID("c:@F@func7")) {
+			t.Error("Symbol() returned the different pointers for the same symbol")
+		}
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		eager, lazy := newFiles()
+		sym := lazy.Symbol(ToID("c:@F@func3"))
+		if !lazy.Equal(eager) {
+			t.Error("lazy File is not equal to the eager File")
+		}
+		if len(lazy.Symbols()) != 20 || lazy.Symbol(ToID("c:@F@func3")) != sym {
+			t.Error("hydration lost the symbols or the looked up pointer")
+		}
+		if !bytes.Equal(mustSerializeBytes(lazy), mustSerializeBytes(eager)) {
+			t.Error("Serialize of the lazy File differs from the eager File")
+		}
+	})
+
+	t.Run("mutation", func(t *testing.T) {
+		eager, lazy := newFiles()
+		lazy.Symbol(ToID("c:@F@func3"))
+		for _, f := range []*File{eager, lazy} {
+			f.AddDecl(Location{fileName: "main.h", line: 1, col: 6, usr: "c:@F@func3"})
+		}
+		if !lazy.Equal(eager) {
+			t.Error("lazy File is not equal to the eager File after the mutation")
+		}
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		f := NewFile("main.c", nil)
+		for i := 0; i < 20; i++ {
+			f.AddDecl(Location{fileName: "main.c", line: uint32(i + 1), usr: fmt.Sprintf("c:@F@func%d", i)})
+		}
+		lazy := GetRootAsFile(mustSerializeBytes(f), 0)
+		lazy.UnmarshalLazy()
+		for i := 0; i < 20; i++ {
+			if lazy.Symbol(ToID(fmt.Sprintf("c:@F@func%d", i))) == nil {
+				t.Errorf("Symbol(func%d) of the unsorted File = nil", i)
+			}
+		}
+	})
+}
+
+func BenchmarkFile_UnmarshalLazy(b *testing.B) {
+	const n = 50000
+	f := NewFile("main.c", nil)
+	f.SetReproducible(true)
+	for i := 0; i < n; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		loc := Location{fileName: "main.c", line: uint32(i + 1), col: 6, usr: usr}
+		f.AddDefinition(loc, loc)
+	}
+	buf := mustSerializeBytes(f)
+	id := ToID("c:@F@func12345")
+
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f := GetRootAsFile(buf, 0)
+			f.Unmarshal()
+			f.Symbol(id)
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f := GetRootAsFile(buf, 0)
+			f.UnmarshalLazy()
+			f.Symbol(id)
+		}
+	})
+}
+
+// batchRecords returns the n records of the definitions and the callers of them.
+func batchRecords(n int) []SymbolRecord {
+	records := make([]SymbolRecord, 0, 3*n)
+	for i := 0; i < n; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		loc := Location{fileName: "main.c", line: uint32(i + 1), col: 6, usr: usr}
+		records = append(records,
+			SymbolRecord{Kind: RecordDecl, Location: Location{fileName: "main.h", line: uint32(i + 1), col: 6, usr: usr}},
+			SymbolRecord{Kind: RecordDefinition, Location: loc, Def: loc},
+			SymbolRecord{Kind: RecordCaller, Location: Location{fileName: "main.c", line: uint32(n + i + 1), col: 3}, Def: loc, FuncCall: true},
+		)
+	}
+	return records
+}
+
+// addRecords adds records into f by the individual calls.
+func addRecords(f *File, records []SymbolRecord) {
+	for _, r := range records {
+		switch r.Kind {
+		case RecordDecl:
+			f.AddDecl(r.Location)
+		case RecordDefinition:
+			f.AddDefinition(r.Location, r.Def)
+		case RecordCaller:
+			f.AddCaller(r.Location, r.Def, r.FuncCall)
+		}
+	}
+}
+
+func TestFile_AddBatch(t *testing.T) {
+	records := batchRecords(100)
+
+	single := NewFile("main.c", nil)
+	addRecords(single, records)
+	batch := NewFile("main.c", nil)
+	batch.AddBatch(records[:150])
+	batch.AddBatch(records[150:])
+
+	if !batch.Equal(single) {
+		t.Error("AddBatch File is not equal to the individually added File")
+	}
+	if !roundTrip(batch).Equal(roundTrip(single)) {
+		t.Error("serialized AddBatch File is not equal to the individually added File")
+	}
+
+	// Grow keeps the existing symbols
+	batch.Grow(1000)
+	if !batch.Equal(single) {
+		t.Error("Grow changed the File")
+	}
+}
+
+func BenchmarkFile_AddBatch(b *testing.B) {
+	records := batchRecords(100000 / 3)
+
+	b.Run("single", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			addRecords(NewFile("main.c", nil), records)
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewFile("main.c", nil).AddBatch(records)
+		}
+	})
+}
+
+func BenchmarkFile_Symbols(b *testing.B) {
+	const n = 50000
+	f := NewFile("main.c", nil)
+	for i := 0; i < n; i++ {
+		usr := fmt.Sprintf("c:@F@func%d", i)
+		loc := Location{fileName: "main.c", line: uint32(i + 1), col: 6, usr: usr}
+		f.AddDefinition(loc, loc)
+		f.AddCaller(Location{fileName: "main.c", line: uint32(i + 2), col: 3}, loc, true)
+	}
+	f = roundTrip(f)
+
+	b.Run("Symbols", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, sym := range f.Symbols() {
+				sym.Decls()
+				sym.Callers()
+			}
+		}
+	})
+	b.Run("AcquireSymbols", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			symbols := f.AcquireSymbols()
+			for _, sym := range symbols.Symbols {
+				sym.Decls()
+				callers := sym.AcquireCallers()
+				callers.Release()
+			}
+			symbols.Release()
+		}
+	})
+}
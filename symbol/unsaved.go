@@ -0,0 +1,154 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"github.com/go-clang/v3.9/clang"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/zchee/clang-server/internal/hashutil"
+	"github.com/zchee/clang-server/internal/symbol"
+)
+
+// UnsavedFile represents a contents of the file which not yet saved by the editor.
+//
+//  table UnsavedFile {
+//    Name: string (required); // -> []byte
+//    Contents: [ubyte];
+//    Version: long;
+//  }
+type UnsavedFile struct {
+	// Name filename of the unsaved file.
+	Name string
+	// Contents unsaved contents of the file.
+	Contents []byte
+	// Version editor version of the contents.
+	Version int
+}
+
+// ToClang converts u to clang.UnsavedFile.
+func (u UnsavedFile) ToClang() clang.UnsavedFile {
+	return clang.NewUnsavedFile(u.Name, string(u.Contents))
+}
+
+// Hash returns the hash of the contents, which used as the CompletionKey contents.
+// It does not depend on the Name and Version.
+func (u UnsavedFile) Hash() [hashutil.Size]byte {
+	return hashutil.NewHash(u.Contents)
+}
+
+// serialize serializes the u data to flatbuffers.UOffsetT.
+func (u UnsavedFile) serialize(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	name := builder.CreateString(u.Name)
+	var contents flatbuffers.UOffsetT
+	if len(u.Contents) > 0 {
+		contents = builder.CreateByteVector(u.Contents)
+	}
+
+	symbol.UnsavedFileStart(builder)
+	symbol.UnsavedFileAddName(builder, name)
+	symbol.UnsavedFileAddContents(builder, contents)
+	symbol.UnsavedFileAddVersion(builder, int64(u.Version))
+
+	return symbol.UnsavedFileEnd(builder)
+}
+
+// UnsavedFiles represents a list of UnsavedFile.
+type UnsavedFiles []UnsavedFile
+
+// ToClang converts files to the clang.UnsavedFile slice, which passed to the clang parse and completion.
+func (files UnsavedFiles) ToClang() []clang.UnsavedFile {
+	if len(files) == 0 {
+		return nil
+	}
+
+	ufs := make([]clang.UnsavedFile, len(files))
+	for i, u := range files {
+		ufs[i] = u.ToClang()
+	}
+
+	return ufs
+}
+
+// ----------------------------------------------------------------------------
+
+// CompletionRequest represents a request of the completion with the unsaved files.
+//
+//  table CompletionRequest {
+//    Location: Location (required);
+//    UnsavedFiles: [UnsavedFile];
+//  }
+type CompletionRequest struct {
+	completionRequest *symbol.CompletionRequest
+}
+
+// SymbolCompletionRequest type alias of symbol.CompletionRequest.
+type SymbolCompletionRequest = symbol.CompletionRequest
+
+// CreateCompletionRequest creates the completion request data of the location and files using flatbuffers binary.
+// The location is same as CreateLocation.
+func CreateCompletionRequest(filename string, line, col uint32, files UnsavedFiles) *flatbuffers.Builder {
+	builder := flatbuffers.NewBuilder(0)
+
+	loc := createLocation(builder, filename, line, fromColumnBase(col))
+
+	var filesVecOffset flatbuffers.UOffsetT
+	if filesNum := len(files); filesNum > 0 {
+		filesOffsets := make([]flatbuffers.UOffsetT, filesNum)
+		for i, u := range files {
+			filesOffsets[i] = u.serialize(builder)
+		}
+		symbol.CompletionRequestStartUnsavedFilesVector(builder, filesNum)
+		for i := filesNum - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(filesOffsets[i])
+		}
+		filesVecOffset = builder.EndVector(filesNum)
+	}
+
+	symbol.CompletionRequestStart(builder)
+	symbol.CompletionRequestAddLocation(builder, loc)
+	symbol.CompletionRequestAddUnsavedFiles(builder, filesVecOffset)
+	builder.Finish(symbol.CompletionRequestEnd(builder))
+
+	return builder
+}
+
+// GetRootAsCompletionRequest gets the root of CompletionRequest flatbuffers binary.
+func GetRootAsCompletionRequest(buf []byte, offset flatbuffers.UOffsetT) *CompletionRequest {
+	return &CompletionRequest{
+		completionRequest: symbol.GetRootAsCompletionRequest(buf, offset),
+	}
+}
+
+// Location return the location of the completion.
+func (r *CompletionRequest) Location() Location {
+	loc := r.completionRequest.Location(nil)
+	if loc == nil {
+		return Location{}
+	}
+	return Location{location: loc}
+}
+
+// UnsavedFiles return the unsaved files of the request.
+// The contents are copied from the flatbuffers binary.
+func (r *CompletionRequest) UnsavedFiles() UnsavedFiles {
+	n := r.completionRequest.UnsavedFilesLength()
+	if n == 0 {
+		return nil
+	}
+
+	files := make(UnsavedFiles, n)
+	obj := new(symbol.UnsavedFile)
+	for i := 0; i < n; i++ {
+		if r.completionRequest.UnsavedFiles(obj, i) {
+			files[i] = UnsavedFile{
+				Name:     string(obj.Name()),
+				Contents: append([]byte(nil), obj.Contents()...),
+				Version:  int(obj.Version()),
+			}
+		}
+	}
+
+	return files
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import "testing"
+
+func TestUnsavedFiles_ToClang(t *testing.T) {
+	files := UnsavedFiles{
+		{Name: "main.c", Contents: []byte("int main() {}\n"), Version: 3},
+		{Name: "foo.h", Contents: []byte("void foo(void);\n"), Version: 1},
+	}
+
+	ufs := files.ToClang()
+	if len(ufs) != len(files) {
+		t.Fatalf("len(ToClang()) = %d, want %d", len(ufs), len(files))
+	}
+	for i, uf := range ufs {
+		if uf.Filename() != files[i].Name {
+			t.Errorf("ToClang()[%d].Filename() = %q, want %q", i, uf.Filename(), files[i].Name)
+		}
+		if uf.Contents() != string(files[i].Contents) {
+			t.Errorf("ToClang()[%d].Contents() = %q, want %q", i, uf.Contents(), files[i].Contents)
+		}
+		if uf.Length() != uint64(len(files[i].Contents)) {
+			t.Errorf("ToClang()[%d].Length() = %d, want %d", i, uf.Length(), len(files[i].Contents))
+		}
+	}
+
+	if ufs := UnsavedFiles(nil).ToClang(); ufs != nil {
+		t.Errorf("ToClang() of no files = %v, want nil", ufs)
+	}
+}
+
+func TestUnsavedFile_Hash(t *testing.T) {
+	u := UnsavedFile{Name: "main.c", Contents: []byte("int main() {}\n"), Version: 3}
+
+	// the hash is stable across the versions and copies of the contents
+	same := UnsavedFile{Name: "main.c", Contents: append([]byte(nil), u.Contents...), Version: 4}
+	if u.Hash() != same.Hash() {
+		t.Error("Hash() differs for the same contents")
+	}
+	edited := UnsavedFile{Name: "main.c", Contents: []byte("int main() {}\r\n"), Version: 4}
+	if u.Hash() == edited.Hash() {
+		t.Error("Hash() is same for the edited contents")
+	}
+
+	key := NewCompletionKey(u.Name, 1, 13, u.Contents, nil)
+	if key.Contents != u.Hash() {
+		t.Error("Hash() differs from the CompletionKey contents hash")
+	}
+}
+
+func TestCreateCompletionRequest(t *testing.T) {
+	files := UnsavedFiles{
+		{Name: "main.c", Contents: []byte("int main() { fo\n"), Version: 7},
+		{Name: "empty.h", Version: 1},
+	}
+
+	buf := CreateCompletionRequest("main.c", 1, 16, files).FinishedBytes()
+	req := GetRootAsCompletionRequest(buf, 0)
+
+	loc := req.Location()
+	if got, want := loc.value(), (Location{fileName: "main.c", line: 1, col: 16}); got != want {
+		t.Errorf("Location() = %+v, want %+v", got, want)
+	}
+	got := req.UnsavedFiles()
+	if len(got) != len(files) {
+		t.Fatalf("len(UnsavedFiles()) = %d, want %d", len(got), len(files))
+	}
+	for i := range files {
+		if got[i].Name != files[i].Name || got[i].Version != files[i].Version || string(got[i].Contents) != string(files[i].Contents) {
+			t.Errorf("UnsavedFiles()[%d] = %+v, want %+v", i, got[i], files[i])
+		}
+	}
+
+	req = GetRootAsCompletionRequest(CreateCompletionRequest("main.c", 1, 16, nil).FinishedBytes(), 0)
+	if got := req.UnsavedFiles(); got != nil {
+		t.Errorf("UnsavedFiles() without files = %+v, want nil", got)
+	}
+}
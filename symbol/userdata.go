@@ -0,0 +1,91 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxUserDataLength default maximum number of bytes of the encoded CompleteItem user data.
+const DefaultMaxUserDataLength = 1024
+
+// UserData represents the payload of CompleteItem which is used by the client after the completion is accepted,
+// such as inserting the missing #include or expanding the full snippet.
+type UserData struct {
+	// USR USR of the completed symbol.
+	USR string
+	// Header path of the header file which provides the completed symbol.
+	Header string
+	// Placeholder full placeholder string of the completed symbol, same as CompleteItem.Snippet.
+	Placeholder string
+}
+
+// encode encodes u to the compact string.
+//
+// Each field is written in order as the decimal byte length, a colon and the field text:
+//  9:c:@F@open17:/usr/include/io.h10:open(${1})
+func (u UserData) encode() string {
+	var buf []byte
+	for _, s := range []string{u.USR, u.Header, u.Placeholder} {
+		buf = strconv.AppendInt(buf, int64(len(s)), 10)
+		buf = append(buf, ':')
+		buf = append(buf, s...)
+	}
+
+	return string(buf)
+}
+
+// DecodeUserData decodes the CompleteItem.UserData s.
+func DecodeUserData(s string) (UserData, error) {
+	if s == "" {
+		return UserData{}, errors.New("empty user data")
+	}
+
+	var fields [3]string
+	for i := range fields {
+		sep := strings.IndexByte(s, ':')
+		if sep < 0 {
+			return UserData{}, errors.Errorf("missing length of field %d", i)
+		}
+		n, err := strconv.Atoi(s[:sep])
+		if err != nil || n < 0 {
+			return UserData{}, errors.Errorf("invalid length of field %d: %q", i, s[:sep])
+		}
+		s = s[sep+1:]
+		if n > len(s) {
+			return UserData{}, errors.Errorf("field %d is truncated: want %d bytes, have %d", i, n, len(s))
+		}
+		fields[i], s = s[:n], s[n:]
+	}
+	if s != "" {
+		return UserData{}, errors.Errorf("trailing %d bytes", len(s))
+	}
+
+	return UserData{USR: fields[0], Header: fields[1], Placeholder: fields[2]}, nil
+}
+
+// buildUserData returns the encoded user data of c, which is at most max bytes.
+// The placeholder is dropped first if the payload is too large, and the empty string is returned if it still exceeds.
+func (c *CompleteItem) buildUserData(opts CompleteOptions) string {
+	var u UserData
+	if opts.SymbolFunc != nil {
+		u.USR, u.Header = opts.SymbolFunc(c.word, c.cursorKind)
+	}
+	u.Placeholder = c.snippet
+
+	max := opts.maxUserDataLength()
+	if s := u.encode(); len(s) <= max {
+		return s
+	}
+	u.Placeholder = ""
+	if s := u.encode(); len(s) <= max {
+		return s
+	}
+
+	return ""
+}
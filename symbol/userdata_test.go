@@ -0,0 +1,106 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"testing"
+
+	"github.com/go-clang/v3.9/clang"
+)
+
+func TestCodeCompleteResults_Marshal_UserData(t *testing.T) {
+	symbols := func(word string, kind clang.CursorKind) (string, string) {
+		if kind != clang.Cursor_FunctionDecl {
+			t.Errorf("SymbolFunc kind = %v, want %v", kind, clang.Cursor_FunctionDecl)
+		}
+		return "c:@F@" + word, "/usr/include/" + word + ".h"
+	}
+	results := []completionResult{fakeFunction("open", "int", 50)}
+
+	tests := []struct {
+		name string
+		opts CompleteOptions
+		want UserData
+	}{
+		{
+			name: "with symbol",
+			opts: CompleteOptions{UserData: true, SymbolFunc: symbols, Snippet: SnippetLSP},
+			want: UserData{USR: "c:@F@open", Header: "/usr/include/open.h", Placeholder: "open()$0"},
+		},
+		{
+			name: "placeholder only",
+			opts: CompleteOptions{UserData: true},
+			want: UserData{Placeholder: "open()"},
+		},
+		{
+			name: "placeholder dropped by size cap",
+			opts: CompleteOptions{UserData: true, SymbolFunc: symbols, MaxUserDataLength: 35},
+			want: UserData{USR: "c:@F@open", Header: "/usr/include/open.h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := marshalResults(tt.opts, results).Results()
+			if len(items) != 1 {
+				t.Fatalf("len(Results()) = %d, want 1", len(items))
+			}
+			ud := items[0].UserData()
+			if max := tt.opts.maxUserDataLength(); len(ud) > max {
+				t.Errorf("len(UserData()) = %d, want at most %d", len(ud), max)
+			}
+			got, err := DecodeUserData(ud)
+			if err != nil {
+				t.Fatalf("DecodeUserData(%q): %v", ud, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeUserData(%q) = %+v, want %+v", ud, got, tt.want)
+			}
+			if lsp := items[0].ToLSP(); lsp.Data != ud {
+				t.Errorf("ToLSP().Data = %q, want %q", lsp.Data, ud)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		items := marshalResults(CompleteOptions{SymbolFunc: symbols}, results).Results()
+		if got := items[0].UserData(); got != "" {
+			t.Errorf("UserData() = %q, want empty", got)
+		}
+	})
+
+	t.Run("exceeds size cap", func(t *testing.T) {
+		opts := CompleteOptions{UserData: true, SymbolFunc: symbols, MaxUserDataLength: 8}
+		items := marshalResults(opts, results).Results()
+		if got := items[0].UserData(); got != "" {
+			t.Errorf("UserData() = %q, want empty", got)
+		}
+	})
+}
+
+func TestDecodeUserData(t *testing.T) {
+	want := UserData{USR: "c:@F@open", Header: "/usr/include/io.h", Placeholder: "open(${1:const char *path})$0"}
+	got, err := DecodeUserData(want.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("DecodeUserData() = %+v, want %+v", got, want)
+	}
+
+	for _, s := range []string{
+		"",
+		"9:c:@F@open",
+		"9:c:@F@open0:",
+		"x:foo0:0:",
+		"-1:0:0:",
+		"20:c:@F@open0:0:",
+		"0:0:0:trailing",
+		"0:0:",
+	} {
+		if _, err := DecodeUserData(s); err == nil {
+			t.Errorf("DecodeUserData(%q) returns no error", s)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ContentProvider returns the contents of the file, such as the unsaved buffer of the editor or the file on disk.
+type ContentProvider func(fileName string) ([]byte, error)
+
+// XrefItem represents the Emacs xref item, which the elisp backend converts to xref-make and
+// xref-make-file-location.
+type XrefItem struct {
+	File    string `json:"file"`
+	Line    uint32 `json:"line"`   // 1-based line
+	Column  uint32 `json:"column"` // 0-based column in characters, as Emacs counts
+	Summary string `json:"summary"`
+}
+
+// ToXref returns the xref items of locs, which are deduplicated and ordered as same as QuickfixItems.
+//
+// If content is not nil, the summary is the source line text without the surrounding whitespace,
+// and the column is counted in characters of the line. Otherwise, or if the file contents are unavailable,
+// the summary is the summaries of the location, and the column is the 0-based byte column.
+// The summaries may be nil, and the location is looked up by the file name, line and column.
+func ToXref(locs []Location, summaries map[Location]string, content ContentProvider) []XrefItem {
+	bySummary := make(map[locationKey]string, len(summaries))
+	for loc, summary := range summaries {
+		bySummary[keyOf(loc)] = summary
+	}
+
+	lines := make(map[string]*LineIndex) // nil if the contents are unavailable
+	locs = dedupLocations(locs)
+	items := make([]XrefItem, len(locs))
+	for i, loc := range locs {
+		item := XrefItem{
+			File:    loc.FileName(),
+			Line:    loc.Line(),
+			Summary: bySummary[keyOf(loc)],
+		}
+		if col := loc.rawCol(); col > 0 {
+			item.Column = col - 1
+		}
+
+		if content != nil {
+			idx, ok := lines[item.File]
+			if !ok {
+				if buf, err := content(item.File); err == nil {
+					idx = NewLineIndex(buf)
+				}
+				lines[item.File] = idx
+			}
+			if idx != nil {
+				if line := idx.Line(item.Line); line != nil {
+					n := int(item.Column)
+					if n > len(line) {
+						n = len(line)
+					}
+					item.Summary = string(bytes.TrimSpace(line))
+					item.Column = uint32(utf8.RuneCount(line[:n]))
+				}
+			}
+		}
+
+		items[i] = item
+	}
+
+	return items
+}
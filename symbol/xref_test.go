@@ -0,0 +1,68 @@
+// Copyright 2017 The clang-server Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToXref(t *testing.T) {
+	contents := map[string]string{
+		"/src/main.c": "#include \"util.h\"\n\nint main(void) {\n\tutil(\"café\"); util(0);\n}\n",
+	}
+	content := func(fileName string) ([]byte, error) {
+		if s, ok := contents[fileName]; ok {
+			return []byte(s), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	locs := []Location{
+		{fileName: "/src/main.c", line: 4, col: 17}, // after the 2 bytes character
+		{fileName: "/src/main.c", line: 4, col: 2},
+		{fileName: "/src/util.h", line: 1, col: 5}, // the contents are unavailable
+		{fileName: "/src/main.c", line: 4, col: 2, offset: 38},
+	}
+	summaries := map[Location]string{
+		{fileName: "/src/util.h", line: 1, col: 5}: "int util(const char *s);",
+		{fileName: "/src/main.c", line: 4, col: 2}: "util",
+	}
+
+	tests := []struct {
+		name    string
+		content ContentProvider
+	}{
+		{"xref", content},
+		{"xref_no_content", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.MarshalIndent(ToXref(locs, summaries, tt.content), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "emacs", tt.name+".json")
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}